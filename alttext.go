@@ -0,0 +1,103 @@
+package xai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// altTextPrompt instructs the vision model to describe an image for accessibility.
+const altTextPrompt = `Describe this image for accessibility. Respond with a JSON object with two fields:
+"alt_text": a concise, literal description suitable for an HTML alt attribute (at most 125 characters),
+"caption": a short, natural one-sentence caption.`
+
+// DescribedImage pairs a generated image with accessibility text.
+type DescribedImage struct {
+	GeneratedImage
+	// AltText is a concise literal description suitable for an alt attribute.
+	AltText string
+	// Caption is a short human-friendly caption.
+	Caption string
+}
+
+// DescribeImages asks a vision-capable model to produce alt text and a caption
+// for each image in resp. If model is empty, the client's default model is used.
+// Images are described sequentially; the first failure aborts and is returned.
+func (c *Client) DescribeImages(ctx context.Context, resp *ImageResponse, model string) ([]DescribedImage, error) {
+	if resp == nil {
+		return nil, &Error{Code: ErrInvalidRequest, Message: "describe images: no image response"}
+	}
+	if model == "" {
+		model = c.config.DefaultModel
+	}
+
+	described := make([]DescribedImage, 0, len(resp.Images))
+	for i, img := range resp.Images {
+		imageURL := img.URL
+		if imageURL == "" && img.Base64 != "" {
+			imageURL = imageDataURL(img.Base64)
+		}
+		if imageURL == "" {
+			return described, &Error{
+				Code:    ErrInvalidRequest,
+				Message: fmt.Sprintf("image %d has neither URL nor data", i),
+			}
+		}
+
+		req := NewChatRequest().
+			WithModel(model).
+			UserMessage(UserContent{Text: altTextPrompt, ImageURL: imageURL}).
+			WithResponseFormat(ResponseFormatJSON)
+
+		chatResp, err := c.CompleteChat(ctx, req)
+		if err != nil {
+			return described, WrapError(err, fmt.Sprintf("describing image %d", i))
+		}
+
+		var out struct {
+			AltText string `json:"alt_text"`
+			Caption string `json:"caption"`
+		}
+		if err := json.Unmarshal([]byte(chatResp.Content), &out); err != nil {
+			return described, &Error{
+				Code:    ErrServerError,
+				Message: fmt.Sprintf("describing image %d: model returned invalid JSON", i),
+				Cause:   err,
+			}
+		}
+
+		described = append(described, DescribedImage{
+			GeneratedImage: img,
+			AltText:        strings.TrimSpace(out.AltText),
+			Caption:        strings.TrimSpace(out.Caption),
+		})
+	}
+
+	return described, nil
+}
+
+// GenerateImageWithAltText generates images and then describes each one using
+// visionModel (or the client's default model if empty).
+func (c *Client) GenerateImageWithAltText(ctx context.Context, req *ImageRequest, visionModel string) ([]DescribedImage, error) {
+	resp, err := c.GenerateImage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.DescribeImages(ctx, resp, visionModel)
+}
+
+// imageDataURL wraps base64 image data in a data URL, sniffing the MIME type
+// from the leading bytes of the encoding.
+func imageDataURL(b64 string) string {
+	mime := "image/jpeg"
+	switch {
+	case strings.HasPrefix(b64, "iVBOR"):
+		mime = "image/png"
+	case strings.HasPrefix(b64, "R0lGOD"):
+		mime = "image/gif"
+	case strings.HasPrefix(b64, "UklGR"):
+		mime = "image/webp"
+	}
+	return "data:" + mime + ";base64," + b64
+}
@@ -0,0 +1,238 @@
+package xai
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Embedder generates embeddings. *Client satisfies it.
+type Embedder interface {
+	Embed(ctx context.Context, req *EmbedRequest, opts ...CallOption) (*EmbedResponse, error)
+}
+
+// DiffOp describes how a sentence changed between two answers.
+type DiffOp int
+
+const (
+	// DiffSame means the sentences say the same thing.
+	DiffSame DiffOp = iota
+	// DiffChanged means the sentences are related but differ in meaning.
+	DiffChanged
+	// DiffRemoved means the sentence only appears in the first answer.
+	DiffRemoved
+	// DiffAdded means the sentence only appears in the second answer.
+	DiffAdded
+)
+
+// String returns the op name.
+func (op DiffOp) String() string {
+	switch op {
+	case DiffSame:
+		return "same"
+	case DiffChanged:
+		return "changed"
+	case DiffRemoved:
+		return "removed"
+	case DiffAdded:
+		return "added"
+	default:
+		return "unknown"
+	}
+}
+
+// Default similarity thresholds for DiffAnswers.
+const (
+	// DefaultDiffSameThreshold is the cosine similarity at or above which two
+	// aligned sentences are considered the same.
+	DefaultDiffSameThreshold = 0.9
+	// DefaultDiffRelatedThreshold is the cosine similarity below which two
+	// sentences are not aligned at all.
+	DefaultDiffRelatedThreshold = 0.6
+)
+
+// DiffOption tunes DiffAnswers.
+type DiffOption func(*diffOptions)
+
+type diffOptions struct {
+	same, related float64
+}
+
+// WithDiffThresholds sets the similarity at or above which aligned sentences
+// are the same, and below which sentences are not aligned (reported as
+// removed and added instead of changed).
+func WithDiffThresholds(same, related float64) DiffOption {
+	return func(o *diffOptions) {
+		o.same = same
+		o.related = related
+	}
+}
+
+// SentenceDiff is one segment of an AnswerDiff. For DiffRemoved only A is
+// set; for DiffAdded only B.
+type SentenceDiff struct {
+	Op DiffOp `json:"op"`
+	A  string `json:"a,omitempty"`
+	B  string `json:"b,omitempty"`
+	// Similarity is the cosine similarity of aligned sentences.
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// AnswerDiff is the sentence-level semantic diff of two answers.
+type AnswerDiff struct {
+	// Segments are in answer order.
+	Segments []SentenceDiff `json:"segments"`
+	// Similarity is an overall score in [0, 1]: the aligned sentence
+	// similarity averaged over all sentences of both answers.
+	Similarity float64 `json:"similarity"`
+}
+
+// Divergent returns the segments that are not DiffSame.
+func (d *AnswerDiff) Divergent() []SentenceDiff {
+	var out []SentenceDiff
+	for _, s := range d.Segments {
+		if s.Op != DiffSame {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// DiffAnswers compares the content of two responses sentence by sentence.
+// Sentences are embedded with model in a single Embed call and aligned in
+// order by cosine similarity, so rephrasings match while changed facts,
+// additions and omissions stand out. It is meant for A/B comparison of
+// model outputs rather than exact text diffing.
+func DiffAnswers(ctx context.Context, embedder Embedder, model string, a, b *ChatResponse, opts ...DiffOption) (*AnswerDiff, error) {
+	return DiffTexts(ctx, embedder, model, a.Content, b.Content, opts...)
+}
+
+// DiffTexts is DiffAnswers for plain text.
+func DiffTexts(ctx context.Context, embedder Embedder, model string, a, b string, opts ...DiffOption) (*AnswerDiff, error) {
+	o := diffOptions{same: DefaultDiffSameThreshold, related: DefaultDiffRelatedThreshold}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	sa, sb := splitSentences(a), splitSentences(b)
+	if len(sa)+len(sb) == 0 {
+		return &AnswerDiff{Similarity: 1}, nil
+	}
+
+	resp, err := embedder.Embed(ctx, NewEmbedRequest(model).AddTexts(append(append([]string{}, sa...), sb...)...))
+	if err != nil {
+		return nil, WrapError(err, "embedding answers for diff")
+	}
+	vectors := make([][]float32, len(sa)+len(sb))
+	for _, e := range resp.Embeddings {
+		if e.Index >= 0 && int(e.Index) < len(vectors) && len(e.Vectors) > 0 {
+			vectors[e.Index] = e.Vectors[0]
+		}
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, &Error{Code: ErrServerError, Message: "diff: missing embedding for sentence " + sentenceAt(sa, sb, i)}
+		}
+	}
+
+	sim := make([][]float64, len(sa))
+	for i := range sa {
+		sim[i] = make([]float64, len(sb))
+		for j := range sb {
+			sim[i][j] = cosineSimilarity(vectors[i], vectors[len(sa)+j])
+		}
+	}
+	return alignSentences(sa, sb, sim, o), nil
+}
+
+func sentenceAt(sa, sb []string, i int) string {
+	if i < len(sa) {
+		return "A" + strconv.Itoa(i)
+	}
+	return "B" + strconv.Itoa(i-len(sa))
+}
+
+// alignSentences aligns sa and sb in order, maximizing the total similarity
+// of aligned pairs (a Needleman-Wunsch alignment with zero gap cost).
+func alignSentences(sa, sb []string, sim [][]float64, o diffOptions) *AnswerDiff {
+	n, m := len(sa), len(sb)
+	score := make([][]float64, n+1)
+	for i := range score {
+		score[i] = make([]float64, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			best := math.Max(score[i+1][j], score[i][j+1])
+			if sim[i][j] >= o.related {
+				best = math.Max(best, sim[i][j]+score[i+1][j+1])
+			}
+			score[i][j] = best
+		}
+	}
+
+	d := &AnswerDiff{}
+	total := 0.0
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && sim[i][j] >= o.related && score[i][j] == sim[i][j]+score[i+1][j+1]:
+			op := DiffChanged
+			if sim[i][j] >= o.same {
+				op = DiffSame
+			}
+			d.Segments = append(d.Segments, SentenceDiff{Op: op, A: sa[i], B: sb[j], Similarity: sim[i][j]})
+			total += 2 * sim[i][j]
+			i++
+			j++
+		case i < n && (j == m || score[i][j] == score[i+1][j]):
+			d.Segments = append(d.Segments, SentenceDiff{Op: DiffRemoved, A: sa[i]})
+			i++
+		default:
+			d.Segments = append(d.Segments, SentenceDiff{Op: DiffAdded, B: sb[j]})
+			j++
+		}
+	}
+	d.Similarity = total / float64(n+m)
+	return d
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// splitSentences splits text at sentence-ending punctuation followed by
+// whitespace, and at line breaks.
+func splitSentences(text string) []string {
+	var out []string
+	var cur strings.Builder
+	runes := []rune(text)
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			out = append(out, s)
+		}
+		cur.Reset()
+	}
+	for i, r := range runes {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		cur.WriteRune(r)
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			flush()
+		}
+	}
+	flush()
+	return out
+}
@@ -81,9 +81,10 @@ func (c *Client) GetAPIKeyInfo(ctx context.Context) (*APIKeyInfo, error) {
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
-	resp, err := c.auth.GetApiKeyInfo(ctx, &emptypb.Empty{})
+	md := &ResponseMetadata{}
+	resp, err := c.auth.GetApiKeyInfo(ctx, &emptypb.Empty{}, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
 	info := &APIKeyInfo{
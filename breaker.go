@@ -0,0 +1,457 @@
+package xai
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Defaults for BreakerConfig.
+const (
+	DefaultBreakerFailureRate = 0.5
+	DefaultBreakerMinRequests = 10
+	DefaultBreakerWindow      = time.Minute
+	DefaultBreakerCoolDown    = 30 * time.Second
+)
+
+// ErrCircuitOpen is the cause of errors returned while a CircuitBreaker is
+// open. Match it with errors.Is.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets all requests through (normal operation).
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects requests until the cool-down has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe request through; its outcome
+	// closes or re-opens the breaker.
+	BreakerHalfOpen
+)
+
+// String returns "closed", "open" or "half-open".
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig configures a CircuitBreaker. Zero fields use the defaults.
+type BreakerConfig struct {
+	// FailureRate is the fraction of failed requests in Window that trips
+	// the breaker (default: 0.5). Only failures that point at the service
+	// count: unavailable, server errors, timeouts and rate limits. Invalid
+	// requests, auth errors and cancellations do not.
+	FailureRate float64
+	// MinRequests is the number of requests in Window before the failure
+	// rate is considered (default: 10).
+	MinRequests int
+	// Window is the period over which the failure rate is measured
+	// (default: 1m).
+	Window time.Duration
+	// CoolDown is how long the breaker stays open before letting a probe
+	// request through (default: 30s).
+	CoolDown time.Duration
+	// Cache, if set, stores successful CompleteChat responses and serves
+	// them while the breaker is open. See NewResponseCache.
+	Cache ResponseCache
+	// Fallback, if set, answers CompleteChat calls while the breaker is
+	// open and Cache has no answer, e.g. with a canned "try again later"
+	// message. cause is the breaker error. Returning an error fails the
+	// call with that error; returning neither a response nor an error
+	// fails it with cause, as without a Fallback.
+	Fallback func(ctx context.Context, req *ChatRequest, cause error) (*ChatResponse, error)
+	// Clock is the source of time for the window and cool-down (default:
+	// SystemClock).
+	Clock Clock
+	// OnStateChange is called synchronously on every state transition, for
+	// alerting. It must not block.
+	OnStateChange func(from, to BreakerState)
+}
+
+// CircuitBreaker stops sending requests to xAI after repeated failures and
+// retries after a cool-down, so an outage fails fast (or degrades to cached
+// and stub answers) instead of tying up callers in timeouts. It is safe for
+// concurrent use.
+//
+// Set it as Config.Breaker. Every RPC is counted and rejected while open;
+// only CompleteChat falls back to Cache and Fallback, other calls return an
+// ErrUnavailable error wrapping ErrCircuitOpen.
+type CircuitBreaker struct {
+	cfg BreakerConfig
+
+	mu          sync.Mutex
+	state       BreakerState
+	openedAt    time.Time
+	windowStart time.Time
+	requests    int
+	failures    int
+	probing     bool
+	forced      bool
+	trips       uint64
+	rejected    uint64
+}
+
+// BreakerStats is a snapshot of a CircuitBreaker.
+type BreakerStats struct {
+	// State is the current state.
+	State BreakerState
+	// Forced is true while the state is held by ForceOpen.
+	Forced bool
+	// Trips counts transitions to BreakerOpen, including forced ones.
+	Trips uint64
+	// Rejected counts requests refused while open or probing.
+	Rejected uint64
+	// Requests and Failures are the counts in the current window.
+	Requests int
+	Failures int
+	// OpenedAt is when the breaker last opened; zero if it never has.
+	OpenedAt time.Time
+}
+
+// NewCircuitBreaker creates a closed breaker.
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.FailureRate <= 0 {
+		cfg.FailureRate = DefaultBreakerFailureRate
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = DefaultBreakerMinRequests
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultBreakerWindow
+	}
+	if cfg.CoolDown <= 0 {
+		cfg.CoolDown = DefaultBreakerCoolDown
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock()
+	}
+	return &CircuitBreaker{cfg: cfg}
+}
+
+// State returns the breaker's current state. An open breaker whose cool-down
+// has passed reports BreakerOpen until the next request probes it.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Stats returns a snapshot of the breaker's state and counters.
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BreakerStats{
+		State:    b.state,
+		Forced:   b.forced,
+		Trips:    b.trips,
+		Rejected: b.rejected,
+		Requests: b.requests,
+		Failures: b.failures,
+		OpenedAt: b.openedAt,
+	}
+}
+
+// ForceOpen opens the breaker and holds it open, without probing, until
+// ForceClose is called. Use it to shed load from xAI during an incident.
+func (b *CircuitBreaker) ForceOpen() {
+	b.mu.Lock()
+	b.forced = true
+	from, changed := b.setStateLocked(BreakerOpen)
+	b.mu.Unlock()
+	if changed {
+		b.notify(from, BreakerOpen)
+	}
+}
+
+// ForceClose closes the breaker, releasing a ForceOpen, and resumes normal
+// failure tracking with fresh counters.
+func (b *CircuitBreaker) ForceClose() {
+	b.mu.Lock()
+	b.forced = false
+	b.probing = false
+	from, changed := b.setStateLocked(BreakerClosed)
+	b.mu.Unlock()
+	if changed {
+		b.notify(from, BreakerClosed)
+	}
+}
+
+// allow reports whether a request may proceed. A nil error means the
+// request must be followed by record or abandon.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	var from BreakerState
+	changed := false
+	switch b.state {
+	case BreakerOpen:
+		if b.forced {
+			b.rejected++
+			b.mu.Unlock()
+			return &Error{Code: ErrUnavailable, Message: ErrCircuitOpen.Error() + " (forced)", Cause: ErrCircuitOpen}
+		}
+		if wait := b.cfg.CoolDown - b.cfg.Clock.Now().Sub(b.openedAt); wait > 0 {
+			b.rejected++
+			b.mu.Unlock()
+			return &Error{Code: ErrUnavailable, Message: ErrCircuitOpen.Error(), Cause: ErrCircuitOpen, RetryAfter: wait}
+		}
+		from, changed = b.setStateLocked(BreakerHalfOpen)
+		b.probing = true
+	case BreakerHalfOpen:
+		if b.probing {
+			b.rejected++
+			b.mu.Unlock()
+			return &Error{Code: ErrUnavailable, Message: ErrCircuitOpen.Error() + " (probing)", Cause: ErrCircuitOpen}
+		}
+		b.probing = true
+	}
+	b.mu.Unlock()
+	if changed {
+		b.notify(from, BreakerHalfOpen)
+	}
+	return nil
+}
+
+// record counts the outcome of an allowed request.
+func (b *CircuitBreaker) record(err error) {
+	failed := breakerFailure(err)
+
+	b.mu.Lock()
+	var from, to BreakerState
+	changed := false
+	switch b.state {
+	case BreakerHalfOpen:
+		b.probing = false
+		to = BreakerClosed
+		if failed {
+			to = BreakerOpen
+		}
+		from, changed = b.setStateLocked(to)
+	case BreakerClosed:
+		now := b.cfg.Clock.Now()
+		if now.Sub(b.windowStart) > b.cfg.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.cfg.MinRequests && float64(b.failures)/float64(b.requests) >= b.cfg.FailureRate {
+			to = BreakerOpen
+			from, changed = b.setStateLocked(to)
+		}
+	}
+	b.mu.Unlock()
+	if changed {
+		b.notify(from, to)
+	}
+}
+
+// abandon releases an allowed request that finished without an outcome,
+// such as a stream closed before its first message.
+func (b *CircuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+	}
+}
+
+// setStateLocked moves to state and resets the counters; it reports the old
+// state and whether anything changed.
+func (b *CircuitBreaker) setStateLocked(state BreakerState) (BreakerState, bool) {
+	from := b.state
+	if from == state {
+		return from, false
+	}
+	b.state = state
+	b.windowStart, b.requests, b.failures = b.cfg.Clock.Now(), 0, 0
+	if state == BreakerOpen {
+		b.openedAt = b.windowStart
+		b.trips++
+	}
+	return from, true
+}
+
+func (b *CircuitBreaker) notify(from, to BreakerState) {
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+}
+
+// degrade answers req from the cache or fallback handler if err is the
+// breaker rejecting the call. A fallback returning neither a response nor
+// an error counts as no fallback.
+func (b *CircuitBreaker) degrade(ctx context.Context, req *ChatRequest, err error) (*ChatResponse, bool, error) {
+	if !errors.Is(err, ErrCircuitOpen) {
+		return nil, false, nil
+	}
+	if b.cfg.Cache != nil {
+		if resp, ok := b.cfg.Cache.Get(ctx, req); ok {
+			out := *resp
+			out.Degraded = true
+			return &out, true, nil
+		}
+	}
+	if b.cfg.Fallback != nil {
+		resp, fbErr := b.cfg.Fallback(ctx, req, err)
+		if fbErr != nil {
+			return nil, true, fbErr
+		}
+		if resp != nil {
+			resp.Degraded = true
+			return resp, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// breakerFailure reports whether err indicates the service is unhealthy.
+func breakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var xaiErr *Error
+	if !errors.As(err, &xaiErr) {
+		xaiErr = FromGRPCError(err)
+	}
+	switch xaiErr.Code {
+	case ErrUnavailable, ErrServerError, ErrTimeout, ErrRateLimit, ErrResourceExhausted:
+		return !errors.Is(err, ErrCircuitOpen)
+	default:
+		return false
+	}
+}
+
+func breakerUnaryInterceptor(b *CircuitBreaker) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := b.allow(); err != nil {
+			return err
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(err)
+		return err
+	}
+}
+
+func breakerStreamInterceptor(b *CircuitBreaker) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err := b.allow(); err != nil {
+			return nil, err
+		}
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			b.record(err)
+			return nil, err
+		}
+		s := &breakerStream{ClientStream: cs, breaker: b}
+		go func() {
+			<-cs.Context().Done()
+			s.once.Do(b.abandon)
+		}()
+		return s, nil
+	}
+}
+
+// breakerStream records the outcome of a stream's first message, which is
+// when connection and server errors surface.
+type breakerStream struct {
+	grpc.ClientStream
+	breaker *CircuitBreaker
+	once    sync.Once
+}
+
+func (s *breakerStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	s.once.Do(func() { s.breaker.record(err) })
+	return err
+}
+
+// ResponseCache stores chat responses for CircuitBreaker to serve while xAI
+// is unreachable. Implementations may match requests exactly (as
+// NewResponseCache does) or semantically, and must be safe for concurrent
+// use.
+type ResponseCache interface {
+	// Get returns a stored response for req, if any.
+	Get(ctx context.Context, req *ChatRequest) (*ChatResponse, bool)
+	// Put stores resp as the answer to req.
+	Put(ctx context.Context, req *ChatRequest, resp *ChatResponse)
+}
+
+// NewResponseCache returns an in-memory ResponseCache holding the size most
+// recently used responses, keyed by model and exact message history.
+func NewResponseCache(size int) ResponseCache {
+	return &memoryResponseCache{size: size, items: map[string]*list.Element{}, order: list.New()}
+}
+
+type memoryResponseCache struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	order *list.List
+}
+
+type cacheEntry struct {
+	key  string
+	resp *ChatResponse
+}
+
+func (c *memoryResponseCache) Get(_ context.Context, req *ChatRequest) (*ChatResponse, bool) {
+	key := responseCacheKey(req)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).resp, true
+}
+
+func (c *memoryResponseCache) Put(_ context.Context, req *ChatRequest, resp *ChatResponse) {
+	if c.size <= 0 {
+		return
+	}
+	key := responseCacheKey(req)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheEntry).resp = resp
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, resp: resp})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func responseCacheKey(req *ChatRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.model))
+	opts := proto.MarshalOptions{Deterministic: true}
+	for _, m := range req.messages {
+		b, _ := opts.Marshal(m)
+		h.Write([]byte{0})
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
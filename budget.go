@@ -0,0 +1,53 @@
+package xai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+)
+
+// ErrBudgetExceeded is the cause of errors returned once the client's spend
+// reaches Config.MaxBudgetUSD. Match it with errors.Is.
+var ErrBudgetExceeded = errors.New("spend budget exceeded")
+
+// checkBudget fails if the tracked spend is known to have reached budget.
+// Pricing is loaded on first use; the pricing request itself is never
+// blocked. While pricing is unavailable, requests go through and usage
+// counts as unpriced: an outage of ListModels must not stop every call.
+func (t *CostTracker) checkBudget(ctx context.Context, method string, budget float64) error {
+	if method == v1.Models_ListLanguageModels_FullMethodName {
+		return nil
+	}
+	// A pricing failure has been logged by the load; spend still holds what
+	// is priced.
+	spend, _ := t.Spend(ctx)
+	if spend.TotalUSD >= budget {
+		return &Error{
+			Code:    ErrResourceExhausted,
+			Message: fmt.Sprintf("%v: spent $%.4f of $%.4f", ErrBudgetExceeded, spend.TotalUSD, budget),
+			Cause:   ErrBudgetExceeded,
+		}
+	}
+	return nil
+}
+
+func budgetUnaryInterceptor(t *CostTracker, budget float64) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := t.checkBudget(ctx, method, budget); err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func budgetStreamInterceptor(t *CostTracker, budget float64) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err := t.checkBudget(ctx, method, budget); err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
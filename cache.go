@@ -0,0 +1,93 @@
+package xai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// cacheKeyHeader routes requests with the same value to the same prompt
+// cache on xAI's side.
+const cacheKeyHeader = "x-grok-conv-id"
+
+// xAI caches prompt prefixes automatically and bills the cached part at the
+// model's CachedPromptPricing; Usage.CachedPromptTokens reports how much of a
+// prompt was served from the cache. A prefix only hits the cache if it is
+// byte-identical and reaches a server that holds it, which the methods below
+// help with.
+
+// WithCacheKey sends key with the request so that requests sharing it are
+// routed to the same prompt cache, e.g. one key per conversation or per
+// agent. Keep the cached prefix (system prompt, tool definitions, earlier
+// turns) identical between those requests: any change invalidates the cache
+// from that point on.
+func (r *ChatRequest) WithCacheKey(key string) *ChatRequest {
+	r.cacheKey = key
+	r.cachePrefix = false
+	return r
+}
+
+// WithPromptCaching marks the stable prefix of the request as cacheable: the
+// model, the system and developer messages that start the history, and the
+// tool definitions. The cache key is derived from them, so independent
+// requests that share the prefix, such as the same agent serving different
+// users, reach the same cache. It replaces a key set with WithCacheKey.
+func (r *ChatRequest) WithPromptCaching() *ChatRequest {
+	r.cacheKey = ""
+	r.cachePrefix = true
+	return r
+}
+
+// cacheContext adds the request's cache key to ctx, if it has one.
+func cacheContext(ctx context.Context, req *ChatRequest, protoReq *v1.GetCompletionsRequest) context.Context {
+	key := req.cacheKey
+	if req.cachePrefix {
+		key = prefixCacheKey(protoReq)
+	}
+	if key == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, cacheKeyHeader, key)
+}
+
+// prefixCacheKey hashes the stable prefix of req as described for
+// WithPromptCaching.
+func prefixCacheKey(req *v1.GetCompletionsRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.GetModel()))
+	marshal := proto.MarshalOptions{Deterministic: true}
+	write := func(m proto.Message) {
+		b, _ := marshal.Marshal(m)
+		h.Write(b)
+	}
+	for _, msg := range req.GetMessages() {
+		if role := msg.GetRole(); role != v1.MessageRole_ROLE_SYSTEM && role != v1.MessageRole_ROLE_DEVELOPER {
+			break
+		}
+		write(msg)
+	}
+	for _, tool := range req.GetTools() {
+		write(tool)
+	}
+	return "xai-go-" + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// CacheHitRate returns the share of prompt tokens served from the prompt
+// cache, from 0 to 1.
+func (u Usage) CacheHitRate() float64 {
+	if u.PromptTokens <= 0 {
+		return 0
+	}
+	return float64(u.CachedPromptTokens) / float64(u.PromptTokens)
+}
+
+// CacheSavings returns what the prompt cache saved on u in USD: the cached
+// tokens at the text rate less their cached rate.
+func (m *LanguageModel) CacheSavings(u Usage) float64 {
+	rate := m.PromptTextPricing.PerMillionTokens - m.CachedPromptPricing.PerMillionTokens
+	return float64(u.CachedPromptTokens) * rate / 1_000_000
+}
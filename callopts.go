@@ -0,0 +1,59 @@
+package xai
+
+import (
+	"context"
+	"time"
+)
+
+// CallOption overrides client defaults for a single call.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	timeout time.Duration
+	model   string
+}
+
+// WithCallTimeout sets the timeout for a single call, overriding the client's
+// Timeout. For streaming calls it bounds the whole stream; streams otherwise
+// have no client-imposed timeout. A deadline already on the context still
+// applies if it is earlier.
+func WithCallTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// WithCallModel sets the model for a single call. It takes precedence over
+// both the model set on the request and the client's DefaultModel.
+func WithCallModel(model string) CallOption {
+	return func(o *callOptions) {
+		o.model = model
+	}
+}
+
+func resolveCallOptions(opts []CallOption) callOptions {
+	var o callOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// callContext applies the per-call timeout, or the client default.
+func (c *Client) callContext(ctx context.Context, o callOptions) (context.Context, context.CancelFunc) {
+	if o.timeout > 0 {
+		return context.WithTimeout(ctx, o.timeout)
+	}
+	return c.withTimeout(ctx)
+}
+
+// streamContext applies the per-call timeout to a stream, if any. The returned
+// cancel function must be called when the stream ends.
+func (c *Client) streamContext(ctx context.Context, o callOptions) (context.Context, context.CancelFunc) {
+	if o.timeout > 0 {
+		return context.WithTimeout(ctx, o.timeout)
+	}
+	return context.WithCancel(ctx)
+}
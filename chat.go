@@ -3,6 +3,8 @@ package xai
 import (
 	"context"
 	"io"
+	"log/slog"
+	"sort"
 	"time"
 
 	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
@@ -14,8 +16,13 @@ type FinishReason string
 const (
 	// FinishReasonStop indicates the model hit a stop sequence or end of message.
 	FinishReasonStop FinishReason = "stop"
-	// FinishReasonLength indicates the model hit the max token limit.
+	// FinishReasonLength indicates the model hit the max token limit set
+	// with WithMaxTokens; raise it to get a longer answer.
 	FinishReasonLength FinishReason = "length"
+	// FinishReasonContextWindow indicates the prompt and answer filled the
+	// model's context window; shorten the history (see WithAutoTruncate)
+	// rather than raising max tokens.
+	FinishReasonContextWindow FinishReason = "max_context"
 	// FinishReasonToolCalls indicates the model wants to call tools.
 	FinishReasonToolCalls FinishReason = "tool_calls"
 	// FinishReasonContentFilter indicates the content was filtered.
@@ -26,8 +33,10 @@ func finishReasonFromProto(r v1.FinishReason) FinishReason {
 	switch r {
 	case v1.FinishReason_REASON_STOP:
 		return FinishReasonStop
-	case v1.FinishReason_REASON_MAX_LEN, v1.FinishReason_REASON_MAX_CONTEXT:
+	case v1.FinishReason_REASON_MAX_LEN:
 		return FinishReasonLength
+	case v1.FinishReason_REASON_MAX_CONTEXT:
+		return FinishReasonContextWindow
 	case v1.FinishReason_REASON_TOOL_CALLS:
 		return FinishReasonToolCalls
 	default:
@@ -38,19 +47,34 @@ func finishReasonFromProto(r v1.FinishReason) FinishReason {
 // Usage contains token usage information.
 type Usage struct {
 	// PromptTokens is the number of tokens in the prompt.
-	PromptTokens int32
+	PromptTokens int32 `json:"prompt_tokens"`
 	// CompletionTokens is the number of tokens in the completion.
-	CompletionTokens int32
+	CompletionTokens int32 `json:"completion_tokens"`
 	// TotalTokens is the total number of tokens.
-	TotalTokens int32
+	TotalTokens int32 `json:"total_tokens"`
 	// ReasoningTokens is the number of tokens used for reasoning.
-	ReasoningTokens int32
+	ReasoningTokens int32 `json:"reasoning_tokens"`
 	// CachedPromptTokens is the number of cached prompt tokens.
-	CachedPromptTokens int32
+	CachedPromptTokens int32 `json:"cached_prompt_tokens"`
 	// PromptTextTokens is the number of text tokens in the prompt.
-	PromptTextTokens int32
+	PromptTextTokens int32 `json:"prompt_text_tokens"`
 	// PromptImageTokens is the number of image tokens in the prompt.
-	PromptImageTokens int32
+	PromptImageTokens int32 `json:"prompt_image_tokens"`
+	// Searches is the number of server-side search tool calls (web, X,
+	// collections and attachment search).
+	Searches int32 `json:"searches,omitempty"`
+	// SourcesUsed is the number of live search sources used.
+	SourcesUsed int32 `json:"sources_used,omitempty"`
+}
+
+// BilledSearches returns the number of searches charged at the model's
+// SearchPricing: the search tool calls, or for live search without tool
+// calls the sources used.
+func (u Usage) BilledSearches() int32 {
+	if u.Searches > 0 {
+		return u.Searches
+	}
+	return u.SourcesUsed
 }
 
 func usageFromProto(u *v1.SamplingUsage) Usage {
@@ -65,31 +89,95 @@ func usageFromProto(u *v1.SamplingUsage) Usage {
 		CachedPromptTokens: u.GetCachedPromptTextTokens(),
 		PromptTextTokens:   u.GetPromptTextTokens(),
 		PromptImageTokens:  u.GetPromptImageTokens(),
+		Searches:           searchCalls(u.GetServerSideToolsUsed()),
+		SourcesUsed:        u.GetNumSourcesUsed(),
+	}
+}
+
+// searchCalls counts the search tools among tools.
+func searchCalls(tools []v1.ServerSideTool) int32 {
+	var n int32
+	for _, t := range tools {
+		switch t {
+		case v1.ServerSideTool_SERVER_SIDE_TOOL_WEB_SEARCH,
+			v1.ServerSideTool_SERVER_SIDE_TOOL_X_SEARCH,
+			v1.ServerSideTool_SERVER_SIDE_TOOL_COLLECTIONS_SEARCH,
+			v1.ServerSideTool_SERVER_SIDE_TOOL_ATTACHMENT_SEARCH:
+			n++
+		}
 	}
+	return n
 }
 
 // ChatResponse represents a complete chat response.
 type ChatResponse struct {
 	// ID is the unique identifier for this response.
-	ID string
+	ID string `json:"id"`
 	// Content is the generated text content.
-	Content string
+	Content string `json:"content"`
 	// ReasoningContent is the reasoning trace (if available).
-	ReasoningContent string
-	// ToolCalls contains any tool calls the model wants to make.
-	ToolCalls []*ToolCallInfo
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	// ToolCalls contains any tool calls the model wants to make, in the
+	// order it issued them (see ToolCallInfo.Index).
+	ToolCalls []*ToolCallInfo `json:"tool_calls,omitempty"`
 	// FinishReason indicates why generation stopped.
-	FinishReason FinishReason
+	FinishReason FinishReason `json:"finish_reason"`
+	// StopSequence is the stop sequence that ended the response, if any
+	// (see WithIncludeStopSequence).
+	StopSequence string `json:"stop_sequence,omitempty"`
 	// Citations are external sources referenced in the response.
-	Citations []string
+	Citations []string `json:"citations,omitempty"`
 	// Usage contains token usage information.
-	Usage Usage
+	Usage Usage `json:"usage"`
 	// Model is the actual model that was used.
-	Model string
+	Model string `json:"model"`
 	// Created is when the response was generated.
-	Created time.Time
+	Created time.Time `json:"created"`
 	// SystemFingerprint identifies the backend configuration.
-	SystemFingerprint string
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+	// EncryptedContent is the opaque encrypted reasoning state, returned when
+	// the request enabled WithEncryptedContent. Pass it back via AppendResponse.
+	EncryptedContent string `json:"encrypted_content,omitempty"`
+	// LanguageCorrected is true when the response came from a corrective
+	// re-prompt requested by WithExpectedLanguage.
+	LanguageCorrected bool `json:"language_corrected,omitempty"`
+	// Degraded is true when the response was served by the circuit
+	// breaker's cache or fallback handler instead of the API.
+	Degraded bool `json:"degraded,omitempty"`
+	// Logprobs are the per-token log probabilities, when requested with
+	// WithLogprobs.
+	Logprobs []TokenLogprob `json:"logprobs,omitempty"`
+	// Outputs holds every generated output, in index order, when more than
+	// one was requested with WithN. The fields above mirror Outputs[0].
+	Outputs []ChatOutput `json:"outputs,omitempty"`
+	// Metadata holds the response headers and trailers, such as the request
+	// ID and rate-limit state.
+	Metadata *ResponseMetadata `json:"-"`
+	// RequestMetadata echoes the pairs the request was tagged with (see
+	// WithMetadata).
+	RequestMetadata map[string]string `json:"request_metadata,omitempty"`
+}
+
+// ChatOutput is one of several outputs generated for a request.
+type ChatOutput struct {
+	// Index is the position of the output.
+	Index int32 `json:"index"`
+	// Content is the generated text content.
+	Content string `json:"content"`
+	// ReasoningContent is the reasoning trace (if available).
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	// ToolCalls contains any tool calls the model wants to make, in the
+	// order it issued them (see ToolCallInfo.Index).
+	ToolCalls []*ToolCallInfo `json:"tool_calls,omitempty"`
+	// FinishReason indicates why generation stopped.
+	FinishReason FinishReason `json:"finish_reason"`
+	// StopSequence is the stop sequence that ended the output, if any (see
+	// WithIncludeStopSequence).
+	StopSequence string `json:"stop_sequence,omitempty"`
+	// EncryptedContent is the opaque encrypted reasoning state.
+	EncryptedContent string `json:"encrypted_content,omitempty"`
+	// Logprobs are the per-token log probabilities, when requested.
+	Logprobs []TokenLogprob `json:"logprobs,omitempty"`
 }
 
 // HasToolCalls returns true if the response contains tool calls.
@@ -97,19 +185,114 @@ func (r *ChatResponse) HasToolCalls() bool {
 	return len(r.ToolCalls) > 0
 }
 
+// AssistantContent returns the response as history content: its text,
+// encrypted reasoning state and client-side tool calls, which are the calls
+// the caller answers with ToolResult. Server-side tool calls ran on the
+// server and are left out. The visible reasoning trace is left out too; set
+// ReasoningContent from the response to send it back.
+func (r *ChatResponse) AssistantContent() AssistantContent {
+	content := AssistantContent{
+		Text:             r.Content,
+		EncryptedContent: r.EncryptedContent,
+	}
+	for _, tc := range r.ToolCalls {
+		if tc == nil || !tc.IsClientSide() || tc.Function == nil {
+			continue
+		}
+		content.ToolCalls = append(content.ToolCalls, HistoryToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	return content
+}
+
 // CompleteChat performs a blocking chat completion.
-func (c *Client) CompleteChat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
-	ctx, cancel := c.withTimeout(ctx)
+func (c *Client) CompleteChat(ctx context.Context, req *ChatRequest, opts ...CallOption) (result *ChatResponse, err error) {
+	if err := req.Err(); err != nil {
+		return nil, err
+	}
+
+	o := resolveCallOptions(opts)
+	ctx, cancel := c.callContext(ctx, o)
 	defer cancel()
 
-	protoReq := req.Build(c.config.DefaultModel)
+	protoReq, err := c.buildChatRequest(ctx, req, o)
+	if err != nil {
+		return nil, err
+	}
+	ctx = metadataContext(cacheContext(ctx, req, protoReq), req)
+
+	if effort, reason, ok := req.chooseEffort(); ok {
+		start := c.config.Clock.Now()
+		defer func() {
+			req.autoEffort.Record(effort, reason, result, err, c.config.Clock.Now().Sub(start))
+		}()
+	}
 
-	resp, err := c.chat.GetCompletion(ctx, protoReq)
+	md := &ResponseMetadata{}
+	resp, err := c.chat.GetCompletion(ctx, protoReq, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		if b := c.config.Breaker; b != nil {
+			if degraded, ok, fbErr := b.degrade(ctx, req, err); ok {
+				return degraded, fbErr
+			}
+		}
+		return nil, errorWithMetadata(err, md)
 	}
 
-	return chatResponseFromProto(resp), nil
+	result = chatResponseFromProto(resp)
+	result.Metadata = md
+	result.RequestMetadata = req.Metadata()
+	if prefill := req.activePrefill(); prefill != "" {
+		result.Content = prefill + result.Content
+		for i := range result.Outputs {
+			result.Outputs[i].Content = prefill + result.Outputs[i].Content
+		}
+	}
+	req.applyStop(result)
+	if req.expectedLanguage != nil {
+		if result, err = c.guardLanguage(ctx, req, protoReq, result); err != nil {
+			return nil, err
+		}
+	}
+	if b := c.config.Breaker; b != nil && b.cfg.Cache != nil {
+		b.cfg.Cache.Put(ctx, req, result)
+	}
+	return result, nil
+}
+
+// buildChatRequest converts req to proto, applying client-level defaults,
+// user preferences and per-call overrides.
+func (c *Client) buildChatRequest(ctx context.Context, req *ChatRequest, o callOptions) (*v1.GetCompletionsRequest, error) {
+	prefs, err := c.userPreferences(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	protoReq := req.build(buildDefaults{
+		model:       c.config.DefaultModel,
+		currentDate: c.config.CurrentDate,
+		now:         c.config.Clock.Now,
+		preferences: prefs,
+	})
+	if o.model != "" {
+		protoReq.Model = o.model
+	}
+	if err := c.gateFeatures(protoReq); err != nil {
+		return nil, err
+	}
+	if req.autoTruncate != 0 {
+		if err := c.autoTruncate(ctx, protoReq, req.autoTruncate); err != nil {
+			return nil, err
+		}
+	}
+	if req.maxTokensAuto {
+		if err := c.autoMaxTokens(ctx, protoReq); err != nil {
+			return nil, err
+		}
+	}
+	return protoReq, nil
 }
 
 func chatResponseFromProto(resp *v1.GetChatCompletionResponse) *ChatResponse {
@@ -125,19 +308,48 @@ func chatResponseFromProto(resp *v1.GetChatCompletionResponse) *ChatResponse {
 		result.Created = resp.GetCreated().AsTime()
 	}
 
-	// Extract from first output (typically only one)
-	if len(resp.GetOutputs()) > 0 {
-		output := resp.GetOutputs()[0]
-		result.FinishReason = finishReasonFromProto(output.GetFinishReason())
-
+	outputs := make([]ChatOutput, 0, len(resp.GetOutputs()))
+	msgs := make([]*v1.CompletionMessage, 0, len(resp.GetOutputs()))
+	for _, output := range resp.GetOutputs() {
+		out := ChatOutput{
+			Index:        output.GetIndex(),
+			FinishReason: finishReasonFromProto(output.GetFinishReason()),
+			Logprobs:     logprobsFromProto(output.GetLogprobs()),
+		}
 		if msg := output.GetMessage(); msg != nil {
-			result.Content = msg.GetContent()
-			result.ReasoningContent = msg.GetReasoningContent()
+			out.Content = msg.GetContent()
+			out.ReasoningContent = msg.GetReasoningContent()
+			out.EncryptedContent = msg.GetEncryptedContent()
 
 			for _, tc := range msg.GetToolCalls() {
-				result.ToolCalls = append(result.ToolCalls, toolCallFromProto(tc))
+				if info := toolCallFromProto(tc); info != nil {
+					info.Index = len(out.ToolCalls)
+					out.ToolCalls = append(out.ToolCalls, info)
+				}
 			}
 		}
+		outputs = append(outputs, out)
+		msgs = append(msgs, output.GetMessage())
+	}
+	var calls []*ToolCallInfo
+	for _, out := range outputs {
+		calls = append(calls, out.ToolCalls...)
+	}
+	attachCodeExecutionOutputs(msgs, calls)
+	sort.SliceStable(outputs, func(i, j int) bool { return outputs[i].Index < outputs[j].Index })
+
+	// The top-level fields describe the first output (typically the only one).
+	if len(outputs) > 0 {
+		first := outputs[0]
+		result.Content = first.Content
+		result.ReasoningContent = first.ReasoningContent
+		result.EncryptedContent = first.EncryptedContent
+		result.ToolCalls = first.ToolCalls
+		result.FinishReason = first.FinishReason
+		result.Logprobs = first.Logprobs
+	}
+	if len(outputs) > 1 {
+		result.Outputs = outputs
 	}
 
 	return result
@@ -146,53 +358,146 @@ func chatResponseFromProto(resp *v1.GetChatCompletionResponse) *ChatResponse {
 // ChatChunk represents a streaming chunk of a chat response.
 type ChatChunk struct {
 	// ID is the response ID.
-	ID string
+	ID string `json:"id"`
 	// Delta is the incremental content.
-	Delta string
+	Delta string `json:"delta"`
 	// ReasoningDelta is the incremental reasoning content.
-	ReasoningDelta string
+	ReasoningDelta string `json:"reasoning_delta,omitempty"`
 	// ToolCalls contains incremental tool call information.
-	ToolCalls []*ToolCallInfo
+	ToolCalls []*ToolCallInfo `json:"tool_calls,omitempty"`
 	// FinishReason is set on the final chunk.
-	FinishReason FinishReason
+	FinishReason FinishReason `json:"finish_reason,omitempty"`
 	// Citations are populated on the final chunk.
-	Citations []string
+	Citations []string `json:"citations,omitempty"`
 	// Usage is updated on each chunk.
-	Usage Usage
+	Usage Usage `json:"usage"`
 	// Model is the actual model used.
-	Model string
+	Model string `json:"model"`
+	// EncryptedContent is incremental encrypted reasoning state.
+	EncryptedContent string `json:"encrypted_content,omitempty"`
+	// Logprobs are the log probabilities of the tokens in Delta, when
+	// requested with WithLogprobs.
+	Logprobs []TokenLogprob `json:"logprobs,omitempty"`
+	// ReasoningRestarted is set on the first chunk after the stream was
+	// restarted at a lower effort by WithReasoningDowngrade.
+	ReasoningRestarted bool `json:"reasoning_restarted,omitempty"`
+}
+
+// ChatStream is an iterator over chat chunks. It is implemented by
+// ChunkStream for live streams and ReplayStream for recorded transcripts,
+// so consumers can be written once against either source.
+type ChatStream interface {
+	// Next returns the next chunk, or io.EOF when done.
+	Next() (*ChatChunk, error)
+	// Close releases the stream.
+	Close() error
+	// Err returns any error that occurred during streaming.
+	Err() error
 }
 
+var _ ChatStream = (*ChunkStream)(nil)
+
 // ChunkStream is an iterator for streaming chat chunks.
 type ChunkStream struct {
-	stream v1.Chat_GetCompletionChunkClient
-	err    error
+	stream     v1.Chat_GetCompletionChunkClient
+	cancel     context.CancelFunc
+	err        error
+	transcript *TranscriptWriter
+	monitor    *reasoningMonitor
+	// prefill is prepended to the first chunk's Delta.
+	prefill string
+	// toolCalls maps the ID of each tool call delivered so far to its
+	// Index, numbering calls across chunks; later chunks for the same call,
+	// such as status updates, reuse it.
+	toolCalls map[string]int
+	// nextToolCall is the Index of the next new tool call.
+	nextToolCall int
+	coalesce     *coalescer
+	// md receives the stream's headers and trailers.
+	md *ResponseMetadata
 }
 
 // Next returns the next chunk, or io.EOF when done.
 // Any error other than io.EOF indicates a failure.
 func (s *ChunkStream) Next() (*ChatChunk, error) {
+	if s.coalesce != nil {
+		return s.coalesce.next(s.next)
+	}
+	return s.next()
+}
+
+// next returns the next chunk as received.
+func (s *ChunkStream) next() (*ChatChunk, error) {
 	if s.err != nil {
 		return nil, s.err
 	}
 
 	chunk, err := s.stream.Recv()
+	if err != nil && s.cancel != nil {
+		s.cancel()
+	}
 	if err == io.EOF {
+		if s.transcript != nil {
+			_ = s.transcript.WriteEnd()
+		}
 		return nil, io.EOF
 	}
 	if err != nil {
-		s.err = FromGRPCError(err)
+		s.err = errorWithMetadata(err, s.md)
+		if s.transcript != nil {
+			_ = s.transcript.WriteError(s.err)
+		}
 		return nil, s.err
 	}
 
-	return chunkFromProto(chunk), nil
+	result := chunkFromProto(chunk)
+	for _, tc := range result.ToolCalls {
+		s.indexToolCall(tc)
+	}
+	if s.prefill != "" {
+		result.Delta = s.prefill + result.Delta
+		s.prefill = ""
+	}
+	if s.monitor != nil {
+		if used, over := s.monitor.observe(result); over {
+			return s.exceedReasoningBudget(used)
+		}
+	}
+	if s.transcript != nil {
+		_ = s.transcript.WriteChunk(result)
+	}
+	return result, nil
+}
+
+// indexToolCall sets the Index of tc: the one its ID was first given, or
+// else the next one. Calls without an ID are always new.
+func (s *ChunkStream) indexToolCall(tc *ToolCallInfo) {
+	if i, ok := s.toolCalls[tc.ID]; ok && tc.ID != "" {
+		tc.Index = i
+		return
+	}
+	tc.Index = s.nextToolCall
+	s.nextToolCall++
+	if tc.ID != "" {
+		if s.toolCalls == nil {
+			s.toolCalls = make(map[string]int)
+		}
+		s.toolCalls[tc.ID] = tc.Index
+	}
+}
+
+// Metadata returns the stream's response headers and trailers, such as the
+// request ID and rate-limit state. They are complete once Next has returned
+// io.EOF or an error; errors carry them in Error.Metadata too.
+func (s *ChunkStream) Metadata() *ResponseMetadata {
+	return s.md
 }
 
-// Close closes the stream.
+// Close closes the stream, canceling it if the server has not finished.
 func (s *ChunkStream) Close() error {
-	// gRPC streams are closed automatically when the context is canceled
-	// or when the server sends EOF. We just need to drain any remaining
-	// messages to be safe.
+	if s.cancel != nil {
+		s.cancel()
+	}
 	return nil
 }
 
@@ -212,17 +517,22 @@ func chunkFromProto(chunk *v1.GetChatCompletionChunk) *ChatChunk {
 		Model:     chunk.GetModel(),
 	}
 
-	// Extract from first output chunk
-	if len(chunk.GetOutputs()) > 0 {
-		output := chunk.GetOutputs()[0]
+	// Streams carry the first output only; with WithN, chunks of other
+	// outputs are skipped.
+	if output := firstOutputChunk(chunk.GetOutputs()); output != nil {
 		result.FinishReason = finishReasonFromProto(output.GetFinishReason())
+		result.Logprobs = logprobsFromProto(output.GetLogprobs())
 
 		if delta := output.GetDelta(); delta != nil {
 			result.Delta = delta.GetContent()
 			result.ReasoningDelta = delta.GetReasoningContent()
+			result.EncryptedContent = delta.GetEncryptedContent()
 
 			for _, tc := range delta.GetToolCalls() {
-				result.ToolCalls = append(result.ToolCalls, toolCallFromProto(tc))
+				if info := toolCallFromProto(tc); info != nil {
+					info.Index = len(result.ToolCalls)
+					result.ToolCalls = append(result.ToolCalls, info)
+				}
 			}
 		}
 	}
@@ -230,16 +540,44 @@ func chunkFromProto(chunk *v1.GetChatCompletionChunk) *ChatChunk {
 	return result
 }
 
+func firstOutputChunk(outputs []*v1.CompletionOutputChunk) *v1.CompletionOutputChunk {
+	for _, o := range outputs {
+		if o.GetIndex() == 0 {
+			return o
+		}
+	}
+	return nil
+}
+
 // StreamChat starts a streaming chat completion.
-func (c *Client) StreamChat(ctx context.Context, req *ChatRequest) (*ChunkStream, error) {
-	protoReq := req.Build(c.config.DefaultModel)
+func (c *Client) StreamChat(ctx context.Context, req *ChatRequest, opts ...CallOption) (*ChunkStream, error) {
+	if err := req.Err(); err != nil {
+		return nil, err
+	}
 
-	stream, err := c.chat.GetCompletionChunk(ctx, protoReq)
+	o := resolveCallOptions(opts)
+	streamCtx, cancel := c.streamContext(ctx, o)
+	protoReq, err := c.buildChatRequest(streamCtx, req, o)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		cancel()
+		return nil, err
 	}
 
-	return &ChunkStream{stream: stream}, nil
+	md := &ResponseMetadata{}
+	stream, err := c.chat.GetCompletionChunk(metadataContext(cacheContext(streamCtx, req, protoReq), req), protoReq, md.callOptions()...)
+	if err != nil {
+		cancel()
+		return nil, errorWithMetadata(err, md)
+	}
+
+	return &ChunkStream{
+		stream:   stream,
+		cancel:   cancel,
+		md:       md,
+		monitor:  c.newReasoningMonitor(ctx, req, protoReq, o),
+		prefill:  req.activePrefill(),
+		coalesce: newCoalescer(c.config),
+	}, nil
 }
 
 // DeferredStatus represents the status of a deferred completion.
@@ -266,15 +604,24 @@ type DeferredResponse struct {
 
 // StartDeferred starts a deferred (async) chat completion.
 // Returns the request ID which can be used to poll for results.
-func (c *Client) StartDeferred(ctx context.Context, req *ChatRequest) (string, error) {
-	ctx, cancel := c.withTimeout(ctx)
+func (c *Client) StartDeferred(ctx context.Context, req *ChatRequest, opts ...CallOption) (string, error) {
+	if err := req.Err(); err != nil {
+		return "", err
+	}
+
+	o := resolveCallOptions(opts)
+	ctx, cancel := c.callContext(ctx, o)
 	defer cancel()
 
-	protoReq := req.Build(c.config.DefaultModel)
+	protoReq, err := c.buildChatRequest(ctx, req, o)
+	if err != nil {
+		return "", err
+	}
 
-	resp, err := c.chat.StartDeferredCompletion(ctx, protoReq)
+	md := &ResponseMetadata{}
+	resp, err := c.chat.StartDeferredCompletion(metadataContext(cacheContext(ctx, req, protoReq), req), protoReq, md.callOptions()...)
 	if err != nil {
-		return "", FromGRPCError(err)
+		return "", errorWithMetadata(err, md)
 	}
 
 	return resp.GetRequestId(), nil
@@ -285,11 +632,12 @@ func (c *Client) GetDeferred(ctx context.Context, requestID string) (*DeferredRe
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
+	md := &ResponseMetadata{}
 	resp, err := c.chat.GetDeferredCompletion(ctx, &v1.GetDeferredRequest{
 		RequestId: requestID,
-	})
+	}, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
 	result := &DeferredResponse{}
@@ -301,6 +649,7 @@ func (c *Client) GetDeferred(ctx context.Context, requestID string) (*DeferredRe
 		result.Status = DeferredStatusCompleted
 		if resp.GetResponse() != nil {
 			result.Response = chatResponseFromProto(resp.GetResponse())
+			result.Response.Metadata = md
 		}
 	case v1.DeferredStatus_EXPIRED:
 		result.Status = DeferredStatusFailed
@@ -312,9 +661,12 @@ func (c *Client) GetDeferred(ctx context.Context, requestID string) (*DeferredRe
 
 // WaitForDeferred polls for a deferred completion until it completes or times out.
 func (c *Client) WaitForDeferred(ctx context.Context, requestID string, pollInterval, timeout time.Duration) (*ChatResponse, error) {
-	deadline := time.Now().Add(timeout)
+	clock := c.config.Clock
+	deadline := clock.Now().Add(timeout)
 
-	for time.Now().Before(deadline) {
+	for attempt := 1; clock.Now().Before(deadline); attempt++ {
+		c.logger.DebugContext(ctx, "polling deferred completion",
+			slog.String("request_id", requestID), slog.Int("attempt", attempt))
 		resp, err := c.GetDeferred(ctx, requestID)
 		if err != nil {
 			return nil, err
@@ -334,7 +686,7 @@ func (c *Client) WaitForDeferred(ctx context.Context, requestID string, pollInte
 		select {
 		case <-ctx.Done():
 			return nil, FromGRPCError(ctx.Err())
-		case <-time.After(pollInterval):
+		case <-clock.After(pollInterval):
 			// Continue polling
 		}
 	}
@@ -350,14 +702,17 @@ func (c *Client) GetStoredCompletion(ctx context.Context, responseID string) (*C
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
+	md := &ResponseMetadata{}
 	resp, err := c.chat.GetStoredCompletion(ctx, &v1.GetStoredCompletionRequest{
 		ResponseId: responseID,
-	})
+	}, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
-	return chatResponseFromProto(resp), nil
+	result := chatResponseFromProto(resp)
+	result.Metadata = md
+	return result, nil
 }
 
 // DeleteStoredCompletion deletes a stored completion by response ID.
@@ -365,11 +720,12 @@ func (c *Client) DeleteStoredCompletion(ctx context.Context, responseID string)
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
+	md := &ResponseMetadata{}
 	_, err := c.chat.DeleteStoredCompletion(ctx, &v1.DeleteStoredCompletionRequest{
 		ResponseId: responseID,
-	})
+	}, md.callOptions()...)
 	if err != nil {
-		return FromGRPCError(err)
+		return errorWithMetadata(err, md)
 	}
 
 	return nil
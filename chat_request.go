@@ -1,7 +1,14 @@
 package xai
 
 import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"time"
+
 	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"golang.org/x/text/language"
+	"google.golang.org/protobuf/proto"
 )
 
 // ReasoningEffort controls how much reasoning effort the model should use.
@@ -37,8 +44,17 @@ const (
 	ResponseFormatText ResponseFormat = iota
 	// ResponseFormatJSON returns JSON output.
 	ResponseFormatJSON
+	// ResponseFormatJSONSchema returns JSON conforming to a schema. Set it
+	// with WithJSONSchema, which supplies the schema.
+	ResponseFormatJSONSchema
 )
 
+// The content structs below describe one message each for the builder
+// methods of the same role: SystemMessage, UserMessage, AssistantMessage,
+// DeveloperMessage and ToolResult. Together they can rebuild any history,
+// including tool calls, images and reasoning state; the zero value of an
+// optional field leaves it out of the message.
+
 // SystemContent represents the content of a system message.
 type SystemContent struct {
 	Text string
@@ -47,14 +63,18 @@ type SystemContent struct {
 // UserContent represents the content of a user message.
 type UserContent struct {
 	Text     string
-	ImageURL string // optional - for vision models
+	ImageURL string    // optional - for vision models
+	Parts    []Content // optional - further images or files, after Text and ImageURL
 }
 
 // AssistantContent represents the content of an assistant message.
 // Used for reconstructing conversation history with tool calls.
+// Use ChatResponse.AssistantContent to get it from a response.
 type AssistantContent struct {
-	Text      string
-	ToolCalls []HistoryToolCall // optional - for history with tool calls
+	Text             string
+	ToolCalls        []HistoryToolCall // optional - for history with tool calls
+	ReasoningContent string            // optional - the visible reasoning trace of a previous response
+	EncryptedContent string            // optional - encrypted reasoning state from a previous response
 }
 
 // HistoryToolCall represents a tool call made by the assistant in conversation history.
@@ -83,6 +103,7 @@ type ChatRequest struct {
 	maxTokens           *int32
 	seed                *int32
 	stop                []string
+	n                   *int32
 	temperature         *float32
 	topP                *float32
 	logprobs            bool
@@ -90,15 +111,35 @@ type ChatRequest struct {
 	tools               []Tool
 	toolChoice          *ToolChoice
 	responseFormat      *ResponseFormat
+	jsonSchema          json.RawMessage
 	frequencyPenalty    *float32
 	presencePenalty     *float32
 	reasoningEffort     *ReasoningEffort
+	autoEffort          *AutoEffort
+	maxReasoningTokens  *int32
+	reasoningDowngrade  bool
 	parallelToolCalls   *bool
 	storeMessages       bool
 	maxTurns            *int32
 	includeOptions      []v1.IncludeOption
 	previousResponseID  string
 	useEncryptedContent bool
+	templateEscape      TemplateEscape
+	systemPrompt        *SystemPrompt
+	locale              *language.Tag
+	timezone            *time.Location
+	currentDate         bool
+	responseLength      ResponseLength
+	expectedLanguage    *language.Tag
+	languageDetector    LanguageDetector
+	prefill             *v1.Message
+	autoTruncate        TruncateStrategy
+	maxTokensAuto       bool
+	includeStop         *bool
+	cacheKey            string
+	cachePrefix         bool
+	metadata            map[string]string
+	err                 error
 }
 
 // NewChatRequest creates a new empty chat request builder.
@@ -106,6 +147,65 @@ func NewChatRequest() *ChatRequest {
 	return &ChatRequest{}
 }
 
+// Clone returns a deep copy of the request, so a base request (system
+// prompt, tools, sampling parameters) can serve as a template and be
+// branched per user without the copies sharing messages or settings:
+//
+//	base := xai.NewChatRequest().WithSystemPrompt(prompt).AddTool(tool)
+//	req := base.Clone().UserMessage(xai.UserContent{Text: question})
+//
+// The AutoEffort and LanguageDetector, if set, are shared: they are meant
+// to serve many requests. So are the functions of TypedTools; their
+// definitions are copied.
+func (r *ChatRequest) Clone() *ChatRequest {
+	c := *r
+	c.messages = make([]*v1.Message, len(r.messages))
+	for i, msg := range r.messages {
+		c.messages[i] = proto.Clone(msg).(*v1.Message)
+	}
+	c.maxTokens = clonePtr(r.maxTokens)
+	c.seed = clonePtr(r.seed)
+	c.stop = slices.Clone(r.stop)
+	c.includeStop = clonePtr(r.includeStop)
+	c.n = clonePtr(r.n)
+	c.temperature = clonePtr(r.temperature)
+	c.topP = clonePtr(r.topP)
+	c.topLogprobs = clonePtr(r.topLogprobs)
+	c.tools = make([]Tool, len(r.tools))
+	for i, tool := range r.tools {
+		c.tools[i] = cloneTool(tool)
+	}
+	c.toolChoice = clonePtr(r.toolChoice)
+	c.responseFormat = clonePtr(r.responseFormat)
+	c.jsonSchema = slices.Clone(r.jsonSchema)
+	c.frequencyPenalty = clonePtr(r.frequencyPenalty)
+	c.presencePenalty = clonePtr(r.presencePenalty)
+	c.reasoningEffort = clonePtr(r.reasoningEffort)
+	c.maxReasoningTokens = clonePtr(r.maxReasoningTokens)
+	c.parallelToolCalls = clonePtr(r.parallelToolCalls)
+	c.maxTurns = clonePtr(r.maxTurns)
+	c.includeOptions = slices.Clone(r.includeOptions)
+	if r.systemPrompt != nil {
+		c.systemPrompt = &SystemPrompt{fragments: slices.Clone(r.systemPrompt.fragments)}
+	}
+	if i := slices.Index(r.messages, r.prefill); i >= 0 {
+		c.prefill = c.messages[i]
+	}
+	c.locale = clonePtr(r.locale)
+	c.expectedLanguage = clonePtr(r.expectedLanguage)
+	c.metadata = maps.Clone(r.metadata)
+	return &c
+}
+
+// clonePtr returns a pointer to a copy of *p, or nil.
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
 // SystemMessage adds a system message to the conversation.
 func (r *ChatRequest) SystemMessage(content SystemContent) *ChatRequest {
 	r.messages = append(r.messages, &v1.Message{
@@ -118,7 +218,8 @@ func (r *ChatRequest) SystemMessage(content SystemContent) *ChatRequest {
 }
 
 // UserMessage adds a user message to the conversation.
-// If ImageURL is set, the message will include the image for vision models.
+// If ImageURL is set, the message will include the image for vision models;
+// Parts follow it, as for UserMessageParts.
 func (r *ChatRequest) UserMessage(content UserContent) *ChatRequest {
 	msg := &v1.Message{
 		Role: v1.MessageRole_ROLE_USER,
@@ -131,6 +232,9 @@ func (r *ChatRequest) UserMessage(content UserContent) *ChatRequest {
 			Content: &v1.Content_ImageUrl{ImageUrl: &v1.ImageUrlContent{ImageUrl: content.ImageURL}},
 		})
 	}
+	for _, p := range content.Parts {
+		msg.Content = append(msg.Content, p.toProto())
+	}
 	r.messages = append(r.messages, msg)
 	return r
 }
@@ -139,7 +243,11 @@ func (r *ChatRequest) UserMessage(content UserContent) *ChatRequest {
 // If ToolCalls is set, the message will include tool calls for history reconstruction.
 func (r *ChatRequest) AssistantMessage(content AssistantContent) *ChatRequest {
 	msg := &v1.Message{
-		Role: v1.MessageRole_ROLE_ASSISTANT,
+		Role:             v1.MessageRole_ROLE_ASSISTANT,
+		EncryptedContent: content.EncryptedContent,
+	}
+	if content.ReasoningContent != "" {
+		msg.ReasoningContent = &content.ReasoningContent
 	}
 	if content.Text != "" {
 		msg.Content = append(msg.Content, &v1.Content{
@@ -162,6 +270,30 @@ func (r *ChatRequest) AssistantMessage(content AssistantContent) *ChatRequest {
 	return r
 }
 
+// AssistantPrefill ends the conversation with a partial assistant message
+// that the model continues, e.g. "{" to force a JSON object or "```go\n" to
+// start a code block. Responses to the request, streamed or not, begin with
+// the prefill, so their content can be used as a whole; AppendResponse
+// replaces the prefill message with the complete answer.
+//
+// The prefill only applies while it is the last message.
+func (r *ChatRequest) AssistantPrefill(text string) *ChatRequest {
+	r.AssistantMessage(AssistantContent{Text: text})
+	r.prefill = r.messages[len(r.messages)-1]
+	return r
+}
+
+// activePrefill returns the prefill text if it is still the last message.
+func (r *ChatRequest) activePrefill() string {
+	if r.prefill == nil || len(r.messages) == 0 || r.messages[len(r.messages)-1] != r.prefill {
+		return ""
+	}
+	if content := r.prefill.GetContent(); len(content) > 0 {
+		return content[0].GetText()
+	}
+	return ""
+}
+
 // ToolResult adds a tool result message to the conversation.
 func (r *ChatRequest) ToolResult(content ToolContent) *ChatRequest {
 	r.messages = append(r.messages, &v1.Message{
@@ -203,6 +335,13 @@ func (r *ChatRequest) WithMaxTokens(n int32) *ChatRequest {
 	return r
 }
 
+// WithN asks for n independent outputs, e.g. for best-of-n sampling. They
+// are returned in ChatResponse.Outputs; streams deliver only the first.
+func (r *ChatRequest) WithN(n int32) *ChatRequest {
+	r.n = &n
+	return r
+}
+
 // WithSeed sets a random seed for deterministic sampling.
 func (r *ChatRequest) WithSeed(seed int32) *ChatRequest {
 	r.seed = &seed
@@ -223,6 +362,10 @@ func (r *ChatRequest) WithTemperature(t float32) *ChatRequest {
 }
 
 // WithTopP sets the nucleus sampling parameter (0-1).
+//
+// There is no WithTopK or WithMinP: the API's GetCompletionsRequest has no
+// top_k or min_p field, so temperature and top_p are the only sampling
+// controls.
 func (r *ChatRequest) WithTopP(p float32) *ChatRequest {
 	r.topP = &p
 	return r
@@ -259,6 +402,27 @@ func (r *ChatRequest) WithResponseFormat(format ResponseFormat) *ChatRequest {
 	return r
 }
 
+// WithJSONSchema requests structured output conforming to schema, a JSON
+// Schema document. name identifies the schema and is added as its "title"
+// unless it has one. The xAI API always enforces the schema, so strict=false
+// currently behaves like true; it is accepted for OpenAI-compatible call
+// sites. Malformed schemas are reported by Err.
+func (r *ChatRequest) WithJSONSchema(name string, schema json.RawMessage, strict bool) *ChatRequest {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		r.setErr(&Error{Code: ErrInvalidRequest, Message: "invalid JSON schema " + name, Cause: err})
+		return r
+	}
+	if _, ok := doc["title"]; !ok && name != "" {
+		doc["title"], _ = json.Marshal(name)
+		schema, _ = json.Marshal(doc)
+	}
+	format := ResponseFormatJSONSchema
+	r.responseFormat = &format
+	r.jsonSchema = schema
+	return r
+}
+
 // WithFrequencyPenalty sets the frequency penalty (-2 to 2).
 func (r *ChatRequest) WithFrequencyPenalty(p float32) *ChatRequest {
 	r.frequencyPenalty = &p
@@ -278,6 +442,9 @@ func (r *ChatRequest) WithReasoningEffort(effort ReasoningEffort) *ChatRequest {
 }
 
 // WithParallelToolCalls controls whether tools can be called in parallel.
+// Parallel calls arrive in the order the model issued them, on every
+// transport and when streamed; ToolCallInfo.Index numbers them in that
+// order, so results can be mapped back by Index as well as by ID.
 func (r *ChatRequest) WithParallelToolCalls(enabled bool) *ChatRequest {
 	r.parallelToolCalls = &enabled
 	return r
@@ -308,6 +475,41 @@ func (r *ChatRequest) WithEncryptedContent(enabled bool) *ChatRequest {
 	return r
 }
 
+// WithEncryptedPassthrough prepares the request for stateless multi-turn use
+// without stored completions. It enables encrypted reasoning content and asks
+// for the encrypted outputs of server-side search and code execution tools,
+// so AppendResponse can carry that state into the next request instead of
+// relying on WithPreviousResponseId.
+func (r *ChatRequest) WithEncryptedPassthrough() *ChatRequest {
+	r.useEncryptedContent = true
+	r.addInclude(v1.IncludeOption_INCLUDE_OPTION_WEB_SEARCH_CALL_OUTPUT)
+	r.addInclude(v1.IncludeOption_INCLUDE_OPTION_X_SEARCH_CALL_OUTPUT)
+	r.addInclude(v1.IncludeOption_INCLUDE_OPTION_CODE_EXECUTION_CALL_OUTPUT)
+	return r
+}
+
+// AppendResponse appends a previous response to the conversation as an
+// assistant message, including its client-side tool calls and any encrypted
+// reasoning state. Server-side tool calls are omitted since xAI already
+// executed them; their effect is carried by the encrypted content.
+func (r *ChatRequest) AppendResponse(resp *ChatResponse) *ChatRequest {
+	if r.activePrefill() != "" {
+		r.messages = r.messages[:len(r.messages)-1]
+		r.prefill = nil
+	}
+	return r.AssistantMessage(resp.AssistantContent())
+}
+
+// addInclude adds an include option if it is not already present.
+func (r *ChatRequest) addInclude(opt v1.IncludeOption) {
+	for _, o := range r.includeOptions {
+		if o == opt {
+			return
+		}
+	}
+	r.includeOptions = append(r.includeOptions, opt)
+}
+
 // WithMaxTurns sets the maximum number of agentic tool calling turns.
 func (r *ChatRequest) WithMaxTurns(n int32) *ChatRequest {
 	r.maxTurns = &n
@@ -326,7 +528,9 @@ func (r *ChatRequest) IncludeXSearchOutput() *ChatRequest {
 	return r
 }
 
-// IncludeCodeExecutionOutput includes code execution tool output.
+// IncludeCodeExecutionOutput includes code execution tool output. The server
+// returns it as tool messages, which stay in ChatResponse.Outputs; the text
+// is also set on the ToolCallInfo.CodeExecutionOutput of the matching call.
 func (r *ChatRequest) IncludeCodeExecutionOutput() *ChatRequest {
 	r.includeOptions = append(r.includeOptions, v1.IncludeOption_INCLUDE_OPTION_CODE_EXECUTION_CALL_OUTPUT)
 	return r
@@ -344,9 +548,22 @@ func (r *ChatRequest) IncludeVerboseStreaming() *ChatRequest {
 	return r
 }
 
+// buildDefaults carries client-level settings applied when building.
+type buildDefaults struct {
+	model       string
+	currentDate bool
+	preferences *Preferences
+	now         func() time.Time // nil means time.Now
+}
+
 // Build converts the request to a proto message.
 // If model is not set, it uses the provided default model.
 func (r *ChatRequest) Build(defaultModel string) *v1.GetCompletionsRequest {
+	return r.build(buildDefaults{model: defaultModel})
+}
+
+func (r *ChatRequest) build(defaults buildDefaults) *v1.GetCompletionsRequest {
+	defaultModel := defaults.model
 	req := &v1.GetCompletionsRequest{
 		Messages:            r.messages,
 		Model:               r.model,
@@ -358,6 +575,9 @@ func (r *ChatRequest) Build(defaultModel string) *v1.GetCompletionsRequest {
 		UseEncryptedContent: r.useEncryptedContent,
 	}
 
+	// Composed system prompt and context hints go first
+	req.Messages = r.promptMessages(defaults)
+
 	// Previous response ID for conversation continuation
 	if r.previousResponseID != "" {
 		req.PreviousResponseId = &r.previousResponseID
@@ -375,6 +595,9 @@ func (r *ChatRequest) Build(defaultModel string) *v1.GetCompletionsRequest {
 	if r.seed != nil {
 		req.Seed = r.seed
 	}
+	if r.n != nil {
+		req.N = r.n
+	}
 	if r.temperature != nil {
 		req.Temperature = r.temperature
 	}
@@ -393,6 +616,9 @@ func (r *ChatRequest) Build(defaultModel string) *v1.GetCompletionsRequest {
 	if r.reasoningEffort != nil {
 		effort := r.reasoningEffort.toProto()
 		req.ReasoningEffort = &effort
+	} else if auto, _, ok := r.chooseEffort(); ok {
+		effort := auto.toProto()
+		req.ReasoningEffort = &effort
 	}
 	if r.parallelToolCalls != nil {
 		req.ParallelToolCalls = r.parallelToolCalls
@@ -418,6 +644,12 @@ func (r *ChatRequest) Build(defaultModel string) *v1.GetCompletionsRequest {
 			req.ResponseFormat = &v1.ResponseFormat{
 				FormatType: v1.FormatType_FORMAT_TYPE_JSON_OBJECT,
 			}
+		case ResponseFormatJSONSchema:
+			schema := string(r.jsonSchema)
+			req.ResponseFormat = &v1.ResponseFormat{
+				FormatType: v1.FormatType_FORMAT_TYPE_JSON_SCHEMA,
+				Schema:     &schema,
+			}
 		default:
 			req.ResponseFormat = &v1.ResponseFormat{
 				FormatType: v1.FormatType_FORMAT_TYPE_TEXT,
@@ -428,6 +660,38 @@ func (r *ChatRequest) Build(defaultModel string) *v1.GetCompletionsRequest {
 	return req
 }
 
+// promptMessages returns the messages sent: the composed system prompt,
+// the history's leading system messages, then context hints and user
+// preferences, then the rest of the history. A continuation (see
+// WithPreviousResponseId) already carries the hints and preferences of the
+// response it continues, so they are not repeated.
+func (r *ChatRequest) promptMessages(defaults buildDefaults) []*v1.Message {
+	var prefix, hints []*v1.Message
+	if msg := r.systemPromptMessage(); msg != nil {
+		prefix = append(prefix, msg)
+	}
+	if r.previousResponseID == "" {
+		if msg := r.hintsMessage(defaults); msg != nil {
+			hints = append(hints, msg)
+		}
+		if msg := preferencesMessage(defaults.preferences); msg != nil {
+			hints = append(hints, msg)
+		}
+	}
+	if len(prefix) == 0 && len(hints) == 0 {
+		return r.messages
+	}
+	n := 0
+	for n < len(r.messages) && r.messages[n].GetRole() == v1.MessageRole_ROLE_SYSTEM {
+		n++
+	}
+	msgs := make([]*v1.Message, 0, len(prefix)+len(hints)+len(r.messages))
+	msgs = append(msgs, prefix...)
+	msgs = append(msgs, r.messages[:n]...)
+	msgs = append(msgs, hints...)
+	return append(msgs, r.messages[n:]...)
+}
+
 // Messages returns the current messages in the request.
 func (r *ChatRequest) Messages() []*v1.Message {
 	return r.messages
@@ -0,0 +1,256 @@
+package xai
+
+import (
+	"encoding/json"
+	"maps"
+	"time"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"golang.org/x/text/language"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// chatRequestJSON is the serialized form of a ChatRequest. Messages and
+// tools are stored in protobuf JSON; enums keep their numeric values.
+type chatRequestJSON struct {
+	Model               string            `json:"model,omitempty"`
+	User                string            `json:"user,omitempty"`
+	Messages            []json.RawMessage `json:"messages,omitempty"`
+	MaxTokens           *int32            `json:"max_tokens,omitempty"`
+	Seed                *int32            `json:"seed,omitempty"`
+	Stop                []string          `json:"stop,omitempty"`
+	IncludeStop         *bool             `json:"include_stop_sequence,omitempty"`
+	N                   *int32            `json:"n,omitempty"`
+	Temperature         *float32          `json:"temperature,omitempty"`
+	TopP                *float32          `json:"top_p,omitempty"`
+	Logprobs            bool              `json:"logprobs,omitempty"`
+	TopLogprobs         *int32            `json:"top_logprobs,omitempty"`
+	Tools               []json.RawMessage `json:"tools,omitempty"`
+	ToolChoice          *ToolChoice       `json:"tool_choice,omitempty"`
+	ResponseFormat      *ResponseFormat   `json:"response_format,omitempty"`
+	JSONSchema          json.RawMessage   `json:"json_schema,omitempty"`
+	FrequencyPenalty    *float32          `json:"frequency_penalty,omitempty"`
+	PresencePenalty     *float32          `json:"presence_penalty,omitempty"`
+	ReasoningEffort     *ReasoningEffort  `json:"reasoning_effort,omitempty"`
+	MaxReasoningTokens  *int32            `json:"max_reasoning_tokens,omitempty"`
+	ReasoningDowngrade  bool              `json:"reasoning_downgrade,omitempty"`
+	ParallelToolCalls   *bool             `json:"parallel_tool_calls,omitempty"`
+	StoreMessages       bool              `json:"store_messages,omitempty"`
+	MaxTurns            *int32            `json:"max_turns,omitempty"`
+	Include             []string          `json:"include,omitempty"`
+	PreviousResponseID  string            `json:"previous_response_id,omitempty"`
+	UseEncryptedContent bool              `json:"use_encrypted_content,omitempty"`
+	TemplateEscape      TemplateEscape    `json:"template_escape,omitempty"`
+	SystemPrompt        []PromptFragment  `json:"system_prompt,omitempty"`
+	Locale              string            `json:"locale,omitempty"`
+	Timezone            string            `json:"timezone,omitempty"`
+	CurrentDate         bool              `json:"current_date,omitempty"`
+	ResponseLength      ResponseLength    `json:"response_length,omitempty"`
+	ExpectedLanguage    string            `json:"expected_language,omitempty"`
+	AutoTruncate        TruncateStrategy  `json:"auto_truncate,omitempty"`
+	MaxTokensAuto       bool              `json:"max_tokens_auto,omitempty"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+	Prefill             bool              `json:"prefill,omitempty"`
+	CacheKey            string            `json:"cache_key,omitempty"`
+	PromptCaching       bool              `json:"prompt_caching,omitempty"`
+}
+
+// MarshalJSON serializes the request so it can be stored, queued or sent to
+// another service, then restored with json.Unmarshal and sent later. It
+// fails with the request's builder error, if any.
+//
+// Settings that hold Go values are not serialized: WithAutoEffort and
+// WithLanguageDetector must be applied again after unmarshaling. Client
+// defaults (model, preferences, current date) are applied when the restored
+// request is sent, not when it is marshaled.
+func (r *ChatRequest) MarshalJSON() ([]byte, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	out := chatRequestJSON{
+		Model:               r.model,
+		User:                r.user,
+		MaxTokens:           r.maxTokens,
+		Seed:                r.seed,
+		Stop:                r.stop,
+		IncludeStop:         r.includeStop,
+		N:                   r.n,
+		Temperature:         r.temperature,
+		TopP:                r.topP,
+		Logprobs:            r.logprobs,
+		TopLogprobs:         r.topLogprobs,
+		ToolChoice:          r.toolChoice,
+		ResponseFormat:      r.responseFormat,
+		JSONSchema:          r.jsonSchema,
+		FrequencyPenalty:    r.frequencyPenalty,
+		PresencePenalty:     r.presencePenalty,
+		ReasoningEffort:     r.reasoningEffort,
+		MaxReasoningTokens:  r.maxReasoningTokens,
+		ReasoningDowngrade:  r.reasoningDowngrade,
+		ParallelToolCalls:   r.parallelToolCalls,
+		StoreMessages:       r.storeMessages,
+		MaxTurns:            r.maxTurns,
+		PreviousResponseID:  r.previousResponseID,
+		UseEncryptedContent: r.useEncryptedContent,
+		TemplateEscape:      r.templateEscape,
+		CurrentDate:         r.currentDate,
+		ResponseLength:      r.responseLength,
+		AutoTruncate:        r.autoTruncate,
+		MaxTokensAuto:       r.maxTokensAuto,
+		Prefill:             r.activePrefill() != "",
+		CacheKey:            r.cacheKey,
+		PromptCaching:       r.cachePrefix,
+		Metadata:            maps.Clone(r.metadata),
+	}
+	for _, msg := range r.messages {
+		b, err := protojson.Marshal(msg)
+		if err != nil {
+			return nil, &Error{Code: ErrInvalidRequest, Message: "marshaling chat request message", Cause: err}
+		}
+		out.Messages = append(out.Messages, b)
+	}
+	for _, tool := range r.tools {
+		b, err := protojson.Marshal(tool.toProto())
+		if err != nil {
+			return nil, &Error{Code: ErrInvalidRequest, Message: "marshaling chat request tool", Cause: err}
+		}
+		out.Tools = append(out.Tools, b)
+	}
+	for _, opt := range r.includeOptions {
+		out.Include = append(out.Include, opt.String())
+	}
+	if r.systemPrompt != nil {
+		out.SystemPrompt = r.systemPrompt.Fragments()
+	}
+	if r.locale != nil {
+		out.Locale = r.locale.String()
+	}
+	if r.timezone != nil {
+		out.Timezone = r.timezone.String()
+	}
+	if r.expectedLanguage != nil {
+		out.ExpectedLanguage = r.expectedLanguage.String()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON restores a request serialized by MarshalJSON, replacing any
+// existing contents. Function tools are restored as *FunctionTool; other
+// tools are restored as opaque tools that send the same configuration.
+func (r *ChatRequest) UnmarshalJSON(data []byte) error {
+	var in chatRequestJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return &Error{Code: ErrInvalidRequest, Message: "unmarshaling chat request", Cause: err}
+	}
+	req := ChatRequest{
+		model:               in.Model,
+		user:                in.User,
+		maxTokens:           in.MaxTokens,
+		seed:                in.Seed,
+		stop:                in.Stop,
+		includeStop:         in.IncludeStop,
+		n:                   in.N,
+		temperature:         in.Temperature,
+		topP:                in.TopP,
+		logprobs:            in.Logprobs,
+		topLogprobs:         in.TopLogprobs,
+		toolChoice:          in.ToolChoice,
+		responseFormat:      in.ResponseFormat,
+		jsonSchema:          in.JSONSchema,
+		frequencyPenalty:    in.FrequencyPenalty,
+		presencePenalty:     in.PresencePenalty,
+		reasoningEffort:     in.ReasoningEffort,
+		maxReasoningTokens:  in.MaxReasoningTokens,
+		reasoningDowngrade:  in.ReasoningDowngrade,
+		parallelToolCalls:   in.ParallelToolCalls,
+		storeMessages:       in.StoreMessages,
+		maxTurns:            in.MaxTurns,
+		previousResponseID:  in.PreviousResponseID,
+		useEncryptedContent: in.UseEncryptedContent,
+		templateEscape:      in.TemplateEscape,
+		currentDate:         in.CurrentDate,
+		responseLength:      in.ResponseLength,
+		autoTruncate:        in.AutoTruncate,
+		maxTokensAuto:       in.MaxTokensAuto,
+		cacheKey:            in.CacheKey,
+		cachePrefix:         in.PromptCaching,
+	}
+	for _, raw := range in.Messages {
+		msg := &v1.Message{}
+		if err := protojson.Unmarshal(raw, msg); err != nil {
+			return &Error{Code: ErrInvalidRequest, Message: "unmarshaling chat request message", Cause: err}
+		}
+		req.messages = append(req.messages, msg)
+	}
+	if in.Prefill && len(req.messages) > 0 {
+		req.prefill = req.messages[len(req.messages)-1]
+	}
+	for _, raw := range in.Tools {
+		tool := &v1.Tool{}
+		if err := protojson.Unmarshal(raw, tool); err != nil {
+			return &Error{Code: ErrInvalidRequest, Message: "unmarshaling chat request tool", Cause: err}
+		}
+		req.tools = append(req.tools, toolFromProto(tool))
+	}
+	for _, name := range in.Include {
+		opt, ok := v1.IncludeOption_value[name]
+		if !ok {
+			return &Error{Code: ErrInvalidRequest, Message: "unmarshaling chat request: unknown include option " + name}
+		}
+		req.includeOptions = append(req.includeOptions, v1.IncludeOption(opt))
+	}
+	if len(in.SystemPrompt) > 0 {
+		req.systemPrompt = NewSystemPrompt("").Include(in.SystemPrompt...)
+	}
+	var err error
+	if req.locale, err = parseTag(in.Locale); err != nil {
+		return &Error{Code: ErrInvalidRequest, Message: "unmarshaling chat request locale", Cause: err}
+	}
+	if req.expectedLanguage, err = parseTag(in.ExpectedLanguage); err != nil {
+		return &Error{Code: ErrInvalidRequest, Message: "unmarshaling chat request language", Cause: err}
+	}
+	if in.Timezone != "" {
+		if req.timezone, err = time.LoadLocation(in.Timezone); err != nil {
+			return &Error{Code: ErrInvalidRequest, Message: "unmarshaling chat request timezone", Cause: err}
+		}
+	}
+	if err := req.WithMetadata(in.Metadata).Err(); err != nil {
+		return err
+	}
+	*r = req
+	return nil
+}
+
+// parseTag parses an optional BCP 47 tag.
+func parseTag(s string) (*language.Tag, error) {
+	if s == "" {
+		return nil, nil
+	}
+	tag, err := language.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// toolFromProto returns a Tool that sends t.
+func toolFromProto(t *v1.Tool) Tool {
+	if fn := t.GetFunction(); fn != nil {
+		f := &FunctionTool{Name: fn.GetName(), Description: fn.GetDescription(), Strict: fn.GetStrict()}
+		if fn.GetParameters() != "" {
+			f.Parameters = json.RawMessage(fn.GetParameters())
+		}
+		return f
+	}
+	return &protoTool{tool: t}
+}
+
+// protoTool is a tool restored from its wire form.
+type protoTool struct {
+	tool *v1.Tool
+}
+
+func (p *protoTool) toProto() *v1.Tool {
+	return proto.Clone(p.tool).(*v1.Tool)
+}
@@ -0,0 +1,187 @@
+package xai
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// CitationSources renders matches as a numbered source list for a RAG
+// prompt, so the model can cite them as [1], [2], ... Pass the model's
+// answer and the same matches to CiteAnswer to render it.
+func CitationSources(matches []SearchMatch) string {
+	var b strings.Builder
+	for i, m := range matches {
+		fmt.Fprintf(&b, "[%d] %s\n\n", i+1, strings.TrimSpace(m.Content))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// CitationOption tunes CiteAnswer.
+type CitationOption func(*citationOptions)
+
+type citationOptions struct {
+	title      func(SearchMatch) string
+	keepAll    bool
+	snippetLen int
+}
+
+// WithCitationTitles names sources in the source list, e.g. by looking up
+// the file name of FileID. By default sources are named by file ID.
+func WithCitationTitles(title func(SearchMatch) string) CitationOption {
+	return func(o *citationOptions) {
+		o.title = title
+	}
+}
+
+// WithUncitedSources lists sources the answer does not cite after the cited
+// ones, instead of dropping them.
+func WithUncitedSources() CitationOption {
+	return func(o *citationOptions) {
+		o.keepAll = true
+	}
+}
+
+// WithCitationSnippetLength sets how much of each source's text the source
+// list quotes (default 160 bytes, 0 for none).
+func WithCitationSnippetLength(n int) CitationOption {
+	return func(o *citationOptions) {
+		o.snippetLen = n
+	}
+}
+
+// CitedAnswer is an answer whose citations have been renumbered in order of
+// first use, with the sources they refer to.
+type CitedAnswer struct {
+	// Text is the answer with citation markers like [1] or [1, 3].
+	Text string `json:"answer"`
+	// Sources are the cited sources, numbered from 1.
+	Sources []CitedSource `json:"sources"`
+}
+
+// CitedSource is one entry of a CitedAnswer's source list.
+type CitedSource struct {
+	Number  int     `json:"number"`
+	Title   string  `json:"title"`
+	FileID  string  `json:"file_id"`
+	ChunkID string  `json:"chunk_id,omitempty"`
+	Score   float32 `json:"score"`
+	Snippet string  `json:"snippet,omitempty"`
+	// Cited is false for sources kept by WithUncitedSources.
+	Cited bool `json:"cited"`
+}
+
+// citationMarker matches a marker with the spaces before it, so dropped
+// markers leave no gap.
+var citationMarker = regexp.MustCompile(`[ \t]*\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// CiteAnswer resolves the [n] markers in answer, where n is the 1-based
+// position of a source in matches, as listed by CitationSources. Sources
+// are renumbered in order of first citation and markers naming no source
+// are removed.
+func CiteAnswer(answer string, matches []SearchMatch, opts ...CitationOption) *CitedAnswer {
+	o := citationOptions{snippetLen: 160}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	renumber := make(map[int]int) // match index -> citation number
+	var order []int
+	text := citationMarker.ReplaceAllStringFunc(answer, func(marker string) string {
+		open := strings.IndexByte(marker, '[')
+		var nums []string
+		seen := make(map[int]bool)
+		for _, part := range strings.Split(marker[open+1:len(marker)-1], ",") {
+			n, _ := strconv.Atoi(strings.TrimSpace(part))
+			i := n - 1
+			if i < 0 || i >= len(matches) || seen[i] {
+				continue
+			}
+			seen[i] = true
+			num, ok := renumber[i]
+			if !ok {
+				order = append(order, i)
+				num = len(order)
+				renumber[i] = num
+			}
+			nums = append(nums, strconv.Itoa(num))
+		}
+		if len(nums) == 0 {
+			return ""
+		}
+		return marker[:open] + "[" + strings.Join(nums, ", ") + "]"
+	})
+
+	cited := len(order)
+	if o.keepAll {
+		for i := range matches {
+			if _, ok := renumber[i]; !ok {
+				order = append(order, i)
+			}
+		}
+	}
+
+	result := &CitedAnswer{Text: text, Sources: []CitedSource{}}
+	for n, i := range order {
+		m := matches[i]
+		src := CitedSource{
+			Number:  n + 1,
+			Title:   m.FileID,
+			FileID:  m.FileID,
+			ChunkID: m.ChunkID,
+			Score:   m.Score,
+			Cited:   n < cited,
+		}
+		if o.title != nil {
+			if t := o.title(m); t != "" {
+				src.Title = t
+			}
+		}
+		if o.snippetLen > 0 {
+			src.Snippet = truncateText(strings.Join(strings.Fields(m.Content), " "), o.snippetLen)
+		}
+		result.Sources = append(result.Sources, src)
+	}
+	return result
+}
+
+// truncateText cuts s to at most n bytes at a word boundary, adding "…".
+func truncateText(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := strings.LastIndexByte(s[:n], ' ')
+	if cut <= 0 {
+		cut = n
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+	}
+	return s[:cut] + "…"
+}
+
+// Markdown renders the answer followed by a "Sources" list.
+func (a *CitedAnswer) Markdown() string {
+	var b strings.Builder
+	b.WriteString(a.Text)
+	if len(a.Sources) == 0 {
+		return b.String()
+	}
+	b.WriteString("\n\n**Sources**\n\n")
+	for _, s := range a.Sources {
+		fmt.Fprintf(&b, "%d. %s", s.Number, s.Title)
+		if s.Snippet != "" {
+			fmt.Fprintf(&b, " — %q", s.Snippet)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// JSON renders the answer and its sources as a JSON object.
+func (a *CitedAnswer) JSON() ([]byte, error) {
+	return json.Marshal(a)
+}
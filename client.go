@@ -4,13 +4,22 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -34,8 +43,13 @@ const (
 type Config struct {
 	// Endpoint is the gRPC endpoint (default: api.x.ai:443).
 	Endpoint string
-	// APIKey is the xAI API key (required).
+	// APIKey is the xAI API key (required unless APIKeyProvider or KeyPool
+	// is set).
 	APIKey *SecureString
+	// APIKeyProvider supplies the API key for every RPC, for keys held in a
+	// secret manager or rotated while the client runs. Takes precedence
+	// over APIKey. Optional.
+	APIKeyProvider APIKeyProvider
 	// Timeout is the default request timeout (default: 120s).
 	Timeout time.Duration
 	// DefaultModel is the model to use when not specified.
@@ -50,11 +64,111 @@ type Config struct {
 	// KeepalivePermitWithoutStream allows pings when no active streams (default: true).
 	// Set to false to only ping during active requests.
 	KeepalivePermitWithoutStream *bool
+	// UnaryInterceptors are additional gRPC interceptors applied to every
+	// unary RPC, in order. Use them for custom logging, auth or tracing.
+	// They run after Client.Use middleware and the client's own
+	// interceptors, closest to the wire, so they see the final request and
+	// headers.
+	UnaryInterceptors []grpc.UnaryClientInterceptor
+	// StreamInterceptors are additional gRPC interceptors applied to every
+	// streaming RPC, in order, in the same place as UnaryInterceptors.
+	StreamInterceptors []grpc.StreamClientInterceptor
+	// Metrics receives request latency, error and token usage observations
+	// for every RPC. See NewPrometheusMetrics. Optional.
+	Metrics Metrics
+	// KeyPool rotates requests across several API keys, benching keys that
+	// hit rate limits. When set, APIKey may be omitted.
+	KeyPool *KeyPool
+	// Logger receives structured debug/info logs for request and stream
+	// lifecycle. API keys are always redacted. If nil, the client is silent.
+	// The client makes a single attempt per call and does not retry, so
+	// each attempt a caller makes is logged as its own request.
+	Logger *slog.Logger
+	// CurrentDate grounds every chat request with today's date, as if
+	// ChatRequest.WithCurrentDate had been called.
+	CurrentDate bool
+	// EnableCompression gzip-compresses large requests (long histories, big
+	// tool schemas). Unary requests are compressed once they reach
+	// CompressionThreshold bytes; streaming requests always are.
+	EnableCompression bool
+	// CompressionThreshold is the minimum unary request size to compress
+	// (default: 4 KiB).
+	CompressionThreshold int
+	// OnConnStateChange is called from a background goroutine whenever the
+	// connection changes state (e.g. Ready to TransientFailure), so daemons
+	// can notice degraded connectivity before a request fails. Brief
+	// intermediate states such as Connecting may be skipped. It must not
+	// block. Optional.
+	OnConnStateChange func(ConnState)
+	// IdleReconnect, if positive, reconnects the connection whenever it has
+	// stayed idle or failing for this long, so the next request does not pay
+	// for connection setup or wait out a reconnect backoff. Optional.
+	IdleReconnect time.Duration
+	// Preferences supplies per-user preferences (tone, language, format
+	// rules) that are rendered into a developer message for every chat
+	// request tagged with WithUser. Optional.
+	Preferences PreferenceStore
+	// Transport selects gRPC (the default), the REST fallback or gRPC-Web.
+	// With TransportREST and TransportGRPCWeb, Endpoint, keepalive and the
+	// gRPC UnaryInterceptors and StreamInterceptors are ignored; everything
+	// else applies. WebAssembly builds use TransportGRPCWeb in place of
+	// TransportGRPC.
+	Transport Transport
+	// RESTEndpoint is the REST API base URL (default: https://api.x.ai/v1).
+	RESTEndpoint string
+	// GRPCWebEndpoint is the base URL of the gRPC-Web endpoint used by
+	// TransportGRPCWeb (default: https:// followed by Endpoint's host).
+	GRPCWebEndpoint string
+	// HTTPClient is the HTTP client used by TransportREST and
+	// TransportGRPCWeb. If nil, one is created from TLSConfig and Dialer.
+	HTTPClient *http.Client
+	// Dialer, if set, opens the network connections to Endpoint (and to
+	// RESTEndpoint's host with TransportREST), e.g. to pin IPs, use
+	// split-horizon DNS or route through an egress gateway. addr is the
+	// unresolved "host:port"; TLS is still layered on top. Optional.
+	Dialer func(ctx context.Context, addr string) (net.Conn, error)
+	// MaxBudgetUSD, if positive, makes every request fail with an error
+	// wrapping ErrBudgetExceeded once the spend recorded by Client.Costs
+	// reaches it, to stop runaway agent loops. The check runs before each
+	// request, so requests already in flight can overshoot; usage of models
+	// without known pricing is not counted, nor is usage while pricing
+	// cannot be loaded (requests then go through, with a logged warning).
+	// CostTracker.Reset re-arms it.
+	MaxBudgetUSD float64
+	// Breaker fails requests fast while xAI is unhealthy and can serve
+	// cached or stub chat answers instead. See NewCircuitBreaker. Optional.
+	Breaker *CircuitBreaker
+	// Clock is the source of time for timestamps, date hints and waits
+	// between polls (default: SystemClock). Tests can inject a fake.
+	Clock Clock
+	// AppName identifies the calling application, e.g. "billing/1.4", in
+	// the client info sent with every request. Optional.
+	AppName string
+	// Warmup makes the client call Warmup in the background on creation, so
+	// the first real request does not pay for the connection handshake.
+	Warmup bool
+	// DropUnsupportedFeatures makes chat requests leave out the fields of
+	// features the server advertises it lacks, instead of failing with
+	// ErrInvalidRequest. It only applies once ServerInfo has been fetched.
+	// A previous_response_id still fails, since the turn would lose its
+	// context.
+	DropUnsupportedFeatures bool
+	// MinDeltaBytes, if positive, makes streams hold back chunks and merge
+	// them until their Delta and ReasoningDelta add up to this many bytes,
+	// to cut per-chunk overhead for frontends relaying them. The merged
+	// chunks carry the same content. The first chunk, tool calls, the final
+	// chunk and the end of the stream are never delayed. Optional.
+	MinDeltaBytes int
+	// MinDeltaInterval, if positive, likewise merges chunks arriving within
+	// this long of the previous delivered one. With MinDeltaBytes, a chunk
+	// is delivered once either is reached. Held text waits for a following
+	// chunk, so a pause in generation also delays it. Optional.
+	MinDeltaInterval time.Duration
 }
 
 // validate checks the config and sets defaults.
 func (c *Config) validate() error {
-	if c.APIKey == nil || c.APIKey.IsZero() {
+	if (c.APIKey == nil || c.APIKey.IsZero()) && c.APIKeyProvider == nil && c.KeyPool == nil {
 		return &Error{
 			Code:    ErrAuth,
 			Message: "API key is required",
@@ -75,6 +189,18 @@ func (c *Config) validate() error {
 	if c.KeepaliveTimeout == 0 {
 		c.KeepaliveTimeout = DefaultKeepaliveTimeout
 	}
+	if c.CompressionThreshold == 0 {
+		c.CompressionThreshold = DefaultCompressionThreshold
+	}
+	if c.RESTEndpoint == "" {
+		c.RESTEndpoint = DefaultRESTEndpoint
+	}
+	if c.GRPCWebEndpoint == "" {
+		c.GRPCWebEndpoint = "https://" + strings.TrimSuffix(c.Endpoint, ":443")
+	}
+	if c.Clock == nil {
+		c.Clock = SystemClock()
+	}
 	return nil
 }
 
@@ -82,6 +208,19 @@ func (c *Config) validate() error {
 type Client struct {
 	conn   *grpc.ClientConn
 	config Config
+	logger *slog.Logger
+	// transport is the REST or gRPC-Web transport, in place of conn.
+	transport grpc.ClientConnInterface
+
+	middleware *middlewares
+	inflight   *inflight
+	costs      *CostTracker
+	wire       *wireLog
+	stopWatch  func()
+	stopWarmup func()
+	server     atomic.Pointer[ServerInfo]
+	// contextWindows caches MaxPromptLength by model for WithAutoTruncate.
+	contextWindows sync.Map
 
 	// Service clients
 	chat      v1.ChatClient
@@ -100,10 +239,20 @@ func New(cfg Config) (*Client, error) {
 	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
+	if !nativeGRPC && cfg.Transport == TransportGRPC {
+		cfg.Transport = TransportGRPCWeb
+	}
+	switch cfg.Transport {
+	case TransportREST:
+		return newClient(&clientConn{http: newRESTConn(cfg)}, cfg), nil
+	case TransportGRPCWeb:
+		return newClient(&clientConn{http: newGRPCWebConn(cfg)}, cfg), nil
+	}
 
 	// Build gRPC dial options
 	opts := []grpc.DialOption{
-		grpc.WithPerRPCCredentials(&bearerAuth{apiKey: cfg.APIKey}),
+		grpc.WithPerRPCCredentials(&bearerAuth{apiKey: cfg.APIKey, provider: cfg.APIKeyProvider}),
+		grpc.WithUserAgent(clientInfo(cfg.AppName)),
 	}
 
 	// Add keepalive if not disabled (KeepaliveTime == -1 disables)
@@ -130,8 +279,26 @@ func New(cfg Config) (*Client, error) {
 	}
 	opts = append(opts, grpc.WithTransportCredentials(creds))
 
+	// Custom dialer. Name resolution is left to the dialer, so the target
+	// is passed through unresolved.
+	target := cfg.Endpoint
+	if cfg.Dialer != nil {
+		opts = append(opts, grpc.WithContextDialer(cfg.Dialer))
+		if !strings.Contains(target, "://") {
+			target = "passthrough:///" + target
+		}
+	}
+
+	// User-supplied interceptors
+	if len(cfg.UnaryInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(cfg.UnaryInterceptors...))
+	}
+	if len(cfg.StreamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(cfg.StreamInterceptors...))
+	}
+
 	// Connect
-	conn, err := grpc.NewClient(cfg.Endpoint, opts...)
+	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
 		return nil, &Error{
 			Code:    ErrUnavailable,
@@ -171,30 +338,125 @@ func WithChannel(conn *grpc.ClientConn, apiKey *SecureString) (*Client, error) {
 	return newClientFromConn(conn, cfg), nil
 }
 
+// WithChannelConfig creates a client using an existing gRPC connection and the
+// given configuration. Connection-level settings (Endpoint, TLSConfig,
+// keepalive and interceptors) are ignored since the connection already exists;
+// client-level settings such as DefaultModel, Timeout and Metrics apply.
+func WithChannelConfig(conn *grpc.ClientConn, cfg Config) (*Client, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return newClientFromConn(conn, cfg), nil
+}
+
 // newClientFromConn initializes all service clients from a connection.
 func newClientFromConn(conn *grpc.ClientConn, cfg Config) *Client {
-	return &Client{
-		conn:      conn,
-		config:    cfg,
-		chat:      v1.NewChatClient(conn),
-		models:    v1.NewModelsClient(conn),
-		embedder:  v1.NewEmbedderClient(conn),
-		tokenizer: v1.NewTokenizeClient(conn),
-		auth:      v1.NewAuthClient(conn),
-		sampler:   v1.NewSampleClient(conn),
-		image:     v1.NewImageClient(conn),
-		documents: v1.NewDocumentsClient(conn),
-		batch:     v1.NewBatchMgmtClient(conn),
-	}
-}
-
-// Close closes the client connection and clears the API key from memory.
+	return newClient(&clientConn{conn: conn}, cfg)
+}
+
+// newClient initializes all service clients on top of cc's transport.
+func newClient(cc *clientConn, cfg Config) *Client {
+	mw := &middlewares{}
+	active := &inflight{}
+	info := clientInfo(cfg.AppName)
+	cc.unary = append(cc.unary, inflightUnaryInterceptor(active), clientInfoUnaryInterceptor(info), middlewareUnaryInterceptor(mw))
+	cc.stream = append(cc.stream, inflightStreamInterceptor(active), clientInfoStreamInterceptor(info), middlewareStreamInterceptor(mw))
+	// The cost tracker loads pricing through the client, so the client is
+	// allocated up front and filled in below.
+	client := &Client{}
+	costs := newCostTracker(client, cfg.Clock.Now())
+	if cfg.MaxBudgetUSD > 0 {
+		cc.unary = append(cc.unary, budgetUnaryInterceptor(costs, cfg.MaxBudgetUSD))
+		cc.stream = append(cc.stream, budgetStreamInterceptor(costs, cfg.MaxBudgetUSD))
+	}
+	if cfg.Breaker != nil {
+		cc.unary = append(cc.unary, breakerUnaryInterceptor(cfg.Breaker))
+		cc.stream = append(cc.stream, breakerStreamInterceptor(cfg.Breaker))
+	}
+	cc.unary = append(cc.unary, metricsUnaryInterceptor(costs, cfg.Clock))
+	cc.stream = append(cc.stream, metricsStreamInterceptor(costs, cfg.Clock))
+	if cfg.Metrics != nil {
+		cc.unary = append(cc.unary, metricsUnaryInterceptor(cfg.Metrics, cfg.Clock))
+		cc.stream = append(cc.stream, metricsStreamInterceptor(cfg.Metrics, cfg.Clock))
+	}
+	if cfg.KeyPool != nil {
+		cc.unary = append(cc.unary, keyPoolUnaryInterceptor(cfg.KeyPool))
+		cc.stream = append(cc.stream, keyPoolStreamInterceptor(cfg.KeyPool))
+	}
+	if cfg.EnableCompression {
+		cc.unary = append(cc.unary, compressionUnaryInterceptor(cfg.CompressionThreshold))
+		cc.stream = append(cc.stream, compressionStreamInterceptor())
+	}
+	// After the key pool and compression, so it shows what is sent.
+	wire := &wireLog{apiKey: cfg.APIKey}
+	cc.unary = append(cc.unary, wireUnaryInterceptor(wire))
+	cc.stream = append(cc.stream, wireStreamInterceptor(wire))
+	logger := newLogger(cfg.Logger)
+	if cfg.Logger != nil {
+		// Innermost, so it sees the key chosen by the pool.
+		cc.unary = append(cc.unary, loggingUnaryInterceptor(logger, cfg.APIKey))
+		cc.stream = append(cc.stream, loggingStreamInterceptor(logger, cfg.APIKey))
+		endpoint := cfg.Endpoint
+		switch cfg.Transport {
+		case TransportREST:
+			endpoint = cfg.RESTEndpoint
+		case TransportGRPCWeb:
+			endpoint = cfg.GRPCWebEndpoint
+		}
+		logger.Info("client created",
+			slog.String("endpoint", endpoint),
+			slog.String("transport", cfg.Transport.String()),
+			slog.String("default_model", cfg.DefaultModel),
+			slog.Duration("timeout", cfg.Timeout),
+			slog.String("api_key", redactKey(cfg.APIKey)),
+			slog.Bool("key_pool", cfg.KeyPool != nil),
+		)
+	}
+
+	*client = Client{
+		conn:       cc.conn,
+		transport:  cc.http,
+		config:     cfg,
+		logger:     logger,
+		middleware: mw,
+		inflight:   active,
+		costs:      costs,
+		wire:       wire,
+		chat:       v1.NewChatClient(cc),
+		models:     v1.NewModelsClient(cc),
+		embedder:   v1.NewEmbedderClient(cc),
+		tokenizer:  v1.NewTokenizeClient(cc),
+		auth:       v1.NewAuthClient(cc),
+		sampler:    v1.NewSampleClient(cc),
+		image:      v1.NewImageClient(cc),
+		documents:  v1.NewDocumentsClient(cc),
+		batch:      v1.NewBatchMgmtClient(cc),
+	}
+	client.startConnWatch()
+	client.startWarmup()
+	return client
+}
+
+// Close closes the client connection immediately, failing any in-flight
+// requests, and clears the API key from memory. See Shutdown for a graceful
+// alternative.
 func (c *Client) Close() error {
+	c.logger.Debug("client closing")
+	if c.stopWarmup != nil {
+		c.stopWarmup()
+	}
 	if c.config.APIKey != nil {
 		c.config.APIKey.Close()
 	}
 	if c.conn != nil {
-		return c.conn.Close()
+		err := c.conn.Close()
+		if c.stopWatch != nil {
+			c.stopWatch()
+		}
+		return err
+	}
+	if closer, ok := c.transport.(io.Closer); ok {
+		return closer.Close()
 	}
 	return nil
 }
@@ -223,21 +485,63 @@ func (c *Client) withTimeout(ctx context.Context) (context.Context, context.Canc
 	return context.WithTimeout(ctx, c.config.Timeout)
 }
 
+// APIKeyProvider supplies an API key on demand. Key is called for every RPC,
+// so implementations that fetch from a remote secret store should cache.
+// It must be safe for concurrent use.
+type APIKeyProvider interface {
+	Key(ctx context.Context) (string, error)
+}
+
+// APIKeyProviderFunc adapts a function to the APIKeyProvider interface.
+type APIKeyProviderFunc func(ctx context.Context) (string, error)
+
+// Key calls f(ctx).
+func (f APIKeyProviderFunc) Key(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// WithAPIKeyContext returns a context whose requests authenticate with key
+// instead of the client's configured key, provider or key pool. It lets a
+// multi-tenant service share one client and connection while billing each
+// request to a different customer's key. The key is not copied; closing it
+// while requests are in flight fails them.
+func WithAPIKeyContext(ctx context.Context, key *SecureString) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
 // bearerAuth implements grpc.PerRPCCredentials for bearer token auth.
 type bearerAuth struct {
-	apiKey *SecureString
+	apiKey   *SecureString
+	provider APIKeyProvider
 }
 
-// GetRequestMetadata returns the authorization header.
+// GetRequestMetadata returns the authorization header. A key chosen for this
+// request (by WithAPIKeyContext or a KeyPool) wins, then the provider, then
+// the static key.
 func (b *bearerAuth) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
-	if b.apiKey == nil || b.apiKey.IsZero() {
+	apiKey := b.apiKey
+	if k, ok := ctx.Value(apiKeyContextKey{}).(*SecureString); ok {
+		apiKey = k
+	} else if b.provider != nil {
+		key, err := b.provider.Key(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "API key provider: %v", err)
+		}
+		if key == "" {
+			return nil, status.Error(codes.Unauthenticated, "API key provider returned an empty key")
+		}
+		return map[string]string{
+			"authorization": "Bearer " + key,
+		}, nil
+	}
+	if apiKey == nil || apiKey.IsZero() {
 		return nil, &Error{
 			Code:    ErrAuth,
 			Message: "API key is not set or has been closed",
 		}
 	}
 	return map[string]string{
-		"authorization": "Bearer " + b.apiKey.Value(),
+		"authorization": "Bearer " + apiKey.Value(),
 	}, nil
 }
 
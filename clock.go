@@ -0,0 +1,28 @@
+package xai
+
+import "time"
+
+// Clock is the client's source of time. Timestamps, date hints, stream
+// coalescing intervals and the waits of WaitForDeferred and
+// MemoryConsolidator.Run go through it, so tests can substitute a fake one
+// and run polling logic instantly and deterministically. Elapsed durations in
+// logs and metrics, TranscriptWriter timestamps and the short grace period
+// of Client.Close use the time package. Implementations must be safe for
+// concurrent use.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After returns a channel that receives the time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock returns the Clock backed by the time package, used when
+// Config.Clock is nil.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+// Job states. Pending, completed and failed mirror xai.DeferredStatus;
+// cancelled jobs are no longer polled.
+const (
+	jobPending   = "pending"
+	jobCompleted = "completed"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+// job is a deferred completion started from the client. The API cannot list
+// deferred completions, so they are kept in a local file, together with the
+// result once fetched (the server keeps it for a limited time only).
+//
+// Batches are not listed yet: the library does not expose the Batch API.
+type job struct {
+	ID       string    `json:"id"`
+	Kind     string    `json:"kind"`
+	Model    string    `json:"model"`
+	Prompt   string    `json:"prompt"`
+	Started  time.Time `json:"started"`
+	Status   string    `json:"status"`
+	Finished time.Time `json:"finished"`
+	Error    string    `json:"error,omitempty"`
+	Content  string    `json:"content,omitempty"`
+	Usage    xai.Usage `json:"usage"`
+}
+
+// jobStore is the file holding the jobs.
+type jobStore struct {
+	path string
+	Jobs []*job `json:"jobs"`
+}
+
+// jobsPath returns the job file: $XAI_JOBS_FILE, or jobs.json in the user's
+// config directory.
+func jobsPath() (string, error) {
+	if p := os.Getenv("XAI_JOBS_FILE"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating the jobs file: %w", err)
+	}
+	return filepath.Join(dir, "xai-go", "jobs.json"), nil
+}
+
+func loadJobs() (*jobStore, error) {
+	path, err := jobsPath()
+	if err != nil {
+		return nil, err
+	}
+	store := &jobStore{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading jobs: %w", err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("reading jobs from %s: %w", path, err)
+	}
+	return store, nil
+}
+
+func (s *jobStore) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("saving jobs: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("saving jobs: %w", err)
+	}
+	return nil
+}
+
+// find returns the job with id, which may be abbreviated to a unique prefix.
+func (s *jobStore) find(id string) (*job, error) {
+	var found *job
+	for _, j := range s.Jobs {
+		if j.ID == id {
+			return j, nil
+		}
+		if strings.HasPrefix(j.ID, id) {
+			if found != nil {
+				return nil, fmt.Errorf("job ID %q is ambiguous", id)
+			}
+			found = j
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no job %q", id)
+	}
+	return found, nil
+}
+
+// refresh polls the pending jobs among jobs and saves any change.
+func (s *jobStore) refresh(ctx context.Context, client *xai.Client, jobs []*job) error {
+	changed := false
+	for _, j := range jobs {
+		if j.Status != jobPending {
+			continue
+		}
+		resp, err := client.GetDeferred(ctx, j.ID)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", j.ID, err)
+		}
+		switch resp.Status {
+		case xai.DeferredStatusCompleted:
+			j.Status = jobCompleted
+			if resp.Response != nil {
+				j.Content = resp.Response.Content
+				j.Usage = resp.Response.Usage
+			}
+		case xai.DeferredStatusFailed:
+			j.Status = jobFailed
+			j.Error = resp.Error
+		default:
+			continue
+		}
+		j.Finished = time.Now()
+		changed = true
+	}
+	if changed {
+		return s.save()
+	}
+	return nil
+}
+
+// pending reports whether any of jobs is still pending.
+func pending(jobs []*job) bool {
+	for _, j := range jobs {
+		if j.Status == jobPending {
+			return true
+		}
+	}
+	return false
+}
+
+// startJob starts a deferred completion of prompt and records it.
+func startJob(client *xai.Client, model, systemPrompt, prompt string) (*job, error) {
+	store, err := loadJobs()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req := xai.NewChatRequest().
+		WithModel(model).
+		WithSystemPrompt(xai.NewSystemPrompt(systemPrompt)).
+		UserMessage(xai.UserContent{Text: prompt})
+	id, err := client.StartDeferred(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	j := &job{ID: id, Kind: "deferred", Model: model, Prompt: prompt, Started: time.Now(), Status: jobPending}
+	store.Jobs = append(store.Jobs, j)
+	return j, store.save()
+}
+
+const jobsUsage = `Usage: minimal-client jobs <command> [flags] [args]
+
+Commands:
+  start <prompt>   Start a deferred completion with -model and -system
+  list             List jobs and their status
+  status <id>      Show a job, and its result once completed
+  cancel <id>      Stop tracking a pending job
+  clear            Forget finished and cancelled jobs
+
+Flags for list and status:
+  -watch           Poll until the jobs finish (Ctrl-C stops watching)
+  -interval d      Poll interval for -watch (default 5s)
+
+Job IDs may be abbreviated to a unique prefix. Jobs are kept in
+$XAI_JOBS_FILE, or jobs.json in the user config directory.
+`
+
+// runJobs runs a jobs subcommand, from the command line or the /jobs
+// command.
+func runJobs(client *xai.Client, model, systemPrompt string, args []string) error {
+	if len(args) == 0 || args[0] == "help" {
+		fmt.Print(jobsUsage)
+		return nil
+	}
+	cmd := args[0]
+	fs := flag.NewFlagSet("jobs "+cmd, flag.ContinueOnError)
+	watch := fs.Bool("watch", false, "Poll until the jobs finish")
+	interval := fs.Duration("interval", 5*time.Second, "Poll interval for -watch")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	rest := fs.Args()
+
+	switch cmd {
+	case "start":
+		if len(rest) == 0 {
+			return errors.New("jobs start: missing prompt")
+		}
+		j, err := startJob(client, model, systemPrompt, strings.Join(rest, " "))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Started deferred job %s\n", j.ID)
+		return nil
+
+	case "list", "ls":
+		return watchJobs(client, *watch, *interval, func(store *jobStore) ([]*job, error) {
+			return store.Jobs, nil
+		}, printJobTable)
+
+	case "status":
+		if len(rest) != 1 {
+			return errors.New("jobs status: want one job ID")
+		}
+		return watchJobs(client, *watch, *interval, func(store *jobStore) ([]*job, error) {
+			j, err := store.find(rest[0])
+			if err != nil {
+				return nil, err
+			}
+			return []*job{j}, nil
+		}, func(jobs []*job) { printJob(jobs[0]) })
+
+	case "cancel":
+		if len(rest) != 1 {
+			return errors.New("jobs cancel: want one job ID")
+		}
+		store, err := loadJobs()
+		if err != nil {
+			return err
+		}
+		j, err := store.find(rest[0])
+		if err != nil {
+			return err
+		}
+		if j.Status != jobPending {
+			return fmt.Errorf("job %s is already %s", j.ID, j.Status)
+		}
+		j.Status = jobCancelled
+		j.Finished = time.Now()
+		if err := store.save(); err != nil {
+			return err
+		}
+		// The API has no call to stop a deferred completion
+		fmt.Printf("Cancelled job %s; it is no longer polled, but the server may still run it.\n", j.ID)
+		return nil
+
+	case "clear":
+		store, err := loadJobs()
+		if err != nil {
+			return err
+		}
+		kept := store.Jobs[:0]
+		for _, j := range store.Jobs {
+			if j.Status == jobPending {
+				kept = append(kept, j)
+			}
+		}
+		removed := len(store.Jobs) - len(kept)
+		store.Jobs = kept
+		if err := store.save(); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d jobs.\n", removed)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown jobs command %q (try: jobs help)", cmd)
+	}
+}
+
+// watchJobs refreshes and prints the jobs selected from the store, and with
+// watch repeats every interval until none is pending or Ctrl-C is pressed.
+func watchJobs(client *xai.Client, watch bool, interval time.Duration, selectJobs func(*jobStore) ([]*job, error), show func([]*job)) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for {
+		store, err := loadJobs()
+		if err != nil {
+			return err
+		}
+		jobs, err := selectJobs(store)
+		if err != nil {
+			return err
+		}
+		pollCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err = store.refresh(pollCtx, client, jobs)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		if watch {
+			fmt.Printf("\n%s\n", time.Now().Format("15:04:05"))
+		}
+		show(jobs)
+		if !watch || !pending(jobs) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopped watching.")
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func printJobTable(jobs []*job) {
+	if len(jobs) == 0 {
+		fmt.Println("No jobs. Start one with: jobs start <prompt>")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tKIND\tMODEL\tSTATUS\tAGE\tPROMPT")
+	for _, j := range jobs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			j.ID, j.Kind, j.Model, j.Status, jobAge(j.Started), truncateStr(strings.ReplaceAll(j.Prompt, "\n", " "), 40))
+	}
+	w.Flush()
+}
+
+func printJob(j *job) {
+	fmt.Printf("ID:      %s\n", j.ID)
+	fmt.Printf("Kind:    %s\n", j.Kind)
+	fmt.Printf("Model:   %s\n", j.Model)
+	fmt.Printf("Status:  %s\n", j.Status)
+	fmt.Printf("Started: %s (%s ago)\n", j.Started.Format(time.DateTime), jobAge(j.Started))
+	if !j.Finished.IsZero() {
+		fmt.Printf("Took:    %s\n", j.Finished.Sub(j.Started).Round(time.Second))
+	}
+	fmt.Printf("Prompt:  %s\n", j.Prompt)
+	if j.Error != "" {
+		fmt.Printf("Error:   %s\n", j.Error)
+	}
+	if j.Status == jobCompleted {
+		fmt.Printf("Tokens:  prompt %d, completion %d, reasoning %d\n",
+			j.Usage.PromptTokens, j.Usage.CompletionTokens, j.Usage.ReasoningTokens)
+		fmt.Printf("\n%s\n", j.Content)
+	}
+}
+
+// jobAge formats the time since t coarsely.
+func jobAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// errInterrupted is returned by readLine when the user presses Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+// continuationPrompt is shown for the lines after one ending in a backslash
+// and for the lines of a block.
+const continuationPrompt = "...  "
+
+// maxHistory bounds the number of remembered lines.
+const maxHistory = 500
+
+// lineEditor reads input lines with readline-style editing when stdin is a
+// terminal: cursor movement, history with the up/down arrows, and the usual
+// Emacs control keys. Otherwise it reads plain lines, so piped input works.
+//
+// The terminal is only in raw mode while a line is being read, so output
+// and Ctrl-C behave normally while a response streams.
+type lineEditor struct {
+	in       *os.File
+	out      io.Writer
+	reader   *bufio.Reader
+	terminal bool
+	history  []string
+}
+
+func newLineEditor(in *os.File, out io.Writer) *lineEditor {
+	return &lineEditor{
+		in:       in,
+		out:      out,
+		reader:   bufio.NewReader(in),
+		terminal: isTerminal(int(in.Fd())),
+	}
+}
+
+// readLine reads one input. A line ending in a backslash continues on the
+// next line; the lines are joined with newlines.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	var lines []string
+	for {
+		line, err := e.readPhysicalLine(prompt)
+		if err != nil {
+			if err == io.EOF && len(lines) > 0 {
+				return strings.Join(lines, "\n"), nil
+			}
+			return "", err
+		}
+		if !strings.HasSuffix(line, `\`) {
+			lines = append(lines, line)
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, strings.TrimSuffix(line, `\`))
+		prompt = continuationPrompt
+	}
+}
+
+func (e *lineEditor) readPhysicalLine(prompt string) (string, error) {
+	if e.terminal {
+		if restore, err := makeRaw(int(e.in.Fd())); err == nil {
+			defer restore()
+			line, err := e.edit(prompt)
+			if err == nil {
+				e.remember(line)
+			}
+			return line, err
+		}
+	}
+
+	fmt.Fprint(e.out, prompt)
+	line, err := e.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readBlock reads lines verbatim, without continuation or history, until
+// isEnd reports the end of the block or the input ends (Ctrl-D). isEnd
+// returns the part of the final line to keep. The lines are joined with
+// newlines; Ctrl-C discards them and returns errInterrupted.
+func (e *lineEditor) readBlock(isEnd func(line string) (string, bool)) (string, error) {
+	next := func() (string, error) {
+		fmt.Fprint(e.out, continuationPrompt)
+		line, err := e.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	// Stay in raw mode for the whole block, so pasted lines are not echoed
+	// or edited by the terminal between reads.
+	if e.terminal {
+		if restore, err := makeRaw(int(e.in.Fd())); err == nil {
+			defer restore()
+			next = func() (string, error) { return e.edit(continuationPrompt) }
+		}
+	}
+
+	var lines []string
+	for {
+		line, err := next()
+		if err == io.EOF {
+			return strings.Join(lines, "\n"), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if last, done := isEnd(line); done {
+			if last != "" {
+				lines = append(lines, last)
+			}
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+// remember appends line to the history, skipping blanks and repeats.
+func (e *lineEditor) remember(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(e.history); n > 0 && e.history[n-1] == line {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+}
+
+// Key codes handled by edit.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlH     = 8
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// edit reads one line in raw mode.
+func (e *lineEditor) edit(prompt string) (string, error) {
+	var buf []rune
+	pos := 0
+	// Browsing history: index into e.history, with len(e.history) being the
+	// line being typed, which is kept in draft.
+	hist := len(e.history)
+	var draft []rune
+
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(buf))
+		if back := len(buf) - pos; back > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", back)
+		}
+	}
+	recall := func(i int) {
+		if i < 0 || i > len(e.history) || i == hist {
+			return
+		}
+		if hist == len(e.history) {
+			draft = buf
+		}
+		hist = i
+		if i == len(e.history) {
+			buf = draft
+		} else {
+			buf = []rune(e.history[i])
+		}
+		pos = len(buf)
+		redraw()
+	}
+
+	fmt.Fprint(e.out, prompt)
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			// Treat a pasted CRLF as one line end.
+			if r == '\r' && e.reader.Buffered() > 0 {
+				if b, _ := e.reader.Peek(1); b[0] == '\n' {
+					_, _ = e.reader.ReadByte()
+				}
+			}
+			fmt.Fprint(e.out, "\r\n")
+			return string(buf), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case keyCtrlD:
+			if len(buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+		case keyBackspace, keyCtrlH:
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+		case keyCtrlA:
+			pos = 0
+		case keyCtrlE:
+			pos = len(buf)
+		case keyCtrlB:
+			pos = max(pos-1, 0)
+		case keyCtrlF:
+			pos = min(pos+1, len(buf))
+		case keyCtrlK:
+			buf = buf[:pos]
+		case keyCtrlU:
+			buf = append([]rune{}, buf[pos:]...)
+			pos = 0
+		case keyCtrlW:
+			start := pos
+			for start > 0 && unicode.IsSpace(buf[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(buf[start-1]) {
+				start--
+			}
+			buf = append(buf[:start], buf[pos:]...)
+			pos = start
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			recall(hist - 1)
+			continue
+		case keyCtrlN:
+			recall(hist + 1)
+			continue
+		case keyEscape:
+			switch e.escapeSequence() {
+			case "A":
+				recall(hist - 1)
+				continue
+			case "B":
+				recall(hist + 1)
+				continue
+			case "C":
+				pos = min(pos+1, len(buf))
+			case "D":
+				pos = max(pos-1, 0)
+			case "H", "1~", "7~":
+				pos = 0
+			case "F", "4~", "8~":
+				pos = len(buf)
+			case "3~":
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+				}
+			}
+		case '\t':
+			buf = append(buf[:pos], append([]rune{' ', ' '}, buf[pos:]...)...)
+			pos += 2
+		default:
+			if !unicode.IsPrint(r) {
+				continue
+			}
+			buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+			pos++
+		}
+		redraw()
+	}
+}
+
+// escapeSequence reads the rest of a CSI or SS3 sequence after ESC and
+// returns its parameters and final byte, e.g. "A" or "3~".
+func (e *lineEditor) escapeSequence() string {
+	b, err := e.reader.ReadByte()
+	if err != nil || (b != '[' && b != 'O') {
+		return ""
+	}
+	var seq []byte
+	for {
+		c, err := e.reader.ReadByte()
+		if err != nil {
+			return ""
+		}
+		seq = append(seq, c)
+		if c >= 0x40 && c <= 0x7e {
+			return string(seq)
+		}
+	}
+}
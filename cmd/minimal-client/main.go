@@ -2,8 +2,8 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -40,6 +40,17 @@ func run() error {
 		return runAutomatedTests(client)
 	}
 
+	if flag.NArg() > 0 {
+		if flag.Arg(0) != "jobs" {
+			return fmt.Errorf("unknown command %q (want: jobs)", flag.Arg(0))
+		}
+		m := *model
+		if m == "" {
+			m = client.DefaultModel()
+		}
+		return runJobs(client, m, *system, flag.Args()[1:])
+	}
+
 	return runInteractive(client, *model, *system, *stream)
 }
 
@@ -107,6 +118,10 @@ func runInteractive(client *xai.Client, model, systemPrompt string, stream bool)
 	reasoningEnabled := true
 	reasoningEffort := xai.ReasoningEffortHigh
 
+	// Per-turn token usage and estimated cost, toggled with /usage
+	showUsage := false
+	usage := newUsageMeter(client)
+
 	fmt.Println("=== xAI Interactive Chat ===")
 	fmt.Printf("Model: %s\n", model)
 	fmt.Printf("Streaming: %v\n", stream)
@@ -116,16 +131,20 @@ func runInteractive(client *xai.Client, model, systemPrompt string, stream bool)
 	if reasoningEnabled {
 		printReasoningModelNote(model)
 	}
-	fmt.Println("Commands: /help, /model, /system, /stream, /tools, /context, /reasoning, /image, /quit")
+	fmt.Println("Commands: /help, /model, /system, /stream, /tools, /server-memory, /reasoning, /usage, /image, /quit")
+	fmt.Println("End a line with \\ to continue on the next, or use \"\"\" or /paste for multi-line input.")
 	fmt.Println("---")
 
-	reader := bufio.NewReader(os.Stdin)
-	var history []*message
+	editor := newLineEditor(os.Stdin, os.Stdout)
+	conv := client.NewConversation(nil).WithResponseChaining(useResponseId)
 
 	for {
-		fmt.Print("\nYou: ")
-		input, err := reader.ReadString('\n')
+		fmt.Println()
+		input, err := editor.readLine("You: ")
 		if err != nil {
+			if errors.Is(err, errInterrupted) {
+				continue
+			}
 			if err == io.EOF {
 				fmt.Println("\nGoodbye!")
 				return nil
@@ -138,8 +157,35 @@ func runInteractive(client *xai.Client, model, systemPrompt string, stream bool)
 			continue
 		}
 
+		// Multi-line input is sent as typed, never run as a command
+		multiline := true
+		switch {
+		case input == "/paste":
+			fmt.Println("Paste mode: end with a line containing only /end, or Ctrl-D.")
+			input, err = editor.readBlock(func(line string) (string, bool) {
+				return "", strings.TrimSpace(line) == "/end"
+			})
+		case strings.HasPrefix(input, quoteDelimiter):
+			input, err = readQuoted(editor, strings.TrimPrefix(input, quoteDelimiter))
+		default:
+			multiline = false
+		}
+		if multiline {
+			if errors.Is(err, errInterrupted) {
+				fmt.Println("Input discarded.")
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			input = strings.TrimSpace(input)
+			if input == "" {
+				continue
+			}
+		}
+
 		// Handle commands
-		if strings.HasPrefix(input, "/") {
+		if !multiline && strings.HasPrefix(input, "/") {
 			switch {
 			case input == "/quit" || input == "/exit" || input == "/q":
 				fmt.Println("Goodbye!")
@@ -150,8 +196,9 @@ func runInteractive(client *xai.Client, model, systemPrompt string, stream bool)
 				continue
 
 			case input == "/clear" || input == "/c":
-				history = nil
+				conv = client.NewConversation(nil).WithResponseChaining(useResponseId)
 				lastResponseId = ""
+				usage.reset()
 				fmt.Println("Conversation cleared.")
 				continue
 
@@ -160,8 +207,33 @@ func runInteractive(client *xai.Client, model, systemPrompt string, stream bool)
 				fmt.Printf("Streaming: %v\n", stream)
 				continue
 
+			case input == "/usage" || input == "/u":
+				showUsage = !showUsage
+				fmt.Printf("Usage display: %v\n", showUsage)
+				continue
+
+			case strings.HasPrefix(input, "/defer "):
+				prompt := strings.TrimSpace(strings.TrimPrefix(input, "/defer "))
+				j, err := startJob(client, model, systemPrompt, prompt)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					continue
+				}
+				fmt.Printf("Started deferred job %s; check it with /jobs\n", j.ID)
+				continue
+
+			case input == "/jobs" || strings.HasPrefix(input, "/jobs "):
+				args := strings.Fields(strings.TrimPrefix(input, "/jobs"))
+				if len(args) == 0 {
+					args = []string{"list"}
+				}
+				if err := runJobs(client, model, systemPrompt, args); err != nil {
+					fmt.Printf("Error: %v\n", err)
+				}
+				continue
+
 			case input == "/info" || input == "/i":
-				printInfo(client, model, systemPrompt, stream, len(history))
+				printInfo(client, model, systemPrompt, stream, len(conv.Request().Messages()))
 				continue
 
 			case input == "/model" || input == "/m":
@@ -256,20 +328,30 @@ func runInteractive(client *xai.Client, model, systemPrompt string, stream bool)
 				if lastResponseId != "" {
 					fmt.Printf("Last response ID: %s\n", lastResponseId)
 				}
-				fmt.Println("Use /context to toggle between modes")
+				fmt.Println("Use /context mode or /server-memory [on|off] to switch modes")
 				continue
 
 			case strings.HasPrefix(input, "/context "):
 				// Toggle mode (ignore any argument for simplicity)
 				useResponseId = !useResponseId
-				if useResponseId {
-					fmt.Println("Context mode: response_id (server-side)")
-					fmt.Println("Conversation will use previous_response_id for context")
-					fmt.Println("Storage: automatically enabled (required)")
-				} else {
-					fmt.Println("Context mode: history (client-side)")
-					fmt.Println("Full message history will be sent with each request")
+				conv.WithResponseChaining(useResponseId)
+				printContextMode(useResponseId)
+				continue
+
+			case input == "/server-memory" || strings.HasPrefix(input, "/server-memory "):
+				switch arg := strings.TrimSpace(strings.TrimPrefix(input, "/server-memory")); arg {
+				case "":
+					useResponseId = !useResponseId
+				case "on":
+					useResponseId = true
+				case "off":
+					useResponseId = false
+				default:
+					fmt.Println("Usage: /server-memory [on|off]")
+					continue
 				}
+				conv.WithResponseChaining(useResponseId)
+				printContextMode(useResponseId)
 				continue
 
 			case input == "/reasoning" || input == "/r":
@@ -312,35 +394,11 @@ func runInteractive(client *xai.Client, model, systemPrompt string, stream bool)
 			}
 		}
 
-		// Add user message to history (always keep for fallback/display)
-		history = append(history, &message{role: "user", content: input})
-
-		// Build request based on context mode
-		// Storage is automatically enabled in response_id mode (required for chaining)
+		// Settings can change between turns; the conversation keeps the
+		// history and, in response_id mode, chains from the last response
 		req := xai.NewChatRequest().
 			WithModel(model).
-			WithStoreMessages(useResponseId)
-
-		if useResponseId && lastResponseId != "" {
-			// Use server-side context - only send the new message
-			req.WithPreviousResponseId(lastResponseId).
-				UserMessage(xai.UserContent{Text: input})
-		} else {
-			// Use client-side history - send everything
-			req.SystemMessage(xai.SystemContent{Text: systemPrompt})
-			for _, msg := range history {
-				switch msg.role {
-				case "user":
-					req.UserMessage(xai.UserContent{Text: msg.content})
-				case "assistant":
-					// Skip empty assistant messages (e.g., tool-only responses)
-					// xAI requires at least one content element per message
-					if msg.content != "" {
-						req.AssistantMessage(xai.AssistantContent{Text: msg.content})
-					}
-				}
-			}
-		}
+			WithSystemPrompt(xai.NewSystemPrompt(systemPrompt))
 
 		// Add enabled tools
 		if tools.webSearch {
@@ -358,52 +416,44 @@ func runInteractive(client *xai.Client, model, systemPrompt string, stream bool)
 			req.WithReasoningEffort(reasoningEffort)
 		}
 
+		conv.Configure(req)
 		ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 
 		fmt.Print("\nAssistant: ")
 
-		var response string
-		var responseId string
+		var resp *xai.ChatResponse
 		if stream {
-			response, responseId, err = streamResponse(ctx, client, req)
+			resp, err = streamResponse(ctx, conv, input)
 		} else {
-			response, responseId, err = blockingResponse(ctx, client, req)
+			resp, err = blockingResponse(ctx, conv, input)
 		}
 		cancel()
 
 		if err != nil {
+			// The conversation drops the failed user message itself
 			fmt.Printf("\nError: %v\n", err)
-			// Remove the failed user message from history
-			history = history[:len(history)-1]
 			continue
 		}
 
 		fmt.Println()
 
 		// Update last response ID for next turn
-		if responseId != "" {
-			lastResponseId = responseId
+		if resp.ID != "" {
+			lastResponseId = resp.ID
+		}
+		if showUsage {
+			usage.print(model, resp.Usage)
 		}
-
-		// Add assistant response to history
-		history = append(history, &message{role: "assistant", content: response})
 	}
 }
 
-type message struct {
-	role    string
-	content string
-}
-
-func streamResponse(ctx context.Context, client *xai.Client, req *xai.ChatRequest) (string, string, error) {
-	stream, err := client.StreamChat(ctx, req)
+func streamResponse(ctx context.Context, conv *xai.Conversation, input string) (*xai.ChatResponse, error) {
+	stream, err := conv.Stream(ctx, input)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
+	defer stream.Close()
 
-	var content strings.Builder
-	var reasoning strings.Builder
-	var responseId string
 	var toolCalls []*xai.ToolCallInfo
 	var citations []string
 	reasoningStarted := false
@@ -417,12 +467,7 @@ func streamResponse(ctx context.Context, client *xai.Client, req *xai.ChatReques
 			break
 		}
 		if err != nil {
-			return content.String(), responseId, err
-		}
-
-		// Capture response ID (usually in first chunk)
-		if chunk.ID != "" {
-			responseId = chunk.ID
+			return nil, err
 		}
 
 		// Debug: show raw chunk fields when XAI_DEBUG is set
@@ -442,7 +487,6 @@ func streamResponse(ctx context.Context, client *xai.Client, req *xai.ChatReques
 				reasoningStarted = true
 			}
 			fmt.Print(chunk.ReasoningDelta)
-			reasoning.WriteString(chunk.ReasoningDelta)
 		}
 
 		// Announce tools as they arrive with status
@@ -487,7 +531,6 @@ func streamResponse(ctx context.Context, client *xai.Client, req *xai.ChatReques
 		}
 
 		fmt.Print(chunk.Delta)
-		content.WriteString(chunk.Delta)
 
 		// Collect tool calls and citations from chunks
 		toolCalls = append(toolCalls, chunk.ToolCalls...)
@@ -505,13 +548,13 @@ func streamResponse(ctx context.Context, client *xai.Client, req *xai.ChatReques
 	displayToolCalls(toolCalls)
 	displayCitations(citations)
 
-	return content.String(), responseId, nil
+	return stream.Response(), nil
 }
 
-func blockingResponse(ctx context.Context, client *xai.Client, req *xai.ChatRequest) (string, string, error) {
-	resp, err := client.CompleteChat(ctx, req)
+func blockingResponse(ctx context.Context, conv *xai.Conversation, input string) (*xai.ChatResponse, error) {
+	resp, err := conv.Send(ctx, input)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	// Display reasoning if present
@@ -528,7 +571,7 @@ func blockingResponse(ctx context.Context, client *xai.Client, req *xai.ChatRequ
 	displayToolCalls(resp.ToolCalls)
 	displayCitations(resp.Citations)
 
-	return resp.Content, resp.ID, nil
+	return resp, nil
 }
 
 func displayToolCalls(toolCalls []*xai.ToolCallInfo) {
@@ -593,16 +636,75 @@ Commands:
   /tools <name>        Toggle tool (web, x, code, all, off)
   /context, /ctx       Show context mode (toggle with /context mode)
   /context mode        Toggle between response_id (server) and history (client)
+  /server-memory [on|off]
+                       Chain turns server-side with previous_response_id
+                       (on) or resend the full history (off); no argument toggles
   /reasoning, /r       Show reasoning settings
   /reasoning <level>   Set reasoning level (off, low, medium, high)
                        Note: Only grok-3-mini shows visible thinking traces
+  /usage, /u           Toggle per-turn token usage and estimated cost
+  /defer <prompt>      Start a deferred completion in the background
+  /jobs [command]      List deferred jobs, or: status|cancel <id>, clear,
+                       help (add -watch to list or status to poll)
   /image <prompt>      Generate an image (options: -wide, -tall, -2k)
   /image-model         Show current image model
   /image-model <name>  Change the image model
   /image-models, /im   List available image models
+
+Editing:
+  Left/Right, Home/End Move the cursor (also Ctrl-B/F, Ctrl-A/E)
+  Up/Down              Recall earlier lines (also Ctrl-P/N)
+  Ctrl-W, Ctrl-U/K     Delete the previous word, or to the start/end of line
+  Ctrl-C               Discard the line; Ctrl-D on an empty line exits
+  Trailing \           Continue the message on the next line
+
+Multi-line input:
+  """                  Start a message; it ends at a line ending in """
+  /paste               Paste a block; it ends at a line "/end" or Ctrl-D
 `)
 }
 
+// quoteDelimiter starts and ends a multi-line message
+const quoteDelimiter = `"""`
+
+// readQuoted reads a """-delimited message whose first line, after the
+// opening delimiter, is first. The message may also close on that line.
+func readQuoted(editor *lineEditor, first string) (string, error) {
+	if last, done := closesQuote(first); done {
+		return last, nil
+	}
+	rest, err := editor.readBlock(closesQuote)
+	if err != nil {
+		return "", err
+	}
+	if first == "" {
+		return rest, nil
+	}
+	return first + "\n" + rest, nil
+}
+
+// closesQuote reports whether line ends the quoted message, and returns the
+// text before the closing delimiter.
+func closesQuote(line string) (string, bool) {
+	trimmed := strings.TrimRight(line, " \t")
+	if !strings.HasSuffix(trimmed, quoteDelimiter) {
+		return "", false
+	}
+	return strings.TrimSuffix(trimmed, quoteDelimiter), true
+}
+
+// printContextMode describes a newly selected context mode
+func printContextMode(useResponseId bool) {
+	if useResponseId {
+		fmt.Println("Context mode: response_id (server-side)")
+		fmt.Println("Only new messages are sent; previous_response_id points at the stored context")
+		fmt.Println("Storage: automatically enabled (required)")
+	} else {
+		fmt.Println("Context mode: history (client-side)")
+		fmt.Println("Full message history will be sent with each request")
+	}
+}
+
 func contextModeString(useResponseId bool) string {
 	if useResponseId {
 		return "response_id (server-side, storage: on)"
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd || windows)
+
+package main
+
+import "errors"
+
+// Line editing is not supported here; input is read as plain lines.
+func isTerminal(int) bool { return false }
+
+func makeRaw(int) (func(), error) { return nil, errors.ErrUnsupported }
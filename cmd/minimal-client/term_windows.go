@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+func isTerminal(fd int) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(fd), &mode) == nil
+}
+
+// makeRaw switches the console to unbuffered input without echo, reporting
+// keys as VT sequences, and enables VT output so the editor's redraws
+// render. It returns a function that restores both modes.
+func makeRaw(fd int) (func(), error) {
+	in := windows.Handle(fd)
+	var oldIn uint32
+	if err := windows.GetConsoleMode(in, &oldIn); err != nil {
+		return nil, err
+	}
+	raw := oldIn&^(windows.ENABLE_ECHO_INPUT|windows.ENABLE_PROCESSED_INPUT|windows.ENABLE_LINE_INPUT) |
+		windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(in, raw); err != nil {
+		return nil, err
+	}
+
+	out := windows.Handle(os.Stdout.Fd())
+	var oldOut uint32
+	outOK := windows.GetConsoleMode(out, &oldOut) == nil &&
+		windows.SetConsoleMode(out, oldOut|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+
+	return func() {
+		_ = windows.SetConsoleMode(in, oldIn)
+		if outOK {
+			_ = windows.SetConsoleMode(out, oldOut)
+		}
+	}, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+// usageMeter prints the token usage and estimated cost of each turn, and
+// keeps the totals for the conversation.
+type usageMeter struct {
+	client  *xai.Client
+	pricing map[string]*xai.LanguageModel
+
+	turns    int
+	tokens   int32
+	usd      float64
+	unpriced bool
+}
+
+func newUsageMeter(client *xai.Client) *usageMeter {
+	return &usageMeter{client: client, pricing: make(map[string]*xai.LanguageModel)}
+}
+
+// reset clears the conversation totals.
+func (m *usageMeter) reset() {
+	m.turns, m.tokens, m.usd, m.unpriced = 0, 0, 0, false
+}
+
+// record adds a turn on model to the totals and returns its cost, or false
+// if the model's pricing is unknown.
+func (m *usageMeter) record(model string, u xai.Usage) (float64, bool) {
+	m.turns++
+	m.tokens += u.TotalTokens
+	info := m.model(model)
+	if info == nil {
+		m.unpriced = true
+		return 0, false
+	}
+	usd := info.UsageCost(u)
+	m.usd += usd
+	return usd, true
+}
+
+// model returns the pricing of model, looked up once per model.
+func (m *usageMeter) model(name string) *xai.LanguageModel {
+	if info, ok := m.pricing[name]; ok {
+		return info
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info, err := m.client.GetModel(ctx, name)
+	if err != nil {
+		// Remember the failure too, so every turn does not retry it
+		info = nil
+	}
+	m.pricing[name] = info
+	return info
+}
+
+// print records the turn and prints its usage and the conversation totals.
+func (m *usageMeter) print(model string, u xai.Usage) {
+	usd, priced := m.record(model, u)
+
+	prompt := fmt.Sprintf("prompt %d", u.PromptTokens)
+	if u.CachedPromptTokens > 0 {
+		prompt += fmt.Sprintf(" (%d cached)", u.CachedPromptTokens)
+	}
+	cost := "cost unknown"
+	if priced {
+		cost = "~" + formatUSD(usd)
+	}
+	fmt.Printf("\n[Usage] %s, completion %d, reasoning %d tokens | %s\n",
+		prompt, u.CompletionTokens, u.ReasoningTokens, cost)
+
+	total := "~" + formatUSD(m.usd)
+	if m.unpriced {
+		total += " (some turns unpriced)"
+	}
+	fmt.Printf("        conversation: %d turns, %d tokens, %s\n", m.turns, m.tokens, total)
+}
+
+// formatUSD formats a small dollar amount with enough digits to compare
+// prompts.
+func formatUSD(usd float64) string {
+	if usd < 0.01 {
+		return fmt.Sprintf("$%.6f", usd)
+	}
+	return fmt.Sprintf("$%.4f", usd)
+}
@@ -0,0 +1,96 @@
+package xai
+
+import "time"
+
+// coalescer holds back small stream chunks and merges them, as configured
+// by Config.MinDeltaBytes and Config.MinDeltaInterval.
+type coalescer struct {
+	minBytes    int
+	minInterval time.Duration
+	clock       Clock
+
+	held *ChatChunk
+	// last is when a chunk was last delivered; zero before the first.
+	last time.Time
+	// err is returned once the held chunk has been delivered.
+	err error
+}
+
+// newCoalescer returns the coalescer for cfg, or nil if it coalesces
+// nothing.
+func newCoalescer(cfg Config) *coalescer {
+	if cfg.MinDeltaBytes <= 0 && cfg.MinDeltaInterval <= 0 {
+		return nil
+	}
+	return &coalescer{minBytes: cfg.MinDeltaBytes, minInterval: cfg.MinDeltaInterval, clock: cfg.Clock}
+}
+
+// next returns the next chunk to deliver, reading as many chunks from
+// recv as it takes. The end of the stream or an error flushes the held
+// chunk first.
+func (c *coalescer) next(recv func() (*ChatChunk, error)) (*ChatChunk, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	for {
+		chunk, err := recv()
+		if err != nil {
+			if held := c.held; held != nil {
+				c.held, c.err = nil, err
+				return held, nil
+			}
+			return nil, err
+		}
+		if c.held == nil {
+			c.held = chunk
+		} else {
+			mergeChunk(c.held, chunk)
+		}
+		if now := c.clock.Now(); c.ready(now) {
+			held := c.held
+			c.held, c.last = nil, now
+			return held, nil
+		}
+	}
+}
+
+// ready reports whether the held chunk should be delivered now. The first
+// chunk and chunks marking a step in the response (tool calls, the finish
+// reason, citations) are never held back.
+func (c *coalescer) ready(now time.Time) bool {
+	h := c.held
+	if c.last.IsZero() || len(h.ToolCalls) > 0 || h.FinishReason != "" || len(h.Citations) > 0 {
+		return true
+	}
+	if c.minBytes > 0 && len(h.Delta)+len(h.ReasoningDelta) >= c.minBytes {
+		return true
+	}
+	return c.minInterval > 0 && now.Sub(c.last) >= c.minInterval
+}
+
+// mergeChunk folds next into dst, as if they had arrived as one chunk.
+func mergeChunk(dst, next *ChatChunk) {
+	if next.ReasoningRestarted {
+		// The restart discards the reasoning so far, held or not.
+		dst.ReasoningDelta = ""
+		dst.ReasoningRestarted = true
+	}
+	dst.Delta += next.Delta
+	dst.ReasoningDelta += next.ReasoningDelta
+	dst.EncryptedContent += next.EncryptedContent
+	dst.ToolCalls = append(dst.ToolCalls, next.ToolCalls...)
+	dst.Logprobs = append(dst.Logprobs, next.Logprobs...)
+	dst.Citations = append(dst.Citations, next.Citations...)
+	if next.Usage != (Usage{}) {
+		dst.Usage = next.Usage
+	}
+	if next.ID != "" {
+		dst.ID = next.ID
+	}
+	if next.Model != "" {
+		dst.Model = next.Model
+	}
+	if next.FinishReason != "" {
+		dst.FinishReason = next.FinishReason
+	}
+}
@@ -0,0 +1,49 @@
+package xai
+
+import (
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// attachCodeExecutionOutputs sets CodeExecutionOutput on the calls that the
+// tool messages among msgs belong to. Tool messages carry the ID of their
+// call when the server provides it; otherwise they are matched in order.
+// The messages remain outputs of the response.
+func attachCodeExecutionOutputs(msgs []*v1.CompletionMessage, calls []*ToolCallInfo) {
+	var pending []*ToolCallInfo
+	byID := make(map[string]*ToolCallInfo)
+	for _, tc := range calls {
+		if tc != nil && tc.kind == v1.ToolCallType_TOOL_CALL_TYPE_CODE_EXECUTION_TOOL {
+			pending = append(pending, tc)
+			byID[tc.ID] = tc
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	attached := make(map[*ToolCallInfo]bool)
+	for _, msg := range msgs {
+		if msg.GetRole() != v1.MessageRole_ROLE_TOOL {
+			continue
+		}
+		var call *ToolCallInfo
+		for _, tc := range msg.GetToolCalls() {
+			if c, ok := byID[tc.GetId()]; ok {
+				call = c
+				break
+			}
+		}
+		if call == nil && len(msg.GetToolCalls()) == 0 {
+			for _, c := range pending {
+				if !attached[c] {
+					call = c
+					break
+				}
+			}
+		}
+		if call != nil && !attached[call] {
+			call.CodeExecutionOutput = msg.GetContent()
+			attached[call] = true
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package xai
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultCompressionThreshold is the request size, in bytes, above which
+// unary requests are gzip-compressed when Config.EnableCompression is set.
+const DefaultCompressionThreshold = 4 * 1024
+
+// compressionUnaryInterceptor gzip-compresses unary requests of at least
+// threshold bytes. Small requests are sent as-is since compressing them
+// costs more CPU than it saves on the wire.
+func compressionUnaryInterceptor(threshold int) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if m, ok := req.(proto.Message); ok && proto.Size(m) >= threshold {
+			opts = append(opts, grpc.UseCompressor(gzip.Name))
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// compressionStreamInterceptor gzip-compresses streaming requests. The size of
+// a streamed request is not known when the stream opens, so streams are
+// always compressed.
+func compressionStreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		opts = append(opts, grpc.UseCompressor(gzip.Name))
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,177 @@
+package xai
+
+import (
+	"math"
+	"strings"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// TokenLogprob is the log probability of one generated token.
+type TokenLogprob struct {
+	// Token is the token text.
+	Token string `json:"token"`
+	// Logprob is the natural log of the token's probability.
+	Logprob float32 `json:"logprob"`
+	// TopLogprobs are the most likely alternatives at this position, when
+	// requested with WithLogprobs(n > 0).
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+// TopLogprob is an alternative token and its log probability.
+type TopLogprob struct {
+	// Token is the token text.
+	Token string `json:"token"`
+	// Logprob is the natural log of the token's probability.
+	Logprob float32 `json:"logprob"`
+}
+
+func logprobsFromProto(lp *v1.LogProbs) []TokenLogprob {
+	if lp == nil || len(lp.GetContent()) == 0 {
+		return nil
+	}
+	out := make([]TokenLogprob, 0, len(lp.GetContent()))
+	for _, p := range lp.GetContent() {
+		t := TokenLogprob{Token: p.GetToken(), Logprob: p.GetLogprob()}
+		for _, top := range p.GetTopLogprobs() {
+			t.TopLogprobs = append(t.TopLogprobs, TopLogprob{Token: top.GetToken(), Logprob: top.GetLogprob()})
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// DefaultLowConfidenceProbability is the token probability below which a
+// token counts as low confidence.
+const DefaultLowConfidenceProbability = 0.3
+
+// ConfidenceOption tunes ConfidenceScore.
+type ConfidenceOption func(*confidenceOptions)
+
+type confidenceOptions struct {
+	lowProb     float64
+	minSpan     int
+	calibration func(float64) float64
+}
+
+// WithLowConfidenceProbability sets the token probability (0-1) below which a
+// token is considered low confidence (default DefaultLowConfidenceProbability).
+func WithLowConfidenceProbability(p float64) ConfidenceOption {
+	return func(o *confidenceOptions) {
+		o.lowProb = p
+	}
+}
+
+// WithMinLowConfidenceSpan sets how many consecutive low-confidence tokens
+// form a reported span (default 1).
+func WithMinLowConfidenceSpan(n int) ConfidenceOption {
+	return func(o *confidenceOptions) {
+		o.minSpan = n
+	}
+}
+
+// WithCalibration maps the raw score to a calibrated one, e.g. a curve
+// fitted on labelled answers for a particular model and task. The result is
+// clamped to [0, 1].
+func WithCalibration(fn func(raw float64) float64) ConfidenceOption {
+	return func(o *confidenceOptions) {
+		o.calibration = fn
+	}
+}
+
+// LowConfidenceSpan is a run of consecutive low-confidence tokens.
+type LowConfidenceSpan struct {
+	// Start and End are token indexes into Logprobs (End exclusive).
+	Start, End int
+	// Text is the concatenated token text.
+	Text string
+	// MeanLogprob is the mean log probability over the span.
+	MeanLogprob float64
+}
+
+// ConfidenceReport summarizes how sure the model was of its answer.
+type ConfidenceReport struct {
+	// Score is the (optionally calibrated) confidence in [0, 1]: the geometric
+	// mean token probability, exp(MeanLogprob), before calibration.
+	Score float64
+	// RawScore is the uncalibrated score.
+	RawScore float64
+	// MeanLogprob is the mean token log probability.
+	MeanLogprob float64
+	// MinLogprob is the lowest token log probability.
+	MinLogprob float64
+	// Perplexity is exp(-MeanLogprob).
+	Perplexity float64
+	// Tokens is the number of tokens scored.
+	Tokens int
+	// LowConfidenceSpans are runs of tokens below the low-confidence threshold.
+	LowConfidenceSpans []LowConfidenceSpan
+}
+
+// ConfidenceScore computes heuristic confidence from the response's token
+// log probabilities. The request must have enabled WithLogprobs; otherwise
+// an ErrInvalidRequest error is returned.
+//
+// These are heuristics rather than guarantees of correctness: a fluent but
+// wrong answer can still score highly.
+func (r *ChatResponse) ConfidenceScore(opts ...ConfidenceOption) (*ConfidenceReport, error) {
+	return confidenceFromLogprobs(r.Logprobs, opts)
+}
+
+func confidenceFromLogprobs(lps []TokenLogprob, opts []ConfidenceOption) (*ConfidenceReport, error) {
+	if len(lps) == 0 {
+		return nil, &Error{
+			Code:    ErrInvalidRequest,
+			Message: "response has no logprobs; enable them with WithLogprobs",
+		}
+	}
+
+	o := confidenceOptions{lowProb: DefaultLowConfidenceProbability, minSpan: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	threshold := math.Log(o.lowProb)
+
+	report := &ConfidenceReport{Tokens: len(lps), MinLogprob: math.Inf(1)}
+	sum := 0.0
+	spanStart := -1
+	flush := func(end int) {
+		if spanStart >= 0 && end-spanStart >= o.minSpan {
+			var text strings.Builder
+			spanSum := 0.0
+			for _, t := range lps[spanStart:end] {
+				text.WriteString(t.Token)
+				spanSum += float64(t.Logprob)
+			}
+			report.LowConfidenceSpans = append(report.LowConfidenceSpans, LowConfidenceSpan{
+				Start:       spanStart,
+				End:         end,
+				Text:        text.String(),
+				MeanLogprob: spanSum / float64(end-spanStart),
+			})
+		}
+		spanStart = -1
+	}
+	for i, t := range lps {
+		lp := float64(t.Logprob)
+		sum += lp
+		report.MinLogprob = math.Min(report.MinLogprob, lp)
+		if lp < threshold {
+			if spanStart < 0 {
+				spanStart = i
+			}
+		} else {
+			flush(i)
+		}
+	}
+	flush(len(lps))
+
+	report.MeanLogprob = sum / float64(len(lps))
+	report.Perplexity = math.Exp(-report.MeanLogprob)
+	report.RawScore = math.Exp(report.MeanLogprob)
+	report.Score = report.RawScore
+	if o.calibration != nil {
+		report.Score = math.Max(0, math.Min(1, o.calibration(report.RawScore)))
+	}
+	return report, nil
+}
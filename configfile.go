@@ -0,0 +1,295 @@
+package xai
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FromConfigFile creates a client from a YAML or JSON configuration file.
+// See LoadConfigFile for the format.
+func FromConfigFile(path string) (*Client, error) {
+	cfg, err := LoadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
+
+// LoadConfigFile reads a YAML or JSON configuration file into a Config, so it
+// can be adjusted before calling New. Files ending in .json are parsed as JSON;
+// anything else as YAML. Only flat "key: value" YAML is supported.
+//
+// Recognized keys (all optional):
+//
+//	endpoint: api.x.ai:443
+//	transport: grpc                    # or rest, grpc-web
+//	rest_endpoint: https://api.x.ai/v1
+//	grpc_web_endpoint: https://grpc-web.example.com
+//	default_model: grok-4-1-fast-reasoning
+//	timeout: 120s                      # Go duration, or a number of seconds
+//	keepalive_time: 30s                # -1 disables keepalive
+//	keepalive_timeout: 10s
+//	keepalive_permit_without_stream: true
+//	enable_compression: false
+//	compression_threshold: 4096
+//	current_date: false
+//	idle_reconnect: 5m
+//	warmup: true
+//	drop_unsupported_features: false
+//	min_delta_bytes: 64
+//	min_delta_interval: 50ms
+//	app_name: billing/1.4
+//	max_budget_usd: 25
+//	api_key_env: XAI_APIKEY            # environment variable holding the key
+//	api_key_file: /run/secrets/xai     # file holding the key
+//
+// The key itself never appears in the file. If neither api_key_env nor
+// api_key_file is given, the XAI_APIKEY environment variable is used.
+// Unknown keys are rejected to catch typos.
+func LoadConfigFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, &Error{Code: ErrInvalidRequest, Message: "reading config file", Cause: err}
+	}
+
+	var values map[string]string
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		values, err = parseJSONConfig(data)
+	} else {
+		values, err = parseYAMLConfig(data)
+	}
+	if err != nil {
+		return Config{}, &Error{Code: ErrInvalidRequest, Message: "parsing config file " + path, Cause: err}
+	}
+
+	cfg, err := configFromValues(values)
+	if err != nil {
+		return Config{}, &Error{Code: ErrInvalidRequest, Message: "config file " + path, Cause: err}
+	}
+	return cfg, nil
+}
+
+func configFromValues(values map[string]string) (Config, error) {
+	var cfg Config
+	keyEnv, keyFile := "", ""
+
+	// Sorted for deterministic error reporting.
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := values[k]
+		var err error
+		switch k {
+		case "endpoint":
+			cfg.Endpoint = v
+		case "transport":
+			switch strings.ToLower(v) {
+			case "grpc":
+				cfg.Transport = TransportGRPC
+			case "rest":
+				cfg.Transport = TransportREST
+			case "grpc-web":
+				cfg.Transport = TransportGRPCWeb
+			default:
+				err = fmt.Errorf("must be grpc, rest or grpc-web, got %q", v)
+			}
+		case "rest_endpoint":
+			cfg.RESTEndpoint = v
+		case "grpc_web_endpoint":
+			cfg.GRPCWebEndpoint = v
+		case "default_model":
+			cfg.DefaultModel = v
+		case "timeout":
+			cfg.Timeout, err = parseConfigDuration(v)
+		case "keepalive_time":
+			cfg.KeepaliveTime, err = parseConfigDuration(v)
+		case "keepalive_timeout":
+			cfg.KeepaliveTimeout, err = parseConfigDuration(v)
+		case "keepalive_permit_without_stream":
+			var b bool
+			b, err = strconv.ParseBool(v)
+			cfg.KeepalivePermitWithoutStream = &b
+		case "enable_compression":
+			cfg.EnableCompression, err = strconv.ParseBool(v)
+		case "compression_threshold":
+			cfg.CompressionThreshold, err = strconv.Atoi(v)
+		case "current_date":
+			cfg.CurrentDate, err = strconv.ParseBool(v)
+		case "idle_reconnect":
+			cfg.IdleReconnect, err = parseConfigDuration(v)
+		case "app_name":
+			cfg.AppName = v
+		case "max_budget_usd":
+			cfg.MaxBudgetUSD, err = strconv.ParseFloat(v, 64)
+		case "warmup":
+			cfg.Warmup, err = strconv.ParseBool(v)
+		case "drop_unsupported_features":
+			cfg.DropUnsupportedFeatures, err = strconv.ParseBool(v)
+		case "min_delta_bytes":
+			cfg.MinDeltaBytes, err = strconv.Atoi(v)
+		case "min_delta_interval":
+			cfg.MinDeltaInterval, err = parseConfigDuration(v)
+		case "api_key_env":
+			keyEnv = v
+		case "api_key_file":
+			keyFile = v
+		default:
+			return Config{}, fmt.Errorf("unknown key %q", k)
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+
+	switch {
+	case keyEnv != "" && keyFile != "":
+		return Config{}, fmt.Errorf("api_key_env and api_key_file are mutually exclusive")
+	case keyFile != "":
+		b, err := os.ReadFile(keyFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("api_key_file: %w", err)
+		}
+		cfg.APIKey = NewSecureString(strings.TrimSpace(string(b)))
+	default:
+		if keyEnv == "" {
+			keyEnv = EnvAPIKey
+		}
+		key := os.Getenv(keyEnv)
+		if key == "" {
+			return Config{}, fmt.Errorf("environment variable %s is not set", keyEnv)
+		}
+		cfg.APIKey = NewSecureString(key)
+	}
+	return cfg, nil
+}
+
+// parseConfigDuration accepts Go durations ("30s") or plain seconds ("30").
+func parseConfigDuration(v string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return time.ParseDuration(v)
+}
+
+func parseJSONConfig(data []byte) (map[string]string, error) {
+	var raw map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			values[k] = v
+		case json.Number:
+			values[k] = v.String()
+		case bool:
+			values[k] = strconv.FormatBool(v)
+		case nil:
+			// Treated as unset.
+		default:
+			return nil, fmt.Errorf("%s: nested values are not supported", k)
+		}
+	}
+	return values, nil
+}
+
+// parseYAMLConfig parses flat "key: value" YAML with comments and quoted
+// strings. Nesting, lists and multi-line values are rejected.
+func parseYAMLConfig(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	seen := make(map[string]bool)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if text[0] == ' ' || text[0] == '\t' || strings.HasPrefix(trimmed, "- ") {
+			return nil, fmt.Errorf("line %d: only flat key: value pairs are supported", line)
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", line)
+		}
+		key = strings.TrimSpace(key)
+		value, set, err := yamlScalar(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line, key)
+		}
+		seen[key] = true
+		// Null and empty values are treated as unset, as null is in JSON.
+		if set {
+			values[key] = value
+		}
+	}
+	return values, sc.Err()
+}
+
+// yamlScalar unquotes a scalar and strips trailing comments. set is false
+// for null and empty values.
+func yamlScalar(v string) (value string, set bool, err error) {
+	switch {
+	case strings.HasPrefix(v, `"`), strings.HasPrefix(v, "'"):
+		end := yamlQuoteEnd(v)
+		if end < 0 {
+			return "", false, fmt.Errorf("unterminated string")
+		}
+		if rest := strings.TrimSpace(v[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", false, fmt.Errorf("unexpected %q after quoted value", rest)
+		}
+		if v[0] == '"' {
+			value, err = strconv.Unquote(v[:end+1])
+			return value, err == nil, err
+		}
+		return strings.ReplaceAll(v[1:end], "''", "'"), true, nil
+	case v == "|" || v == ">":
+		return "", false, fmt.Errorf("multi-line values are not supported")
+	}
+	if strings.HasPrefix(v, "#") {
+		v = ""
+	} else if i := strings.Index(v, " #"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	switch v {
+	case "", "~", "null", "Null", "NULL":
+		return "", false, nil
+	}
+	return v, true, nil
+}
+
+// yamlQuoteEnd returns the index of the quote closing the quoted value at
+// the start of v, or -1 if it is unterminated. Double-quoted values escape
+// with a backslash, single-quoted ones by doubling the quote.
+func yamlQuoteEnd(v string) int {
+	q := v[0]
+	for i := 1; i < len(v); i++ {
+		switch {
+		case q == '"' && v[i] == '\\':
+			i++
+		case v[i] == q && q == '\'' && i+1 < len(v) && v[i+1] == '\'':
+			i++
+		case v[i] == q:
+			return i
+		}
+	}
+	return -1
+}
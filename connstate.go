@@ -0,0 +1,133 @@
+package xai
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+// ConnState is the state of the client's gRPC connection.
+type ConnState int
+
+const (
+	// ConnStateIdle means there is no connection; the next request (or an
+	// idle reconnect) will establish one.
+	ConnStateIdle ConnState = iota
+	// ConnStateConnecting means a connection is being established.
+	ConnStateConnecting
+	// ConnStateReady means the connection is up.
+	ConnStateReady
+	// ConnStateTransientFailure means connecting failed and is being retried
+	// with backoff.
+	ConnStateTransientFailure
+	// ConnStateShutdown means the client was closed.
+	ConnStateShutdown
+)
+
+// String returns the gRPC name of the state, e.g. "READY".
+func (s ConnState) String() string {
+	switch s {
+	case ConnStateIdle:
+		return "IDLE"
+	case ConnStateConnecting:
+		return "CONNECTING"
+	case ConnStateReady:
+		return "READY"
+	case ConnStateTransientFailure:
+		return "TRANSIENT_FAILURE"
+	case ConnStateShutdown:
+		return "SHUTDOWN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func connStateFromGRPC(s connectivity.State) ConnState {
+	switch s {
+	case connectivity.Connecting:
+		return ConnStateConnecting
+	case connectivity.Ready:
+		return ConnStateReady
+	case connectivity.TransientFailure:
+		return ConnStateTransientFailure
+	case connectivity.Shutdown:
+		return ConnStateShutdown
+	default:
+		return ConnStateIdle
+	}
+}
+
+// ConnState returns the current state of the connection.
+func (c *Client) ConnState() ConnState {
+	if c.conn == nil {
+		return ConnStateShutdown
+	}
+	return connStateFromGRPC(c.conn.GetState())
+}
+
+// watchConnState reports state transitions away from state to the
+// configured callback and, if IdleReconnect is set, reconnects whenever the
+// connection has been idle or failing for that long. It returns when the
+// connection shuts down or ctx is done. Short-lived intermediate states may
+// be coalesced.
+func (c *Client) watchConnState(ctx context.Context, state connectivity.State) {
+	onChange := c.config.OnConnStateChange
+	every := c.config.IdleReconnect
+	for {
+		wctx, cancel := ctx, context.CancelFunc(func() {})
+		if every > 0 {
+			wctx, cancel = context.WithTimeout(ctx, every)
+		}
+		changed := c.conn.WaitForStateChange(wctx, state)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if changed {
+			prev := state
+			state = c.conn.GetState()
+			c.logger.Debug("connection state changed",
+				slog.String("from", connStateFromGRPC(prev).String()),
+				slog.String("to", connStateFromGRPC(state).String()))
+			if onChange != nil {
+				onChange(connStateFromGRPC(state))
+			}
+			if state == connectivity.Shutdown {
+				return
+			}
+			continue
+		}
+
+		// No change for a whole interval.
+		if state == connectivity.Idle || state == connectivity.TransientFailure {
+			c.logger.Debug("reconnecting idle connection", slog.String("state", connStateFromGRPC(state).String()))
+			c.conn.ResetConnectBackoff()
+			c.conn.Connect()
+		}
+	}
+}
+
+// startConnWatch starts watchConnState if the config asks for it.
+func (c *Client) startConnWatch() {
+	if c.conn == nil || (c.config.OnConnStateChange == nil && c.config.IdleReconnect <= 0) {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	state := c.conn.GetState()
+	go func() {
+		defer close(done)
+		c.watchConnState(ctx, state)
+	}()
+	c.stopWatch = func() {
+		// Give the watcher a moment to report the shutdown transition.
+		select {
+		case <-done:
+		case <-time.After(100 * time.Millisecond):
+		}
+		cancel()
+	}
+}
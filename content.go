@@ -0,0 +1,182 @@
+package xai
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// Content is one part of a multimodal user message. Create parts with
+// Text, ImageURL, ImageBase64 and FileAttachment and send them with
+// ChatRequest.UserMessageParts.
+type Content interface {
+	toProto() *v1.Content
+}
+
+// ImageDetail sets how closely a vision model looks at an image.
+type ImageDetail int
+
+const (
+	// ImageDetailAuto lets the model choose (default).
+	ImageDetailAuto ImageDetail = iota
+	// ImageDetailLow processes a low-resolution version, using fewer tokens.
+	ImageDetailLow
+	// ImageDetailHigh processes the image at full resolution.
+	ImageDetailHigh
+)
+
+func (d ImageDetail) toProto() v1.ImageDetail {
+	switch d {
+	case ImageDetailLow:
+		return v1.ImageDetail_DETAIL_LOW
+	case ImageDetailHigh:
+		return v1.ImageDetail_DETAIL_HIGH
+	default:
+		return v1.ImageDetail_DETAIL_AUTO
+	}
+}
+
+// TextPart is a text content part.
+type TextPart struct {
+	Text string
+}
+
+// Text returns a text content part.
+func Text(text string) TextPart {
+	return TextPart{Text: text}
+}
+
+func (t TextPart) toProto() *v1.Content {
+	return &v1.Content{Content: &v1.Content_Text{Text: t.Text}}
+}
+
+// ImagePart is an image content part.
+type ImagePart struct {
+	// URL is an http(s) URL or a base64 data URL.
+	URL    string
+	Detail ImageDetail
+}
+
+// ImageURL returns an image part for a public http(s) URL. PNG, JPEG and
+// WebP images are supported.
+func ImageURL(url string) ImagePart {
+	return ImagePart{URL: url}
+}
+
+// ImageBase64 returns an image part for base64-encoded image data of the
+// given MIME type, e.g. "image/png".
+func ImageBase64(data, mimeType string) ImagePart {
+	return ImagePart{URL: "data:" + mimeType + ";base64," + data}
+}
+
+// MaxImageBytes is the largest image the API accepts.
+const MaxImageBytes = 10 << 20
+
+// imageTypes are the image formats the API accepts.
+var imageTypes = map[string]bool{"image/png": true, "image/jpeg": true, "image/webp": true}
+
+// ImageBytes returns an image part embedding data as a base64 data URL. If
+// mimeType is empty it is detected from the data. Images over MaxImageBytes
+// or in formats other than PNG, JPEG and WebP fail with ErrInvalidRequest.
+func ImageBytes(data []byte, mimeType string) (ImagePart, error) {
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	if !imageTypes[mimeType] {
+		return ImagePart{}, &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf("unsupported image type %q (want PNG, JPEG or WebP)", mimeType)}
+	}
+	if len(data) > MaxImageBytes {
+		return ImagePart{}, &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf("image is %d bytes, the limit is %d", len(data), MaxImageBytes)}
+	}
+	return ImageBase64(base64.StdEncoding.EncodeToString(data), mimeType), nil
+}
+
+// ImageFile reads a local image and returns it as for ImageBytes, with the
+// type detected from its contents.
+func ImageFile(path string) (ImagePart, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ImagePart{}, &Error{Code: ErrInvalidRequest, Message: "reading image", Cause: err}
+	}
+	if info.Size() > MaxImageBytes {
+		return ImagePart{}, &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf("image %s is %d bytes, the limit is %d", filepath.Base(path), info.Size(), MaxImageBytes)}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ImagePart{}, &Error{Code: ErrInvalidRequest, Message: "reading image", Cause: err}
+	}
+	return ImageBytes(data, "")
+}
+
+// WithDetail sets the image detail level.
+func (p ImagePart) WithDetail(detail ImageDetail) ImagePart {
+	p.Detail = detail
+	return p
+}
+
+func (p ImagePart) toProto() *v1.Content {
+	return &v1.Content{Content: &v1.Content_ImageUrl{ImageUrl: &v1.ImageUrlContent{
+		ImageUrl: p.URL,
+		Detail:   p.Detail.toProto(),
+	}}}
+}
+
+// FilePart is a file attachment content part.
+type FilePart struct {
+	// FileID is the ID returned by the Files API on upload.
+	FileID string
+}
+
+// FileAttachment returns a part attaching a previously uploaded file, such
+// as a PDF. File attachments are not supported by the REST transport.
+func FileAttachment(fileID string) FilePart {
+	return FilePart{FileID: fileID}
+}
+
+func (f FilePart) toProto() *v1.Content {
+	return &v1.Content{Content: &v1.Content_File{File: &v1.FileContent{FileId: f.FileID}}}
+}
+
+// UserMessageParts adds a user message made of several parts, mixing text,
+// images and files in the order given:
+//
+//	req.UserMessageParts(
+//		xai.Text("What changed between these two screenshots?"),
+//		xai.ImageURL(before),
+//		xai.ImageURL(after).WithDetail(xai.ImageDetailHigh),
+//	)
+func (r *ChatRequest) UserMessageParts(parts ...Content) *ChatRequest {
+	msg := &v1.Message{Role: v1.MessageRole_ROLE_USER}
+	for _, p := range parts {
+		msg.Content = append(msg.Content, p.toProto())
+	}
+	r.messages = append(r.messages, msg)
+	return r
+}
+
+// UserWithImageFile adds a user message with text and a local image file,
+// embedded as a data URL (see ImageFile). Errors are reported by Err.
+func (r *ChatRequest) UserWithImageFile(text, path string) *ChatRequest {
+	img, err := ImageFile(path)
+	if err != nil {
+		r.setErr(err)
+		return r
+	}
+	return r.UserMessageParts(Text(text), img)
+}
+
+// UserWithImageBytes adds a user message with text and image data of the
+// given MIME type, or detected if empty (see ImageBytes). Errors are
+// reported by Err.
+func (r *ChatRequest) UserWithImageBytes(text string, data []byte, mimeType string) *ChatRequest {
+	img, err := ImageBytes(data, mimeType)
+	if err != nil {
+		r.setErr(err)
+		return r
+	}
+	return r.UserMessageParts(Text(text), img)
+}
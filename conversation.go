@@ -0,0 +1,409 @@
+package xai
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// Conversation holds the running history of a multi-turn chat together with
+// the client used to talk to the model. Add to the history with Send,
+// Stream, AppendResponse and Edit.
+//
+// A Conversation's methods are safe for concurrent use. Request returns a
+// copy, so reading it never races with a turn in progress.
+type Conversation struct {
+	client *Client
+	req    *ChatRequest
+
+	mu          sync.Mutex
+	tokenCounts map[*v1.Message]int
+	citations   map[*v1.Message][]string
+	// modelInfo is the model ContextUsage last looked up, by the name in
+	// infoModel.
+	modelInfo *LanguageModel
+	infoModel string
+	// memory is the message holding consolidated memory, if any.
+	memory *v1.Message
+	// searches is set by EnableSearchMemory.
+	searches *searchMemory
+	// chain is set by WithResponseChaining. lastResponseID is the stored
+	// response to continue from and sent the number of messages the server
+	// holds through it.
+	chain          bool
+	lastResponseID string
+	sent           int
+	usage          Usage
+}
+
+// NewConversation starts a conversation on client. If req is nil an empty
+// request is used; otherwise its messages and settings seed the history.
+func (c *Client) NewConversation(req *ChatRequest) *Conversation {
+	if req == nil {
+		req = NewChatRequest()
+	}
+	return &Conversation{
+		client:      c,
+		req:         req,
+		tokenCounts: make(map[*v1.Message]int),
+		citations:   make(map[*v1.Message][]string),
+	}
+}
+
+// Request returns a copy of the request holding the conversation history
+// and settings. Changing it does not affect the conversation; use Edit or
+// Configure for that.
+func (cv *Conversation) Request() *ChatRequest {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return cv.req.Clone()
+}
+
+// Edit calls fn with the request holding the conversation history, so
+// messages can be added with the ChatRequest builder methods. The
+// conversation is locked while fn runs; fn must not call its methods or
+// keep req.
+//
+//	conv.Edit(func(req *xai.ChatRequest) {
+//	    req.ToolResult(xai.ToolContent{CallID: call.ID, Result: result})
+//	})
+func (cv *Conversation) Edit(fn func(req *ChatRequest)) *Conversation {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	fn(cv.req)
+	return cv
+}
+
+// AppendResponse adds resp to the history as an assistant message (see
+// ChatRequest.AppendResponse) and remembers its citations for export.
+func (cv *Conversation) AppendResponse(resp *ChatResponse) *Conversation {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	cv.appendResponse(resp)
+	return cv
+}
+
+func (cv *Conversation) appendResponse(resp *ChatResponse) {
+	cv.req.AppendResponse(resp)
+	if len(resp.Citations) > 0 {
+		cv.citations[cv.req.messages[len(cv.req.messages)-1]] = resp.Citations
+	}
+	cv.rememberSearches(resp)
+}
+
+// Configure replaces the conversation's request settings (model, tools,
+// sampling, system prompt and so on) with those of settings, keeping the
+// history. The messages of settings are ignored. Use it to change settings
+// between turns, e.g. to switch models or tools.
+func (cv *Conversation) Configure(settings *ChatRequest) *Conversation {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	s := *settings
+	s.messages = cv.req.messages
+	*cv.req = s
+	return cv
+}
+
+// WithResponseChaining makes Send and Stream store each response on the
+// server and send only the messages added since the last one, continuing
+// from it with previous_response_id. The full history is still kept
+// locally, for export and for switching chaining off again; the generated
+// system prompt is only sent until the first response is stored.
+func (cv *Conversation) WithResponseChaining(on bool) *Conversation {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	cv.chain = on
+	if on {
+		// Start a fresh chain; earlier turns were not stored.
+		cv.lastResponseID = ""
+	}
+	return cv
+}
+
+// Usage returns the token usage of all turns completed with Send and
+// Stream.
+func (cv *Conversation) Usage() Usage {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return cv.usage
+}
+
+// Send adds text as a user message, completes the conversation and appends
+// the reply, so the next Send continues from it. If the call fails, the user
+// message is removed again so Send can be retried.
+//
+// Turns must not overlap: wait for one Send or Stream to finish before
+// starting the next.
+func (cv *Conversation) Send(ctx context.Context, text string, opts ...CallOption) (*ChatResponse, error) {
+	req, user := cv.beginTurn(text)
+	resp, err := cv.client.CompleteChat(ctx, req, opts...)
+	if err != nil {
+		cv.abortTurn(user)
+		return nil, err
+	}
+	cv.finishTurn(resp)
+	return resp, nil
+}
+
+// Stream is the streaming form of Send. The reply is appended to the
+// history when the stream ends; if it fails or is closed early, the user
+// message is removed again.
+func (cv *Conversation) Stream(ctx context.Context, text string, opts ...CallOption) (*ConversationStream, error) {
+	req, user := cv.beginTurn(text)
+	stream, err := cv.client.StreamChat(ctx, req, opts...)
+	if err != nil {
+		cv.abortTurn(user)
+		return nil, err
+	}
+	return &ConversationStream{cv: cv, stream: stream, user: user, metadata: req.Metadata()}, nil
+}
+
+// beginTurn adds the user message and returns the request to send for the
+// turn: the history, or with chaining the messages the server lacks.
+func (cv *Conversation) beginTurn(text string) (*ChatRequest, *v1.Message) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	cv.req.UserMessage(UserContent{Text: text})
+	user := cv.req.messages[len(cv.req.messages)-1]
+
+	req := *cv.req
+	req.messages = slices.Clone(cv.req.messages)
+	req.metadata = maps.Clone(cv.req.metadata)
+	if cv.chain {
+		req.storeMessages = true
+		if cv.lastResponseID != "" && cv.sent <= len(req.messages) {
+			req.messages = req.messages[cv.sent:]
+			req.previousResponseID = cv.lastResponseID
+			req.systemPrompt = nil
+		}
+	}
+	return &req, user
+}
+
+// abortTurn removes the user message of a failed turn, unless the history
+// has moved on since.
+func (cv *Conversation) abortTurn(user *v1.Message) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	if n := len(cv.req.messages); n > 0 && cv.req.messages[n-1] == user {
+		cv.req.messages = cv.req.messages[:n-1]
+	}
+}
+
+// finishTurn appends the reply of a completed turn and records its usage.
+func (cv *Conversation) finishTurn(resp *ChatResponse) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	// An empty assistant message would be rejected on the next turn.
+	if resp.Content != "" || resp.EncryptedContent != "" || slices.ContainsFunc(resp.ToolCalls, func(tc *ToolCallInfo) bool {
+		return tc != nil && tc.IsClientSide()
+	}) {
+		cv.appendResponse(resp)
+	}
+	addUsage(&cv.usage, resp.Usage)
+	if cv.chain && resp.ID != "" {
+		cv.lastResponseID = resp.ID
+		cv.sent = len(cv.req.messages)
+	}
+}
+
+// ConversationStream is a streamed conversation turn, returned by
+// Conversation.Stream.
+type ConversationStream struct {
+	cv     *Conversation
+	stream *ChunkStream
+	user   *v1.Message
+	acc    streamAccumulator
+	resp   *ChatResponse
+	ended  bool
+	// metadata is the turn's request metadata, taken when it began.
+	metadata map[string]string
+}
+
+var _ ChatStream = (*ConversationStream)(nil)
+
+// Next returns the next chunk, or io.EOF when done, at which point the
+// reply has been appended to the conversation.
+func (s *ConversationStream) Next() (*ChatChunk, error) {
+	if s.ended {
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	chunk, err := s.stream.Next()
+	if err == io.EOF {
+		s.ended = true
+		s.resp = s.acc.response()
+		s.resp.RequestMetadata = s.metadata
+		s.cv.finishTurn(s.resp)
+		return nil, io.EOF
+	}
+	if err != nil {
+		s.ended = true
+		s.cv.abortTurn(s.user)
+		return nil, err
+	}
+	s.acc.add(chunk)
+	return chunk, nil
+}
+
+// Response returns the assembled reply once Next has returned io.EOF, and
+// nil before.
+func (s *ConversationStream) Response() *ChatResponse {
+	return s.resp
+}
+
+// Close releases the stream. Closing before the end abandons the turn.
+func (s *ConversationStream) Close() error {
+	if !s.ended {
+		s.ended = true
+		s.cv.abortTurn(s.user)
+	}
+	return s.stream.Close()
+}
+
+// Err returns any error that occurred during streaming.
+func (s *ConversationStream) Err() error {
+	return s.stream.Err()
+}
+
+// Model returns the model the conversation uses: the request's model, or the
+// client's default.
+func (cv *Conversation) Model() string {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return cv.model()
+}
+
+// model is Model for callers holding cv.mu.
+func (cv *Conversation) model() string {
+	if cv.req.model != "" {
+		return cv.req.model
+	}
+	return cv.client.config.DefaultModel
+}
+
+// TurnUsage is the token size of one message in the history.
+type TurnUsage struct {
+	// Index is the message's position in the history.
+	Index int
+	// Role is the message author: system, user, assistant, tool or developer.
+	Role string
+	// Tokens is the tokenized size of the message text.
+	Tokens int
+}
+
+// ContextUsage reports how much of the model's context window a conversation
+// occupies.
+type ContextUsage struct {
+	// Model is the model whose context window was measured.
+	Model string
+	// MaxTokens is the model's context window size.
+	MaxTokens int
+	// UsedTokens is the total size of all messages.
+	UsedTokens int
+	// RemainingTokens is MaxTokens minus UsedTokens, never negative.
+	RemainingTokens int
+	// Turns is the per-message breakdown, in history order.
+	Turns []TurnUsage
+}
+
+// Fraction returns UsedTokens as a fraction of MaxTokens (0 when unknown).
+func (u *ContextUsage) Fraction() float64 {
+	if u.MaxTokens <= 0 {
+		return 0
+	}
+	return float64(u.UsedTokens) / float64(u.MaxTokens)
+}
+
+// ContextUsage tokenizes the conversation history and compares it with the
+// model's maximum prompt length. Per-message counts and model details are
+// cached, so calling this after every turn only tokenizes new messages.
+//
+// Counts cover message text and tool call arguments; images and per-message
+// framing overhead are not included.
+func (cv *Conversation) ContextUsage(ctx context.Context) (*ContextUsage, error) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	// The cache is keyed on the requested name: for an alias the model's
+	// Name is the canonical one.
+	model := cv.model()
+	if cv.modelInfo == nil || cv.infoModel != model {
+		info, err := cv.client.GetModel(ctx, model)
+		if err != nil {
+			return nil, WrapError(err, "looking up model context length")
+		}
+		cv.modelInfo = info
+		cv.infoModel = model
+		// Counts are model specific.
+		cv.tokenCounts = make(map[*v1.Message]int)
+	}
+
+	usage := &ContextUsage{
+		Model:     model,
+		MaxTokens: int(cv.modelInfo.MaxPromptLength),
+	}
+	for i, msg := range cv.req.messages {
+		n, ok := cv.tokenCounts[msg]
+		if !ok {
+			text := messageText(msg)
+			if text != "" {
+				resp, err := cv.client.Tokenize(ctx, model, text)
+				if err != nil {
+					return nil, WrapError(err, "counting conversation tokens")
+				}
+				n = resp.TokenCount()
+			}
+			cv.tokenCounts[msg] = n
+		}
+		usage.Turns = append(usage.Turns, TurnUsage{Index: i, Role: roleName(msg.GetRole()), Tokens: n})
+		usage.UsedTokens += n
+	}
+	usage.RemainingTokens = max(usage.MaxTokens-usage.UsedTokens, 0)
+	return usage, nil
+}
+
+// messageText returns the text content and tool call arguments of msg.
+func messageText(msg *v1.Message) string {
+	var b strings.Builder
+	for _, c := range msg.GetContent() {
+		if t := c.GetText(); t != "" {
+			b.WriteString(t)
+			b.WriteByte('\n')
+		}
+	}
+	for _, tc := range msg.GetToolCalls() {
+		if fn := tc.GetFunction(); fn != nil {
+			b.WriteString(fn.GetName())
+			b.WriteByte('\n')
+			b.WriteString(fn.GetArguments())
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// roleName returns the lowercase name of a message role.
+func roleName(role v1.MessageRole) string {
+	switch role {
+	case v1.MessageRole_ROLE_SYSTEM:
+		return "system"
+	case v1.MessageRole_ROLE_USER:
+		return "user"
+	case v1.MessageRole_ROLE_ASSISTANT:
+		return "assistant"
+	case v1.MessageRole_ROLE_TOOL:
+		return "tool"
+	case v1.MessageRole_ROLE_DEVELOPER:
+		return "developer"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,259 @@
+package xai
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Failed pricing loads are retried after pricingRetryMin, doubling up to
+// pricingRetryMax while they keep failing.
+const (
+	pricingRetryMin = 30 * time.Second
+	pricingRetryMax = 10 * time.Minute
+)
+
+// CostTracker accumulates the token usage of every request a client makes
+// and prices it with the models' published rates. Get it with Client.Costs.
+//
+// Usage is recorded per request; pricing is fetched with ListModels the
+// first time spend is requested and cached afterwards. Concurrent callers
+// share one load, and a failed load is not retried until a backoff has
+// passed. Since pricing is linear in tokens, pricing totals at read time is
+// equivalent to pricing each request.
+type CostTracker struct {
+	client *Client
+	loads  singleflight.Group
+
+	mu      sync.Mutex
+	since   time.Time
+	models  map[string]*modelSpend
+	pricing map[string]*LanguageModel
+	// loadErr is the last failed pricing load, returned until retryAt.
+	loadErr error
+	retryAt time.Time
+	backoff time.Duration
+}
+
+type modelSpend struct {
+	requests int
+	usage    Usage
+	// searches sums BilledSearches per request, which differs from
+	// usage.BilledSearches() when requests mix tool calls and live search.
+	searches int32
+}
+
+func newCostTracker(client *Client, since time.Time) *CostTracker {
+	return &CostTracker{
+		client: client,
+		since:  since,
+		models: make(map[string]*modelSpend),
+	}
+}
+
+// Costs returns the client's cost tracker.
+func (c *Client) Costs() *CostTracker {
+	return c.costs
+}
+
+// ObserveRequest implements Metrics by recording the usage of successful
+// requests. It is called by the client and need not be called directly.
+func (t *CostTracker) ObserveRequest(m RequestMetrics) {
+	if m.Err != nil || m.Model == "" || m.Usage == (Usage{}) {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.models[m.Model]
+	if !ok {
+		s = &modelSpend{}
+		t.models[m.Model] = s
+	}
+	s.requests++
+	addUsage(&s.usage, m.Usage)
+	s.searches += m.Usage.BilledSearches()
+}
+
+// ObserveTimeToFirstToken implements Metrics; it does nothing.
+func (t *CostTracker) ObserveTimeToFirstToken(string, string, time.Duration) {}
+
+// ModelSpend is the accumulated usage and cost of one model.
+type ModelSpend struct {
+	// Model is the model name as requested.
+	Model string `json:"model"`
+	// Requests is the number of successful requests with usage.
+	Requests int `json:"requests"`
+	// Usage is the summed token usage.
+	Usage Usage `json:"usage"`
+	// USD is the cost of Usage, including searches. It is 0 if the model
+	// has no known pricing.
+	USD float64 `json:"usd"`
+	// SearchUSD is the part of USD charged for searches.
+	SearchUSD float64 `json:"search_usd"`
+	// CacheSavingsUSD is what the prompt cache saved, not part of USD.
+	// Usage.CacheHitRate gives the share of prompt tokens that were cached.
+	CacheSavingsUSD float64 `json:"cache_savings_usd"`
+	// Priced is false if no pricing was found for the model.
+	Priced bool `json:"priced"`
+}
+
+// Spend is a snapshot of a CostTracker.
+type Spend struct {
+	// Since is when tracking started or was last reset.
+	Since time.Time `json:"since"`
+	// TotalUSD is the cost across all models.
+	TotalUSD float64 `json:"total_usd"`
+	// SearchUSD is the part of TotalUSD charged for searches.
+	SearchUSD float64 `json:"search_usd"`
+	// CacheSavingsUSD is what the prompt cache saved across all models.
+	CacheSavingsUSD float64 `json:"cache_savings_usd"`
+	// Models is the per-model breakdown, most expensive first.
+	Models []ModelSpend `json:"models"`
+}
+
+// Spend returns the cumulative spend since the tracker was created or reset.
+// Pricing is loaded on first use; if that fails, the error is returned
+// together with a Spend holding unpriced usage.
+func (t *CostTracker) Spend(ctx context.Context) (*Spend, error) {
+	err := t.loadPricing(ctx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spend := &Spend{Since: t.since}
+	for name, s := range t.models {
+		ms := ModelSpend{Model: name, Requests: s.requests, Usage: s.usage}
+		if model, ok := t.pricing[name]; ok {
+			ms.SearchUSD = searchCost(model, s.searches)
+			ms.USD = tokenCost(model, s.usage) + ms.SearchUSD
+			ms.CacheSavingsUSD = model.CacheSavings(s.usage)
+			ms.Priced = true
+		}
+		spend.TotalUSD += ms.USD
+		spend.SearchUSD += ms.SearchUSD
+		spend.CacheSavingsUSD += ms.CacheSavingsUSD
+		spend.Models = append(spend.Models, ms)
+	}
+	sort.Slice(spend.Models, func(i, j int) bool {
+		if spend.Models[i].USD != spend.Models[j].USD {
+			return spend.Models[i].USD > spend.Models[j].USD
+		}
+		return spend.Models[i].Model < spend.Models[j].Model
+	})
+	return spend, err
+}
+
+// CacheHitRate returns the share of prompt tokens served from the prompt
+// cache across all models since the tracker was created or reset, from 0
+// to 1.
+func (s *Spend) CacheHitRate() float64 {
+	var total Usage
+	for _, m := range s.Models {
+		addUsage(&total, m.Usage)
+	}
+	return total.CacheHitRate()
+}
+
+// loadPricing loads pricing unless it is loaded or a failed load is still
+// backing off, in which case that failure is returned.
+func (t *CostTracker) loadPricing(ctx context.Context) error {
+	if done, err := t.pricingState(); done {
+		return err
+	}
+	// The load is shared, so it must not fail because one caller gave up.
+	_, err, _ := t.loads.Do("pricing", func() (any, error) {
+		// A load may have finished since the check above.
+		if done, err := t.pricingState(); done {
+			return nil, err
+		}
+		return nil, t.RefreshPricing(context.WithoutCancel(ctx))
+	})
+	return err
+}
+
+// pricingState reports whether no load is needed: pricing is loaded, or a
+// failed load, returned as err, is still backing off.
+func (t *CostTracker) pricingState() (done bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pricing != nil {
+		return true, nil
+	}
+	if t.loadErr != nil && t.client.config.Clock.Now().Before(t.retryAt) {
+		return true, t.loadErr
+	}
+	return false, nil
+}
+
+// RefreshPricing reloads model pricing with ListModels, e.g. after xAI
+// changes its rates.
+func (t *CostTracker) RefreshPricing(ctx context.Context) error {
+	models, err := t.client.ListModels(ctx)
+	if err != nil {
+		err = WrapError(err, "loading model pricing")
+		t.mu.Lock()
+		t.backoff = min(max(2*t.backoff, pricingRetryMin), pricingRetryMax)
+		t.loadErr, t.retryAt = err, t.client.config.Clock.Now().Add(t.backoff)
+		backoff := t.backoff
+		t.mu.Unlock()
+		t.client.logger.WarnContext(ctx, "model pricing unavailable; usage is unpriced until it loads",
+			append(errorAttrs(err), slog.Duration("retry_in", backoff))...)
+		return err
+	}
+	pricing := make(map[string]*LanguageModel, len(models))
+	for _, m := range models {
+		pricing[m.Name] = m
+		for _, alias := range m.Aliases {
+			pricing[alias] = m
+		}
+	}
+	t.mu.Lock()
+	t.pricing = pricing
+	t.loadErr, t.backoff = nil, 0
+	t.mu.Unlock()
+	return nil
+}
+
+// Reset clears the accumulated usage. Cached pricing is kept.
+func (t *CostTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.models = make(map[string]*modelSpend)
+	t.since = t.client.config.Clock.Now()
+}
+
+// usageCost prices u with model's rates. Cached and image prompt tokens are
+// billed at their own rates and the rest of the prompt at the text rate;
+// reasoning tokens are billed as completion tokens. Searches are billed per
+// Usage.BilledSearches.
+func usageCost(model *LanguageModel, u Usage) float64 {
+	return tokenCost(model, u) + searchCost(model, u.BilledSearches())
+}
+
+// tokenCost prices the tokens of u.
+func tokenCost(model *LanguageModel, u Usage) float64 {
+	text := max(int(u.PromptTokens-u.CachedPromptTokens-u.PromptImageTokens), 0)
+	cost := model.CalculateCost(text, int(u.CompletionTokens+u.ReasoningTokens), int(u.CachedPromptTokens))
+	return cost + float64(u.PromptImageTokens)*model.PromptImagePricing.PerMillionTokens/1_000_000
+}
+
+// searchCost prices n searches. SearchPricing is per million searches.
+func searchCost(model *LanguageModel, n int32) float64 {
+	return float64(n) * model.SearchPricing.PerMillionTokens / 1_000_000
+}
+
+// addUsage adds u to dst.
+func addUsage(dst *Usage, u Usage) {
+	dst.PromptTokens += u.PromptTokens
+	dst.CompletionTokens += u.CompletionTokens
+	dst.TotalTokens += u.TotalTokens
+	dst.ReasoningTokens += u.ReasoningTokens
+	dst.CachedPromptTokens += u.CachedPromptTokens
+	dst.PromptTextTokens += u.PromptTextTokens
+	dst.PromptImageTokens += u.PromptImageTokens
+	dst.Searches += u.Searches
+	dst.SourcesUsed += u.SourcesUsed
+}
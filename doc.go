@@ -113,4 +113,16 @@
 //	        }
 //	    }
 //	}
+//
+// # Examples
+//
+// Runnable programs for common tasks live in the examples directory of the
+// repository; run them with XAI_APIKEY set, e.g. go run ./examples/toolcalling:
+//
+//   - examples/toolcalling: a tool calling loop with [NewTypedTool] and [Client.RunChat]
+//   - examples/rag: retrieval-augmented answers with [Client.SearchDocuments]
+//   - examples/sse: an HTTP server streaming answers as server-sent events
+//   - examples/embeddings: semantic search with [Client.Embed]
+//   - examples/imagegen: image generation with [Client.GenerateImage]
+//   - examples/deferred: concurrent deferred completions with [Client.StartDeferred]
 package xai
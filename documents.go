@@ -2,6 +2,12 @@ package xai
 
 import (
 	"context"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
 )
@@ -25,6 +31,7 @@ type SearchRequest struct {
 	limit         *int32
 	instructions  *string
 	mode          *RetrievalMode
+	snippetWindow int
 }
 
 // NewSearchRequest creates a new document search request.
@@ -56,6 +63,14 @@ func (r *SearchRequest) WithRetrievalMode(mode RetrievalMode) *SearchRequest {
 	return r
 }
 
+// WithSnippetWindow trims each match's Snippet to about n bytes around the
+// densest cluster of query terms, at word boundaries. By default the
+// snippet is the whole chunk.
+func (r *SearchRequest) WithSnippetWindow(n int) *SearchRequest {
+	r.snippetWindow = n
+	return r
+}
+
 func (r *SearchRequest) toProto() *v1.SearchRequest {
 	req := &v1.SearchRequest{
 		Query: r.query,
@@ -100,34 +115,160 @@ type SearchMatch struct {
 	ChunkID string
 	// CollectionIDs are the collections this document belongs to.
 	CollectionIDs []string
+	// Snippet is the part of Content to display (see WithSnippetWindow).
+	Snippet string
+	// SnippetOffset is the byte offset of Snippet in Content.
+	SnippetOffset int
+	// Highlights are the query terms found in Snippet, as byte ranges in
+	// order. The API does not report match positions, so they are found
+	// by case-insensitive search for the query's words.
+	Highlights []Highlight
+}
+
+// Highlight is a byte range [Start, End) of text matching the query.
+type Highlight struct {
+	Start int
+	End   int
 }
 
 // SearchResponse contains the document search results.
 type SearchResponse struct {
 	// Matches are the matching document chunks.
 	Matches []SearchMatch
+	// Metadata holds the response headers and trailers, such as the request
+	// ID and rate-limit state.
+	Metadata *ResponseMetadata
 }
 
 // SearchDocuments searches document collections.
-func (c *Client) SearchDocuments(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
-	ctx, cancel := c.withTimeout(ctx)
+// Only WithCallTimeout applies; search has no model.
+func (c *Client) SearchDocuments(ctx context.Context, req *SearchRequest, opts ...CallOption) (*SearchResponse, error) {
+	ctx, cancel := c.callContext(ctx, resolveCallOptions(opts))
 	defer cancel()
 
-	resp, err := c.documents.Search(ctx, req.toProto())
+	md := &ResponseMetadata{}
+	resp, err := c.documents.Search(ctx, req.toProto(), md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
-	result := &SearchResponse{}
+	terms := queryTermsPattern(req.query)
+	result := &SearchResponse{Metadata: md}
 	for _, match := range resp.GetMatches() {
-		result.Matches = append(result.Matches, SearchMatch{
+		m := SearchMatch{
 			Content:       match.GetChunkContent(),
 			Score:         match.GetScore(),
 			FileID:        match.GetFileId(),
 			ChunkID:       match.GetChunkId(),
 			CollectionIDs: match.GetCollectionIds(),
-		})
+		}
+		m.Snippet, m.SnippetOffset, m.Highlights = snippet(m.Content, terms, req.snippetWindow)
+		result.Matches = append(result.Matches, m)
 	}
 
 	return result, nil
 }
+
+// queryTermsPattern matches any word of query case-insensitively, longest
+// first so overlapping terms highlight the longer one. It is nil if the
+// query has no words.
+func queryTermsPattern(query string) *regexp.Regexp {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := make(map[string]bool)
+	var terms []string
+	for _, w := range words {
+		w = strings.ToLower(w)
+		if !seen[w] {
+			seen[w] = true
+			terms = append(terms, regexp.QuoteMeta(w))
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	sort.Slice(terms, func(i, j int) bool { return len(terms[i]) > len(terms[j]) })
+	return regexp.MustCompile("(?i)" + strings.Join(terms, "|"))
+}
+
+// snippet cuts content to a window of about size bytes holding the most
+// term matches, returning it with its offset and the matches within it.
+func snippet(content string, terms *regexp.Regexp, size int) (string, int, []Highlight) {
+	var all []Highlight
+	if terms != nil {
+		for _, loc := range terms.FindAllStringIndex(content, -1) {
+			all = append(all, Highlight{Start: loc[0], End: loc[1]})
+		}
+	}
+	if size <= 0 || len(content) <= size {
+		return content, 0, all
+	}
+
+	// Find the window start, anchored at a match, covering the most matches.
+	start, best := 0, 0
+	for i, h := range all {
+		n := 0
+		for _, g := range all[i:] {
+			if g.End-h.Start > size {
+				break
+			}
+			n++
+		}
+		if n > best {
+			best = n
+			// Center the covered matches in the window.
+			span := all[i+n-1].End - h.Start
+			start = max(h.Start-(size-span)/2, 0)
+		}
+	}
+	end := min(start+size, len(content))
+	start = max(end-size, 0)
+
+	// Snap to word boundaries, without cutting into a highlight.
+	if start > 0 {
+		if i := strings.IndexAny(content[start:end], " \t\n"); i >= 0 && (best == 0 || start+i < firstIn(all, start)) {
+			start += i + 1
+		}
+	}
+	if end < len(content) {
+		if i := strings.LastIndexAny(content[start:end], " \t\n"); i > 0 && (best == 0 || start+i >= lastIn(all, end)) {
+			end = start + i
+		}
+	}
+	for start < end && !utf8.RuneStart(content[start]) {
+		start++
+	}
+	for end < len(content) && end > start && !utf8.RuneStart(content[end]) {
+		end--
+	}
+
+	var in []Highlight
+	for _, h := range all {
+		if h.Start >= start && h.End <= end {
+			in = append(in, Highlight{Start: h.Start - start, End: h.End - start})
+		}
+	}
+	return content[start:end], start, in
+}
+
+// firstIn returns the start of the first highlight at or after pos.
+func firstIn(hs []Highlight, pos int) int {
+	for _, h := range hs {
+		if h.Start >= pos {
+			return h.Start
+		}
+	}
+	return math.MaxInt
+}
+
+// lastIn returns the end of the last highlight ending at or before pos.
+func lastIn(hs []Highlight, pos int) int {
+	last := 0
+	for _, h := range hs {
+		if h.End <= pos {
+			last = h.End
+		}
+	}
+	return last
+}
@@ -0,0 +1,232 @@
+package xai
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// Reasons reported by AutoEffort for its built-in heuristics. Rules report
+// their own Name.
+const (
+	EffortReasonLength = "length"
+	EffortReasonCode   = "code"
+	EffortReasonMath   = "math"
+)
+
+// Default prompt lengths, in characters, at which AutoEffort raises the
+// effort by length alone.
+const (
+	DefaultEffortMediumChars = 400
+	DefaultEffortHighChars   = 4000
+)
+
+// EffortRule forces a reasoning effort for prompts it matches. Rules are
+// checked in order before the built-in heuristics; the first match wins.
+type EffortRule struct {
+	// Name identifies the rule in EffortOutcome.Reason.
+	Name string
+	// Match reports whether the rule applies to the prompt, which is the
+	// text of the last user message.
+	Match func(prompt string) bool
+	// Effort is the effort used when Match returns true.
+	Effort ReasoningEffort
+}
+
+// AutoEffortOption configures an AutoEffort.
+type AutoEffortOption func(*AutoEffort)
+
+// WithEffortRules adds rules that are checked before the heuristics.
+func WithEffortRules(rules ...EffortRule) AutoEffortOption {
+	return func(a *AutoEffort) {
+		a.rules = append(a.rules, rules...)
+	}
+}
+
+// WithEffortLengths sets the prompt lengths, in characters, at which the
+// effort is raised to medium and high. Zero keeps the default.
+func WithEffortLengths(medium, high int) AutoEffortOption {
+	return func(a *AutoEffort) {
+		if medium > 0 {
+			a.mediumChars = medium
+		}
+		if high > 0 {
+			a.highChars = high
+		}
+	}
+}
+
+// AutoEffort picks a ReasoningEffort per request from the prompt and records
+// how each choice turned out, so the rules can be tuned for cost and quality.
+// Attach it with ChatRequest.WithAutoEffort; one AutoEffort may be shared by
+// many requests and goroutines.
+//
+// Without rules, the effort is the highest of:
+//   - by length: low below the medium length, high from the high length,
+//     medium in between;
+//   - medium if the prompt contains code;
+//   - high if the prompt contains math or asks for a proof.
+type AutoEffort struct {
+	rules       []EffortRule
+	mediumChars int
+	highChars   int
+
+	mu       sync.Mutex
+	outcomes map[effortKey]*EffortOutcome
+}
+
+type effortKey struct {
+	effort ReasoningEffort
+	reason string
+}
+
+// NewAutoEffort creates an AutoEffort.
+func NewAutoEffort(opts ...AutoEffortOption) *AutoEffort {
+	a := &AutoEffort{
+		mediumChars: DefaultEffortMediumChars,
+		highChars:   DefaultEffortHighChars,
+		outcomes:    make(map[effortKey]*EffortOutcome),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+var (
+	codePattern = regexp.MustCompile("(?m)```|^\\s*(func|def|class|import|package|#include|public|select|SELECT)\\b|[;{}]\\s*$")
+	mathPattern = regexp.MustCompile(`\$[^$]+\$|\\(frac|int|sum|sqrt|lim)\b|\b\d+\s*[\^*=]\s*\d+|(?i:\b(prove|proof|theorem|lemma|integral|derivative|equation)s?\b)`)
+)
+
+// Choose returns the effort for prompt and the reason: the name of the
+// matching rule, or one of the EffortReason constants.
+func (a *AutoEffort) Choose(prompt string) (ReasoningEffort, string) {
+	for _, rule := range a.rules {
+		if rule.Match != nil && rule.Match(prompt) {
+			return rule.Effort, rule.Name
+		}
+	}
+
+	effort, reason := ReasoningEffortMedium, EffortReasonLength
+	switch n := len(prompt); {
+	case n < a.mediumChars:
+		effort = ReasoningEffortLow
+	case n >= a.highChars:
+		effort = ReasoningEffortHigh
+	}
+	if effort < ReasoningEffortMedium && codePattern.MatchString(prompt) {
+		effort, reason = ReasoningEffortMedium, EffortReasonCode
+	}
+	if effort < ReasoningEffortHigh && mathPattern.MatchString(prompt) {
+		effort, reason = ReasoningEffortHigh, EffortReasonMath
+	}
+	return effort, reason
+}
+
+// EffortOutcome is the recorded result of one effort and reason.
+type EffortOutcome struct {
+	Effort ReasoningEffort `json:"effort"`
+	Reason string          `json:"reason"`
+	// Requests is the number of completed requests, including failures.
+	Requests int `json:"requests"`
+	// Errors is the number of failed requests.
+	Errors int `json:"errors"`
+	// Truncated is the number of responses cut off by the token limit.
+	Truncated int `json:"truncated"`
+	// Usage is the summed token usage of successful requests.
+	Usage Usage `json:"usage"`
+	// Latency is the summed duration of all requests.
+	Latency time.Duration `json:"latency"`
+}
+
+// AvgLatency returns the mean request duration.
+func (o EffortOutcome) AvgLatency() time.Duration {
+	if o.Requests == 0 {
+		return 0
+	}
+	return o.Latency / time.Duration(o.Requests)
+}
+
+// AvgReasoningTokens returns the mean reasoning tokens per successful request.
+func (o EffortOutcome) AvgReasoningTokens() float64 {
+	if ok := o.Requests - o.Errors; ok > 0 {
+		return float64(o.Usage.ReasoningTokens) / float64(ok)
+	}
+	return 0
+}
+
+// Outcomes returns the recorded outcomes, ordered by effort then reason.
+func (a *AutoEffort) Outcomes() []EffortOutcome {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]EffortOutcome, 0, len(a.outcomes))
+	for _, o := range a.outcomes {
+		out = append(out, *o)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Effort != out[j].Effort {
+			return out[i].Effort < out[j].Effort
+		}
+		return out[i].Reason < out[j].Reason
+	})
+	return out
+}
+
+// Reset clears the recorded outcomes.
+func (a *AutoEffort) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.outcomes = make(map[effortKey]*EffortOutcome)
+}
+
+// Record adds the outcome of a request made with effort chosen for reason.
+// CompleteChat calls it for requests using WithAutoEffort; call it directly
+// to record streamed or externally made requests.
+func (a *AutoEffort) Record(effort ReasoningEffort, reason string, resp *ChatResponse, err error, latency time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := effortKey{effort, reason}
+	o, ok := a.outcomes[key]
+	if !ok {
+		o = &EffortOutcome{Effort: effort, Reason: reason}
+		a.outcomes[key] = o
+	}
+	o.Requests++
+	o.Latency += latency
+	switch {
+	case err != nil:
+		o.Errors++
+	case resp != nil:
+		addUsage(&o.Usage, resp.Usage)
+		if resp.FinishReason == FinishReasonLength {
+			o.Truncated++
+		}
+	}
+}
+
+// WithAutoEffort chooses the reasoning effort from the last user message.
+// An explicit WithReasoningEffort takes precedence.
+func (r *ChatRequest) WithAutoEffort(a *AutoEffort) *ChatRequest {
+	r.autoEffort = a
+	return r
+}
+
+// chooseEffort returns the automatic effort for r, if one applies.
+func (r *ChatRequest) chooseEffort() (ReasoningEffort, string, bool) {
+	if r.autoEffort == nil || r.reasoningEffort != nil {
+		return 0, "", false
+	}
+	var prompt string
+	for i := len(r.messages) - 1; i >= 0; i-- {
+		if r.messages[i].GetRole() == v1.MessageRole_ROLE_USER {
+			prompt = strings.TrimSpace(messageText(r.messages[i]))
+			break
+		}
+	}
+	effort, reason := r.autoEffort.Choose(prompt)
+	return effort, reason, true
+}
@@ -78,6 +78,12 @@ func (r *EmbedRequest) WithUser(user string) *EmbedRequest {
 	return r
 }
 
+// Inputs returns the inputs added so far, e.g. for custom Embedder
+// implementations.
+func (r *EmbedRequest) Inputs() []EmbedInput {
+	return r.inputs
+}
+
 func (r *EmbedRequest) toProto() *v1.EmbedRequest {
 	req := &v1.EmbedRequest{
 		Model: r.model,
@@ -107,20 +113,31 @@ type EmbedResponse struct {
 	NumTextEmbeddings int32
 	// NumImageEmbeddings is the number of image embeddings generated.
 	NumImageEmbeddings int32
+	// Metadata holds the response headers and trailers, such as the request
+	// ID and rate-limit state.
+	Metadata *ResponseMetadata
 }
 
 // Embed generates embeddings for the given inputs.
-func (c *Client) Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error) {
-	ctx, cancel := c.withTimeout(ctx)
+func (c *Client) Embed(ctx context.Context, req *EmbedRequest, opts ...CallOption) (*EmbedResponse, error) {
+	o := resolveCallOptions(opts)
+	ctx, cancel := c.callContext(ctx, o)
 	defer cancel()
 
-	resp, err := c.embedder.Embed(ctx, req.toProto())
+	protoReq := req.toProto()
+	if o.model != "" {
+		protoReq.Model = o.model
+	}
+
+	md := &ResponseMetadata{}
+	resp, err := c.embedder.Embed(ctx, protoReq, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
 	result := &EmbedResponse{
-		Model: resp.GetModel(),
+		Model:    resp.GetModel(),
+		Metadata: md,
 	}
 
 	if usage := resp.GetUsage(); usage != nil {
@@ -73,6 +73,9 @@ type Error struct {
 	RetryAfter time.Duration
 	// GRPCCode is the original gRPC status code.
 	GRPCCode codes.Code
+	// Metadata holds the response headers and trailers (request ID,
+	// rate-limit state), when the server sent any.
+	Metadata *ResponseMetadata
 }
 
 // Error implements the error interface.
@@ -136,6 +139,13 @@ func FromGRPCError(err error) *Error {
 		return nil
 	}
 
+	// Errors produced inside the client (e.g. by interceptors) are already
+	// classified.
+	var existing *Error
+	if errors.As(err, &existing) {
+		return existing
+	}
+
 	st, ok := status.FromError(err)
 	if !ok {
 		return &Error{
@@ -162,7 +172,6 @@ func FromGRPCError(err error) *Error {
 		// Could be rate limit or quota
 		xaiErr.Code = ErrRateLimit
 		xaiErr.Message = "rate limit exceeded: " + st.Message()
-		// TODO: Parse retry-after from metadata if available
 	case codes.InvalidArgument:
 		xaiErr.Code = ErrInvalidRequest
 	case codes.NotFound:
@@ -206,6 +215,7 @@ func WrapError(err error, message string) error {
 			Cause:      xaiErr.Cause,
 			RetryAfter: xaiErr.RetryAfter,
 			GRPCCode:   xaiErr.GRPCCode,
+			Metadata:   xaiErr.Metadata,
 		}
 	}
 	return fmt.Errorf("%s: %w", message, err)
@@ -0,0 +1,213 @@
+package xai
+
+import (
+	"context"
+	"strings"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// Tokenizer counts tokens for a model. *Client satisfies this interface.
+type Tokenizer interface {
+	Tokenize(ctx context.Context, model, text string) (*TokenizeResponse, error)
+}
+
+// CostEstimate is the projected price range of a chat request.
+type CostEstimate struct {
+	// Model is the model the estimate was computed for.
+	Model string
+	// PromptTokens is the tokenized size of the prompt text.
+	PromptTokens int
+	// MaxCompletionTokens is the largest completion the request allows: the
+	// request's max_tokens, or the rest of the model's context window if unset.
+	MaxCompletionTokens int
+	// MinUSD is the cost if the model returns no completion tokens.
+	MinUSD float64
+	// MaxUSD is the cost if the model uses all MaxCompletionTokens.
+	MaxUSD float64
+}
+
+// EstimateCost projects the minimum and maximum USD cost of sending the
+// request to model, using tokenizer to count prompt tokens. The request is
+// counted as it would be sent: when tokenizer is the *Client, with the
+// client's defaults (such as Config.CurrentDate and user preferences)
+// applied.
+//
+// The estimate covers message text and function tool definitions. Images,
+// cached prompt discounts and server-side tool charges are not included, and
+// reasoning tokens count against MaxCompletionTokens.
+func (r *ChatRequest) EstimateCost(ctx context.Context, model *LanguageModel, tokenizer Tokenizer) (*CostEstimate, error) {
+	if model == nil {
+		return nil, &Error{Code: ErrInvalidRequest, Message: "model is required for cost estimation"}
+	}
+
+	protoReq, err := r.estimateRequest(ctx, model.Name, tokenizer)
+	if err != nil {
+		return nil, err
+	}
+	promptTokens, err := countPromptTokens(ctx, protoReq, tokenizer)
+	if err != nil {
+		return nil, err
+	}
+
+	maxCompletion := 0
+	if protoReq.MaxTokens != nil {
+		maxCompletion = int(protoReq.GetMaxTokens())
+	} else if model.MaxPromptLength > 0 {
+		maxCompletion = max(int(model.MaxPromptLength)-promptTokens, 0)
+	}
+
+	return &CostEstimate{
+		Model:               model.Name,
+		PromptTokens:        promptTokens,
+		MaxCompletionTokens: maxCompletion,
+		MinUSD:              model.CalculateCost(promptTokens, 0, 0),
+		MaxUSD:              model.CalculateCost(promptTokens, maxCompletion, 0),
+	}, nil
+}
+
+// estimateRequest builds the request sent to model: by the client, if
+// tokenizer is one, or else without client defaults, as Build does.
+func (r *ChatRequest) estimateRequest(ctx context.Context, model string, tokenizer Tokenizer) (*v1.GetCompletionsRequest, error) {
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	if c, ok := tokenizer.(*Client); ok {
+		return c.buildChatRequest(ctx, r, callOptions{model: model})
+	}
+	return r.build(buildDefaults{model: model}), nil
+}
+
+// countPromptTokens tokenizes the prompt text of a built request in a single
+// call.
+func countPromptTokens(ctx context.Context, req *v1.GetCompletionsRequest, tokenizer Tokenizer) (int, error) {
+	messages, tools := promptText(req)
+	text := messages + tools
+	if text == "" {
+		return 0, nil
+	}
+	resp, err := tokenizer.Tokenize(ctx, req.GetModel(), text)
+	if err != nil {
+		return 0, WrapError(err, "counting prompt tokens")
+	}
+	return resp.TokenCount(), nil
+}
+
+// promptText returns the text the model will read from a built request: the
+// message contents and the function tool definitions, of every tool type.
+func promptText(req *v1.GetCompletionsRequest) (messages, tools string) {
+	var m, t strings.Builder
+	for _, msg := range req.GetMessages() {
+		m.WriteString(messageText(msg))
+	}
+	for _, tool := range req.GetTools() {
+		if fn := tool.GetFunction(); fn != nil {
+			t.WriteString(fn.GetName() + "\n" + fn.GetDescription() + "\n" + fn.GetParameters() + "\n")
+		}
+	}
+	return m.String(), t.String()
+}
+
+// Per-item token estimates used by CountRequestTokens for parts of a request
+// the tokenizer does not see.
+const (
+	// EstimatedImageTokens is the assumed cost of one image input. The real
+	// cost depends on the image's size and the model.
+	EstimatedImageTokens = 1024
+	// messageOverheadTokens covers the role and separator tokens the model
+	// adds around each message.
+	messageOverheadTokens = 4
+)
+
+// RequestTokens is the token count of a chat request.
+type RequestTokens struct {
+	// Model is the model the request was tokenized for.
+	Model string
+	// Messages is the tokenized size of the message text, including the
+	// generated system prompt, hints and preferences messages.
+	Messages int
+	// Tools is the tokenized size of the function tool definitions.
+	Tools int
+	// Images is the estimated cost of the image inputs
+	// (EstimatedImageTokens each).
+	Images int
+	// Overhead is the estimated formatting cost of the messages.
+	Overhead int
+	// Total is the sum of the above.
+	Total int
+}
+
+// Remaining returns how many tokens of model's context window are left for
+// the completion, or a negative number if the prompt alone exceeds it. The
+// API does not reject oversized prompts but silently drops the oldest
+// context, so check this before sending long conversations. It returns 0 if
+// the model's context window is unknown.
+func (t *RequestTokens) Remaining(model *LanguageModel) int {
+	if model == nil || model.MaxPromptLength <= 0 {
+		return 0
+	}
+	return int(model.MaxPromptLength) - t.Total
+}
+
+// CountRequestTokens tokenizes req as it would be sent, with the client's
+// defaults applied, against its model (or the default model). Text is
+// counted exactly; images and per-message formatting are estimated.
+//
+//	tokens, err := client.CountRequestTokens(ctx, req)
+//	model, err := client.GetModel(ctx, tokens.Model)
+//	if tokens.Remaining(model) < 1000 {
+//	    // trim or summarize the history first
+//	}
+func (c *Client) CountRequestTokens(ctx context.Context, req *ChatRequest) (*RequestTokens, error) {
+	if err := req.Err(); err != nil {
+		return nil, err
+	}
+	protoReq, err := c.buildChatRequest(ctx, req, callOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return c.requestTokens(ctx, protoReq)
+}
+
+// requestTokens counts the tokens of a built request.
+func (c *Client) requestTokens(ctx context.Context, protoReq *v1.GetCompletionsRequest) (*RequestTokens, error) {
+	var err error
+	out := &RequestTokens{Model: protoReq.GetModel()}
+	for _, msg := range protoReq.GetMessages() {
+		out.Images += imageTokens(msg)
+		out.Overhead += messageOverheadTokens
+	}
+	messages, tools := promptText(protoReq)
+
+	if out.Messages, err = c.countTokens(ctx, out.Model, messages); err != nil {
+		return nil, WrapError(err, "counting message tokens")
+	}
+	if out.Tools, err = c.countTokens(ctx, out.Model, tools); err != nil {
+		return nil, WrapError(err, "counting tool tokens")
+	}
+	out.Total = out.Messages + out.Tools + out.Images + out.Overhead
+	return out, nil
+}
+
+// imageTokens is the estimated cost of msg's image inputs.
+func imageTokens(msg *v1.Message) int {
+	n := 0
+	for _, part := range msg.GetContent() {
+		if part.GetImageUrl() != nil {
+			n += EstimatedImageTokens
+		}
+	}
+	return n
+}
+
+// countTokens tokenizes text, skipping the call for empty text.
+func (c *Client) countTokens(ctx context.Context, model, text string) (int, error) {
+	if text == "" {
+		return 0, nil
+	}
+	resp, err := c.Tokenize(ctx, model, text)
+	if err != nil {
+		return 0, err
+	}
+	return resp.TokenCount(), nil
+}
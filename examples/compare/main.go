@@ -0,0 +1,78 @@
+// Command compare asks two models the same question and prints where their
+// answers diverge, using the sentence-level semantic diff of DiffAnswers
+// rather than dumping both answers.
+//
+//	XAI_APIKEY=... go run ./examples/compare -a grok-3 -b grok-3-mini "Why is the sky blue?"
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func main() {
+	modelA := flag.String("a", "grok-3", "First model")
+	modelB := flag.String("b", "grok-3-mini", "Second model")
+	embedModel := flag.String("embed", "", "Embedding model for the diff (default: the first listed)")
+	flag.Parse()
+	prompt := strings.Join(flag.Args(), " ")
+	if prompt == "" {
+		prompt = "Why is the sky blue?"
+	}
+
+	client, err := xai.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if *embedModel == "" {
+		models, err := client.ListEmbeddingModels(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(models) == 0 {
+			log.Fatal("no embedding models available")
+		}
+		*embedModel = models[0].Name
+	}
+
+	ask := func(model string) *xai.ChatResponse {
+		resp, err := client.CompleteChat(ctx, xai.NewChatRequest().
+			WithModel(model).
+			UserMessage(xai.UserContent{Text: prompt}))
+		if err != nil {
+			log.Fatalf("%s: %v", model, err)
+		}
+		return resp
+	}
+	a, b := ask(*modelA), ask(*modelB)
+
+	diff, err := xai.DiffAnswers(ctx, client, *embedModel, a, b)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%s vs %s: %.0f%% similar\n\n", *modelA, *modelB, 100*diff.Similarity)
+	for _, s := range diff.Segments {
+		switch s.Op {
+		case xai.DiffSame:
+			fmt.Printf("  %s\n", s.A)
+		case xai.DiffChanged:
+			fmt.Printf("~ %s\n  -> %s (%.2f)\n", s.A, s.B, s.Similarity)
+		case xai.DiffRemoved:
+			fmt.Printf("- %s\n", s.A)
+		case xai.DiffAdded:
+			fmt.Printf("+ %s\n", s.B)
+		}
+	}
+}
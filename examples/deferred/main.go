@@ -0,0 +1,65 @@
+// Command deferred starts several deferred completions at once and collects
+// the results as they finish. Deferred completions run server-side without a
+// held connection, which suits slow, high-effort or bulk requests.
+//
+//	XAI_APIKEY=... go run ./examples/deferred "Explain TCP" "Explain UDP" "Explain QUIC"
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func main() {
+	prompts := os.Args[1:]
+	if len(prompts) == 0 {
+		prompts = []string{"Explain TCP in two sentences.", "Explain UDP in two sentences."}
+	}
+
+	client, err := xai.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+
+	// Start all jobs first; each returns a request ID right away.
+	ids := make([]string, len(prompts))
+	for i, p := range prompts {
+		id, err := client.StartDeferred(ctx, xai.NewChatRequest().
+			UserMessage(xai.UserContent{Text: p}).
+			WithReasoningEffort(xai.ReasoningEffortHigh))
+		if err != nil {
+			log.Fatalf("starting %q: %v", p, err)
+		}
+		ids[i] = id
+		fmt.Printf("started %s: %s\n", id, p)
+	}
+
+	// Then poll them concurrently.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.WaitForDeferred(ctx, id, 5*time.Second, 10*time.Minute)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Printf("\n[%s] failed: %v\n", prompts[i], err)
+				return
+			}
+			fmt.Printf("\n[%s]\n%s\n", prompts[i], resp.Content)
+		}()
+	}
+	wg.Wait()
+}
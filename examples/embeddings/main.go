@@ -0,0 +1,98 @@
+// Command embeddings ranks a set of passages by semantic similarity to a
+// query: it embeds them in one request and sorts by cosine similarity.
+//
+//	XAI_APIKEY=... go run ./examples/embeddings "how do I reset my password?"
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+// passages stand in for a document store.
+var passages = []string{
+	"To change your password, open Settings and choose Security.",
+	"Invoices are emailed on the first day of each month.",
+	"Two-factor authentication can be enabled from the Security page.",
+	"Our office is closed on public holidays.",
+	"If you forgot your password, use the 'Forgot password' link on the sign-in page.",
+}
+
+func main() {
+	model := flag.String("model", "", "Embedding model (default: the first listed)")
+	flag.Parse()
+	query := strings.Join(flag.Args(), " ")
+	if query == "" {
+		query = "how do I reset my password?"
+	}
+
+	client, err := xai.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if *model == "" {
+		models, err := client.ListEmbeddingModels(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(models) == 0 {
+			log.Fatal("no embedding models available")
+		}
+		*model = models[0].Name
+	}
+
+	// The query is embedded with the passages, as the last input.
+	resp, err := client.Embed(ctx, xai.NewEmbedRequest(*model).AddTexts(passages...).AddText(query))
+	if err != nil {
+		log.Fatal(err)
+	}
+	vectors := make([][]float32, len(passages)+1)
+	for _, e := range resp.Embeddings {
+		if int(e.Index) < len(vectors) && len(e.Vectors) > 0 {
+			vectors[e.Index] = e.Vectors[0]
+		}
+	}
+	q := vectors[len(passages)]
+
+	type hit struct {
+		text  string
+		score float64
+	}
+	hits := make([]hit, len(passages))
+	for i, p := range passages {
+		hits[i] = hit{p, cosine(q, vectors[i])}
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].score > hits[j].score })
+
+	fmt.Printf("Query: %s (model %s)\n\n", query, *model)
+	for _, h := range hits {
+		fmt.Printf("%.3f  %s\n", h.score, h.text)
+	}
+}
+
+// cosine returns the cosine similarity of a and b.
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
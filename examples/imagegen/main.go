@@ -0,0 +1,64 @@
+// Command imagegen generates images from a prompt and saves them as files.
+//
+//	XAI_APIKEY=... go run ./examples/imagegen -n 2 -wide "a lighthouse at dusk"
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func main() {
+	n := flag.Int("n", 1, "Number of images")
+	wide := flag.Bool("wide", false, "16:9 instead of square")
+	model := flag.String("model", "", "Image model (default: the client's)")
+	out := flag.String("out", "image", "Output file name prefix")
+	flag.Parse()
+	prompt := strings.Join(flag.Args(), " ")
+	if prompt == "" {
+		log.Fatal("usage: imagegen [flags] <prompt>")
+	}
+
+	client, err := xai.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	req := xai.NewImageRequest(prompt).
+		WithCount(int32(*n)).
+		WithFormat(xai.ImageFormatBase64) // returned inline, so no download step
+	if *model != "" {
+		req.WithModel(*model)
+	}
+	if *wide {
+		req.WithAspectRatio(xai.ImageAspectRatio16x9)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	resp, err := client.GenerateImage(ctx, req)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i, img := range resp.Images {
+		data, err := base64.StdEncoding.DecodeString(img.Base64)
+		if err != nil {
+			log.Fatalf("image %d: %v", i+1, err)
+		}
+		name := fmt.Sprintf("%s-%d.jpg", *out, i+1)
+		if err := os.WriteFile(name, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("wrote %s (%d bytes)\n", name, len(data))
+	}
+}
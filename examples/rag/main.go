@@ -0,0 +1,68 @@
+// Command rag answers a question from your document collections: it
+// searches them, puts the best matches in the prompt and asks the model to
+// answer from those sources only, citing them.
+//
+//	XAI_APIKEY=... go run ./examples/rag -collection <id> "How do I rotate keys?"
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func main() {
+	collection := flag.String("collection", "", "Collection ID to search (required)")
+	limit := flag.Int("limit", 5, "Number of chunks to retrieve")
+	model := flag.String("model", "", "Chat model (default: the client's)")
+	flag.Parse()
+	question := strings.Join(flag.Args(), " ")
+	if *collection == "" || question == "" {
+		log.Fatal("usage: rag -collection <id> <question>")
+	}
+
+	client, err := xai.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Retrieve
+	found, err := client.SearchDocuments(ctx, xai.NewSearchRequest(question).
+		WithCollections(*collection).
+		WithLimit(int32(*limit)).
+		WithRetrievalMode(xai.RetrievalModeHybrid))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(found.Matches) == 0 {
+		log.Fatal("no matching documents")
+	}
+
+	// Augment
+	var sources strings.Builder
+	for i, m := range found.Matches {
+		fmt.Fprintf(&sources, "[%d] (file %s, score %.2f)\n%s\n\n", i+1, m.FileID, m.Score, m.Content)
+	}
+
+	// Generate
+	req := xai.NewChatRequest().
+		WithModel(*model).
+		SystemMessage(xai.SystemContent{Text: "Answer only from the numbered sources. " +
+			"Cite them like [1]. If they do not contain the answer, say so."}).
+		UserMessage(xai.UserContent{Text: "Sources:\n\n" + sources.String() + "Question: " + question}).
+		WithTemperature(0)
+	resp, err := client.CompleteChat(ctx, req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(resp.Content)
+}
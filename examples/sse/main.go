@@ -0,0 +1,84 @@
+// Command sse serves chat completions as server-sent events, the way a web
+// front end consumes streamed answers.
+//
+//	XAI_APIKEY=... go run ./examples/sse
+//	curl -N 'localhost:8080/chat?q=Tell+me+a+joke'
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "Listen address")
+	flag.Parse()
+
+	client, err := xai.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	http.HandleFunc("GET /chat", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		if q == "" {
+			http.Error(w, "missing q", http.StatusBadRequest)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		// The request context is cancelled when the browser disconnects,
+		// which ends the stream upstream too.
+		stream, err := client.StreamChat(r.Context(), xai.NewChatRequest().
+			UserMessage(xai.UserContent{Text: q}))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer stream.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		var usage xai.Usage
+		for {
+			chunk, err := stream.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				writeEvent(w, "error", map[string]string{"message": err.Error()})
+				flusher.Flush()
+				return
+			}
+			if chunk.Usage != (xai.Usage{}) {
+				usage = chunk.Usage
+			}
+			if chunk.Delta != "" {
+				writeEvent(w, "delta", map[string]string{"text": chunk.Delta})
+				flusher.Flush()
+			}
+		}
+		writeEvent(w, "done", map[string]any{"usage": usage})
+		flusher.Flush()
+	})
+
+	log.Printf("listening on http://%s/chat?q=...", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// writeEvent writes one server-sent event with a JSON payload.
+func writeEvent(w io.Writer, event string, data any) {
+	b, _ := json.Marshal(data)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+}
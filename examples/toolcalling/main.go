@@ -0,0 +1,80 @@
+// Command toolcalling runs a tool calling loop: the model calls Go functions
+// registered with a ToolRunner until it can answer.
+//
+//	XAI_APIKEY=... go run ./examples/toolcalling "Is it warmer in Paris or Oslo?"
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+// WeatherArgs are the arguments of get_weather; the tool's parameter schema
+// is derived from them.
+type WeatherArgs struct {
+	City string `json:"city" description:"city name, e.g. Paris"`
+}
+
+// Weather is the result of get_weather, sent to the model as JSON.
+type Weather struct {
+	City       string  `json:"city"`
+	TempC      float64 `json:"temp_c"`
+	Conditions string  `json:"conditions"`
+}
+
+// forecasts stands in for a weather service.
+var forecasts = map[string]Weather{
+	"paris":  {City: "Paris", TempC: 18, Conditions: "cloudy"},
+	"oslo":   {City: "Oslo", TempC: 9, Conditions: "rain"},
+	"lisbon": {City: "Lisbon", TempC: 24, Conditions: "sunny"},
+}
+
+func main() {
+	question := "Is it warmer in Paris or Oslo right now?"
+	if len(os.Args) > 1 {
+		question = strings.Join(os.Args[1:], " ")
+	}
+
+	client, err := xai.FromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	weather := xai.NewTypedTool("get_weather", "Current weather for a city",
+		func(_ context.Context, in WeatherArgs) (Weather, error) {
+			w, ok := forecasts[strings.ToLower(in.City)]
+			if !ok {
+				return Weather{}, fmt.Errorf("no forecast for %q", in.City)
+			}
+			return w, nil
+		})
+
+	runner := xai.NewToolRunner().
+		Add(weather).
+		WithMaxTurns(5).
+		WithHooks(xai.RunHooks{
+			OnToolResult: func(call *xai.ToolCallInfo, result string, err error) {
+				fmt.Printf("-> %s(%s) = %s\n", call.Function.Name, call.Function.Arguments, result)
+			},
+			OnAssistantDelta: func(delta string) { fmt.Print(delta) },
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	req := xai.NewChatRequest().
+		SystemMessage(xai.SystemContent{Text: "Use the tools for weather; never guess."}).
+		UserMessage(xai.UserContent{Text: question})
+	resp, err := client.RunChat(ctx, req, runner)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\n\n(%d prompt + %d completion tokens)\n", resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+}
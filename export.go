@@ -0,0 +1,213 @@
+package xai
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// ExportOption tunes conversation export.
+type ExportOption func(*exportOptions)
+
+type exportOptions struct {
+	title        string
+	instructions bool
+}
+
+// WithExportTitle sets the document title (default "Conversation").
+func WithExportTitle(title string) ExportOption {
+	return func(o *exportOptions) {
+		o.title = title
+	}
+}
+
+// WithExportInstructions includes system and developer messages, which are
+// left out by default since they are usually not meant for end users.
+func WithExportInstructions() ExportOption {
+	return func(o *exportOptions) {
+		o.instructions = true
+	}
+}
+
+// exportDoc is the format-independent view of a conversation.
+type exportDoc struct {
+	Title     string
+	Messages  []exportMessage
+	Footnotes []string
+}
+
+type exportMessage struct {
+	Role       string
+	Text       string
+	Images     []string
+	ToolCalls  []exportToolCall
+	ToolCallID string
+	// Footnotes are 1-based indexes into exportDoc.Footnotes.
+	Footnotes []int
+}
+
+type exportToolCall struct {
+	Name      string
+	Arguments string
+}
+
+func (cv *Conversation) exportDoc(opts []ExportOption) *exportDoc {
+	o := exportOptions{title: "Conversation"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	doc := &exportDoc{Title: o.title}
+	footnote := make(map[string]int)
+	for _, msg := range cv.req.messages {
+		role := msg.GetRole()
+		if !o.instructions && (role == v1.MessageRole_ROLE_SYSTEM || role == v1.MessageRole_ROLE_DEVELOPER) {
+			continue
+		}
+		m := exportMessage{Role: roleName(role), ToolCallID: msg.GetToolCallId()}
+		var text []string
+		for _, c := range msg.GetContent() {
+			if t := c.GetText(); t != "" {
+				text = append(text, t)
+			}
+			if img := c.GetImageUrl(); img != nil && img.GetImageUrl() != "" {
+				m.Images = append(m.Images, img.GetImageUrl())
+			}
+		}
+		m.Text = strings.Join(text, "\n\n")
+		for _, tc := range msg.GetToolCalls() {
+			if fn := tc.GetFunction(); fn != nil {
+				m.ToolCalls = append(m.ToolCalls, exportToolCall{Name: fn.GetName(), Arguments: fn.GetArguments()})
+			}
+		}
+		for _, url := range cv.citations[msg] {
+			n, ok := footnote[url]
+			if !ok {
+				doc.Footnotes = append(doc.Footnotes, url)
+				n = len(doc.Footnotes)
+				footnote[url] = n
+			}
+			m.Footnotes = append(m.Footnotes, n)
+		}
+		doc.Messages = append(doc.Messages, m)
+	}
+	return doc
+}
+
+func (m exportMessage) heading() string {
+	switch m.Role {
+	case "tool":
+		if m.ToolCallID != "" {
+			return "Tool result (" + m.ToolCallID + ")"
+		}
+		return "Tool result"
+	case "":
+		return "Unknown"
+	default:
+		return strings.ToUpper(m.Role[:1]) + m.Role[1:]
+	}
+}
+
+// ExportMarkdown writes the conversation as Markdown. Images are embedded by
+// URL, tool calls are rendered as JSON code blocks and citations recorded
+// with AppendResponse become footnotes.
+func (cv *Conversation) ExportMarkdown(w io.Writer, opts ...ExportOption) error {
+	doc := cv.exportDoc(opts)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", doc.Title)
+	for _, m := range doc.Messages {
+		fmt.Fprintf(&b, "\n### %s\n\n", m.heading())
+		if m.Text != "" {
+			if m.Role == "tool" {
+				b.WriteString(fence(m.Text, ""))
+			} else {
+				b.WriteString(m.Text)
+			}
+			for _, n := range m.Footnotes {
+				fmt.Fprintf(&b, " [^%d]", n)
+			}
+			b.WriteString("\n")
+		}
+		for _, img := range m.Images {
+			fmt.Fprintf(&b, "\n![image](<%s>)\n", img)
+		}
+		for _, tc := range m.ToolCalls {
+			fmt.Fprintf(&b, "\n**Tool call:** `%s`\n\n%s", tc.Name, fence(tc.Arguments, "json"))
+		}
+	}
+	if len(doc.Footnotes) > 0 {
+		b.WriteString("\n")
+		for i, url := range doc.Footnotes {
+			fmt.Fprintf(&b, "[^%d]: <%s>\n", i+1, url)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// fence wraps text in a code block whose fence is longer than any backtick
+// run inside it.
+func fence(text, lang string) string {
+	ticks := "```"
+	for strings.Contains(text, ticks) {
+		ticks += "`"
+	}
+	return ticks + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + ticks + "\n"
+}
+
+var exportHTMLTemplate = template.Must(template.New("conversation").Funcs(template.FuncMap{
+	"heading":  exportMessage.heading,
+	"inc":      func(i int) int { return i + 1 },
+	"imageSrc": imageSrc,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+.message { border-radius: 8px; padding: 0.75rem 1rem; margin: 1rem 0; background: #f4f4f5; }
+.message.user { background: #e0ecff; }
+.message.tool { background: #f0f7ec; }
+.role { font-weight: 600; font-size: 0.85rem; text-transform: uppercase; color: #555; margin-bottom: 0.5rem; }
+.text { white-space: pre-wrap; }
+pre { background: #fff; padding: 0.5rem; overflow-x: auto; border-radius: 4px; }
+img { max-width: 100%; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Messages}}<div class="message {{.Role}}">
+<div class="role">{{heading .}}</div>
+{{if .Text}}{{if eq .Role "tool"}}<pre>{{.Text}}</pre>{{else}}<div class="text">{{.Text}}{{range .Footnotes}}<sup><a href="#fn{{.}}">[{{.}}]</a></sup>{{end}}</div>{{end}}
+{{end}}{{range .Images}}<img src="{{imageSrc .}}" alt="image">
+{{end}}{{range .ToolCalls}}<div class="tool-call">Tool call: <code>{{.Name}}</code><pre>{{.Arguments}}</pre></div>
+{{end}}</div>
+{{end}}{{if .Footnotes}}<ol class="footnotes">
+{{range $i, $url := .Footnotes}}<li id="fn{{inc $i}}"><a href="{{$url}}">{{$url}}</a></li>
+{{end}}</ol>
+{{end}}</body>
+</html>
+`))
+
+// imageSrc marks data: image URLs, as produced by UserWithImageBytes, as safe
+// so html/template keeps them instead of replacing them with #ZgotmplZ. Other
+// URLs are left for the template to filter.
+func imageSrc(url string) any {
+	if strings.HasPrefix(url, "data:image/") {
+		return template.URL(url)
+	}
+	return url
+}
+
+// ExportHTML writes the conversation as a standalone HTML page with inline
+// styles. Content is escaped, so untrusted model output is safe to share.
+func (cv *Conversation) ExportHTML(w io.Writer, opts ...ExportOption) error {
+	return exportHTMLTemplate.Execute(w, cv.exportDoc(opts))
+}
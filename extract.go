@@ -0,0 +1,331 @@
+package xai
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// TextExtractor converts a document to plain text for indexing.
+type TextExtractor interface {
+	Extract(ctx context.Context, content []byte) (string, error)
+}
+
+// TextExtractorFunc adapts a function to TextExtractor.
+type TextExtractorFunc func(ctx context.Context, content []byte) (string, error)
+
+// Extract calls f.
+func (f TextExtractorFunc) Extract(ctx context.Context, content []byte) (string, error) {
+	return f(ctx, content)
+}
+
+// Content types with built-in extractors.
+const (
+	ContentTypeText     = "text/plain"
+	ContentTypeMarkdown = "text/markdown"
+	ContentTypeHTML     = "text/html"
+	ContentTypePDF      = "application/pdf"
+	ContentTypeDOCX     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+// Extractors picks a TextExtractor by content type. The zero value has no
+// extractors; DefaultExtractors returns one with the built-in ones.
+type Extractors struct {
+	byType map[string]TextExtractor
+}
+
+// MaxPDFStreamSize is the most the PDF extractor decompresses from one
+// Flate-compressed stream; a larger stream fails the extraction with
+// ErrInvalidRequest, so a compression bomb cannot exhaust memory.
+const MaxPDFStreamSize = 16 << 20
+
+// DefaultExtractors returns Extractors for plain text, Markdown, HTML, PDF
+// and DOCX. The PDF extractor reads text drawn with simple font encodings
+// from uncompressed or Flate-compressed pages, up to MaxPDFStreamSize per
+// stream; scanned documents and CID fonts need an extractor of your own,
+// registered for ContentTypePDF.
+func DefaultExtractors() *Extractors {
+	plain := TextExtractorFunc(extractPlain)
+	return (&Extractors{}).
+		Register(ContentTypeText, plain).
+		Register(ContentTypeMarkdown, plain).
+		Register(ContentTypeHTML, TextExtractorFunc(extractHTML)).
+		Register(ContentTypePDF, TextExtractorFunc(extractPDF)).
+		Register(ContentTypeDOCX, TextExtractorFunc(extractDOCX))
+}
+
+// Register sets the extractor for a content type (without parameters),
+// replacing any existing one. A type of the form "text/*" matches every
+// subtype without an extractor of its own.
+func (e *Extractors) Register(contentType string, x TextExtractor) *Extractors {
+	if e.byType == nil {
+		e.byType = make(map[string]TextExtractor)
+	}
+	e.byType[contentType] = x
+	return e
+}
+
+// Extract detects the content type of a document from its name, falling
+// back to sniffing content, and converts it to text. Documents without a
+// matching extractor fail with ErrInvalidRequest.
+func (e *Extractors) Extract(ctx context.Context, name string, content []byte) (text, contentType string, err error) {
+	contentType = DetectContentType(name, content)
+	x, ok := e.byType[contentType]
+	if !ok {
+		major, _, _ := strings.Cut(contentType, "/")
+		x, ok = e.byType[major+"/*"]
+	}
+	if !ok {
+		return "", contentType, &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf("no text extractor for %s (%s)", name, contentType)}
+	}
+	text, err = x.Extract(ctx, content)
+	if err != nil {
+		return "", contentType, WrapError(err, "extracting text from "+name)
+	}
+	return text, contentType, nil
+}
+
+// extensionTypes covers extensions that the system MIME table often lacks.
+var extensionTypes = map[string]string{
+	".txt":      ContentTypeText,
+	".md":       ContentTypeMarkdown,
+	".markdown": ContentTypeMarkdown,
+	".html":     ContentTypeHTML,
+	".htm":      ContentTypeHTML,
+	".pdf":      ContentTypePDF,
+	".docx":     ContentTypeDOCX,
+}
+
+// DetectContentType returns the media type of a document, without
+// parameters, from its file extension or else its leading bytes.
+func DetectContentType(name string, content []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+	ct, ok := extensionTypes[ext]
+	if !ok && ext != "" {
+		ct = mime.TypeByExtension(ext)
+	}
+	if ct == "" {
+		ct = http.DetectContentType(content)
+	}
+	ct, _, _ = strings.Cut(ct, ";")
+	return strings.TrimSpace(ct)
+}
+
+func extractPlain(_ context.Context, content []byte) (string, error) {
+	if !utf8.Valid(content) {
+		return "", fmt.Errorf("not valid UTF-8")
+	}
+	return string(content), nil
+}
+
+// htmlSkip lists elements whose content is not document text.
+var htmlSkip = map[string]bool{"script": true, "style": true, "noscript": true, "template": true, "head": true, "svg": true}
+
+// htmlBlock lists elements that start a new line.
+var htmlBlock = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "pre": true, "blockquote": true, "section": true,
+	"article": true, "header": true, "footer": true, "table": true, "ul": true, "ol": true, "hr": true,
+}
+
+func extractHTML(_ context.Context, content []byte) (string, error) {
+	z := html.NewTokenizer(bytes.NewReader(content))
+	var b strings.Builder
+	skip := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return normalizeText(b.String()), nil
+			}
+			return "", z.Err()
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(strings.Join(strings.Fields(string(z.Text())), " "))
+				b.WriteByte(' ')
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			if htmlSkip[string(name)] {
+				skip++
+			} else if htmlBlock[string(name)] {
+				b.WriteByte('\n')
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if htmlSkip[string(name)] && skip > 0 {
+				skip--
+			} else if htmlBlock[string(name)] {
+				b.WriteByte('\n')
+			}
+		}
+	}
+}
+
+func extractDOCX(_ context.Context, content []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", err
+	}
+	f, err := zr.Open("word/document.xml")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	dec := xml.NewDecoder(f)
+	var b strings.Builder
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return normalizeText(b.String()), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+}
+
+var (
+	pdfStream = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n(.*?)\r?\nendstream`)
+	pdfText   = regexp.MustCompile(`(?s)BT(.*?)ET`)
+	pdfOp     = regexp.MustCompile(`(?s)\((?:\\.|[^\\)])*\)|<[0-9A-Fa-f\s]*>|\[|\]|T\*|Tj|TJ|Td|TD|'|"`)
+)
+
+func extractPDF(_ context.Context, content []byte) (string, error) {
+	if !bytes.HasPrefix(content, []byte("%PDF")) {
+		return "", fmt.Errorf("not a PDF")
+	}
+	var b strings.Builder
+	for _, m := range pdfStream.FindAllSubmatch(content, -1) {
+		data := m[2]
+		if bytes.Contains(m[1], []byte("/FlateDecode")) {
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				continue
+			}
+			data, err = io.ReadAll(io.LimitReader(zr, MaxPDFStreamSize+1))
+			if len(data) > MaxPDFStreamSize {
+				return "", &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf("PDF stream decompresses to more than %d bytes", MaxPDFStreamSize)}
+			}
+			if err != nil {
+				continue
+			}
+		}
+		for _, block := range pdfText.FindAllSubmatch(data, -1) {
+			pdfTextOps(&b, block[1])
+			b.WriteByte('\n')
+		}
+	}
+	return normalizeText(b.String()), nil
+}
+
+// pdfTextOps writes the strings shown by the operators of one text object.
+func pdfTextOps(b *strings.Builder, ops []byte) {
+	var pending []string
+	for _, tok := range pdfOp.FindAll(ops, -1) {
+		switch s := string(tok); {
+		case s[0] == '(':
+			pending = append(pending, pdfLiteral(s[1:len(s)-1]))
+		case s[0] == '<':
+			pending = append(pending, pdfHex(s[1:len(s)-1]))
+		case s == "[" || s == "]":
+		case s == "Tj" || s == "TJ":
+			b.WriteString(strings.Join(pending, ""))
+			pending = pending[:0]
+		case s == "'" || s == `"`:
+			b.WriteByte('\n')
+			b.WriteString(strings.Join(pending, ""))
+			pending = pending[:0]
+		default: // T*, Td, TD move to a new line
+			b.WriteByte('\n')
+		}
+	}
+}
+
+func pdfLiteral(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r', 't', 'b', 'f':
+			b.WriteByte(' ')
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			v, n := 0, 0
+			for ; n < 3 && i+n < len(s) && s[i+n] >= '0' && s[i+n] <= '7'; n++ {
+				v = v*8 + int(s[i+n]-'0')
+			}
+			i += n - 1
+			b.WriteRune(rune(v))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func pdfHex(s string) string {
+	s = strings.Join(strings.Fields(s), "")
+	if len(s)%2 == 1 {
+		s += "0"
+	}
+	data, _ := hex.DecodeString(s)
+	var b strings.Builder
+	for _, c := range data {
+		b.WriteRune(rune(c))
+	}
+	return b.String()
+}
+
+var blankLines = regexp.MustCompile(`\n\s*\n\s*\n+`)
+
+// normalizeText trims every line and collapses runs of blank lines.
+func normalizeText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
@@ -0,0 +1,125 @@
+package xai
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/status"
+)
+
+// GroupResult is the outcome of one request run by a Group.
+type GroupResult struct {
+	// Index is the order in which the request was added with Go.
+	Index int
+	// Request is the request as passed to Go.
+	Request *ChatRequest
+	// Response is the chat response, or nil if Err is set.
+	Response *ChatResponse
+	// Err is the request's error, including context cancellation for
+	// requests cut short by a sibling's fatal error.
+	Err error
+}
+
+// GroupOption configures a Group.
+type GroupOption func(*Group)
+
+// WithGroupLimit caps how many requests run at once. n <= 0 means no limit.
+func WithGroupLimit(n int) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithGroupFatal sets which errors cancel the rest of the group. The
+// default treats every error as fatal, like errgroup; pass a function that
+// returns false to let siblings keep running, e.g. for partial results:
+//
+//	xai.WithGroupFatal(func(err error) bool { return false })
+func WithGroupFatal(fatal func(error) bool) GroupOption {
+	return func(g *Group) {
+		g.fatal = fatal
+	}
+}
+
+// Group runs chat completions concurrently under one context, in the style
+// of errgroup: the first fatal error cancels the requests still running, and
+// Wait returns every result. Create one with NewGroup; a Group is not
+// reusable after Wait.
+type Group struct {
+	client *Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+	fatal  func(error) bool
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	results []GroupResult
+	err     error
+}
+
+// NewGroup creates a group whose requests use client and are canceled when
+// ctx is.
+func NewGroup(ctx context.Context, client *Client, opts ...GroupOption) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &Group{
+		client: client,
+		ctx:    ctx,
+		cancel: cancel,
+		fatal:  func(error) bool { return true },
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Go starts req in the background and returns its result index.
+func (g *Group) Go(req *ChatRequest, opts ...CallOption) int {
+	g.mu.Lock()
+	idx := len(g.results)
+	g.results = append(g.results, GroupResult{Index: idx, Request: req})
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		resp, err := g.run(req, opts)
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.results[idx].Response, g.results[idx].Err = resp, err
+		if err != nil && g.err == nil && g.fatal(err) {
+			g.err = err
+			g.cancel()
+		}
+	}()
+	return idx
+}
+
+func (g *Group) run(req *ChatRequest, opts []CallOption) (*ChatResponse, error) {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+			defer func() { <-g.sem }()
+		case <-g.ctx.Done():
+			return nil, FromGRPCError(status.FromContextError(g.ctx.Err()).Err())
+		}
+	}
+	if err := g.ctx.Err(); err != nil {
+		return nil, FromGRPCError(status.FromContextError(err).Err())
+	}
+	return g.client.CompleteChat(g.ctx, req, opts...)
+}
+
+// Wait blocks until every request has finished and returns the results in
+// the order they were added, along with the first fatal error, if any.
+func (g *Group) Wait() ([]GroupResult, error) {
+	g.wg.Wait()
+	g.cancel()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.results, g.err
+}
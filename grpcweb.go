@@ -0,0 +1,296 @@
+package xai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcWebConn serves the service clients' RPCs with the gRPC-Web protocol
+// over plain HTTP requests. Unlike the REST fallback it carries the same
+// protobuf messages as gRPC, so every RPC is available; it needs an
+// endpoint that speaks gRPC-Web, such as an Envoy proxy in front of the API.
+type grpcWebConn struct {
+	baseURL string
+	http    *http.Client
+	auth    *bearerAuth
+	info    string
+}
+
+func newGRPCWebConn(cfg Config) *grpcWebConn {
+	return &grpcWebConn{
+		baseURL: strings.TrimRight(cfg.GRPCWebEndpoint, "/"),
+		http:    httpClientFor(cfg),
+		auth:    &bearerAuth{apiKey: cfg.APIKey, provider: cfg.APIKeyProvider},
+		info:    clientInfo(cfg.AppName),
+	}
+}
+
+// gRPC-Web frame flags.
+const (
+	grpcWebDataFrame    = 0x00
+	grpcWebCompressed   = 0x01
+	grpcWebTrailerFrame = 0x80
+)
+
+// grpcWebMaxRecvMsgSize is the largest frame read unless the call sets
+// grpc.MaxCallRecvMsgSize; it matches grpc-go's default.
+const grpcWebMaxRecvMsgSize = 4 << 20
+
+// Invoke performs a unary RPC.
+func (w *grpcWebConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	s := &grpcWebStream{ctx: ctx, conn: w, method: method, opts: opts}
+	if err := s.SendMsg(args); err != nil {
+		return err
+	}
+	if err := s.RecvMsg(reply); err != nil {
+		if err == io.EOF {
+			return status.Errorf(codes.Internal, "%s: no response message", shortMethod(method))
+		}
+		return err
+	}
+	// Read on to the trailers, which carry the call's status.
+	if err := s.RecvMsg(nil); err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// NewStream opens a server-streaming RPC. The request is sent on the first
+// RecvMsg, once the request message has been passed to SendMsg.
+func (w *grpcWebConn) NewStream(ctx context.Context, _ *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return &grpcWebStream{ctx: ctx, conn: w, method: method, opts: opts}, nil
+}
+
+// grpcWebStream is one gRPC-Web call. It implements grpc.ClientStream for
+// server-streaming calls and backs unary calls too.
+type grpcWebStream struct {
+	ctx     context.Context
+	conn    *grpcWebConn
+	method  string
+	opts    []grpc.CallOption
+	req     []byte
+	body    io.ReadCloser
+	reader  *bufio.Reader
+	header  metadata.MD
+	trailer metadata.MD
+	done    bool
+}
+
+func (s *grpcWebStream) Header() (metadata.MD, error) { return s.header, nil }
+func (s *grpcWebStream) Trailer() metadata.MD         { return s.trailer }
+func (s *grpcWebStream) CloseSend() error             { return nil }
+func (s *grpcWebStream) Context() context.Context     { return s.ctx }
+
+// SendMsg encodes the request; it is sent on the first RecvMsg.
+func (s *grpcWebStream) SendMsg(m any) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "gRPC-Web: %T is not a protobuf message", m)
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return status.Errorf(codes.Internal, "encoding request: %v", err)
+	}
+	s.req = make([]byte, 5+len(data))
+	s.req[0] = grpcWebDataFrame
+	binary.BigEndian.PutUint32(s.req[1:5], uint32(len(data)))
+	copy(s.req[5:], data)
+	return nil
+}
+
+// RecvMsg decodes the next response message into m, returning io.EOF once
+// the server has ended the call successfully.
+func (s *grpcWebStream) RecvMsg(m any) error {
+	if s.body == nil && !s.done {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	if s.done {
+		return io.EOF
+	}
+	for {
+		flag, payload, err := s.nextFrame()
+		if err != nil {
+			return s.fail(err)
+		}
+		if flag&grpcWebTrailerFrame != 0 {
+			s.trailer = parseGRPCWebTrailer(payload)
+			deliverTrailer(s.trailer, s.opts)
+			s.done = true
+			_ = s.body.Close()
+			if err := grpcWebStatus(s.trailer); err != nil {
+				return err
+			}
+			return io.EOF
+		}
+		msg, ok := m.(proto.Message)
+		if !ok {
+			return s.fail(status.Error(codes.Internal, "gRPC-Web: unexpected response message"))
+		}
+		if err := proto.Unmarshal(payload, msg); err != nil {
+			return s.fail(status.Errorf(codes.Internal, "decoding response: %v", err))
+		}
+		return nil
+	}
+}
+
+func (s *grpcWebStream) fail(err error) error {
+	s.done = true
+	if s.body != nil {
+		_ = s.body.Close()
+	}
+	return err
+}
+
+// open sends the HTTP request and checks the response headers.
+func (s *grpcWebStream) open() error {
+	if s.req == nil {
+		return status.Error(codes.Internal, "gRPC-Web stream: request not sent")
+	}
+	auth, err := s.conn.auth.GetRequestMetadata(s.ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.conn.baseURL+s.method, bytes.NewReader(s.req))
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "building request: %v", err)
+	}
+	if md, ok := metadata.FromOutgoingContext(s.ctx); ok {
+		for k, vs := range md {
+			for _, v := range vs {
+				req.Header.Add(k, v)
+			}
+		}
+	}
+	req.Header.Set("Authorization", auth["authorization"])
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	req.Header.Set("Accept", "application/grpc-web+proto")
+	req.Header.Set("X-Grpc-Web", "1")
+	req.Header.Set("X-User-Agent", s.conn.info)
+	if deadline, ok := s.ctx.Deadline(); ok {
+		req.Header.Set("Grpc-Timeout", grpcTimeout(time.Until(deadline)))
+	}
+
+	resp, err := s.conn.http.Do(req)
+	if err != nil {
+		return restTransportError(s.ctx, err)
+	}
+	opts := append(s.opts, grpc.Header(&s.header))
+	deliverRESTHeaders(resp.Header, opts)
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return restStatusError(resp.StatusCode, data)
+	}
+	// A trailers-only response reports the status in the headers.
+	if len(s.header.Get("grpc-status")) > 0 {
+		resp.Body.Close()
+		s.done, s.trailer = true, s.header
+		deliverTrailer(s.trailer, s.opts)
+		return grpcWebStatus(s.header)
+	}
+	s.body = resp.Body
+	s.reader = bufio.NewReader(resp.Body)
+	return nil
+}
+
+// nextFrame reads one length-prefixed gRPC-Web frame.
+func (s *grpcWebStream) nextFrame() (byte, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(s.reader, hdr[:]); err != nil {
+		if err == io.EOF {
+			return 0, nil, status.Error(codes.Internal, "gRPC-Web: response ended without trailers")
+		}
+		return 0, nil, restTransportError(s.ctx, err)
+	}
+	if hdr[0]&grpcWebCompressed != 0 {
+		return 0, nil, status.Error(codes.Internal, "gRPC-Web: compressed frames are not supported")
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if limit := s.maxRecvMsgSize(); uint64(n) > uint64(limit) {
+		return 0, nil, status.Errorf(codes.Internal, "gRPC-Web: frame of %d bytes exceeds the %d byte limit", n, limit)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(s.reader, payload); err != nil {
+		return 0, nil, restTransportError(s.ctx, err)
+	}
+	return hdr[0], payload, nil
+}
+
+// maxRecvMsgSize returns the frame size limit of the call.
+func (s *grpcWebStream) maxRecvMsgSize() int {
+	limit := grpcWebMaxRecvMsgSize
+	for _, opt := range s.opts {
+		if o, ok := opt.(grpc.MaxRecvMsgSizeCallOption); ok {
+			limit = o.MaxRecvMsgSize
+		}
+	}
+	return limit
+}
+
+// parseGRPCWebTrailer decodes a trailer frame, which holds HTTP/1-style
+// "key: value" lines.
+func parseGRPCWebTrailer(payload []byte) metadata.MD {
+	md := metadata.MD{}
+	r := textproto.NewReader(bufio.NewReader(bytes.NewReader(append(payload, "\r\n\r\n"...))))
+	h, _ := r.ReadMIMEHeader()
+	for k, v := range h {
+		md[strings.ToLower(k)] = v
+	}
+	return md
+}
+
+// grpcWebStatus returns the error for the grpc-status in md, or nil if it
+// is absent or OK.
+func grpcWebStatus(md metadata.MD) error {
+	v := md.Get("grpc-status")
+	if len(v) == 0 {
+		return nil
+	}
+	code, err := strconv.Atoi(v[0])
+	if err != nil {
+		return status.Errorf(codes.Internal, "gRPC-Web: invalid grpc-status %q", v[0])
+	}
+	if codes.Code(code) == codes.OK {
+		return nil
+	}
+	var msg string
+	if m := md.Get("grpc-message"); len(m) > 0 {
+		msg, _ = url.PathUnescape(m[0])
+	}
+	return status.Error(codes.Code(code), msg)
+}
+
+// deliverTrailer hands md to grpc.Trailer call options.
+func deliverTrailer(md metadata.MD, opts []grpc.CallOption) {
+	for _, opt := range opts {
+		if to, ok := opt.(grpc.TrailerCallOption); ok {
+			*to.TrailerAddr = md
+		}
+	}
+}
+
+// grpcTimeout formats d as a grpc-timeout header value.
+func grpcTimeout(d time.Duration) string {
+	if d < time.Millisecond {
+		return "1m"
+	}
+	return fmt.Sprintf("%dm", min(d.Milliseconds(), 99_999_999))
+}
@@ -0,0 +1,50 @@
+package xai
+
+import (
+	"context"
+	"time"
+)
+
+// PingResult describes a health check round trip to the xAI API.
+type PingResult struct {
+	// Endpoint is the configured API endpoint.
+	Endpoint string
+	// Latency is the round-trip time of the check.
+	Latency time.Duration
+	// ConnState is the gRPC connection state after the check
+	// (e.g. "READY", "TRANSIENT_FAILURE").
+	ConnState string
+	// KeyStatus is the status of the API key, when the call succeeded.
+	KeyStatus APIKeyStatus
+	// CheckedAt is when the check started.
+	CheckedAt time.Time
+}
+
+// Ping performs a cheap authenticated round trip (GetApiKeyInfo) and reports
+// latency and connectivity. It returns an error if the backend is unreachable,
+// rejects the key, or reports the key as inactive; the result is returned
+// either way so readiness probes can log it.
+func (c *Client) Ping(ctx context.Context) (*PingResult, error) {
+	result := &PingResult{
+		Endpoint:  c.config.Endpoint,
+		CheckedAt: c.config.Clock.Now(),
+	}
+
+	info, err := c.GetAPIKeyInfo(ctx)
+	result.Latency = c.config.Clock.Now().Sub(result.CheckedAt)
+	if c.conn != nil {
+		result.ConnState = c.conn.GetState().String()
+	}
+	if err != nil {
+		return result, WrapError(err, "ping")
+	}
+
+	result.KeyStatus = info.Status
+	if !info.IsActive() {
+		return result, &Error{
+			Code:    ErrAuth,
+			Message: "ping: API key is " + info.Status.String(),
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,124 @@
+package xai
+
+import (
+	"strings"
+	"time"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"golang.org/x/text/language"
+)
+
+// ResponseLength is a preset for how long answers should be.
+type ResponseLength int
+
+const (
+	// ResponseLengthShort asks for a few sentences (max 256 tokens).
+	ResponseLengthShort ResponseLength = iota + 1
+	// ResponseLengthMedium asks for a few paragraphs (max 1024 tokens).
+	ResponseLengthMedium
+	// ResponseLengthLong allows detailed answers (max 4096 tokens).
+	ResponseLengthLong
+)
+
+// maxTokens returns the token cap for the preset.
+func (l ResponseLength) maxTokens() int32 {
+	switch l {
+	case ResponseLengthShort:
+		return 256
+	case ResponseLengthMedium:
+		return 1024
+	case ResponseLengthLong:
+		return 4096
+	default:
+		return 0
+	}
+}
+
+// instruction returns the length guidance sent to the model.
+func (l ResponseLength) instruction() string {
+	switch l {
+	case ResponseLengthShort:
+		return "Keep the response short: answer in at most three sentences."
+	case ResponseLengthMedium:
+		return "Keep the response moderately concise: a few short paragraphs at most."
+	case ResponseLengthLong:
+		return "A detailed, thorough response is welcome."
+	default:
+		return ""
+	}
+}
+
+// WithResponseLength applies a length preset: it sets max_tokens to match and
+// adds a standardized length instruction to the developer context, so the
+// model aims for the length instead of being cut off. Call WithMaxTokens
+// afterwards to override the cap. With reasoning models, reasoning tokens
+// count against the cap too.
+func (r *ChatRequest) WithResponseLength(length ResponseLength) *ChatRequest {
+	r.responseLength = length
+	if n := length.maxTokens(); n > 0 {
+		r.maxTokens = &n
+	}
+	return r
+}
+
+// WithLocale tells the model the end user's locale, so it answers in the
+// matching language and uses local conventions for dates, numbers and units.
+// The API has no locale field; the hint is sent as a developer message
+// after the leading system messages, and left out of continuations (see
+// WithPreviousResponseId), which already carry it.
+func (r *ChatRequest) WithLocale(tag language.Tag) *ChatRequest {
+	r.locale = &tag
+	return r
+}
+
+// WithTimezone tells the model the end user's time zone, so relative times
+// ("tonight", "in two hours") are interpreted correctly. Sent alongside the
+// locale hint.
+func (r *ChatRequest) WithTimezone(loc *time.Location) *ChatRequest {
+	r.timezone = loc
+	return r
+}
+
+// WithCurrentDate tells the model today's date, so it answers relative to
+// the present instead of its training cutoff. The date is taken when the
+// request is built, in the WithTimezone zone if set and UTC otherwise.
+// Config.CurrentDate enables this for every request.
+func (r *ChatRequest) WithCurrentDate() *ChatRequest {
+	r.currentDate = true
+	return r
+}
+
+// hintsMessage returns the developer message carrying date, locale, time zone
+// and length hints, or nil if none are set.
+func (r *ChatRequest) hintsMessage(defaults buildDefaults) *v1.Message {
+	clock := defaults.now
+	if clock == nil {
+		clock = time.Now
+	}
+	var lines []string
+	if r.currentDate || defaults.currentDate {
+		loc := r.timezone
+		if loc == nil {
+			loc = time.UTC
+		}
+		now := clock().In(loc)
+		lines = append(lines, "Current date: "+now.Format("2006-01-02")+" ("+now.Weekday().String()+").")
+	}
+	if r.locale != nil {
+		lines = append(lines, "User locale: "+r.locale.String()+". Respond in this locale's language and conventions unless asked otherwise.")
+	}
+	if r.timezone != nil {
+		offset := clock().In(r.timezone).Format("-07:00")
+		lines = append(lines, "User time zone: "+r.timezone.String()+" (UTC"+offset+"). Interpret and express times in this zone.")
+	}
+	if text := r.responseLength.instruction(); text != "" {
+		lines = append(lines, text)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return &v1.Message{
+		Role:    v1.MessageRole_ROLE_DEVELOPER,
+		Content: []*v1.Content{{Content: &v1.Content_Text{Text: strings.Join(lines, "\n")}}},
+	}
+}
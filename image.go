@@ -179,20 +179,31 @@ type ImageResponse struct {
 	Images []GeneratedImage
 	// Model is the model that was used.
 	Model string
+	// Metadata holds the response headers and trailers, such as the request
+	// ID and rate-limit state.
+	Metadata *ResponseMetadata
 }
 
 // GenerateImage generates images from a text prompt.
-func (c *Client) GenerateImage(ctx context.Context, req *ImageRequest) (*ImageResponse, error) {
-	ctx, cancel := c.withTimeout(ctx)
+func (c *Client) GenerateImage(ctx context.Context, req *ImageRequest, opts ...CallOption) (*ImageResponse, error) {
+	o := resolveCallOptions(opts)
+	ctx, cancel := c.callContext(ctx, o)
 	defer cancel()
 
-	resp, err := c.image.GenerateImage(ctx, req.toProto())
+	protoReq := req.toProto()
+	if o.model != "" {
+		protoReq.Model = o.model
+	}
+
+	md := &ResponseMetadata{}
+	resp, err := c.image.GenerateImage(ctx, protoReq, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
 	result := &ImageResponse{
-		Model: resp.GetModel(),
+		Model:    resp.GetModel(),
+		Metadata: md,
 	}
 
 	for _, img := range resp.GetImages() {
@@ -459,7 +459,7 @@ func TestContextWindowBehavior(t *testing.T) {
 	t.Logf("\n=== FINDING FOR GOCLAW ===")
 	t.Logf("xAI does NOT return context window exceeded errors")
 	t.Logf("Detection method: Compare resp.Usage.PromptTokens against expected")
-	t.Logf("Or pre-flight check: Use client.Tokenize() before sending")
+	t.Logf("Or pre-flight check: Use client.CountRequestTokens() and RequestTokens.Remaining() before sending")
 }
 
 // containsIgnoreCase checks if s contains substr (case-insensitive).
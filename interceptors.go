@@ -0,0 +1,94 @@
+package xai
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// clientConn routes every RPC made by the service clients through the
+// client's internal interceptor chain before it reaches the transport: the
+// gRPC connection, or the HTTP-based transport (REST or gRPC-Web) when
+// http is set. Unlike dial
+// options, this also applies to clients created with WithChannel.
+type clientConn struct {
+	conn   *grpc.ClientConn
+	http   grpc.ClientConnInterface
+	unary  []grpc.UnaryClientInterceptor
+	stream []grpc.StreamClientInterceptor
+}
+
+var _ grpc.ClientConnInterface = (*clientConn)(nil)
+
+// Invoke implements grpc.ClientConnInterface.
+func (c *clientConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return chainUnary(c.unary, c.invoker())(ctx, method, args, reply, c.conn, opts...)
+}
+
+// NewStream implements grpc.ClientConnInterface.
+func (c *clientConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return chainStream(c.stream, c.streamer())(ctx, desc, c.conn, method, opts...)
+}
+
+// invoker returns the transport's unary call.
+func (c *clientConn) invoker() grpc.UnaryInvoker {
+	if c.http != nil {
+		return func(ctx context.Context, method string, req, reply any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return c.http.Invoke(ctx, method, req, reply, opts...)
+		}
+	}
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return cc.Invoke(ctx, method, req, reply, opts...)
+	}
+}
+
+// streamer returns the transport's stream constructor.
+func (c *clientConn) streamer() grpc.Streamer {
+	if c.http != nil {
+		return func(ctx context.Context, desc *grpc.StreamDesc, _ *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return c.http.NewStream(ctx, desc, method, opts...)
+		}
+	}
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return cc.NewStream(ctx, desc, method, opts...)
+	}
+}
+
+// chainUnary composes interceptors around invoker so the first one is
+// outermost.
+func chainUnary(interceptors []grpc.UnaryClientInterceptor, invoker grpc.UnaryInvoker) grpc.UnaryInvoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		next, ic := invoker, interceptors[i]
+		invoker = func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return ic(ctx, method, req, reply, cc, next, opts...)
+		}
+	}
+	return invoker
+}
+
+// chainStream composes stream interceptors around streamer so the first one
+// is outermost.
+func chainStream(interceptors []grpc.StreamClientInterceptor, streamer grpc.Streamer) grpc.Streamer {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		next, ic := streamer, interceptors[i]
+		streamer = func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return ic(ctx, desc, cc, method, next, opts...)
+		}
+	}
+	return streamer
+}
+
+// shortMethod turns "/xai_api.Chat/GetCompletion" into "Chat/GetCompletion".
+func shortMethod(method string) string {
+	method = strings.TrimPrefix(method, "/")
+	return strings.TrimPrefix(method, "xai_api.")
+}
+
+// modelOf extracts the model name from a request message, if it has one.
+func modelOf(req any) string {
+	if m, ok := req.(interface{ GetModel() string }); ok {
+		return m.GetModel()
+	}
+	return ""
+}
@@ -0,0 +1,281 @@
+package xai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// DefaultKeyBenchDuration is how long a key is taken out of rotation after a
+// rate limit error when the server does not say how long to wait.
+const DefaultKeyBenchDuration = 30 * time.Second
+
+// KeyPoolStrategy selects which key a KeyPool hands out next.
+type KeyPoolStrategy int
+
+const (
+	// KeyPoolRoundRobin cycles through available keys in order (default).
+	KeyPoolRoundRobin KeyPoolStrategy = iota
+	// KeyPoolLeastLoaded picks the available key with the fewest in-flight requests.
+	KeyPoolLeastLoaded
+)
+
+// KeyPool rotates requests across several API keys. Keys that return a rate
+// limit error are benched for a while and skipped until the bench expires.
+// It is safe for concurrent use and may be shared between clients.
+//
+// Set it as Config.KeyPool; Config.APIKey may then be omitted.
+type KeyPool struct {
+	mu       sync.Mutex
+	keys     []*pooledKey
+	strategy KeyPoolStrategy
+	bench    time.Duration
+	next     int
+	now      func() time.Time
+}
+
+type pooledKey struct {
+	key           *SecureString
+	inFlight      int
+	requests      uint64
+	rateLimitHits uint64
+	benchedUntil  time.Time
+}
+
+// KeyStats is a snapshot of a pooled key's usage.
+type KeyStats struct {
+	// Index is the key's position in the pool.
+	Index int
+	// RedactedKey is the key with all but the last few characters hidden.
+	RedactedKey string
+	// InFlight is the number of requests currently using the key.
+	InFlight int
+	// Requests is the total number of requests made with the key.
+	Requests uint64
+	// RateLimitHits is the number of rate limit errors returned for the key.
+	RateLimitHits uint64
+	// BenchedUntil is when the key returns to rotation; zero if available.
+	BenchedUntil time.Time
+}
+
+// NewKeyPool creates a pool over keys using the given strategy.
+func NewKeyPool(keys []*SecureString, strategy KeyPoolStrategy) *KeyPool {
+	p := &KeyPool{
+		strategy: strategy,
+		bench:    DefaultKeyBenchDuration,
+		now:      time.Now,
+	}
+	for _, k := range keys {
+		p.keys = append(p.keys, &pooledKey{key: k})
+	}
+	return p
+}
+
+// WithBenchDuration sets how long a rate-limited key is benched when the
+// server provides no retry hint. Returns the pool for chaining.
+func (p *KeyPool) WithBenchDuration(d time.Duration) *KeyPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bench = d
+	return p
+}
+
+// Stats returns a snapshot of every key's usage.
+func (p *KeyPool) Stats() []KeyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	stats := make([]KeyStats, len(p.keys))
+	for i, k := range p.keys {
+		stats[i] = KeyStats{
+			Index:         i,
+			RedactedKey:   redactKey(k.key),
+			InFlight:      k.inFlight,
+			Requests:      k.requests,
+			RateLimitHits: k.rateLimitHits,
+		}
+		if k.benchedUntil.After(now) {
+			stats[i].BenchedUntil = k.benchedUntil
+		}
+	}
+	return stats
+}
+
+// Close clears all keys in the pool from memory.
+func (p *KeyPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, k := range p.keys {
+		if k.key != nil {
+			k.key.Close()
+		}
+	}
+}
+
+// acquire picks a key and marks it in flight.
+func (p *KeyPool) acquire() (*pooledKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.keys) == 0 {
+		return nil, &Error{Code: ErrAuth, Message: "key pool is empty"}
+	}
+
+	now := p.now()
+	var chosen *pooledKey
+	var soonest time.Time
+	for i := range p.keys {
+		idx := (p.next + i) % len(p.keys)
+		k := p.keys[idx]
+		if k.key == nil || k.key.IsZero() {
+			continue
+		}
+		if k.benchedUntil.After(now) {
+			if soonest.IsZero() || k.benchedUntil.Before(soonest) {
+				soonest = k.benchedUntil
+			}
+			continue
+		}
+		if chosen == nil || (p.strategy == KeyPoolLeastLoaded && k.inFlight < chosen.inFlight) {
+			chosen = k
+			if p.strategy == KeyPoolRoundRobin {
+				p.next = idx + 1
+				break
+			}
+		}
+	}
+
+	if chosen == nil {
+		if soonest.IsZero() {
+			return nil, &Error{Code: ErrAuth, Message: "key pool has no usable keys"}
+		}
+		return nil, &Error{
+			Code:       ErrRateLimit,
+			Message:    "all pooled API keys are rate limited",
+			RetryAfter: soonest.Sub(now),
+		}
+	}
+	if p.strategy == KeyPoolLeastLoaded {
+		p.next++
+	}
+	chosen.inFlight++
+	chosen.requests++
+	return chosen, nil
+}
+
+// release marks a request finished and benches the key on rate limit errors.
+func (p *KeyPool) release(k *pooledKey, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k.inFlight--
+	p.penalizeLocked(k, err)
+}
+
+// penalize benches k if err is a rate limit error.
+func (p *KeyPool) penalize(k *pooledKey, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.penalizeLocked(k, err)
+}
+
+func (p *KeyPool) penalizeLocked(k *pooledKey, err error) {
+	if err == nil {
+		return
+	}
+	var xaiErr *Error
+	if !errors.As(err, &xaiErr) {
+		xaiErr = FromGRPCError(err)
+	}
+	if xaiErr.Code != ErrRateLimit {
+		return
+	}
+	k.rateLimitHits++
+	bench := p.bench
+	if xaiErr.RetryAfter > bench {
+		bench = xaiErr.RetryAfter
+	}
+	k.benchedUntil = p.now().Add(bench)
+}
+
+// redactKey shows only the last four characters of a key.
+func redactKey(s *SecureString) string {
+	if s == nil || s.IsZero() {
+		return ""
+	}
+	v := s.Value()
+	if len(v) <= 4 {
+		return "****"
+	}
+	return fmt.Sprintf("****%s", v[len(v)-4:])
+}
+
+// apiKeyContextKey carries a per-request API key to bearerAuth.
+type apiKeyContextKey struct{}
+
+// hasContextKey reports whether ctx already names a key, via
+// WithAPIKeyContext, in which case the pool is bypassed.
+func hasContextKey(ctx context.Context) bool {
+	_, ok := ctx.Value(apiKeyContextKey{}).(*SecureString)
+	return ok
+}
+
+// keyPoolUnaryInterceptor leases a key from pool for each unary RPC.
+func keyPoolUnaryInterceptor(pool *KeyPool) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if hasContextKey(ctx) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		k, err := pool.acquire()
+		if err != nil {
+			return err
+		}
+		err = invoker(context.WithValue(ctx, apiKeyContextKey{}, k.key), method, req, reply, cc, opts...)
+		pool.release(k, err)
+		return err
+	}
+}
+
+// keyPoolStreamInterceptor leases a key from pool for the lifetime of a stream.
+func keyPoolStreamInterceptor(pool *KeyPool) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if hasContextKey(ctx) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		k, err := pool.acquire()
+		if err != nil {
+			return nil, err
+		}
+		cs, err := streamer(context.WithValue(ctx, apiKeyContextKey{}, k.key), desc, cc, method, opts...)
+		if err != nil {
+			pool.release(k, err)
+			return nil, err
+		}
+		// The stream context is canceled once the stream finishes for any
+		// reason, including callers abandoning it without draining.
+		go func() {
+			<-cs.Context().Done()
+			pool.release(k, nil)
+		}()
+		return &keyPoolStream{ClientStream: cs, pool: pool, key: k}, nil
+	}
+}
+
+// keyPoolStream benches its key when the stream fails with a rate limit error.
+type keyPoolStream struct {
+	grpc.ClientStream
+	pool *KeyPool
+	key  *pooledKey
+}
+
+func (s *keyPoolStream) RecvMsg(msg any) error {
+	err := s.ClientStream.RecvMsg(msg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.pool.penalize(s.key, err)
+	}
+	return err
+}
@@ -0,0 +1,222 @@
+package xai
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"golang.org/x/text/language"
+	"google.golang.org/protobuf/proto"
+)
+
+// LanguageDetector identifies the language of a piece of text. Detect returns
+// language.Und when it cannot tell; confidence is in [0, 1].
+type LanguageDetector interface {
+	Detect(text string) (tag language.Tag, confidence float64)
+}
+
+// LanguageDetectorFunc adapts a function to the LanguageDetector interface.
+type LanguageDetectorFunc func(text string) (language.Tag, float64)
+
+// Detect calls f(text).
+func (f LanguageDetectorFunc) Detect(text string) (language.Tag, float64) {
+	return f(text)
+}
+
+// DefaultLanguageDetector returns a small dependency-free detector. Non-Latin
+// scripts (CJK, Cyrillic, Arabic, Devanagari, Greek, Hebrew, Thai) are
+// recognized by script; Latin-script text is scored against common function
+// words for English, Spanish, French, German, Portuguese, Italian, Dutch and
+// Afrikaans. It needs a sentence or two to be reliable and returns
+// language.Und for short or ambiguous text.
+func DefaultLanguageDetector() LanguageDetector {
+	return LanguageDetectorFunc(detectLanguage)
+}
+
+// minLanguageConfidence is the detector confidence below which the guard
+// does not act.
+const minLanguageConfidence = 0.5
+
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "in", "that", "it", "you", "with", "for", "this", "was", "not", "have", "be", "on"},
+	"es": {"el", "la", "los", "las", "y", "es", "de", "que", "en", "un", "una", "por", "con", "para", "no", "se", "del", "su"},
+	"fr": {"le", "la", "les", "et", "est", "de", "que", "en", "un", "une", "pour", "dans", "pas", "vous", "des", "du", "avec", "sur"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "mit", "den", "von", "sie", "ich", "es", "auf", "für", "auch"},
+	"pt": {"o", "a", "os", "as", "e", "é", "de", "que", "em", "um", "uma", "para", "com", "não", "do", "da", "se", "por"},
+	"it": {"il", "lo", "la", "gli", "le", "e", "è", "di", "che", "in", "un", "una", "per", "con", "non", "del", "della", "sono"},
+	"nl": {"de", "het", "een", "en", "is", "van", "dat", "niet", "in", "op", "met", "voor", "zijn", "je", "ik", "ook", "maar", "er"},
+	"af": {"die", "en", "is", "van", "nie", "dat", "in", "op", "met", "vir", "ek", "jy", "sy", "ook", "maar", "word", "het", "'n"},
+}
+
+func detectLanguage(text string) (language.Tag, float64) {
+	if tag, conf, ok := detectScript(text); ok {
+		return tag, conf
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) < 4 {
+		return language.Und, 0
+	}
+
+	scores := make(map[string]int, len(stopwords))
+	for lang, list := range stopwords {
+		set := make(map[string]bool, len(list))
+		for _, w := range list {
+			set[w] = true
+		}
+		for _, w := range words {
+			if set[w] {
+				scores[lang]++
+			}
+		}
+	}
+
+	langs := make([]string, 0, len(scores))
+	for lang := range scores {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	best, second := "", 0
+	for _, lang := range langs {
+		switch n := scores[lang]; {
+		case best == "" || n > scores[best]:
+			if best != "" {
+				second = scores[best]
+			}
+			best = lang
+		case n > second:
+			second = n
+		}
+	}
+	if best == "" || scores[best] == 0 {
+		return language.Und, 0
+	}
+	// Confidence combines how clearly the winner leads with how much of the
+	// text is made of recognized function words.
+	lead := float64(scores[best]-second) / float64(scores[best])
+	coverage := min(float64(scores[best])/float64(len(words))*4, 1)
+	return language.Make(best), lead * coverage
+}
+
+// detectScript recognizes languages written in a distinctive script.
+func detectScript(text string) (language.Tag, float64, bool) {
+	counts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			counts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		}
+	}
+	if letters == 0 {
+		return language.Und, 0, false
+	}
+	// Japanese mixes kana with Han characters.
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"]
+		counts["zh"] = 0
+	}
+	best := ""
+	for lang, n := range counts {
+		if best == "" || n > counts[best] {
+			best = lang
+		}
+	}
+	if best == "" || counts[best]*2 < letters {
+		return language.Und, 0, false
+	}
+	return language.Make(best), float64(counts[best]) / float64(letters), true
+}
+
+// WithExpectedLanguage makes CompleteChat check that the response is written
+// in tag's language. If the detector is confident the response is in another
+// language, the model is re-prompted once with a corrective instruction and
+// the corrected response is returned (with LanguageCorrected set). Regional
+// variants are not distinguished: en-GB matches en-US.
+//
+// Streaming calls are not checked.
+func (r *ChatRequest) WithExpectedLanguage(tag language.Tag) *ChatRequest {
+	r.expectedLanguage = &tag
+	return r
+}
+
+// WithLanguageDetector replaces DefaultLanguageDetector for the expected
+// language check, e.g. with a more accurate third-party detector.
+func (r *ChatRequest) WithLanguageDetector(d LanguageDetector) *ChatRequest {
+	r.languageDetector = d
+	return r
+}
+
+// languageMismatch reports whether content is confidently not in want.
+func languageMismatch(d LanguageDetector, want language.Tag, content string) (language.Tag, bool) {
+	got, conf := d.Detect(content)
+	if got == language.Und || conf < minLanguageConfidence {
+		return got, false
+	}
+	wantBase, _ := want.Base()
+	gotBase, _ := got.Base()
+	return got, wantBase != gotBase
+}
+
+// guardLanguage re-prompts once if resp is not in the request's expected
+// language.
+func (c *Client) guardLanguage(ctx context.Context, req *ChatRequest, protoReq *v1.GetCompletionsRequest, resp *ChatResponse) (*ChatResponse, error) {
+	detector := req.languageDetector
+	if detector == nil {
+		detector = DefaultLanguageDetector()
+	}
+	got, mismatch := languageMismatch(detector, *req.expectedLanguage, resp.Content)
+	if !mismatch {
+		return resp, nil
+	}
+	c.logger.InfoContext(ctx, "response language mismatch, re-prompting",
+		slog.String("want", req.expectedLanguage.String()), slog.String("got", got.String()))
+
+	retry := proto.Clone(protoReq).(*v1.GetCompletionsRequest)
+	retry.Messages = append(append([]*v1.Message{}, protoReq.Messages...),
+		&v1.Message{
+			Role:    v1.MessageRole_ROLE_ASSISTANT,
+			Content: []*v1.Content{{Content: &v1.Content_Text{Text: resp.Content}}},
+		},
+		&v1.Message{
+			Role: v1.MessageRole_ROLE_USER,
+			Content: []*v1.Content{{Content: &v1.Content_Text{Text: "Your previous answer was not in the expected language. " +
+				"Rewrite it entirely in the language with BCP 47 tag \"" + req.expectedLanguage.String() + "\"."}}},
+		},
+	)
+
+	md := &ResponseMetadata{}
+	out, err := c.chat.GetCompletion(ctx, retry, md.callOptions()...)
+	if err != nil {
+		return nil, WrapError(errorWithMetadata(err, md), "re-prompting for expected language")
+	}
+	corrected := chatResponseFromProto(out)
+	corrected.Metadata = md
+	corrected.LanguageCorrected = true
+	return corrected, nil
+}
@@ -0,0 +1,126 @@
+package xai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// discardHandler is a slog.Handler that drops every record. It is used when
+// Config.Logger is nil so call sites never need a nil check.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
+
+// newLogger returns l scoped to this package, or a discarding logger if nil.
+func newLogger(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return slog.New(discardHandler{})
+	}
+	return l.With(slog.String("component", "xai"))
+}
+
+// requestKeyAttr describes the API key used for a request in redacted form.
+func requestKeyAttr(ctx context.Context, fallback *SecureString) slog.Attr {
+	key := fallback
+	if k, ok := ctx.Value(apiKeyContextKey{}).(*SecureString); ok {
+		key = k
+	}
+	return slog.String("api_key", redactKey(key))
+}
+
+// errorAttrs describes a failed request.
+func errorAttrs(err error) []any {
+	xaiErr := FromGRPCError(err)
+	attrs := []any{slog.String("code", xaiErr.Code.String()), slog.String("error", xaiErr.Message)}
+	if xaiErr.RetryAfter > 0 {
+		attrs = append(attrs, slog.Duration("retry_after", xaiErr.RetryAfter))
+	}
+	return attrs
+}
+
+// loggingUnaryInterceptor logs the start and end of every unary RPC.
+func loggingUnaryInterceptor(logger *slog.Logger, apiKey *SecureString) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		attrs := []any{slog.String("method", shortMethod(method))}
+		if model := modelOf(req); model != "" {
+			attrs = append(attrs, slog.String("model", model))
+		}
+		logger.DebugContext(ctx, "request started", append(attrs, requestKeyAttr(ctx, apiKey))...)
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+		if err != nil {
+			logger.WarnContext(ctx, "request failed", append(attrs, errorAttrs(err)...)...)
+			return err
+		}
+		if u, ok := usageOf(reply); ok {
+			attrs = append(attrs, slog.Int("prompt_tokens", int(u.PromptTokens)), slog.Int("completion_tokens", int(u.CompletionTokens)))
+		}
+		logger.InfoContext(ctx, "request finished", attrs...)
+		return nil
+	}
+}
+
+// loggingStreamInterceptor logs the lifecycle of every streaming RPC.
+func loggingStreamInterceptor(logger *slog.Logger, apiKey *SecureString) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		attrs := []any{slog.String("method", shortMethod(method))}
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			logger.WarnContext(ctx, "stream failed to open", append(attrs, errorAttrs(err)...)...)
+			return nil, err
+		}
+		logger.DebugContext(ctx, "stream opened", append(attrs, requestKeyAttr(ctx, apiKey))...)
+		return &loggingStream{ClientStream: cs, ctx: ctx, logger: logger, attrs: attrs, start: start}, nil
+	}
+}
+
+// loggingStream logs the first message and the end of a stream.
+type loggingStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	logger *slog.Logger
+	attrs  []any
+	start  time.Time
+
+	mu       sync.Mutex
+	messages int
+	done     bool
+}
+
+func (s *loggingStream) RecvMsg(msg any) error {
+	err := s.ClientStream.RecvMsg(msg)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return err
+	}
+	if err == nil {
+		s.messages++
+		if s.messages == 1 {
+			s.logger.DebugContext(s.ctx, "stream first message", append(s.attrs, slog.Duration("ttft", time.Since(s.start)))...)
+		}
+		return nil
+	}
+
+	s.done = true
+	attrs := append(s.attrs, slog.Int("messages", s.messages), slog.Duration("duration", time.Since(s.start)))
+	if errors.Is(err, io.EOF) {
+		s.logger.InfoContext(s.ctx, "stream finished", attrs...)
+	} else {
+		s.logger.WarnContext(s.ctx, "stream failed", append(attrs, errorAttrs(err)...)...)
+	}
+	return err
+}
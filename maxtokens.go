@@ -0,0 +1,50 @@
+package xai
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// WithMaxTokensAuto sets max tokens, when the request is sent, to what is
+// left of the model's context window (MaxPromptLength) after the prompt, so
+// a growing history shrinks the answer's budget instead of overflowing the
+// window mid-answer. A limit set with WithMaxTokens still applies as a cap.
+// If the prompt alone fills the window, the call fails with
+// ErrInvalidRequest; if the window is unknown, max tokens is left as is.
+//
+// It costs a Tokenize call per request (the model lookup is cached per
+// client). Combined with WithAutoTruncate, also set WithMaxTokens, so the
+// truncated history leaves that much room for the answer.
+func (r *ChatRequest) WithMaxTokensAuto() *ChatRequest {
+	r.maxTokensAuto = true
+	return r
+}
+
+// autoMaxTokens sets the max tokens of req to the room its prompt leaves in
+// the model's context window.
+func (c *Client) autoMaxTokens(ctx context.Context, req *v1.GetCompletionsRequest) error {
+	model := req.GetModel()
+	window, err := c.contextWindow(ctx, model)
+	if err != nil {
+		return WrapError(err, "auto max tokens: looking up model context length")
+	}
+	if window <= 0 {
+		return nil
+	}
+	tokens, err := c.requestTokens(ctx, req)
+	if err != nil {
+		return WrapError(err, "auto max tokens")
+	}
+	left := window - tokens.Total
+	if left <= 0 {
+		return &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf(
+			"auto max tokens: prompt needs %d tokens, filling %s's context window of %d", tokens.Total, model, window)}
+	}
+	if req.MaxTokens == nil || int(*req.MaxTokens) > left {
+		n := int32(left)
+		req.MaxTokens = &n
+	}
+	return nil
+}
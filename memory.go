@@ -0,0 +1,207 @@
+package xai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// DefaultMemorySchema describes the state kept by a MemoryConsolidator when
+// no schema is configured.
+const DefaultMemorySchema = `A compact profile and state, as short bullet points under two headings:
+Profile: stable facts about the user (name, role, preferences, constraints).
+State: where the conversation stands (goals, decisions made, open questions).`
+
+const memoryPrompt = `You maintain the long-term memory of a conversation. Merge the PREVIOUS MEMORY (if any) with the new CONVERSATION TURNS into an updated memory following this SCHEMA:
+%s
+
+Keep everything still relevant, drop what was superseded, and do not invent facts. Reply with the memory only.`
+
+// memoryPrefix starts the developer message holding consolidated memory.
+const memoryPrefix = "Memory of the earlier conversation:\n"
+
+// Defaults for MemoryConsolidator.
+const (
+	// DefaultMemoryKeepRecent is the number of most recent messages kept
+	// verbatim.
+	DefaultMemoryKeepRecent = 6
+	// DefaultMemoryMinTurns is the number of older messages needed before a
+	// consolidation runs.
+	DefaultMemoryMinTurns = 4
+)
+
+// MemoryOption configures a MemoryConsolidator.
+type MemoryOption func(*MemoryConsolidator)
+
+// WithMemoryModel sets the model used for summarizing (default: the client's
+// DefaultModel).
+func WithMemoryModel(model string) MemoryOption {
+	return func(m *MemoryConsolidator) {
+		m.model = model
+	}
+}
+
+// WithMemorySchema sets the description of what the memory should contain
+// and how it is laid out (default DefaultMemorySchema).
+func WithMemorySchema(schema string) MemoryOption {
+	return func(m *MemoryConsolidator) {
+		m.schema = schema
+	}
+}
+
+// WithMemoryKeepRecent sets how many recent messages are never consolidated
+// (default DefaultMemoryKeepRecent).
+func WithMemoryKeepRecent(n int) MemoryOption {
+	return func(m *MemoryConsolidator) {
+		m.keepRecent = n
+	}
+}
+
+// WithMemoryMinTurns sets how many older messages must accumulate before a
+// consolidation runs (default DefaultMemoryMinTurns).
+func WithMemoryMinTurns(n int) MemoryOption {
+	return func(m *MemoryConsolidator) {
+		m.minTurns = n
+	}
+}
+
+// MemoryConsolidator keeps a conversation's context bounded by summarizing
+// old turns into a single developer message and pruning the originals.
+// System messages at the start of the history are always kept.
+type MemoryConsolidator struct {
+	client     *Client
+	model      string
+	schema     string
+	keepRecent int
+	minTurns   int
+}
+
+// NewMemoryConsolidator creates a consolidator that summarizes with client.
+func NewMemoryConsolidator(client *Client, opts ...MemoryOption) *MemoryConsolidator {
+	m := &MemoryConsolidator{
+		client:     client,
+		schema:     DefaultMemorySchema,
+		keepRecent: DefaultMemoryKeepRecent,
+		minTurns:   DefaultMemoryMinTurns,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Consolidate summarizes cv's older turns, together with any memory from a
+// previous consolidation, into an updated memory message and removes the
+// summarized turns. It returns false if there were too few older turns.
+//
+// Messages added to the conversation while the summary is generated are
+// kept.
+func (m *MemoryConsolidator) Consolidate(ctx context.Context, cv *Conversation) (bool, error) {
+	cv.mu.Lock()
+	snapshot := append([]*v1.Message(nil), cv.req.messages...)
+	prevMemory := cv.memory
+	cv.mu.Unlock()
+
+	start := 0
+	for start < len(snapshot) && snapshot[start].GetRole() == v1.MessageRole_ROLE_SYSTEM {
+		start++
+	}
+	cut := len(snapshot) - max(m.keepRecent, 0)
+	// Keep tool results together with the assistant message that called them.
+	for cut > start && (splitsToolCall(snapshot[cut-1]) || (cut < len(snapshot) && snapshot[cut].GetRole() == v1.MessageRole_ROLE_TOOL)) {
+		cut--
+	}
+	if cut <= start {
+		return false, nil
+	}
+
+	var turns strings.Builder
+	n := 0
+	for _, msg := range snapshot[start:cut] {
+		if msg == prevMemory {
+			continue
+		}
+		turns.WriteString(roleName(msg.GetRole()))
+		turns.WriteString(": ")
+		turns.WriteString(strings.TrimSpace(messageText(msg)))
+		turns.WriteString("\n")
+		n++
+	}
+	if n < max(m.minTurns, 1) {
+		return false, nil
+	}
+
+	var input strings.Builder
+	if prevMemory != nil {
+		input.WriteString("PREVIOUS MEMORY:\n")
+		input.WriteString(strings.TrimPrefix(messageText(prevMemory), memoryPrefix))
+		input.WriteString("\n")
+	}
+	input.WriteString("CONVERSATION TURNS:\n")
+	input.WriteString(turns.String())
+
+	model := m.model
+	if model == "" {
+		model = cv.Model()
+	}
+	req := NewChatRequest().
+		WithModel(model).
+		SystemMessage(SystemContent{Text: fmt.Sprintf(memoryPrompt, m.schema)}).
+		UserMessage(UserContent{Text: input.String()}).
+		WithTemperature(0)
+	resp, err := m.client.CompleteChat(ctx, req)
+	if err != nil {
+		return false, WrapError(err, "consolidating conversation memory")
+	}
+
+	memory := &v1.Message{
+		Role:    v1.MessageRole_ROLE_DEVELOPER,
+		Content: []*v1.Content{{Content: &v1.Content_Text{Text: memoryPrefix + strings.TrimSpace(resp.Content)}}},
+	}
+
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	current := cv.req.messages
+	for i := 0; i < cut; i++ {
+		if i >= len(current) || current[i] != snapshot[i] {
+			// The history was rewritten meanwhile; the summary no longer applies.
+			return false, nil
+		}
+	}
+	messages := make([]*v1.Message, 0, start+1+len(current)-cut)
+	messages = append(messages, current[:start]...)
+	messages = append(messages, memory)
+	messages = append(messages, current[cut:]...)
+	for _, msg := range current[start:cut] {
+		delete(cv.tokenCounts, msg)
+		delete(cv.citations, msg)
+	}
+	cv.req.messages = messages
+	cv.memory = memory
+	return true, nil
+}
+
+// splitsToolCall reports whether msg is an assistant message with tool calls,
+// whose results follow it.
+func splitsToolCall(msg *v1.Message) bool {
+	return msg.GetRole() == v1.MessageRole_ROLE_ASSISTANT && len(msg.GetToolCalls()) > 0
+}
+
+// Run consolidates cv every interval, measured on the client's Clock, until
+// ctx is done, and returns ctx's error. Failed consolidations are logged and
+// retried on the next tick.
+func (m *MemoryConsolidator) Run(ctx context.Context, cv *Conversation, interval time.Duration) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.client.config.Clock.After(interval):
+			if _, err := m.Consolidate(ctx, cv); err != nil && ctx.Err() == nil {
+				m.client.logger.WarnContext(ctx, "memory consolidation failed", errorAttrs(err)...)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package xai
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ResponseMetadata holds the gRPC headers and trailers returned with a
+// response, such as request IDs and rate-limit information.
+type ResponseMetadata struct {
+	// Header is the response header metadata.
+	Header metadata.MD
+	// Trailer is the response trailer metadata.
+	Trailer metadata.MD
+}
+
+// Get returns the first value for key (case-insensitive), looking at headers
+// before trailers, or "" if absent.
+func (m *ResponseMetadata) Get(key string) string {
+	if m == nil {
+		return ""
+	}
+	for _, md := range []metadata.MD{m.Header, m.Trailer} {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// RequestID returns the server-assigned request ID, if any. Quote it when
+// contacting xAI support about a failure.
+func (m *ResponseMetadata) RequestID() string {
+	for _, key := range []string{"x-request-id", "request-id", "x-trace-id"} {
+		if v := m.Get(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// RateLimit is the rate-limit state reported by the server. Fields are zero
+// when the corresponding header is absent.
+type RateLimit struct {
+	// LimitRequests and RemainingRequests are the request quota and what is
+	// left of it in the current window.
+	LimitRequests, RemainingRequests int
+	// LimitTokens and RemainingTokens are the token quota and what is left of
+	// it in the current window.
+	LimitTokens, RemainingTokens int
+	// ResetRequests and ResetTokens are the times until the quotas reset.
+	ResetRequests, ResetTokens time.Duration
+	// RetryAfter is how long the server asked the client to wait.
+	RetryAfter time.Duration
+}
+
+// RateLimit parses the x-ratelimit-* and retry-after metadata, if present.
+func (m *ResponseMetadata) RateLimit() RateLimit {
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(m.Get(key))
+		return n
+	}
+	return RateLimit{
+		LimitRequests:     atoi("x-ratelimit-limit-requests"),
+		RemainingRequests: atoi("x-ratelimit-remaining-requests"),
+		LimitTokens:       atoi("x-ratelimit-limit-tokens"),
+		RemainingTokens:   atoi("x-ratelimit-remaining-tokens"),
+		ResetRequests:     parseMetadataDuration(m.Get("x-ratelimit-reset-requests")),
+		ResetTokens:       parseMetadataDuration(m.Get("x-ratelimit-reset-tokens")),
+		RetryAfter:        parseMetadataDuration(m.Get("retry-after")),
+	}
+}
+
+// parseMetadataDuration accepts plain seconds ("2", "0.5") or Go durations
+// ("1m30s"). Invalid or negative values yield 0.
+func parseMetadataDuration(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return max(time.Duration(secs*float64(time.Second)), 0)
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0
+	}
+	return max(d, 0)
+}
+
+// callOptions returns gRPC call options that capture headers and trailers
+// into m.
+func (m *ResponseMetadata) callOptions() []grpc.CallOption {
+	return []grpc.CallOption{grpc.Header(&m.Header), grpc.Trailer(&m.Trailer)}
+}
+
+// errorWithMetadata converts a gRPC error and attaches the response metadata,
+// filling RetryAfter from the retry-after header when the status has none.
+func errorWithMetadata(err error, md *ResponseMetadata) *Error {
+	xaiErr := FromGRPCError(err)
+	if len(md.Header) == 0 && len(md.Trailer) == 0 {
+		return xaiErr
+	}
+	xaiErr.Metadata = md
+	if xaiErr.RetryAfter == 0 {
+		xaiErr.RetryAfter = md.RateLimit().RetryAfter
+	}
+	return xaiErr
+}
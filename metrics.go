@@ -0,0 +1,179 @@
+package xai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics receives observations for every RPC made by a Client.
+// Implementations must be safe for concurrent use. See PrometheusMetrics for
+// a ready-made implementation.
+type Metrics interface {
+	// ObserveRequest is called when a unary RPC returns or a stream ends,
+	// including a stream closed before it was fully read.
+	ObserveRequest(m RequestMetrics)
+	// ObserveTimeToFirstToken is called when the first message of a
+	// streaming RPC arrives.
+	ObserveTimeToFirstToken(method, model string, ttft time.Duration)
+}
+
+// RequestMetrics describes a completed RPC.
+type RequestMetrics struct {
+	// Method is the RPC name without package, e.g. "Chat/GetCompletion".
+	Method string
+	// Model is the model named in the request, if any.
+	Model string
+	// Streaming is true for streaming RPCs.
+	Streaming bool
+	// Time is when the request started, read from Config.Clock.
+	Time time.Time
+	// Duration is the time from start until the response (or end of stream).
+	Duration time.Duration
+	// Err is the error, or nil on success.
+	Err *Error
+	// Usage is the token usage reported by the server, if any.
+	Usage Usage
+}
+
+// Code returns the error code name, or "ok" on success.
+func (m RequestMetrics) Code() string {
+	if m.Err == nil {
+		return "ok"
+	}
+	return m.Err.Code.String()
+}
+
+// usageOf extracts token usage from a response message, if it has any.
+func usageOf(msg any) (Usage, bool) {
+	if u, ok := msg.(interface{ GetUsage() *v1.SamplingUsage }); ok && u.GetUsage() != nil {
+		return usageFromProto(u.GetUsage()), true
+	}
+	return Usage{}, false
+}
+
+// metricsUnaryInterceptor reports unary RPCs to m.
+func metricsUnaryInterceptor(m Metrics, clock Clock) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		at, start := clock.Now(), time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		obs := RequestMetrics{
+			Method:   shortMethod(method),
+			Model:    modelOf(req),
+			Time:     at,
+			Duration: time.Since(start),
+			Err:      FromGRPCError(err),
+		}
+		if err == nil {
+			obs.Usage, _ = usageOf(reply)
+		}
+		m.ObserveRequest(obs)
+		return err
+	}
+}
+
+// metricsStreamInterceptor reports streaming RPCs to m. The request is
+// observed once the stream is drained to io.EOF or fails, or, for a stream
+// abandoned before that, when its context is canceled (as ChunkStream.Close
+// does), with an ErrCanceled or ErrTimeout error.
+func metricsStreamInterceptor(m Metrics, clock Clock) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		at, start := clock.Now(), time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			m.ObserveRequest(RequestMetrics{
+				Method:    shortMethod(method),
+				Streaming: true,
+				Time:      at,
+				Duration:  time.Since(start),
+				Err:       FromGRPCError(err),
+			})
+			return nil, err
+		}
+		s := &metricsStream{ClientStream: cs, metrics: m, method: shortMethod(method), at: at, start: start}
+		s.stop = context.AfterFunc(ctx, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.finish(status.FromContextError(ctx.Err()).Err())
+		})
+		return s, nil
+	}
+}
+
+// metricsStream observes messages received on a client stream.
+type metricsStream struct {
+	grpc.ClientStream
+	metrics Metrics
+	method  string
+	model   string
+	at      time.Time
+	start   time.Time
+
+	mu       sync.Mutex
+	gotFirst bool
+	usage    Usage
+	done     bool
+	// stop unregisters the observation on cancellation.
+	stop func() bool
+}
+
+// SendMsg captures the model from the request message.
+func (s *metricsStream) SendMsg(msg any) error {
+	s.mu.Lock()
+	if model := modelOf(msg); model != "" {
+		s.model = model
+	}
+	s.mu.Unlock()
+	return s.ClientStream.SendMsg(msg)
+}
+
+// RecvMsg records time to first message, usage and completion.
+func (s *metricsStream) RecvMsg(msg any) error {
+	err := s.ClientStream.RecvMsg(msg)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return err
+	}
+	if err == nil {
+		if !s.gotFirst {
+			s.gotFirst = true
+			s.metrics.ObserveTimeToFirstToken(s.method, s.model, time.Since(s.start))
+		}
+		if u, ok := usageOf(msg); ok {
+			s.usage = u
+		}
+		return nil
+	}
+
+	s.stop()
+	s.finish(err)
+	return err
+}
+
+// finish observes the request once, ending with err. s.mu must be held.
+func (s *metricsStream) finish(err error) {
+	if s.done {
+		return
+	}
+	s.done = true
+	obs := RequestMetrics{
+		Method:    s.method,
+		Model:     s.model,
+		Streaming: true,
+		Time:      s.at,
+		Duration:  time.Since(s.start),
+		Usage:     s.usage,
+	}
+	if !errors.Is(err, io.EOF) {
+		obs.Err = FromGRPCError(err)
+	}
+	s.metrics.ObserveRequest(obs)
+}
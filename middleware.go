@@ -0,0 +1,130 @@
+package xai
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// Call describes one RPC passing through the middleware chain.
+type Call struct {
+	// Method is the short RPC name, e.g. "Chat/GetCompletion".
+	Method string
+	// Request is the protobuf request message. Middleware may modify it
+	// before calling next.
+	Request any
+	// Response is the protobuf response message, populated after next returns
+	// without error. It is nil for streaming calls.
+	Response any
+	// Streaming is true for server-streaming RPCs such as StreamChat.
+	Streaming bool
+}
+
+// Handler performs a Call.
+type Handler func(ctx context.Context, call *Call) error
+
+// Middleware wraps a Handler to add cross-cutting behavior such as audit
+// logging, request mutation or cost caps. Returning an error without calling
+// next aborts the RPC; the error is returned from the public method.
+type Middleware func(next Handler) Handler
+
+// middlewares is the client's middleware stack, shared with the connection.
+type middlewares struct {
+	mu    sync.RWMutex
+	chain []Middleware
+}
+
+func (m *middlewares) handler(terminal Handler) Handler {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	h := terminal
+	for i := len(m.chain) - 1; i >= 0; i-- {
+		h = m.chain[i](h)
+	}
+	return h
+}
+
+// Use appends middleware applied to every RPC the client makes, including
+// calls made internally (e.g. by VerifyAgainstSources). The first middleware
+// added is outermost. Middleware runs before metrics, key selection and
+// logging, so request changes are reflected there.
+//
+// For streaming calls the handler runs when the request is sent, and
+// Call.Response stays nil; individual stream messages are not seen.
+//
+//	client.Use(func(next xai.Handler) xai.Handler {
+//	    return func(ctx context.Context, call *xai.Call) error {
+//	        log.Printf("calling %s", call.Method)
+//	        return next(ctx, call)
+//	    }
+//	})
+func (c *Client) Use(mw ...Middleware) {
+	c.middleware.mu.Lock()
+	defer c.middleware.mu.Unlock()
+	c.middleware.chain = append(c.middleware.chain, mw...)
+}
+
+func middlewareUnaryInterceptor(m *middlewares) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		call := &Call{Method: shortMethod(method), Request: req}
+		h := m.handler(func(ctx context.Context, call *Call) error {
+			if err := invoker(ctx, method, call.Request, reply, cc, opts...); err != nil {
+				return err
+			}
+			call.Response = reply
+			return nil
+		})
+		return h(ctx, call)
+	}
+}
+
+func middlewareStreamInterceptor(m *middlewares) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		streamCtx, cancel := context.WithCancel(ctx)
+		return &middlewareStream{ctx: ctx, streamCtx: streamCtx, cancel: cancel, m: m, method: shortMethod(method), open: func(ctx context.Context) (grpc.ClientStream, error) {
+			return streamer(ctx, desc, cc, method, opts...)
+		}}, nil
+	}
+}
+
+// middlewareStream runs the middleware chain around the first SendMsg, which
+// carries the request of a server-streaming RPC. The underlying stream is
+// only opened inside the chain, so it sees the context middleware passes to
+// next, including any outgoing metadata.
+type middlewareStream struct {
+	grpc.ClientStream
+	ctx context.Context
+	// streamCtx is what Context returns, even before the stream is opened:
+	// it is canceled once the opened stream finishes, or if it fails to open.
+	streamCtx context.Context
+	cancel    context.CancelFunc
+	m         *middlewares
+	method    string
+	open      func(context.Context) (grpc.ClientStream, error)
+}
+
+func (s *middlewareStream) SendMsg(msg any) error {
+	if s.ClientStream != nil {
+		return s.ClientStream.SendMsg(msg)
+	}
+	call := &Call{Method: s.method, Request: msg, Streaming: true}
+	h := s.m.handler(func(ctx context.Context, call *Call) error {
+		cs, err := s.open(ctx)
+		if err != nil {
+			return err
+		}
+		s.ClientStream = cs
+		context.AfterFunc(cs.Context(), s.cancel)
+		return cs.SendMsg(call.Request)
+	})
+	err := h(s.ctx, call)
+	if err != nil {
+		s.cancel()
+	}
+	return err
+}
+
+func (s *middlewareStream) Context() context.Context {
+	return s.streamCtx
+}
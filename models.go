@@ -65,7 +65,8 @@ type LanguageModel struct {
 	CachedPromptPricing Pricing
 	// CompletionPricing is the price for completion tokens.
 	CompletionPricing Pricing
-	// SearchPricing is the price per search.
+	// SearchPricing is the price of searches. Despite the field name,
+	// PerMillionTokens holds USD per million searches.
 	SearchPricing Pricing
 }
 
@@ -87,6 +88,13 @@ func (m *LanguageModel) CalculateCost(inputTokens, outputTokens, cacheReadTokens
 	return inputCost + outputCost + cacheCost
 }
 
+// UsageCost returns the cost in USD of u, as billed: cached and image prompt
+// tokens at their own rates, reasoning tokens as completion tokens, and
+// searches per Usage.BilledSearches.
+func (m *LanguageModel) UsageCost(u Usage) float64 {
+	return usageCost(m, u)
+}
+
 func languageModelFromProto(m *v1.LanguageModel) *LanguageModel {
 	model := &LanguageModel{
 		Name:              m.GetName(),
@@ -128,9 +136,10 @@ func (c *Client) ListModels(ctx context.Context) ([]*LanguageModel, error) {
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
-	resp, err := c.models.ListLanguageModels(ctx, &emptypb.Empty{})
+	md := &ResponseMetadata{}
+	resp, err := c.models.ListLanguageModels(ctx, &emptypb.Empty{}, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
 	models := make([]*LanguageModel, 0, len(resp.GetModels()))
@@ -146,9 +155,10 @@ func (c *Client) GetModel(ctx context.Context, name string) (*LanguageModel, err
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
-	resp, err := c.models.GetLanguageModel(ctx, &v1.GetModelRequest{Name: name})
+	md := &ResponseMetadata{}
+	resp, err := c.models.GetLanguageModel(ctx, &v1.GetModelRequest{Name: name}, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
 	return languageModelFromProto(resp), nil
@@ -210,9 +220,10 @@ func (c *Client) ListEmbeddingModels(ctx context.Context) ([]*EmbeddingModel, er
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
-	resp, err := c.models.ListEmbeddingModels(ctx, &emptypb.Empty{})
+	md := &ResponseMetadata{}
+	resp, err := c.models.ListEmbeddingModels(ctx, &emptypb.Empty{}, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
 	models := make([]*EmbeddingModel, 0, len(resp.GetModels()))
@@ -228,9 +239,10 @@ func (c *Client) GetEmbeddingModel(ctx context.Context, name string) (*Embedding
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
-	resp, err := c.models.GetEmbeddingModel(ctx, &v1.GetModelRequest{Name: name})
+	md := &ResponseMetadata{}
+	resp, err := c.models.GetEmbeddingModel(ctx, &v1.GetModelRequest{Name: name}, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
 	return embeddingModelFromProto(resp), nil
@@ -289,9 +301,10 @@ func (c *Client) ListImageModels(ctx context.Context) ([]*ImageModel, error) {
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
-	resp, err := c.models.ListImageGenerationModels(ctx, &emptypb.Empty{})
+	md := &ResponseMetadata{}
+	resp, err := c.models.ListImageGenerationModels(ctx, &emptypb.Empty{}, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
 	models := make([]*ImageModel, 0, len(resp.GetModels()))
@@ -307,9 +320,10 @@ func (c *Client) GetImageModel(ctx context.Context, name string) (*ImageModel, e
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
-	resp, err := c.models.GetImageGenerationModel(ctx, &v1.GetModelRequest{Name: name})
+	md := &ResponseMetadata{}
+	resp, err := c.models.GetImageGenerationModel(ctx, &v1.GetModelRequest{Name: name}, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
 	return imageModelFromProto(resp), nil
@@ -0,0 +1,116 @@
+package xai
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// Preferences are a user's standing instructions for how the model should
+// respond to them.
+type Preferences struct {
+	// Tone is the desired voice, e.g. "formal" or "friendly and brief".
+	Tone string `json:"tone,omitempty"`
+	// Language is the language to answer in, e.g. "Afrikaans" or "pt-BR".
+	Language string `json:"language,omitempty"`
+	// FormatRules are formatting instructions, e.g. "Use metric units".
+	FormatRules []string `json:"format_rules,omitempty"`
+	// Notes are free-form instructions appended as is.
+	Notes string `json:"notes,omitempty"`
+}
+
+// String renders the preferences as instructions, or "" if there are none.
+func (p *Preferences) String() string {
+	var lines []string
+	if p.Tone != "" {
+		lines = append(lines, "- Tone: "+p.Tone)
+	}
+	if p.Language != "" {
+		lines = append(lines, "- Language: respond in "+p.Language+" unless asked otherwise")
+	}
+	for _, rule := range p.FormatRules {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			lines = append(lines, "- Format: "+rule)
+		}
+	}
+	if notes := strings.TrimSpace(p.Notes); notes != "" {
+		lines = append(lines, "- "+notes)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "User preferences:\n" + strings.Join(lines, "\n")
+}
+
+// PreferenceStore looks up per-user preferences. Preferences returns nil
+// (and no error) for users without any.
+type PreferenceStore interface {
+	Preferences(ctx context.Context, userID string) (*Preferences, error)
+}
+
+// MemoryPreferenceStore is an in-memory PreferenceStore. It is safe for
+// concurrent use.
+type MemoryPreferenceStore struct {
+	mu    sync.RWMutex
+	users map[string]Preferences
+}
+
+// NewMemoryPreferenceStore creates an empty store.
+func NewMemoryPreferenceStore() *MemoryPreferenceStore {
+	return &MemoryPreferenceStore{users: make(map[string]Preferences)}
+}
+
+// Set stores the preferences for userID, replacing any earlier ones.
+func (s *MemoryPreferenceStore) Set(userID string, p Preferences) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID] = p
+}
+
+// Delete removes the preferences for userID.
+func (s *MemoryPreferenceStore) Delete(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, userID)
+}
+
+// Preferences implements PreferenceStore.
+func (s *MemoryPreferenceStore) Preferences(_ context.Context, userID string) (*Preferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// preferencesMessage renders p as a developer message, or nil.
+func preferencesMessage(p *Preferences) *v1.Message {
+	if p == nil {
+		return nil
+	}
+	text := p.String()
+	if text == "" {
+		return nil
+	}
+	return &v1.Message{
+		Role:    v1.MessageRole_ROLE_DEVELOPER,
+		Content: []*v1.Content{{Content: &v1.Content_Text{Text: text}}},
+	}
+}
+
+// userPreferences looks up the preferences of req's user, if a store is
+// configured and the request has a user.
+func (c *Client) userPreferences(ctx context.Context, req *ChatRequest) (*Preferences, error) {
+	if c.config.Preferences == nil || req.user == "" {
+		return nil, nil
+	}
+	p, err := c.config.Preferences.Preferences(ctx, req.user)
+	if err != nil {
+		return nil, WrapError(err, "loading preferences for user "+req.user)
+	}
+	return p, nil
+}
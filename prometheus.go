@@ -0,0 +1,205 @@
+package xai
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the histogram buckets, in seconds, used by
+// PrometheusMetrics for request latency and time to first token.
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// PrometheusMetrics is a Metrics implementation that aggregates observations
+// in memory and serves them in the Prometheus text exposition format. It
+// implements http.Handler, so it can be mounted directly:
+//
+//	metrics := xai.NewPrometheusMetrics()
+//	client, _ := xai.New(xai.Config{APIKey: key, Metrics: metrics})
+//	http.Handle("/metrics", metrics)
+//
+// Exposed series:
+//
+//	xai_requests_total{method,model,code}
+//	xai_request_duration_seconds{method,model} (histogram)
+//	xai_time_to_first_token_seconds{method,model} (histogram)
+//	xai_tokens_total{method,model,direction} (direction: input, output, reasoning, cached)
+//
+// With WithBreaker, the circuit breaker is exposed too:
+//
+//	xai_breaker_state (0 closed, 1 open, 2 half-open)
+//	xai_breaker_trips_total
+//	xai_breaker_rejected_total
+type PrometheusMetrics struct {
+	mu       sync.Mutex
+	breaker  *CircuitBreaker
+	buckets  []float64
+	requests map[[3]string]uint64
+	tokens   map[[3]string]uint64
+	duration map[[2]string]*histogram
+	ttft     map[[2]string]*histogram
+}
+
+var _ Metrics = (*PrometheusMetrics)(nil)
+
+// NewPrometheusMetrics creates an empty Prometheus metrics collector using
+// DefaultLatencyBuckets.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		buckets:  DefaultLatencyBuckets,
+		requests: make(map[[3]string]uint64),
+		tokens:   make(map[[3]string]uint64),
+		duration: make(map[[2]string]*histogram),
+		ttft:     make(map[[2]string]*histogram),
+	}
+}
+
+// WithBreaker adds b's state and counters to the exposed metrics. Returns
+// the collector for chaining.
+func (p *PrometheusMetrics) WithBreaker(b *CircuitBreaker) *PrometheusMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.breaker = b
+	return p
+}
+
+// ObserveRequest implements Metrics.
+func (p *PrometheusMetrics) ObserveRequest(m RequestMetrics) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests[[3]string{m.Method, m.Model, m.Code()}]++
+	p.observe(p.duration, [2]string{m.Method, m.Model}, m.Duration)
+
+	for _, t := range []struct {
+		direction string
+		n         int32
+	}{
+		{"input", m.Usage.PromptTokens},
+		{"output", m.Usage.CompletionTokens},
+		{"reasoning", m.Usage.ReasoningTokens},
+		{"cached", m.Usage.CachedPromptTokens},
+	} {
+		if t.n > 0 {
+			p.tokens[[3]string{m.Method, m.Model, t.direction}] += uint64(t.n)
+		}
+	}
+}
+
+// ObserveTimeToFirstToken implements Metrics.
+func (p *PrometheusMetrics) ObserveTimeToFirstToken(method, model string, ttft time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.observe(p.ttft, [2]string{method, model}, ttft)
+}
+
+func (p *PrometheusMetrics) observe(hs map[[2]string]*histogram, key [2]string, d time.Duration) {
+	h, ok := hs[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(p.buckets))}
+		hs[key] = h
+	}
+	v := d.Seconds()
+	for i, b := range p.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// ServeHTTP writes all metrics in the Prometheus text format.
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = p.WriteTo(w)
+}
+
+// WriteTo writes all metrics in the Prometheus text format to w.
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+
+	b.WriteString("# HELP xai_requests_total Total xAI API requests.\n")
+	b.WriteString("# TYPE xai_requests_total counter\n")
+	for _, k := range sortedKeys(p.requests) {
+		fmt.Fprintf(&b, "xai_requests_total{method=%s,model=%s,code=%s} %d\n",
+			quoteLabel(k[0]), quoteLabel(k[1]), quoteLabel(k[2]), p.requests[k])
+	}
+
+	b.WriteString("# HELP xai_tokens_total Total tokens processed by the xAI API.\n")
+	b.WriteString("# TYPE xai_tokens_total counter\n")
+	for _, k := range sortedKeys(p.tokens) {
+		fmt.Fprintf(&b, "xai_tokens_total{method=%s,model=%s,direction=%s} %d\n",
+			quoteLabel(k[0]), quoteLabel(k[1]), quoteLabel(k[2]), p.tokens[k])
+	}
+
+	p.writeHistograms(&b, "xai_request_duration_seconds", "xAI API request latency in seconds.", p.duration)
+	p.writeHistograms(&b, "xai_time_to_first_token_seconds", "Time until the first streamed message in seconds.", p.ttft)
+
+	if p.breaker != nil {
+		st := p.breaker.Stats()
+		b.WriteString("# HELP xai_breaker_state Circuit breaker state (0 closed, 1 open, 2 half-open).\n")
+		b.WriteString("# TYPE xai_breaker_state gauge\n")
+		fmt.Fprintf(&b, "xai_breaker_state %d\n", st.State)
+		b.WriteString("# HELP xai_breaker_trips_total Times the circuit breaker opened.\n")
+		b.WriteString("# TYPE xai_breaker_trips_total counter\n")
+		fmt.Fprintf(&b, "xai_breaker_trips_total %d\n", st.Trips)
+		b.WriteString("# HELP xai_breaker_rejected_total Requests rejected by the circuit breaker.\n")
+		b.WriteString("# TYPE xai_breaker_rejected_total counter\n")
+		fmt.Fprintf(&b, "xai_breaker_rejected_total %d\n", st.Rejected)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (p *PrometheusMetrics) writeHistograms(b *strings.Builder, name, help string, hs map[[2]string]*histogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+	for _, k := range sortedKeys(hs) {
+		h := hs[k]
+		labels := "method=" + quoteLabel(k[0]) + ",model=" + quoteLabel(k[1])
+		for i, bound := range p.buckets {
+			fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels,
+				strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
+
+// histogram holds cumulative bucket counts.
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// quoteLabel quotes a label value per the Prometheus text format.
+func quoteLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return `"` + v + `"`
+}
+
+// sortedKeys returns map keys in a stable order for deterministic output.
+func sortedKeys[K [2]string | [3]string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
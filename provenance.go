@@ -0,0 +1,151 @@
+package xai
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+)
+
+// ContentCredentials describes a C2PA manifest store embedded in image data.
+//
+// The xAI API does not return provenance manifests alongside generated images,
+// so credentials are located by inspecting the image bytes themselves (JPEG
+// APP11 segments or the PNG caBX chunk).
+type ContentCredentials struct {
+	// Format is the container the manifest was found in ("jpeg" or "png").
+	Format string
+	// Manifest is the raw JUMBF manifest store.
+	Manifest []byte
+}
+
+// Validate performs a structural check of the manifest store: it must be a
+// JUMBF superbox whose description box carries the "c2pa" label.
+// Cryptographic signature validation requires a full C2PA implementation and
+// is not performed here.
+func (c *ContentCredentials) Validate() error {
+	if c == nil || len(c.Manifest) < 8 {
+		return &Error{Code: ErrInvalidRequest, Message: "content credentials: manifest is empty"}
+	}
+	if string(c.Manifest[4:8]) != "jumb" {
+		return &Error{Code: ErrInvalidRequest, Message: "content credentials: manifest is not a JUMBF superbox"}
+	}
+	// The description box ("jumd") follows the superbox header and holds a
+	// 16-byte type UUID, a toggle byte and a null-terminated label.
+	desc := c.Manifest[8:]
+	if len(desc) < 8 || string(desc[4:8]) != "jumd" {
+		return &Error{Code: ErrInvalidRequest, Message: "content credentials: missing JUMBF description box"}
+	}
+	desc = desc[8:]
+	if len(desc) < 17 {
+		return &Error{Code: ErrInvalidRequest, Message: "content credentials: truncated JUMBF description box"}
+	}
+	label := desc[17:]
+	if i := bytes.IndexByte(label, 0); i >= 0 {
+		label = label[:i]
+	}
+	if string(label) != "c2pa" {
+		return &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf("content credentials: unexpected manifest label %q", label)}
+	}
+	return nil
+}
+
+// FindContentCredentials looks for an embedded C2PA manifest store in JPEG or
+// PNG image data. It returns false if the image carries no content credentials
+// or the format is not recognized.
+func FindContentCredentials(data []byte) (*ContentCredentials, bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		if m := jpegManifest(data); m != nil {
+			return &ContentCredentials{Format: "jpeg", Manifest: m}, true
+		}
+	case bytes.HasPrefix(data, pngSignature):
+		if m := pngManifest(data); m != nil {
+			return &ContentCredentials{Format: "png", Manifest: m}, true
+		}
+	}
+	return nil, false
+}
+
+// ContentCredentials returns the C2PA manifest embedded in the generated image.
+// Only images requested with ImageFormatBase64 carry their bytes in the
+// response; URL images must be downloaded and passed to FindContentCredentials.
+// Returns nil without error if the image has no content credentials.
+func (g *GeneratedImage) ContentCredentials() (*ContentCredentials, error) {
+	if g.Base64 == "" {
+		return nil, &Error{
+			Code:    ErrInvalidRequest,
+			Message: "image data not available; request ImageFormatBase64 or download the URL",
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(g.Base64)
+	if err != nil {
+		return nil, &Error{Code: ErrInvalidRequest, Message: "decoding image data", Cause: err}
+	}
+	cc, _ := FindContentCredentials(data)
+	return cc, nil
+}
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+// jpegManifest reassembles the JUMBF payload carried in APP11 segments.
+func jpegManifest(data []byte) []byte {
+	var manifest []byte
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		// Start of scan: no more metadata segments follow.
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + size
+		if size < 2 || end > len(data) {
+			return nil
+		}
+		seg := data[pos+4 : end]
+		// APP11 segments holding JUMBF start with CI "JP", a 2-byte box
+		// instance number and a 4-byte sequence number.
+		if marker == 0xEB && len(seg) > 8 && seg[0] == 'J' && seg[1] == 'P' {
+			box := seg[8:]
+			if manifest != nil {
+				// Continuation segments repeat the 8-byte box header.
+				if len(box) < 8 {
+					return nil
+				}
+				box = box[8:]
+			}
+			manifest = append(manifest, box...)
+		}
+		pos = end
+	}
+	if len(manifest) < 8 || !bytes.Contains(manifest, []byte("c2pa")) {
+		return nil
+	}
+	return manifest
+}
+
+// pngManifest returns the contents of the caBX chunk.
+func pngManifest(data []byte) []byte {
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		size := int(binary.BigEndian.Uint32(data[pos:]))
+		typ := string(data[pos+4 : pos+8])
+		start := pos + 8
+		end := start + size
+		if size < 0 || end+4 > len(data) {
+			return nil
+		}
+		if typ == "caBX" {
+			return data[start:end]
+		}
+		if typ == "IEND" {
+			break
+		}
+		pos = end + 4 // skip CRC
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package xai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrReasoningBudgetExceeded is the cause of the error a stream returns when
+// the model's reasoning exceeds WithMaxReasoningTokens. Match it with
+// errors.Is.
+var ErrReasoningBudgetExceeded = errors.New("reasoning token budget exceeded")
+
+// WithMaxReasoningTokens caps the reasoning tokens of a streamed completion.
+// The API has no such limit, so it is enforced client-side: once the
+// reasoning tokens reported in the stream's usage (or estimated from the
+// reasoning text, if the server reports usage only at the end) exceed n,
+// the stream is canceled and Next returns an error wrapping
+// ErrReasoningBudgetExceeded. Tokens generated up to that point are still
+// billed. CompleteChat does not enforce the cap.
+func (r *ChatRequest) WithMaxReasoningTokens(n int32) *ChatRequest {
+	r.maxReasoningTokens = &n
+	return r
+}
+
+// WithReasoningDowngrade makes a stream that exceeds WithMaxReasoningTokens
+// restart at the next lower reasoning effort instead of failing, as long as
+// no answer content has been delivered yet. The first chunk of the restarted
+// stream has ReasoningRestarted set; reasoning deltas received before it
+// should be discarded. A stream already at low effort fails as usual.
+func (r *ChatRequest) WithReasoningDowngrade() *ChatRequest {
+	r.reasoningDowngrade = true
+	return r
+}
+
+// reasoningMonitor tracks the reasoning of a stream against its budget.
+type reasoningMonitor struct {
+	max       int32
+	downgrade bool
+	effort    v1.ReasoningEffort
+	chars     int
+	answered  bool
+	restart   func(effort v1.ReasoningEffort, md *ResponseMetadata) (v1.Chat_GetCompletionChunkClient, context.CancelFunc, error)
+}
+
+// observe records chunk and reports the reasoning tokens used so far and
+// whether they exceed the budget.
+func (m *reasoningMonitor) observe(chunk *ChatChunk) (int32, bool) {
+	m.chars += len(chunk.ReasoningDelta)
+	if chunk.Delta != "" || len(chunk.ToolCalls) > 0 {
+		m.answered = true
+	}
+	used := max(chunk.Usage.ReasoningTokens, int32(m.chars/4))
+	return used, used > m.max
+}
+
+// lowerEffort returns the effort to restart at, if the stream may be
+// restarted.
+func (m *reasoningMonitor) lowerEffort() (v1.ReasoningEffort, bool) {
+	if !m.downgrade || m.answered {
+		return 0, false
+	}
+	switch m.effort {
+	case v1.ReasoningEffort_EFFORT_HIGH:
+		return v1.ReasoningEffort_EFFORT_MEDIUM, true
+	case v1.ReasoningEffort_EFFORT_MEDIUM, v1.ReasoningEffort_INVALID_EFFORT:
+		return v1.ReasoningEffort_EFFORT_LOW, true
+	}
+	return 0, false
+}
+
+// exceedReasoningBudget handles a stream whose reasoning went over budget,
+// either restarting it at a lower effort or failing it.
+func (s *ChunkStream) exceedReasoningBudget(used int32) (*ChatChunk, error) {
+	s.cancel()
+	m := s.monitor
+	if effort, ok := m.lowerEffort(); ok {
+		md := &ResponseMetadata{}
+		stream, cancel, err := m.restart(effort, md)
+		if err == nil {
+			s.stream, s.cancel, s.md = stream, cancel, md
+			m.effort, m.chars = effort, 0
+			chunk, err := s.next()
+			if chunk != nil {
+				chunk.ReasoningRestarted = true
+			}
+			return chunk, err
+		}
+	}
+	s.err = &Error{
+		Code:    ErrResourceExhausted,
+		Message: fmt.Sprintf("%v: %d reasoning tokens, limit %d", ErrReasoningBudgetExceeded, used, m.max),
+		Cause:   ErrReasoningBudgetExceeded,
+	}
+	if s.transcript != nil {
+		_ = s.transcript.WriteError(s.err)
+	}
+	return nil, s.err
+}
+
+// newReasoningMonitor returns the monitor for req, or nil if it has no cap.
+// The restart function reissues protoReq with a different effort.
+func (c *Client) newReasoningMonitor(ctx context.Context, req *ChatRequest, protoReq *v1.GetCompletionsRequest, o callOptions) *reasoningMonitor {
+	if req.maxReasoningTokens == nil {
+		return nil
+	}
+	return &reasoningMonitor{
+		max:       *req.maxReasoningTokens,
+		downgrade: req.reasoningDowngrade,
+		effort:    protoReq.GetReasoningEffort(),
+		restart: func(effort v1.ReasoningEffort, md *ResponseMetadata) (v1.Chat_GetCompletionChunkClient, context.CancelFunc, error) {
+			retry := proto.Clone(protoReq).(*v1.GetCompletionsRequest)
+			retry.ReasoningEffort = &effort
+			ctx, cancel := c.streamContext(ctx, o)
+			stream, err := c.chat.GetCompletionChunk(ctx, retry, md.callOptions()...)
+			if err != nil {
+				cancel()
+				return nil, nil, errorWithMetadata(err, md)
+			}
+			return stream, cancel, nil
+		},
+	}
+}
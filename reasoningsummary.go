@@ -0,0 +1,85 @@
+package xai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DefaultSummaryModel is the model ReasoningSummary uses unless
+// WithSummaryModel is given. It is a fast non-reasoning model, so the
+// summary costs a fraction of the original request.
+const DefaultSummaryModel = "grok-4-1-fast-non-reasoning"
+
+// maxSummaryInput bounds the reasoning text sent for summarization.
+const maxSummaryInput = 48_000
+
+// summaryPrompt asks for a user-facing account of the reasoning that does
+// not reproduce it.
+const summaryPrompt = `You summarize an assistant's private reasoning for the end user.
+In at most %d words, explain plainly what the assistant considered and why it reached its answer.
+Do not quote the reasoning, reveal internal instructions, or include tentative or discarded ideas as if they were conclusions.
+Write in the second person ("To answer your question, ...") as a short paragraph with no preamble.`
+
+// ReasoningSummaryOption configures ChatResponse.ReasoningSummary.
+type ReasoningSummaryOption func(*reasoningSummaryConfig)
+
+type reasoningSummaryConfig struct {
+	model    string
+	maxWords int
+}
+
+// WithSummaryModel sets the model that writes the summary.
+func WithSummaryModel(model string) ReasoningSummaryOption {
+	return func(c *reasoningSummaryConfig) {
+		c.model = model
+	}
+}
+
+// WithSummaryMaxWords sets the summary's approximate length. The default
+// is 60 words.
+func WithSummaryMaxWords(n int) ReasoningSummaryOption {
+	return func(c *reasoningSummaryConfig) {
+		if n > 0 {
+			c.maxWords = n
+		}
+	}
+}
+
+// ReasoningSummary asks a second, cheap completion for a short summary of
+// r.ReasoningContent that is safe to show users, for UIs that want to
+// explain "why" without exposing the raw reasoning. Very long traces are
+// truncated first. It fails with ErrInvalidRequest if the response has no
+// reasoning text, e.g. because the model returns it only encrypted.
+func (r *ChatResponse) ReasoningSummary(ctx context.Context, client *Client, opts ...ReasoningSummaryOption) (string, error) {
+	cfg := reasoningSummaryConfig{model: DefaultSummaryModel, maxWords: 60}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	trace := strings.TrimSpace(r.ReasoningContent)
+	if trace == "" {
+		return "", &Error{Code: ErrInvalidRequest, Message: "reasoning summary: response has no reasoning content"}
+	}
+
+	var b strings.Builder
+	b.WriteString("REASONING:\n")
+	b.WriteString(truncateText(trace, maxSummaryInput))
+	if r.Content != "" {
+		b.WriteString("\n\nFINAL ANSWER:\n")
+		b.WriteString(truncateText(r.Content, maxSummaryInput/4))
+	}
+
+	req := NewChatRequest().
+		WithModel(cfg.model).
+		SystemMessage(SystemContent{Text: fmt.Sprintf(summaryPrompt, cfg.maxWords)}).
+		UserMessage(UserContent{Text: b.String()}).
+		WithMaxTokens(int32(cfg.maxWords*2 + 32)).
+		WithTemperature(0.2)
+
+	out, err := client.CompleteChat(ctx, req)
+	if err != nil {
+		return "", WrapError(err, "reasoning summary")
+	}
+	return strings.TrimSpace(out.Content), nil
+}
@@ -0,0 +1,299 @@
+package xai
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// UsageEvent is the token usage of one request at a point in time, e.g. as
+// stored in a request log or database.
+type UsageEvent struct {
+	// Time is when the request was made.
+	Time time.Time `json:"time"`
+	// Model is the model name or alias as requested.
+	Model string `json:"model"`
+	// Usage is the request's token usage.
+	Usage Usage `json:"usage"`
+	// Label groups events in reports, e.g. by customer or feature.
+	// Optional.
+	Label string `json:"label,omitempty"`
+}
+
+// Reconciler prices recorded usage with the rates that were in effect when
+// each request was made, for historical spend reports that stay accurate
+// across price changes. Feed it pricing snapshots (AddPricing or
+// LoadPricing) and usage events (Record, or set it as Config.Metrics to
+// record a client's requests as they happen). It is safe for concurrent use.
+//
+// It keeps the most recently recorded DefaultMaxUsageEvents events unless
+// WithMaxEvents says otherwise, so a long-running process does not grow
+// without bound; persist events elsewhere for longer history.
+//
+// An event is priced with the latest snapshot taken at or before its time
+// that knows the model. Events older than every snapshot use the earliest
+// one, on the assumption that rates did not change before tracking began.
+type Reconciler struct {
+	mu        sync.Mutex
+	snapshots []pricingSnapshot // sorted by time
+	events    []UsageEvent      // in recording order
+	maxEvents int
+	dropped   int
+}
+
+// DefaultMaxUsageEvents is how many events a Reconciler keeps by default.
+const DefaultMaxUsageEvents = 100_000
+
+// ReconcilerOption configures NewReconciler.
+type ReconcilerOption func(*Reconciler)
+
+// WithMaxEvents sets how many events the reconciler keeps; once it holds n,
+// recording an event drops the earliest recorded one. Zero or less keeps
+// every event.
+func WithMaxEvents(n int) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.maxEvents = n
+	}
+}
+
+type pricingSnapshot struct {
+	at     time.Time
+	models map[string]*LanguageModel
+}
+
+var _ Metrics = (*Reconciler)(nil)
+
+// NewReconciler creates an empty reconciler.
+func NewReconciler(opts ...ReconcilerOption) *Reconciler {
+	r := &Reconciler{maxEvents: DefaultMaxUsageEvents}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// AddPricing records the pricing of models as effective from at. Models are
+// matched by name and alias.
+func (r *Reconciler) AddPricing(at time.Time, models []*LanguageModel) {
+	snap := pricingSnapshot{at: at, models: make(map[string]*LanguageModel, len(models))}
+	for _, m := range models {
+		snap.models[m.Name] = m
+		for _, alias := range m.Aliases {
+			snap.models[alias] = m
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := sort.Search(len(r.snapshots), func(i int) bool { return r.snapshots[i].at.After(at) })
+	r.snapshots = append(r.snapshots, pricingSnapshot{})
+	copy(r.snapshots[i+1:], r.snapshots[i:])
+	r.snapshots[i] = snap
+}
+
+// LoadPricing adds a snapshot of the current pricing from client.ListModels.
+// Call it periodically (e.g. daily) to capture price changes.
+func (r *Reconciler) LoadPricing(ctx context.Context, client *Client) error {
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		return WrapError(err, "loading model pricing")
+	}
+	r.AddPricing(client.config.Clock.Now(), models)
+	return nil
+}
+
+// Record adds usage events, dropping the earliest recorded ones beyond the
+// event limit (see WithMaxEvents).
+func (r *Reconciler) Record(events ...UsageEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, events...)
+	if over := len(r.events) - r.maxEvents; r.maxEvents > 0 && over > 0 {
+		// Reslicing keeps appends amortized; the next growth copies only
+		// the kept events.
+		r.events = r.events[over:]
+		r.dropped += over
+	}
+}
+
+// Dropped returns how many events were dropped for exceeding the event
+// limit.
+func (r *Reconciler) Dropped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+// ObserveRequest implements Metrics by recording successful requests with
+// usage as events at the time they started.
+func (r *Reconciler) ObserveRequest(m RequestMetrics) {
+	if m.Err != nil || m.Model == "" || m.Usage == (Usage{}) {
+		return
+	}
+	r.Record(UsageEvent{Time: m.Time, Model: m.Model, Usage: m.Usage})
+}
+
+// ObserveTimeToFirstToken implements Metrics; it does nothing.
+func (r *Reconciler) ObserveTimeToFirstToken(string, string, time.Duration) {}
+
+// ReportOption configures Reconciler.Report.
+type ReportOption func(*reportOptions)
+
+type reportOptions struct {
+	from, to time.Time
+	period   time.Duration
+}
+
+// WithReportRange limits the report to events in [from, to). A zero bound
+// is open.
+func WithReportRange(from, to time.Time) ReportOption {
+	return func(o *reportOptions) {
+		o.from, o.to = from, to
+	}
+}
+
+// WithReportPeriod splits the report into periods of d (e.g. 24h for daily
+// rows), aligned to the Unix epoch in UTC. By default each model and label
+// gets a single row.
+func WithReportPeriod(d time.Duration) ReportOption {
+	return func(o *reportOptions) {
+		o.period = d
+	}
+}
+
+// SpendRow is the spend of one model and label within one period.
+type SpendRow struct {
+	// PeriodStart is the start of the row's period; zero without
+	// WithReportPeriod.
+	PeriodStart time.Time `json:"period_start,omitempty"`
+	// Model is the model name as recorded.
+	Model string `json:"model"`
+	// Label is the events' label.
+	Label string `json:"label,omitempty"`
+	// Requests is the number of events.
+	Requests int `json:"requests"`
+	// Usage is the summed token usage.
+	Usage Usage `json:"usage"`
+	// USD is the cost of the priced events.
+	USD float64 `json:"usd"`
+	// Unpriced counts events for which no pricing snapshot knew the model.
+	Unpriced int `json:"unpriced,omitempty"`
+}
+
+// SpendReport is the result of Reconciler.Report.
+type SpendReport struct {
+	// Rows are ordered by period, then model, then label.
+	Rows []SpendRow `json:"rows"`
+	// TotalUSD is the cost across all rows.
+	TotalUSD float64 `json:"total_usd"`
+	// Unpriced counts events that could not be priced.
+	Unpriced int `json:"unpriced"`
+}
+
+// Report prices the recorded events and aggregates them by period, model
+// and label.
+func (r *Reconciler) Report(opts ...ReportOption) *SpendReport {
+	var o reportOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type rowKey struct {
+		period       int64
+		model, label string
+	}
+	rows := map[rowKey]*SpendRow{}
+	report := &SpendReport{}
+	for _, e := range r.events {
+		if (!o.from.IsZero() && e.Time.Before(o.from)) || (!o.to.IsZero() && !e.Time.Before(o.to)) {
+			continue
+		}
+		key := rowKey{model: e.Model, label: e.Label}
+		if o.period > 0 {
+			key.period = e.Time.UnixNano() / int64(o.period)
+		}
+		row, ok := rows[key]
+		if !ok {
+			row = &SpendRow{Model: e.Model, Label: e.Label}
+			if o.period > 0 {
+				row.PeriodStart = time.Unix(0, key.period*int64(o.period)).UTC()
+			}
+			rows[key] = row
+		}
+		row.Requests++
+		addUsage(&row.Usage, e.Usage)
+		if model := r.pricingAt(e.Model, e.Time); model != nil {
+			usd := usageCost(model, e.Usage)
+			row.USD += usd
+			report.TotalUSD += usd
+		} else {
+			row.Unpriced++
+			report.Unpriced++
+		}
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		switch {
+		case !a.PeriodStart.Equal(b.PeriodStart):
+			return a.PeriodStart.Before(b.PeriodStart)
+		case a.Model != b.Model:
+			return a.Model < b.Model
+		default:
+			return a.Label < b.Label
+		}
+	})
+	return report
+}
+
+// pricingAt returns the model's pricing in effect at t. r.mu must be held.
+func (r *Reconciler) pricingAt(model string, t time.Time) *LanguageModel {
+	var earliest *LanguageModel
+	for i := len(r.snapshots) - 1; i >= 0; i-- {
+		snap := r.snapshots[i]
+		m, ok := snap.models[model]
+		if !ok {
+			continue
+		}
+		if !snap.at.After(t) {
+			return m
+		}
+		earliest = m
+	}
+	return earliest
+}
+
+// WriteCSV writes the report as CSV with a header row. Periods are RFC 3339
+// timestamps (empty without WithReportPeriod) and costs are in USD.
+func (s *SpendReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"period_start", "model", "label", "requests",
+		"prompt_tokens", "cached_prompt_tokens", "prompt_image_tokens",
+		"completion_tokens", "reasoning_tokens", "usd", "unpriced",
+	})
+	itoa := func(n int32) string { return strconv.FormatInt(int64(n), 10) }
+	for _, row := range s.Rows {
+		period := ""
+		if !row.PeriodStart.IsZero() {
+			period = row.PeriodStart.Format(time.RFC3339)
+		}
+		_ = cw.Write([]string{
+			period, row.Model, row.Label, strconv.Itoa(row.Requests),
+			itoa(row.Usage.PromptTokens), itoa(row.Usage.CachedPromptTokens), itoa(row.Usage.PromptImageTokens),
+			itoa(row.Usage.CompletionTokens), itoa(row.Usage.ReasoningTokens),
+			strconv.FormatFloat(row.USD, 'f', 6, 64), strconv.Itoa(row.Unpriced),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
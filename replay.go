@@ -0,0 +1,120 @@
+package xai
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+var _ ChatStream = (*ReplayStream)(nil)
+
+// ReplayStream replays a JSONL transcript written by TranscriptWriter as a
+// ChatStream. Chunks are delivered with their original relative timing,
+// optionally scaled with WithSpeed, so front-end and agent code can be
+// developed against recorded generations.
+type ReplayStream struct {
+	dec    *json.Decoder
+	closer io.Closer
+	speed  float64
+	start  time.Time
+	err    error
+	done   bool
+}
+
+// NewReplayStream creates a replay stream reading transcript events from r.
+// If r implements io.Closer, Close closes it.
+func NewReplayStream(r io.Reader) *ReplayStream {
+	s := &ReplayStream{
+		dec:   json.NewDecoder(r),
+		speed: 1,
+	}
+	if c, ok := r.(io.Closer); ok {
+		s.closer = c
+	}
+	return s
+}
+
+// WithSpeed scales replay timing: 2 replays twice as fast, 0.5 at half speed.
+// A factor of 0 or less disables delays and delivers chunks immediately.
+func (s *ReplayStream) WithSpeed(factor float64) *ReplayStream {
+	s.speed = factor
+	return s
+}
+
+// Next returns the next recorded chunk, or io.EOF when the transcript ends.
+// A recorded stream error is returned as an *Error with the original code.
+func (s *ReplayStream) Next() (*ChatChunk, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.done {
+		return nil, io.EOF
+	}
+	if s.start.IsZero() {
+		s.start = time.Now()
+	}
+
+	for {
+		var ev TranscriptEvent
+		if err := s.dec.Decode(&ev); err != nil {
+			if err == io.EOF {
+				// Transcript ended without an explicit end event.
+				s.done = true
+				return nil, io.EOF
+			}
+			s.err = &Error{Code: ErrInvalidRequest, Message: "reading transcript", Cause: err}
+			return nil, s.err
+		}
+
+		s.wait(ev.ElapsedMs)
+
+		switch ev.Type {
+		case TranscriptEventChunk:
+			if ev.Chunk == nil {
+				continue
+			}
+			return ev.Chunk, nil
+		case TranscriptEventError:
+			s.err = &Error{Code: errorCodeFromString(ev.ErrorCode), Message: ev.Error}
+			return nil, s.err
+		case TranscriptEventEnd:
+			s.done = true
+			return nil, io.EOF
+		}
+	}
+}
+
+// wait sleeps until the scaled event offset has elapsed since replay started.
+func (s *ReplayStream) wait(elapsedMs int64) {
+	if s.speed <= 0 {
+		return
+	}
+	target := time.Duration(float64(elapsedMs) * float64(time.Millisecond) / s.speed)
+	if d := target - time.Since(s.start); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// Close closes the underlying reader if it is an io.Closer.
+func (s *ReplayStream) Close() error {
+	s.done = true
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
+
+// Err returns any error that occurred during replay, including recorded stream errors.
+func (s *ReplayStream) Err() error {
+	return s.err
+}
+
+// errorCodeFromString maps ErrorCode.String output back to the code.
+func errorCodeFromString(name string) ErrorCode {
+	for c := ErrUnknown; c <= ErrResourceExhausted; c++ {
+		if c.String() == name {
+			return c
+		}
+	}
+	return ErrUnknown
+}
@@ -0,0 +1,101 @@
+package xai
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// Request is a request the client can send with Do. It is implemented by
+// *ChatRequest, *ImageRequest, *EmbedRequest and *SampleRequest, so
+// behavior such as retries, metrics or caching can be written once for all
+// of them by wrapping a DoFunc.
+type Request interface {
+	// Method returns the short name of the RPC the request is sent with,
+	// as in Call.Method, e.g. "Chat/GetCompletion".
+	Method() string
+
+	do(ctx context.Context, c *Client, opts []CallOption) (any, error)
+}
+
+// DoFunc has the signature of Client.Do. Wrap it to build request-level
+// middleware:
+//
+//	func withRetry(do xai.DoFunc) xai.DoFunc {
+//	    return func(ctx context.Context, req xai.Request, opts ...xai.CallOption) (any, error) {
+//	        resp, err := do(ctx, req, opts...)
+//	        var xerr *xai.Error
+//	        if errors.As(err, &xerr) && xerr.IsRetryable() {
+//	            resp, err = do(ctx, req, opts...)
+//	        }
+//	        return resp, err
+//	    }
+//	}
+type DoFunc func(ctx context.Context, req Request, opts ...CallOption) (any, error)
+
+// Do sends req with the method for its type and returns the response:
+// *ChatResponse for a *ChatRequest, *ImageResponse for an *ImageRequest,
+// *EmbedResponse for an *EmbedRequest and *SampleResponse for a
+// *SampleRequest. Use DoRequest for a typed result.
+func (c *Client) Do(ctx context.Context, req Request, opts ...CallOption) (any, error) {
+	if req == nil {
+		return nil, &Error{Code: ErrInvalidRequest, Message: "do: nil request"}
+	}
+	return req.do(ctx, c, opts)
+}
+
+// DoRequest calls do, typically Client.Do or a wrapper of it, and returns
+// the response as Resp. It fails with ErrInvalidRequest if the response has
+// a different type.
+//
+//	resp, err := xai.DoRequest[*xai.ChatResponse](ctx, withRetry(client.Do), req)
+func DoRequest[Resp any](ctx context.Context, do DoFunc, req Request, opts ...CallOption) (Resp, error) {
+	var zero Resp
+	out, err := do(ctx, req, opts...)
+	if err != nil {
+		return zero, err
+	}
+	resp, ok := out.(Resp)
+	if !ok {
+		return zero, &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf("do %s: response is %T, not %T", req.Method(), out, zero)}
+	}
+	return resp, nil
+}
+
+// Method implements Request.
+func (r *ChatRequest) Method() string { return shortMethod(v1.Chat_GetCompletion_FullMethodName) }
+
+func (r *ChatRequest) do(ctx context.Context, c *Client, opts []CallOption) (any, error) {
+	return response(c.CompleteChat(ctx, r, opts...))
+}
+
+// Method implements Request.
+func (r *ImageRequest) Method() string { return shortMethod(v1.Image_GenerateImage_FullMethodName) }
+
+func (r *ImageRequest) do(ctx context.Context, c *Client, opts []CallOption) (any, error) {
+	return response(c.GenerateImage(ctx, r, opts...))
+}
+
+// Method implements Request.
+func (r *EmbedRequest) Method() string { return shortMethod(v1.Embedder_Embed_FullMethodName) }
+
+func (r *EmbedRequest) do(ctx context.Context, c *Client, opts []CallOption) (any, error) {
+	return response(c.Embed(ctx, r, opts...))
+}
+
+// Method implements Request.
+func (r *SampleRequest) Method() string { return shortMethod(v1.Sample_SampleText_FullMethodName) }
+
+func (r *SampleRequest) do(ctx context.Context, c *Client, opts []CallOption) (any, error) {
+	return response(c.SampleText(ctx, r, opts...))
+}
+
+// response returns resp as a Do result, keeping a failed call's result a
+// nil interface rather than a typed nil pointer.
+func response[T any](resp *T, err error) (any, error) {
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
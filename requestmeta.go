@@ -0,0 +1,72 @@
+package xai
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// requestMetadataPrefix starts the header each WithMetadata pair is sent in.
+const requestMetadataPrefix = "x-xai-metadata-"
+
+// WithMetadata tags the request with key/value pairs, such as tenant or
+// feature IDs, for reconciling usage afterwards. The API has no metadata
+// field, so each pair is sent as an x-xai-metadata-<key> header, where
+// gateways and proxies can pick it up, and the pairs are echoed on
+// ChatResponse.RequestMetadata. Calling it again adds to the pairs.
+//
+// Keys are case-insensitive and stored lowercased; they may contain letters,
+// digits, '-', '_' and '.'. Values must be printable ASCII. Anything else
+// fails the request with ErrInvalidRequest.
+func (r *ChatRequest) WithMetadata(md map[string]string) *ChatRequest {
+	for k, v := range md {
+		key := strings.ToLower(k)
+		if err := checkMetadata(key, v); err != nil {
+			r.setErr(&Error{Code: ErrInvalidRequest, Message: fmt.Sprintf("metadata %q: %v", k, err)})
+			return r
+		}
+		if r.metadata == nil {
+			r.metadata = make(map[string]string, len(md))
+		}
+		r.metadata[key] = v
+	}
+	return r
+}
+
+// Metadata returns a copy of the pairs set with WithMetadata, or nil.
+func (r *ChatRequest) Metadata() map[string]string {
+	return maps.Clone(r.metadata)
+}
+
+func checkMetadata(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+	for _, c := range key {
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return fmt.Errorf("key contains %q", c)
+		}
+	}
+	for _, c := range value {
+		if c < 0x20 || c > 0x7e {
+			return fmt.Errorf("value contains %q", c)
+		}
+	}
+	return nil
+}
+
+// metadataContext adds the request's metadata headers to ctx.
+func metadataContext(ctx context.Context, req *ChatRequest) context.Context {
+	if len(req.metadata) == 0 {
+		return ctx
+	}
+	kv := make([]string, 0, 2*len(req.metadata))
+	for _, k := range slices.Sorted(maps.Keys(req.metadata)) {
+		kv = append(kv, requestMetadataPrefix+k, req.metadata[k])
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
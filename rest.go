@@ -0,0 +1,507 @@
+package xai
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Transport selects how the client talks to xAI.
+type Transport int
+
+const (
+	// TransportGRPC uses the gRPC API (the default).
+	TransportGRPC Transport = iota
+	// TransportREST uses xAI's HTTPS/JSON API, for networks where outbound
+	// gRPC or HTTP/2 is blocked by proxies or middleboxes. The client API is
+	// the same, but features without a REST equivalent (server-side tools,
+	// stored completions, previous_response_id, encrypted content, image
+	// embeddings and edits, documents, batches) fail with ErrInvalidRequest.
+	TransportREST
+	// TransportGRPCWeb uses the gRPC-Web protocol over ordinary HTTP
+	// requests, for WebAssembly builds running in a browser (where it is
+	// used in place of TransportGRPC) and other environments limited to
+	// HTTP/1.1 or fetch. Every RPC is available, but GRPCWebEndpoint must
+	// speak gRPC-Web, e.g. an Envoy proxy with the grpc_web filter in front
+	// of the API.
+	TransportGRPCWeb
+)
+
+// String returns "grpc", "rest" or "grpc-web".
+func (t Transport) String() string {
+	switch t {
+	case TransportGRPC:
+		return "grpc"
+	case TransportREST:
+		return "rest"
+	case TransportGRPCWeb:
+		return "grpc-web"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultRESTEndpoint is the base URL of xAI's REST API.
+const DefaultRESTEndpoint = "https://api.x.ai/v1"
+
+// restConn serves the service clients' RPCs over xAI's REST API. It sits
+// behind the client's interceptor chain in place of the gRPC connection, so
+// metrics, key pools, logging and middleware behave the same on both
+// transports.
+type restConn struct {
+	baseURL string
+	http    *http.Client
+	auth    *bearerAuth
+	info    string
+}
+
+func newRESTConn(cfg Config) *restConn {
+	return &restConn{
+		baseURL: strings.TrimRight(cfg.RESTEndpoint, "/"),
+		http:    httpClientFor(cfg),
+		auth:    &bearerAuth{apiKey: cfg.APIKey, provider: cfg.APIKeyProvider},
+		info:    clientInfo(cfg.AppName),
+	}
+}
+
+// Close closes the HTTP client's idle connections.
+func (r *restConn) Close() error {
+	r.http.CloseIdleConnections()
+	return nil
+}
+
+// httpClientFor returns cfg.HTTPClient, or a client built from TLSConfig
+// and Dialer for the HTTP-based transports.
+func httpClientFor(cfg Config) *http.Client {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient
+	}
+	tlsConfig := cfg.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if cfg.Dialer != nil {
+		transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return cfg.Dialer(ctx, addr)
+		}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// Invoke performs a unary RPC as a REST call.
+func (r *restConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	var err error
+	switch method {
+	case v1.Chat_GetCompletion_FullMethodName:
+		err = r.getCompletion(ctx, args.(*v1.GetCompletionsRequest), reply.(*v1.GetChatCompletionResponse), opts)
+	case v1.Models_ListLanguageModels_FullMethodName:
+		var out struct {
+			Models []restLanguageModel `json:"models"`
+		}
+		if err = r.call(ctx, http.MethodGet, "/language-models", nil, &out, opts); err == nil {
+			resp := reply.(*v1.ListLanguageModelsResponse)
+			for _, m := range out.Models {
+				resp.Models = append(resp.Models, m.toProto())
+			}
+		}
+	case v1.Models_GetLanguageModel_FullMethodName:
+		var out restLanguageModel
+		name := args.(*v1.GetModelRequest).GetName()
+		if err = r.call(ctx, http.MethodGet, "/language-models/"+url.PathEscape(name), nil, &out, opts); err == nil {
+			proto.Merge(reply.(proto.Message), out.toProto())
+		}
+	case v1.Auth_GetApiKeyInfo_FullMethodName:
+		var out restAPIKey
+		if err = r.call(ctx, http.MethodGet, "/api-key", nil, &out, opts); err == nil {
+			proto.Merge(reply.(proto.Message), out.toProto())
+		}
+	default:
+		var ok bool
+		if ok, err = r.invokeService(ctx, method, args, reply, opts); !ok {
+			err = unsupportedOverREST(shortMethod(method))
+		}
+	}
+	return err
+}
+
+// NewStream opens a server-streaming RPC as a server-sent events request.
+// The HTTP request is sent on the first RecvMsg, once the request message
+// has been passed to SendMsg.
+func (r *restConn) NewStream(ctx context.Context, _ *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	switch method {
+	case v1.Chat_GetCompletionChunk_FullMethodName:
+		return &restStream{ctx: ctx, conn: r, opts: opts}, nil
+	default:
+		return nil, unsupportedOverREST(shortMethod(method))
+	}
+}
+
+func unsupportedOverREST(what string) error {
+	return status.Errorf(codes.InvalidArgument, "%s is not supported over the REST transport", what)
+}
+
+// call sends a JSON request and decodes the JSON response into out.
+func (r *restConn) call(ctx context.Context, method, path string, body, out any, opts []grpc.CallOption) error {
+	resp, err := r.send(ctx, method, path, body, opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return restTransportError(ctx, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return status.Errorf(codes.Internal, "decoding %s response: %v", path, err)
+	}
+	return nil
+}
+
+// send performs the HTTP request and converts error statuses. On success the
+// caller must close the response body. Response headers are delivered to any
+// grpc.Header call option, so ResponseMetadata works on both transports.
+func (r *restConn) send(ctx context.Context, method, path string, body any, opts []grpc.CallOption) (*http.Response, error) {
+	md, err := r.auth.GetRequestMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader
+	gzipped := false
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "encoding request: %v", err)
+		}
+		if restCompress(opts) {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			_, _ = zw.Write(data)
+			_ = zw.Close()
+			data, gzipped = buf.Bytes(), true
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reader)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "building request: %v", err)
+	}
+	req.Header.Set("Authorization", md["authorization"])
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", r.info)
+	req.Header.Set(clientInfoHeader, r.info)
+	if out, ok := metadata.FromOutgoingContext(ctx); ok {
+		if key := out.Get(cacheKeyHeader); len(key) > 0 {
+			req.Header.Set(cacheKeyHeader, key[0])
+		}
+		for k, v := range out {
+			if strings.HasPrefix(k, requestMetadataPrefix) && len(v) > 0 {
+				req.Header.Set(k, v[0])
+			}
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, restTransportError(ctx, err)
+	}
+	deliverRESTHeaders(resp.Header, opts)
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, restStatusError(resp.StatusCode, data)
+	}
+	return resp, nil
+}
+
+// restCompress reports whether the call asked for gzip compression.
+func restCompress(opts []grpc.CallOption) bool {
+	for _, opt := range opts {
+		if c, ok := opt.(grpc.CompressorCallOption); ok && c.CompressorType == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverRESTHeaders hands HTTP response headers to grpc.Header call options.
+func deliverRESTHeaders(h http.Header, opts []grpc.CallOption) {
+	md := metadata.MD{}
+	for k, v := range h {
+		md[strings.ToLower(k)] = v
+	}
+	for _, opt := range opts {
+		if ho, ok := opt.(grpc.HeaderCallOption); ok {
+			*ho.HeaderAddr = md
+		}
+	}
+}
+
+// restTransportError converts a failed HTTP round trip into a status error.
+func restTransportError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return status.FromContextError(ctxErr).Err()
+	}
+	return status.Errorf(codes.Unavailable, "REST request failed: %v", err)
+}
+
+// restStatusError converts an HTTP error response into a status error with
+// the message from the JSON error body, if any.
+func restStatusError(code int, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	var parsed struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && len(parsed.Error) > 0 {
+		var text string
+		var obj struct {
+			Message string `json:"message"`
+		}
+		switch {
+		case json.Unmarshal(parsed.Error, &text) == nil && text != "":
+			msg = text
+		case json.Unmarshal(parsed.Error, &obj) == nil && obj.Message != "":
+			msg = obj.Message
+		}
+	}
+	if msg == "" {
+		msg = http.StatusText(code)
+	}
+
+	var c codes.Code
+	switch {
+	case code == http.StatusBadRequest, code == http.StatusUnprocessableEntity:
+		c = codes.InvalidArgument
+	case code == http.StatusUnauthorized:
+		c = codes.Unauthenticated
+	case code == http.StatusForbidden:
+		c = codes.PermissionDenied
+	case code == http.StatusNotFound:
+		c = codes.NotFound
+	case code == http.StatusTooManyRequests:
+		c = codes.ResourceExhausted
+	case code == http.StatusRequestTimeout, code == http.StatusGatewayTimeout:
+		c = codes.DeadlineExceeded
+	case code == http.StatusBadGateway, code == http.StatusServiceUnavailable:
+		c = codes.Unavailable
+	case code >= 500:
+		c = codes.Internal
+	default:
+		c = codes.Unknown
+	}
+	return status.Error(c, msg)
+}
+
+// restStream adapts a server-sent events response to grpc.ClientStream.
+type restStream struct {
+	ctx    context.Context
+	conn   *restConn
+	opts   []grpc.CallOption
+	req    any
+	body   io.ReadCloser
+	reader *bufio.Reader
+	header metadata.MD
+}
+
+func (s *restStream) Header() (metadata.MD, error) { return s.header, nil }
+func (s *restStream) Trailer() metadata.MD         { return nil }
+func (s *restStream) CloseSend() error             { return nil }
+func (s *restStream) Context() context.Context     { return s.ctx }
+
+// SendMsg records the request; it is sent on the first RecvMsg.
+func (s *restStream) SendMsg(m any) error {
+	s.req = m
+	return nil
+}
+
+// RecvMsg decodes the next event into m, returning io.EOF when the server
+// signals the end of the stream.
+func (s *restStream) RecvMsg(m any) error {
+	if s.body == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	data, err := s.nextEvent()
+	if err != nil {
+		_ = s.body.Close()
+		return err
+	}
+	if data == "[DONE]" {
+		_ = s.body.Close()
+		return io.EOF
+	}
+	var chunk restChatResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		_ = s.body.Close()
+		return status.Errorf(codes.Internal, "decoding stream event: %v", err)
+	}
+	chunk.toChunkProto(m.(*v1.GetChatCompletionChunk))
+	return nil
+}
+
+func (s *restStream) open() error {
+	req, ok := s.req.(*v1.GetCompletionsRequest)
+	if !ok {
+		return status.Error(codes.Internal, "REST stream: request not sent")
+	}
+	body, err := chatRequestToREST(req)
+	if err != nil {
+		return err
+	}
+	body.Stream = true
+	body.StreamOptions = &restStreamOptions{IncludeUsage: true}
+
+	opts := append(s.opts, grpc.Header(&s.header))
+	resp, err := s.conn.send(s.ctx, http.MethodPost, "/chat/completions", body, opts)
+	if err != nil {
+		return err
+	}
+	s.body = resp.Body
+	s.reader = bufio.NewReader(resp.Body)
+	return nil
+}
+
+// nextEvent returns the data of the next server-sent event.
+func (s *restStream) nextEvent() (string, error) {
+	var data []string
+	for {
+		line, err := s.reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// A blank line ends the event. Comments (":") and other fields such
+		// as "event:" are ignored.
+		if (line == "" || err != nil) && len(data) > 0 {
+			return strings.Join(data, "\n"), nil
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// The server closed the stream without [DONE].
+				return "[DONE]", nil
+			}
+			return "", restTransportError(s.ctx, err)
+		}
+	}
+}
+
+// restLanguageModel is a language model as returned by the REST API.
+type restLanguageModel struct {
+	ID                         string   `json:"id"`
+	Fingerprint                string   `json:"fingerprint"`
+	Created                    int64    `json:"created"`
+	Version                    string   `json:"version"`
+	InputModalities            []string `json:"input_modalities"`
+	OutputModalities           []string `json:"output_modalities"`
+	PromptTextTokenPrice       int64    `json:"prompt_text_token_price"`
+	CachedPromptTextTokenPrice int64    `json:"cached_prompt_text_token_price"`
+	PromptImageTokenPrice      int64    `json:"prompt_image_token_price"`
+	CompletionTextTokenPrice   int64    `json:"completion_text_token_price"`
+	SearchPrice                int64    `json:"search_price"`
+	MaxPromptLength            int32    `json:"max_prompt_length"`
+	Aliases                    []string `json:"aliases"`
+}
+
+func (m restLanguageModel) toProto() *v1.LanguageModel {
+	out := &v1.LanguageModel{
+		Name:                     m.ID,
+		Aliases:                  m.Aliases,
+		Version:                  m.Version,
+		PromptTextTokenPrice:     m.PromptTextTokenPrice,
+		PromptImageTokenPrice:    m.PromptImageTokenPrice,
+		CachedPromptTokenPrice:   m.CachedPromptTextTokenPrice,
+		CompletionTextTokenPrice: m.CompletionTextTokenPrice,
+		SearchPrice:              m.SearchPrice,
+		MaxPromptLength:          m.MaxPromptLength,
+		SystemFingerprint:        m.Fingerprint,
+		InputModalities:          restModalities(m.InputModalities),
+		OutputModalities:         restModalities(m.OutputModalities),
+	}
+	if m.Created > 0 {
+		out.Created = timestamppb.New(time.Unix(m.Created, 0))
+	}
+	return out
+}
+
+func restModalities(names []string) []v1.Modality {
+	var out []v1.Modality
+	for _, name := range names {
+		if m, ok := v1.Modality_value[strings.ToUpper(name)]; ok {
+			out = append(out, v1.Modality(m))
+		}
+	}
+	return out
+}
+
+// restAPIKey is the REST API's description of the calling key.
+type restAPIKey struct {
+	RedactedAPIKey string    `json:"redacted_api_key"`
+	UserID         string    `json:"user_id"`
+	Name           string    `json:"name"`
+	CreateTime     time.Time `json:"create_time"`
+	ModifyTime     time.Time `json:"modify_time"`
+	ModifiedBy     string    `json:"modified_by"`
+	TeamID         string    `json:"team_id"`
+	ACLs           []string  `json:"acls"`
+	APIKeyID       string    `json:"api_key_id"`
+	TeamBlocked    bool      `json:"team_blocked"`
+	APIKeyBlocked  bool      `json:"api_key_blocked"`
+	APIKeyDisabled bool      `json:"api_key_disabled"`
+}
+
+func (k restAPIKey) toProto() *v1.ApiKey {
+	out := &v1.ApiKey{
+		RedactedApiKey: k.RedactedAPIKey,
+		UserId:         k.UserID,
+		Name:           k.Name,
+		ModifiedBy:     k.ModifiedBy,
+		TeamId:         k.TeamID,
+		Acls:           k.ACLs,
+		ApiKeyId:       k.APIKeyID,
+		ApiKeyBlocked:  k.APIKeyBlocked,
+		TeamBlocked:    k.TeamBlocked,
+		Disabled:       k.APIKeyDisabled,
+	}
+	if !k.CreateTime.IsZero() {
+		out.CreateTime = timestamppb.New(k.CreateTime)
+	}
+	if !k.ModifyTime.IsZero() {
+		out.ModifyTime = timestamppb.New(k.ModifyTime)
+	}
+	return out
+}
+
+// restError formats a conversion failure for fields without a REST
+// equivalent.
+func restError(field string) error {
+	return unsupportedOverREST(fmt.Sprintf("request field %q", field))
+}
@@ -0,0 +1,466 @@
+package xai
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// REST chat completion wire types (the OpenAI-compatible format).
+
+type restChatRequest struct {
+	Model             string              `json:"model"`
+	Messages          []restMessage       `json:"messages"`
+	User              string              `json:"user,omitempty"`
+	N                 *int32              `json:"n,omitempty"`
+	MaxTokens         *int32              `json:"max_tokens,omitempty"`
+	Seed              *int32              `json:"seed,omitempty"`
+	Stop              []string            `json:"stop,omitempty"`
+	Temperature       *float32            `json:"temperature,omitempty"`
+	TopP              *float32            `json:"top_p,omitempty"`
+	Logprobs          bool                `json:"logprobs,omitempty"`
+	TopLogprobs       *int32              `json:"top_logprobs,omitempty"`
+	Tools             []restTool          `json:"tools,omitempty"`
+	ToolChoice        any                 `json:"tool_choice,omitempty"`
+	ResponseFormat    *restResponseFormat `json:"response_format,omitempty"`
+	FrequencyPenalty  *float32            `json:"frequency_penalty,omitempty"`
+	PresencePenalty   *float32            `json:"presence_penalty,omitempty"`
+	ReasoningEffort   string              `json:"reasoning_effort,omitempty"`
+	ParallelToolCalls *bool               `json:"parallel_tool_calls,omitempty"`
+	Stream            bool                `json:"stream,omitempty"`
+	StreamOptions     *restStreamOptions  `json:"stream_options,omitempty"`
+}
+
+type restStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type restMessage struct {
+	Role             string         `json:"role"`
+	Content          any            `json:"content,omitempty"`
+	ReasoningContent string         `json:"reasoning_content,omitempty"`
+	Name             string         `json:"name,omitempty"`
+	ToolCalls        []restToolCall `json:"tool_calls,omitempty"`
+	ToolCallID       string         `json:"tool_call_id,omitempty"`
+}
+
+type restContentPart struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *restImageURL `json:"image_url,omitempty"`
+}
+
+type restImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type restToolCall struct {
+	Index    *int             `json:"index,omitempty"`
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function restFunctionCall `json:"function"`
+}
+
+type restFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+type restTool struct {
+	Type     string       `json:"type"`
+	Function restFunction `json:"function"`
+}
+
+type restFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Strict      bool            `json:"strict,omitempty"`
+}
+
+type restResponseFormat struct {
+	Type       string          `json:"type"`
+	JSONSchema *restJSONSchema `json:"json_schema,omitempty"`
+}
+
+type restJSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+	Strict bool            `json:"strict"`
+}
+
+type restChatResponse struct {
+	ID                string       `json:"id"`
+	Created           int64        `json:"created"`
+	Model             string       `json:"model"`
+	SystemFingerprint string       `json:"system_fingerprint"`
+	Choices           []restChoice `json:"choices"`
+	Usage             *restUsage   `json:"usage"`
+	Citations         []string     `json:"citations"`
+}
+
+type restChoice struct {
+	Index        int32                `json:"index"`
+	Message      *restResponseMessage `json:"message"`
+	Delta        *restResponseMessage `json:"delta"`
+	FinishReason string               `json:"finish_reason"`
+	Logprobs     *restLogprobs        `json:"logprobs"`
+}
+
+type restResponseMessage struct {
+	Role             string         `json:"role"`
+	Content          string         `json:"content"`
+	ReasoningContent string         `json:"reasoning_content"`
+	ToolCalls        []restToolCall `json:"tool_calls"`
+}
+
+type restUsage struct {
+	PromptTokens        int32 `json:"prompt_tokens"`
+	CompletionTokens    int32 `json:"completion_tokens"`
+	TotalTokens         int32 `json:"total_tokens"`
+	NumSourcesUsed      int32 `json:"num_sources_used"`
+	PromptTokensDetails *struct {
+		TextTokens   int32 `json:"text_tokens"`
+		ImageTokens  int32 `json:"image_tokens"`
+		CachedTokens int32 `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails *struct {
+		ReasoningTokens int32 `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+type restLogprobs struct {
+	Content []restLogprob `json:"content"`
+}
+
+type restLogprob struct {
+	Token       string        `json:"token"`
+	Logprob     float32       `json:"logprob"`
+	Bytes       []int         `json:"bytes"`
+	TopLogprobs []restLogprob `json:"top_logprobs"`
+}
+
+func (r *restConn) getCompletion(ctx context.Context, req *v1.GetCompletionsRequest, reply *v1.GetChatCompletionResponse, opts []grpc.CallOption) error {
+	body, err := chatRequestToREST(req)
+	if err != nil {
+		return err
+	}
+	var out restChatResponse
+	if err := r.call(ctx, http.MethodPost, "/chat/completions", body, &out, opts); err != nil {
+		return err
+	}
+	out.toProto(reply)
+	return nil
+}
+
+// chatRequestToREST converts a chat request to the REST format, rejecting
+// features the REST API does not offer rather than silently dropping them.
+func chatRequestToREST(req *v1.GetCompletionsRequest) (*restChatRequest, error) {
+	switch {
+	case req.PreviousResponseId != nil:
+		return nil, restError("previous_response_id")
+	case req.GetStoreMessages():
+		return nil, restError("store_messages")
+	case req.GetUseEncryptedContent():
+		return nil, restError("use_encrypted_content")
+	case req.SearchParameters != nil:
+		return nil, restError("search_parameters")
+	}
+
+	out := &restChatRequest{
+		Model:             req.GetModel(),
+		User:              req.GetUser(),
+		N:                 req.N,
+		MaxTokens:         req.MaxTokens,
+		Seed:              req.Seed,
+		Stop:              req.GetStop(),
+		Temperature:       req.Temperature,
+		TopP:              req.TopP,
+		Logprobs:          req.GetLogprobs(),
+		TopLogprobs:       req.TopLogprobs,
+		FrequencyPenalty:  req.FrequencyPenalty,
+		PresencePenalty:   req.PresencePenalty,
+		ParallelToolCalls: req.ParallelToolCalls,
+	}
+
+	for _, msg := range req.GetMessages() {
+		m, err := messageToREST(msg)
+		if err != nil {
+			return nil, err
+		}
+		out.Messages = append(out.Messages, m)
+	}
+
+	for _, tool := range req.GetTools() {
+		fn := tool.GetFunction()
+		if fn == nil {
+			return nil, unsupportedOverREST("server-side tools")
+		}
+		t := restTool{Type: "function", Function: restFunction{
+			Name:        fn.GetName(),
+			Description: fn.GetDescription(),
+			Strict:      fn.GetStrict(),
+		}}
+		if p := fn.GetParameters(); p != "" {
+			t.Function.Parameters = json.RawMessage(p)
+		}
+		out.Tools = append(out.Tools, t)
+	}
+
+	if tc := req.GetToolChoice(); tc != nil {
+		if name := tc.GetFunctionName(); name != "" {
+			out.ToolChoice = map[string]any{"type": "function", "function": map[string]string{"name": name}}
+		} else {
+			switch tc.GetMode() {
+			case v1.ToolMode_TOOL_MODE_AUTO:
+				out.ToolChoice = "auto"
+			case v1.ToolMode_TOOL_MODE_NONE:
+				out.ToolChoice = "none"
+			case v1.ToolMode_TOOL_MODE_REQUIRED:
+				out.ToolChoice = "required"
+			}
+		}
+	}
+
+	if rf := req.GetResponseFormat(); rf != nil {
+		switch rf.GetFormatType() {
+		case v1.FormatType_FORMAT_TYPE_JSON_OBJECT:
+			out.ResponseFormat = &restResponseFormat{Type: "json_object"}
+		case v1.FormatType_FORMAT_TYPE_JSON_SCHEMA:
+			var doc struct {
+				Title string `json:"title"`
+			}
+			_ = json.Unmarshal([]byte(rf.GetSchema()), &doc)
+			name := cmp.Or(doc.Title, "response")
+			out.ResponseFormat = &restResponseFormat{Type: "json_schema", JSONSchema: &restJSONSchema{
+				Name:   name,
+				Schema: json.RawMessage(rf.GetSchema()),
+				Strict: true,
+			}}
+		default:
+			out.ResponseFormat = &restResponseFormat{Type: "text"}
+		}
+	}
+
+	if req.ReasoningEffort != nil {
+		switch req.GetReasoningEffort() {
+		case v1.ReasoningEffort_EFFORT_LOW:
+			out.ReasoningEffort = "low"
+		case v1.ReasoningEffort_EFFORT_MEDIUM:
+			out.ReasoningEffort = "medium"
+		case v1.ReasoningEffort_EFFORT_HIGH:
+			out.ReasoningEffort = "high"
+		}
+	}
+	return out, nil
+}
+
+func messageToREST(msg *v1.Message) (restMessage, error) {
+	out := restMessage{
+		Role:             roleName(msg.GetRole()),
+		ReasoningContent: msg.GetReasoningContent(),
+		Name:             msg.GetName(),
+		ToolCallID:       msg.GetToolCallId(),
+	}
+	if msg.GetEncryptedContent() != "" {
+		return out, restError("encrypted_content")
+	}
+
+	var parts []restContentPart
+	textOnly := true
+	for _, c := range msg.GetContent() {
+		switch {
+		case c.GetImageUrl() != nil:
+			img := c.GetImageUrl()
+			part := restContentPart{Type: "image_url", ImageURL: &restImageURL{URL: img.GetImageUrl()}}
+			switch img.GetDetail() {
+			case v1.ImageDetail_DETAIL_AUTO:
+				part.ImageURL.Detail = "auto"
+			case v1.ImageDetail_DETAIL_LOW:
+				part.ImageURL.Detail = "low"
+			case v1.ImageDetail_DETAIL_HIGH:
+				part.ImageURL.Detail = "high"
+			}
+			parts = append(parts, part)
+			textOnly = false
+		case c.GetFile() != nil:
+			return out, unsupportedOverREST("file content")
+		default:
+			parts = append(parts, restContentPart{Type: "text", Text: c.GetText()})
+		}
+	}
+	switch {
+	case textOnly && len(parts) == 1:
+		out.Content = parts[0].Text
+	case len(parts) > 0:
+		out.Content = parts
+	}
+
+	for _, tc := range msg.GetToolCalls() {
+		if fn := tc.GetFunction(); fn != nil {
+			out.ToolCalls = append(out.ToolCalls, restToolCall{
+				ID:       tc.GetId(),
+				Type:     "function",
+				Function: restFunctionCall{Name: fn.GetName(), Arguments: fn.GetArguments()},
+			})
+		}
+	}
+	return out, nil
+}
+
+func (r *restChatResponse) toProto(out *v1.GetChatCompletionResponse) {
+	out.Id = r.ID
+	out.Model = r.Model
+	out.SystemFingerprint = r.SystemFingerprint
+	out.Citations = r.Citations
+	out.Usage = r.Usage.toProto()
+	if r.Created > 0 {
+		out.Created = timestamppb.New(time.Unix(r.Created, 0))
+	}
+	for _, c := range r.Choices {
+		o := &v1.CompletionOutput{
+			Index:        c.Index,
+			FinishReason: restFinishReason(c.FinishReason),
+			Logprobs:     c.Logprobs.toProto(),
+		}
+		if c.Message != nil {
+			o.Message = &v1.CompletionMessage{
+				Content:          c.Message.Content,
+				ReasoningContent: c.Message.ReasoningContent,
+				Role:             v1.MessageRole_ROLE_ASSISTANT,
+				ToolCalls:        restToolCallsToProto(c.Message.ToolCalls),
+			}
+		}
+		out.Outputs = append(out.Outputs, o)
+	}
+}
+
+func (r *restChatResponse) toChunkProto(out *v1.GetChatCompletionChunk) {
+	out.Id = r.ID
+	out.Model = r.Model
+	out.SystemFingerprint = r.SystemFingerprint
+	out.Citations = r.Citations
+	out.Usage = r.Usage.toProto()
+	if r.Created > 0 {
+		out.Created = timestamppb.New(time.Unix(r.Created, 0))
+	}
+	for _, c := range r.Choices {
+		o := &v1.CompletionOutputChunk{
+			Index:        c.Index,
+			FinishReason: restFinishReason(c.FinishReason),
+			Logprobs:     c.Logprobs.toProto(),
+		}
+		if c.Delta != nil {
+			o.Delta = &v1.Delta{
+				Content:          c.Delta.Content,
+				ReasoningContent: c.Delta.ReasoningContent,
+				Role:             v1.MessageRole_ROLE_ASSISTANT,
+				ToolCalls:        restToolCallsToProto(c.Delta.ToolCalls),
+			}
+		}
+		out.Outputs = append(out.Outputs, o)
+	}
+}
+
+func restToolCallsToProto(calls []restToolCall) []*v1.ToolCall {
+	// The proto has no index, so it is carried by order; calls without
+	// one go last.
+	if slices.ContainsFunc(calls, func(tc restToolCall) bool { return tc.Index != nil }) {
+		calls = slices.Clone(calls)
+		slices.SortStableFunc(calls, func(a, b restToolCall) int {
+			return cmp.Compare(restToolCallIndex(a), restToolCallIndex(b))
+		})
+	}
+	var out []*v1.ToolCall
+	for _, tc := range calls {
+		out = append(out, &v1.ToolCall{
+			Id:   tc.ID,
+			Type: v1.ToolCallType_TOOL_CALL_TYPE_CLIENT_SIDE_TOOL,
+			Tool: &v1.ToolCall_Function{Function: &v1.FunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			}},
+		})
+	}
+	return out
+}
+
+func restToolCallIndex(tc restToolCall) int {
+	if tc.Index == nil {
+		return math.MaxInt
+	}
+	return *tc.Index
+}
+
+func restFinishReason(reason string) v1.FinishReason {
+	switch reason {
+	case "stop", "end_turn":
+		return v1.FinishReason_REASON_STOP
+	case "length":
+		return v1.FinishReason_REASON_MAX_LEN
+	case "tool_calls":
+		return v1.FinishReason_REASON_TOOL_CALLS
+	case "max_context":
+		return v1.FinishReason_REASON_MAX_CONTEXT
+	case "time_limit":
+		return v1.FinishReason_REASON_TIME_LIMIT
+	default:
+		return v1.FinishReason_REASON_INVALID
+	}
+}
+
+func (u *restUsage) toProto() *v1.SamplingUsage {
+	if u == nil {
+		return nil
+	}
+	out := &v1.SamplingUsage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+		NumSourcesUsed:   u.NumSourcesUsed,
+	}
+	if d := u.PromptTokensDetails; d != nil {
+		out.PromptTextTokens = d.TextTokens
+		out.PromptImageTokens = d.ImageTokens
+		out.CachedPromptTextTokens = d.CachedTokens
+	}
+	if d := u.CompletionTokensDetails; d != nil {
+		out.ReasoningTokens = d.ReasoningTokens
+	}
+	return out
+}
+
+func (l *restLogprobs) toProto() *v1.LogProbs {
+	if l == nil || len(l.Content) == 0 {
+		return nil
+	}
+	out := &v1.LogProbs{}
+	for _, p := range l.Content {
+		lp := &v1.LogProb{Token: p.Token, Logprob: p.Logprob, Bytes: restBytes(p.Bytes)}
+		for _, top := range p.TopLogprobs {
+			lp.TopLogprobs = append(lp.TopLogprobs, &v1.TopLogProb{Token: top.Token, Logprob: top.Logprob, Bytes: restBytes(top.Bytes)})
+		}
+		out.Content = append(out.Content, lp)
+	}
+	return out
+}
+
+func restBytes(b []int) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	out := make([]byte, len(b))
+	for i, v := range b {
+		out[i] = byte(v)
+	}
+	return out
+}
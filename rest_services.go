@@ -0,0 +1,388 @@
+package xai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// invokeService performs the REST calls for the embedding, image, sampling,
+// tokenization and model services. It reports false for methods it does
+// not handle.
+func (r *restConn) invokeService(ctx context.Context, method string, args, reply any, opts []grpc.CallOption) (bool, error) {
+	switch method {
+	case v1.Embedder_Embed_FullMethodName:
+		return true, r.embed(ctx, args.(*v1.EmbedRequest), reply.(*v1.EmbedResponse), opts)
+	case v1.Image_GenerateImage_FullMethodName:
+		return true, r.generateImage(ctx, args.(*v1.GenerateImageRequest), reply.(*v1.ImageResponse), opts)
+	case v1.Sample_SampleText_FullMethodName:
+		return true, r.sampleText(ctx, args.(*v1.SampleTextRequest), reply.(*v1.SampleTextResponse), opts)
+	case v1.Tokenize_TokenizeText_FullMethodName:
+		return true, r.tokenize(ctx, args.(*v1.TokenizeTextRequest), reply.(*v1.TokenizeTextResponse), opts)
+	case v1.Models_ListEmbeddingModels_FullMethodName:
+		var out struct {
+			Models []restEmbeddingModel `json:"models"`
+		}
+		err := r.call(ctx, http.MethodGet, "/embedding-models", nil, &out, opts)
+		if err == nil {
+			resp := reply.(*v1.ListEmbeddingModelsResponse)
+			for _, m := range out.Models {
+				resp.Models = append(resp.Models, m.toProto())
+			}
+		}
+		return true, err
+	case v1.Models_GetEmbeddingModel_FullMethodName:
+		var out restEmbeddingModel
+		name := args.(*v1.GetModelRequest).GetName()
+		err := r.call(ctx, http.MethodGet, "/embedding-models/"+url.PathEscape(name), nil, &out, opts)
+		if err == nil {
+			proto.Merge(reply.(proto.Message), out.toProto())
+		}
+		return true, err
+	case v1.Models_ListImageGenerationModels_FullMethodName:
+		var out struct {
+			Models []restImageModel `json:"models"`
+		}
+		err := r.call(ctx, http.MethodGet, "/image-generation-models", nil, &out, opts)
+		if err == nil {
+			resp := reply.(*v1.ListImageGenerationModelsResponse)
+			for _, m := range out.Models {
+				resp.Models = append(resp.Models, m.toProto())
+			}
+		}
+		return true, err
+	case v1.Models_GetImageGenerationModel_FullMethodName:
+		var out restImageModel
+		name := args.(*v1.GetModelRequest).GetName()
+		err := r.call(ctx, http.MethodGet, "/image-generation-models/"+url.PathEscape(name), nil, &out, opts)
+		if err == nil {
+			proto.Merge(reply.(proto.Message), out.toProto())
+		}
+		return true, err
+	}
+	return false, nil
+}
+
+// REST embedding wire types.
+
+type restEmbedRequest struct {
+	Model          string   `json:"model"`
+	Input          []string `json:"input"`
+	EncodingFormat string   `json:"encoding_format,omitempty"`
+	User           string   `json:"user,omitempty"`
+}
+
+type restEmbedResponse struct {
+	ID    string `json:"id"`
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int32           `json:"index"`
+		Embedding json.RawMessage `json:"embedding"`
+	} `json:"data"`
+	Usage *struct {
+		PromptTokens int32 `json:"prompt_tokens"`
+	} `json:"usage"`
+	SystemFingerprint string `json:"system_fingerprint"`
+}
+
+func (r *restConn) embed(ctx context.Context, req *v1.EmbedRequest, reply *v1.EmbedResponse, opts []grpc.CallOption) error {
+	body := restEmbedRequest{Model: req.GetModel(), User: req.GetUser()}
+	for _, in := range req.GetInput() {
+		if in.GetImageUrl() != nil {
+			return restError("input.image_url")
+		}
+		body.Input = append(body.Input, in.GetString_())
+	}
+	base64Format := req.GetEncodingFormat() == v1.EmbedEncodingFormat_FORMAT_BASE64
+	if base64Format {
+		body.EncodingFormat = "base64"
+	}
+
+	var out restEmbedResponse
+	if err := r.call(ctx, http.MethodPost, "/embeddings", body, &out, opts); err != nil {
+		return err
+	}
+	reply.Id, reply.Model, reply.SystemFingerprint = out.ID, out.Model, out.SystemFingerprint
+	for _, d := range out.Data {
+		vec := &v1.FeatureVector{}
+		if err := json.Unmarshal(d.Embedding, &vec.FloatArray); err != nil {
+			var encoded string
+			if err := json.Unmarshal(d.Embedding, &encoded); err != nil {
+				return status.Errorf(codes.Internal, "decoding embedding %d: %v", d.Index, err)
+			}
+			if base64Format {
+				vec.Base64Array = encoded
+			} else if vec.FloatArray, err = restFloats(encoded); err != nil {
+				return status.Errorf(codes.Internal, "decoding embedding %d: %v", d.Index, err)
+			}
+		}
+		reply.Embeddings = append(reply.Embeddings, &v1.Embedding{Index: d.Index, Embeddings: []*v1.FeatureVector{vec}})
+	}
+	// The REST API reports tokens, not inputs; count the text inputs sent.
+	reply.Usage = &v1.EmbeddingUsage{NumTextEmbeddings: int32(len(body.Input))}
+	return nil
+}
+
+// restFloats decodes a base64 array of little-endian float32 values.
+func restFloats(encoded string) ([]float32, error) {
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float32, len(b)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return out, nil
+}
+
+// REST image generation wire types.
+
+type restImageRequest struct {
+	Model          string `json:"model,omitempty"`
+	Prompt         string `json:"prompt"`
+	N              *int32 `json:"n,omitempty"`
+	User           string `json:"user,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"`
+	AspectRatio    string `json:"aspect_ratio,omitempty"`
+	Resolution     string `json:"resolution,omitempty"`
+}
+
+type restImageResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		URL     string `json:"url"`
+		B64JSON string `json:"b64_json"`
+	} `json:"data"`
+	Usage *restUsage `json:"usage"`
+}
+
+var restAspectRatios = map[v1.ImageAspectRatio]string{
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_1_1:    "1:1",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_3_4:    "3:4",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_4_3:    "4:3",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_9_16:   "9:16",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_16_9:   "16:9",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_2_3:    "2:3",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_3_2:    "3:2",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_AUTO:   "auto",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_9_19_5: "9:19.5",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_19_5_9: "19.5:9",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_9_20:   "9:20",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_20_9:   "20:9",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_1_2:    "1:2",
+	v1.ImageAspectRatio_IMG_ASPECT_RATIO_2_1:    "2:1",
+}
+
+func (r *restConn) generateImage(ctx context.Context, req *v1.GenerateImageRequest, reply *v1.ImageResponse, opts []grpc.CallOption) error {
+	if req.GetImage() != nil {
+		return restError("image")
+	}
+	body := restImageRequest{Model: req.GetModel(), Prompt: req.GetPrompt(), N: req.N, User: req.GetUser()}
+	switch req.GetFormat() {
+	case v1.ImageFormat_IMG_FORMAT_BASE64:
+		body.ResponseFormat = "b64_json"
+	case v1.ImageFormat_IMG_FORMAT_URL:
+		body.ResponseFormat = "url"
+	}
+	if req.AspectRatio != nil {
+		body.AspectRatio = restAspectRatios[req.GetAspectRatio()]
+	}
+	switch req.GetResolution() {
+	case v1.ImageResolution_IMG_RESOLUTION_1K:
+		body.Resolution = "1k"
+	case v1.ImageResolution_IMG_RESOLUTION_2K:
+		body.Resolution = "2k"
+	}
+
+	var out restImageResponse
+	if err := r.call(ctx, http.MethodPost, "/images/generations", body, &out, opts); err != nil {
+		return err
+	}
+	reply.Model, reply.Usage = out.Model, out.Usage.toProto()
+	for _, d := range out.Data {
+		img := &v1.GeneratedImage{}
+		if d.B64JSON != "" {
+			img.Image = &v1.GeneratedImage_Base64{Base64: d.B64JSON}
+		} else {
+			img.Image = &v1.GeneratedImage_Url{Url: d.URL}
+		}
+		reply.Images = append(reply.Images, img)
+	}
+	return nil
+}
+
+// REST text sampling wire types.
+
+type restSampleRequest struct {
+	Model            string   `json:"model,omitempty"`
+	Prompt           []string `json:"prompt"`
+	N                *int32   `json:"n,omitempty"`
+	MaxTokens        *int32   `json:"max_tokens,omitempty"`
+	Seed             *int32   `json:"seed,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	Temperature      *float32 `json:"temperature,omitempty"`
+	TopP             *float32 `json:"top_p,omitempty"`
+	FrequencyPenalty *float32 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float32 `json:"presence_penalty,omitempty"`
+	Logprobs         *int32   `json:"logprobs,omitempty"`
+	User             string   `json:"user,omitempty"`
+}
+
+type restSampleResponse struct {
+	ID                string `json:"id"`
+	Created           int64  `json:"created"`
+	Model             string `json:"model"`
+	SystemFingerprint string `json:"system_fingerprint"`
+	Choices           []struct {
+		Index        int32  `json:"index"`
+		Text         string `json:"text"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *restUsage `json:"usage"`
+}
+
+func (r *restConn) sampleText(ctx context.Context, req *v1.SampleTextRequest, reply *v1.SampleTextResponse, opts []grpc.CallOption) error {
+	body := restSampleRequest{
+		Model:            req.GetModel(),
+		Prompt:           req.GetPrompt(),
+		N:                req.N,
+		MaxTokens:        req.MaxTokens,
+		Seed:             req.Seed,
+		Stop:             req.GetStop(),
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
+		User:             req.GetUser(),
+	}
+	if req.GetLogprobs() {
+		// The legacy completions format takes the number of top log
+		// probabilities in "logprobs".
+		top := req.GetTopLogprobs()
+		body.Logprobs = &top
+	}
+
+	var out restSampleResponse
+	if err := r.call(ctx, http.MethodPost, "/completions", body, &out, opts); err != nil {
+		return err
+	}
+	reply.Id, reply.Model, reply.SystemFingerprint = out.ID, out.Model, out.SystemFingerprint
+	reply.Usage = out.Usage.toProto()
+	if out.Created > 0 {
+		reply.Created = timestamppb.New(time.Unix(out.Created, 0))
+	}
+	for _, c := range out.Choices {
+		reply.Choices = append(reply.Choices, &v1.SampleChoice{
+			Index:        c.Index,
+			Text:         c.Text,
+			FinishReason: restFinishReason(c.FinishReason),
+		})
+	}
+	return nil
+}
+
+// REST tokenization wire types.
+
+type restTokenizeRequest struct {
+	Model string `json:"model"`
+	Text  string `json:"text"`
+	User  string `json:"user,omitempty"`
+}
+
+type restTokenizeResponse struct {
+	TokenIDs []struct {
+		TokenID     uint32 `json:"token_id"`
+		StringToken string `json:"string_token"`
+		TokenBytes  []int  `json:"token_bytes"`
+	} `json:"token_ids"`
+}
+
+func (r *restConn) tokenize(ctx context.Context, req *v1.TokenizeTextRequest, reply *v1.TokenizeTextResponse, opts []grpc.CallOption) error {
+	var out restTokenizeResponse
+	body := restTokenizeRequest{Model: req.GetModel(), Text: req.GetText(), User: req.GetUser()}
+	if err := r.call(ctx, http.MethodPost, "/tokenize-text", body, &out, opts); err != nil {
+		return err
+	}
+	reply.Model = req.GetModel()
+	for _, t := range out.TokenIDs {
+		reply.Tokens = append(reply.Tokens, &v1.Token{
+			TokenId:     t.TokenID,
+			StringToken: t.StringToken,
+			TokenBytes:  restBytes(t.TokenBytes),
+		})
+	}
+	return nil
+}
+
+// restEmbeddingModel is an embedding model as returned by the REST API.
+type restEmbeddingModel struct {
+	ID                    string   `json:"id"`
+	Fingerprint           string   `json:"fingerprint"`
+	Created               int64    `json:"created"`
+	Version               string   `json:"version"`
+	InputModalities       []string `json:"input_modalities"`
+	OutputModalities      []string `json:"output_modalities"`
+	PromptTextTokenPrice  int64    `json:"prompt_text_token_price"`
+	PromptImageTokenPrice int64    `json:"prompt_image_token_price"`
+	Aliases               []string `json:"aliases"`
+}
+
+func (m restEmbeddingModel) toProto() *v1.EmbeddingModel {
+	out := &v1.EmbeddingModel{
+		Name:                  m.ID,
+		Aliases:               m.Aliases,
+		Version:               m.Version,
+		InputModalities:       restModalities(m.InputModalities),
+		OutputModalities:      restModalities(m.OutputModalities),
+		PromptTextTokenPrice:  m.PromptTextTokenPrice,
+		PromptImageTokenPrice: m.PromptImageTokenPrice,
+		SystemFingerprint:     m.Fingerprint,
+	}
+	if m.Created > 0 {
+		out.Created = timestamppb.New(time.Unix(m.Created, 0))
+	}
+	return out
+}
+
+// restImageModel is an image generation model as returned by the REST API.
+type restImageModel struct {
+	ID               string   `json:"id"`
+	Fingerprint      string   `json:"fingerprint"`
+	Created          int64    `json:"created"`
+	Version          string   `json:"version"`
+	InputModalities  []string `json:"input_modalities"`
+	OutputModalities []string `json:"output_modalities"`
+	ImagePrice       int64    `json:"image_price"`
+	MaxPromptLength  int32    `json:"max_prompt_length"`
+	Aliases          []string `json:"aliases"`
+}
+
+func (m restImageModel) toProto() *v1.ImageGenerationModel {
+	out := &v1.ImageGenerationModel{
+		Name:              m.ID,
+		Aliases:           m.Aliases,
+		Version:           m.Version,
+		InputModalities:   restModalities(m.InputModalities),
+		OutputModalities:  restModalities(m.OutputModalities),
+		ImagePrice:        m.ImagePrice,
+		MaxPromptLength:   m.MaxPromptLength,
+		SystemFingerprint: m.Fingerprint,
+	}
+	if m.Created > 0 {
+		out.Created = timestamppb.New(time.Unix(m.Created, 0))
+	}
+	return out
+}
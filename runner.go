@@ -0,0 +1,473 @@
+package xai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc/status"
+)
+
+// DefaultMaxToolTurns is the number of model turns RunChat allows unless
+// ToolRunner.WithMaxTurns is set.
+const DefaultMaxToolTurns = 10
+
+// ErrMaxTurnsExceeded is the cause of the error RunChat returns when the
+// model still wants tools after the runner's last turn. Match it with
+// errors.Is.
+var ErrMaxTurnsExceeded = errors.New("tool runner: max turns exceeded")
+
+// ErrRunAborted is the cause of the error a run returns after Run.AbortRun.
+// Match it with errors.Is.
+var ErrRunAborted = errors.New("run aborted")
+
+// ToolHandler executes a client-side function tool call. args holds the
+// call's JSON arguments; the returned string is sent to the model as the
+// tool result. ctx is the run's context: it is canceled when the caller's
+// context is, or when the run is aborted, and slow handlers should return
+// promptly once it is done.
+type ToolHandler func(ctx context.Context, args json.RawMessage) (string, error)
+
+// ToolRunner pairs function tools with the Go handlers that execute them,
+// for use with Client.RunChat. Register all tools before the first run; a
+// ToolRunner may then be shared by concurrent runs.
+type ToolRunner struct {
+	tools    []*FunctionTool
+	handlers map[string]ToolHandler
+	maxTurns int
+	hooks    RunHooks
+}
+
+// RunHooks are callbacks invoked as a run progresses, so UIs can show live
+// status ("searching...", "running get_weather...") without handling the
+// loop or the stream themselves. Any of them may be nil. They are called
+// from the goroutine running RunChat, in order.
+type RunHooks struct {
+	// OnTurnStart is called before each completion, with turn counting
+	// from 1.
+	OnTurnStart func(turn int)
+	// OnToolCall is called for every tool call the model makes, both
+	// server-side calls xAI runs and client-side calls about to be
+	// executed.
+	OnToolCall func(call *ToolCallInfo)
+	// OnToolResult is called after a client-side call was executed, with
+	// the result sent to the model and the handler's error, if any.
+	OnToolResult func(call *ToolCallInfo, result string, err error)
+	// OnAssistantDelta receives the answer text as it is generated. Setting
+	// it makes the run stream its completions.
+	OnAssistantDelta func(delta string)
+}
+
+// NewToolRunner creates an empty tool runner.
+func NewToolRunner() *ToolRunner {
+	return &ToolRunner{
+		handlers: make(map[string]ToolHandler),
+		maxTurns: DefaultMaxToolTurns,
+	}
+}
+
+// Register adds tool and the handler that executes its calls. Registering a
+// name again replaces the earlier tool.
+func (r *ToolRunner) Register(tool *FunctionTool, handler ToolHandler) *ToolRunner {
+	if _, ok := r.handlers[tool.Name]; ok {
+		for i, t := range r.tools {
+			if t.Name == tool.Name {
+				r.tools = append(r.tools[:i], r.tools[i+1:]...)
+				break
+			}
+		}
+	}
+	r.tools = append(r.tools, tool)
+	r.handlers[tool.Name] = handler
+	return r
+}
+
+// WithMaxTurns sets how many completions a run may make before it fails
+// with ErrMaxTurnsExceeded. The default is DefaultMaxToolTurns.
+func (r *ToolRunner) WithMaxTurns(n int) *ToolRunner {
+	if n > 0 {
+		r.maxTurns = n
+	}
+	return r
+}
+
+// WithHooks sets the callbacks invoked during runs.
+func (r *ToolRunner) WithHooks(hooks RunHooks) *ToolRunner {
+	r.hooks = hooks
+	return r
+}
+
+// Tools returns the registered tools.
+func (r *ToolRunner) Tools() []Tool {
+	tools := make([]Tool, len(r.tools))
+	for i, t := range r.tools {
+		tools[i] = t
+	}
+	return tools
+}
+
+// execute runs one client-side call and returns the text to send back.
+// Handler failures and unknown tools are reported to the model as the
+// result so it can recover, rather than failing the run; the error is
+// returned as well for OnToolResult.
+func (r *ToolRunner) execute(ctx context.Context, call *ToolCallInfo) (string, error) {
+	var handler ToolHandler
+	err := errors.New("tool call has no function")
+	if call.Function != nil {
+		var ok bool
+		if handler, ok = r.handlers[call.Function.Name]; ok {
+			err = nil
+		} else {
+			err = fmt.Errorf("unknown tool %q", call.Function.Name)
+		}
+	}
+	if err != nil {
+		return "error: " + err.Error(), err
+	}
+	args := json.RawMessage(call.Function.Arguments)
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	result, err := handler(ctx, args)
+	if err != nil {
+		return "error: " + err.Error(), err
+	}
+	return result, nil
+}
+
+// complete runs one turn, streaming it when the answer text is wanted as it
+// is generated.
+func (r *ToolRunner) complete(ctx context.Context, c *Client, req *ChatRequest, opts []CallOption) (*ChatResponse, error) {
+	if r.hooks.OnAssistantDelta == nil {
+		resp, err := c.CompleteChat(ctx, req, opts...)
+		if err == nil && r.hooks.OnToolCall != nil {
+			for _, tc := range resp.ToolCalls {
+				if tc != nil && tc.IsServerSide() {
+					r.hooks.OnToolCall(tc)
+				}
+			}
+		}
+		return resp, err
+	}
+	stream, err := c.StreamChat(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	resp, err := accumulateStream(stream, func(chunk *ChatChunk) {
+		if chunk.Delta != "" {
+			r.hooks.OnAssistantDelta(chunk.Delta)
+		}
+		if r.hooks.OnToolCall != nil {
+			for _, tc := range chunk.ToolCalls {
+				if tc != nil && tc.IsServerSide() {
+					r.hooks.OnToolCall(tc)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.RequestMetadata = req.Metadata()
+	return resp, nil
+}
+
+// accumulateStream reads stream to the end, calling onChunk for each chunk,
+// and assembles the chunks into a response.
+func accumulateStream(stream ChatStream, onChunk func(*ChatChunk)) (*ChatResponse, error) {
+	var acc streamAccumulator
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+		acc.add(chunk)
+	}
+	return acc.response(), nil
+}
+
+// streamAccumulator assembles streamed chunks into a response.
+type streamAccumulator struct {
+	resp                          ChatResponse
+	content, reasoning, encrypted []byte
+}
+
+func (a *streamAccumulator) add(chunk *ChatChunk) {
+	if chunk.ReasoningRestarted {
+		a.reasoning = a.reasoning[:0]
+	}
+	a.content = append(a.content, chunk.Delta...)
+	a.reasoning = append(a.reasoning, chunk.ReasoningDelta...)
+	a.encrypted = append(a.encrypted, chunk.EncryptedContent...)
+	for _, tc := range chunk.ToolCalls {
+		a.addToolCall(tc)
+	}
+	a.resp.Logprobs = append(a.resp.Logprobs, chunk.Logprobs...)
+	if chunk.ID != "" {
+		a.resp.ID = chunk.ID
+	}
+	if chunk.Model != "" {
+		a.resp.Model = chunk.Model
+	}
+	if chunk.FinishReason != "" {
+		a.resp.FinishReason = chunk.FinishReason
+	}
+	if len(chunk.Citations) > 0 {
+		a.resp.Citations = chunk.Citations
+	}
+	if chunk.Usage != (Usage{}) {
+		a.resp.Usage = chunk.Usage
+	}
+}
+
+// addToolCall adds tc to the response, replacing the earlier mention of the
+// same call ID so status updates don't list a call twice. Calls without an ID
+// are always new.
+func (a *streamAccumulator) addToolCall(tc *ToolCallInfo) {
+	if tc.ID != "" {
+		for i, prev := range a.resp.ToolCalls {
+			if prev.ID == tc.ID {
+				a.resp.ToolCalls[i] = tc
+				return
+			}
+		}
+	}
+	a.resp.ToolCalls = append(a.resp.ToolCalls, tc)
+}
+
+// response returns the response assembled so far.
+func (a *streamAccumulator) response() *ChatResponse {
+	resp := a.resp
+	resp.Content = string(a.content)
+	resp.ReasoningContent = string(a.reasoning)
+	resp.EncryptedContent = string(a.encrypted)
+	return &resp
+}
+
+// RunChat completes req, executing the client-side tool calls the model
+// makes with runner's handlers and sending the results back until the
+// model gives a final answer. The runner's tools are added to req if it
+// does not have them yet. Server-side tools are run by xAI as usual.
+//
+// Each assistant turn and tool result is appended to req, so on return req
+// holds the whole exchange and can be continued. The returned response is
+// the final one; its Usage covers that turn only.
+//
+// RunChat stops with the context's error as soon as ctx is canceled, also
+// between turns and tool calls. Use StartRun for a run that can be aborted
+// or paused.
+func (c *Client) RunChat(ctx context.Context, req *ChatRequest, runner *ToolRunner, opts ...CallOption) (*ChatResponse, error) {
+	resp, _, err := c.runChat(ctx, req, runner, opts, nil, nil)
+	return resp, err
+}
+
+// runChat is the agent loop. It starts from state, if given, by executing
+// its pending calls. When pause is closed, or a handler returns
+// ErrPauseRun, it stops at the next call or turn and returns the state to
+// resume from.
+func (c *Client) runChat(ctx context.Context, req *ChatRequest, runner *ToolRunner, opts []CallOption, state *RunState, pause <-chan struct{}) (*ChatResponse, *RunState, error) {
+	have := make(map[string]bool)
+	for _, t := range req.tools {
+		if f := functionTool(t); f != nil {
+			have[f.Name] = true
+		}
+	}
+	for _, t := range runner.tools {
+		if !have[t.Name] {
+			req.AddTool(t)
+		}
+	}
+
+	turn := 0
+	var pending []*ToolCallInfo
+	if state != nil {
+		turn, pending = state.Turn, state.Pending
+	}
+	paused := func() bool {
+		select {
+		case <-pause:
+			return true
+		default:
+			return false
+		}
+	}
+	stop := func(calls []*ToolCallInfo) (*ChatResponse, *RunState, error) {
+		if err := ctx.Err(); err != nil {
+			if errors.Is(context.Cause(ctx), ErrRunAborted) {
+				return nil, nil, &Error{Code: ErrCanceled, Message: ErrRunAborted.Error(), Cause: ErrRunAborted}
+			}
+			return nil, nil, FromGRPCError(status.FromContextError(err).Err())
+		}
+		st, err := newRunState(turn, req, calls)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, st, &Error{Code: ErrCanceled, Message: ErrRunPaused.Error(), Cause: ErrRunPaused}
+	}
+
+	hooks := runner.hooks
+	for {
+		for i, call := range pending {
+			if ctx.Err() != nil || paused() {
+				return stop(pending[i:])
+			}
+			if hooks.OnToolCall != nil {
+				hooks.OnToolCall(call)
+			}
+			result, err := runner.execute(ctx, call)
+			if errors.Is(err, ErrPauseRun) || ctx.Err() != nil {
+				return stop(pending[i:])
+			}
+			if hooks.OnToolResult != nil {
+				hooks.OnToolResult(call, result, err)
+			}
+			req.ToolResult(ToolContent{CallID: call.ID, Result: result})
+		}
+		pending = nil
+
+		if ctx.Err() != nil || paused() {
+			return stop(nil)
+		}
+		turn++
+		if hooks.OnTurnStart != nil {
+			hooks.OnTurnStart(turn)
+		}
+		resp, err := runner.complete(ctx, c, req, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return stop(nil)
+			}
+			return nil, nil, err
+		}
+		for _, tc := range resp.ToolCalls {
+			if tc != nil && tc.IsClientSide() {
+				pending = append(pending, tc)
+			}
+		}
+		if len(pending) == 0 {
+			return resp, nil, nil
+		}
+		if turn >= runner.maxTurns {
+			return resp, nil, &Error{
+				Code:    ErrResourceExhausted,
+				Message: fmt.Sprintf("%v after %d turns", ErrMaxTurnsExceeded, turn),
+				Cause:   ErrMaxTurnsExceeded,
+			}
+		}
+		req.AppendResponse(resp)
+	}
+}
+
+// Run is an agent run started in the background with StartRun or
+// ResumeRun.
+type Run struct {
+	client *Client
+	req    *ChatRequest
+	runner *ToolRunner
+	opts   []CallOption
+
+	cancel    context.CancelCauseFunc
+	pause     chan struct{}
+	pauseOnce sync.Once
+	done      chan struct{}
+	resp      *ChatResponse
+	state     *RunState
+	err       error
+}
+
+// StartRun starts RunChat in the background and returns a handle to wait
+// for, pause or abort it. req must not be used until the run is done.
+func (c *Client) StartRun(ctx context.Context, req *ChatRequest, runner *ToolRunner, opts ...CallOption) *Run {
+	return c.startRun(ctx, req, runner, opts, nil)
+}
+
+// ResumeRun continues a paused run from state, e.g. one saved before a
+// restart. req supplies the settings (model, tools, options) of the
+// original request; its messages are replaced by the state's history.
+// Calls still pending in state are executed first, unless answered
+// beforehand with RunState.Resolve.
+func (c *Client) ResumeRun(ctx context.Context, req *ChatRequest, state *RunState, runner *ToolRunner, opts ...CallOption) (*Run, error) {
+	if err := state.restore(req); err != nil {
+		return nil, err
+	}
+	return c.startRun(ctx, req, runner, opts, state), nil
+}
+
+func (c *Client) startRun(ctx context.Context, req *ChatRequest, runner *ToolRunner, opts []CallOption, state *RunState) *Run {
+	ctx, cancel := context.WithCancelCause(ctx)
+	run := &Run{
+		client: c,
+		req:    req,
+		runner: runner,
+		opts:   opts,
+		cancel: cancel,
+		pause:  make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(run.done)
+		defer cancel(nil)
+		run.resp, run.state, run.err = c.runChat(ctx, req, runner, opts, state, run.pause)
+	}()
+	return run
+}
+
+// AbortRun stops the run: the in-flight completion and the context passed
+// to tool handlers are canceled, and the run ends with an ErrCanceled error
+// wrapping ErrRunAborted. It is safe to call more than once and after the
+// run finished, in which case it has no effect.
+func (r *Run) AbortRun() {
+	r.cancel(ErrRunAborted)
+}
+
+// Pause asks the run to stop before its next tool call or completion; an
+// in-flight completion or handler is allowed to finish. The run then ends
+// with an ErrCanceled error wrapping ErrRunPaused, and State returns what
+// is needed to resume it. Pausing a finished run has no effect.
+func (r *Run) Pause() {
+	r.pauseOnce.Do(func() { close(r.pause) })
+}
+
+// State returns the state of a paused run, or nil if the run is still
+// going or ended otherwise.
+func (r *Run) State() *RunState {
+	select {
+	case <-r.done:
+		return r.state
+	default:
+		return nil
+	}
+}
+
+// Resume continues a paused run in this process with the same request,
+// runner and call options, returning the new run. It fails if the run was
+// not paused.
+func (r *Run) Resume(ctx context.Context) (*Run, error) {
+	state := r.State()
+	if state == nil {
+		return nil, &Error{Code: ErrInvalidRequest, Message: "resume: run is not paused"}
+	}
+	return r.client.ResumeRun(ctx, r.req, state, r.runner, r.opts...)
+}
+
+// Done is closed when the run has finished.
+func (r *Run) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait blocks until the run has finished and returns its result, as
+// RunChat would.
+func (r *Run) Wait() (*ChatResponse, error) {
+	<-r.done
+	return r.resp, r.err
+}
@@ -0,0 +1,85 @@
+package xai
+
+import (
+	"encoding/json"
+	"errors"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ErrRunPaused is the cause of the error a run returns after Run.Pause or
+// ErrPauseRun. Match it with errors.Is.
+var ErrRunPaused = errors.New("run paused")
+
+// ErrPauseRun may be returned (or wrapped) by a ToolHandler to pause the run
+// with its call still pending, e.g. to wait for a human to approve or
+// answer it. Resolve the call on the Run's State and resume. Under RunChat,
+// which cannot be resumed, the run simply fails with ErrRunPaused.
+var ErrPauseRun = errors.New("pause run")
+
+// RunState is a snapshot of a paused run: the conversation so far and the
+// client-side tool calls that still need results. It marshals to JSON, so a
+// run can be saved, the process restarted, and the run continued with
+// Client.ResumeRun.
+type RunState struct {
+	// Turn is the number of completions made so far.
+	Turn int `json:"turn"`
+	// Messages is the conversation history, each message in protobuf JSON.
+	Messages []json.RawMessage `json:"messages"`
+	// Pending are the tool calls of the last response that have no result
+	// yet. They are executed when the run resumes.
+	Pending []*ToolCallInfo `json:"pending,omitempty"`
+}
+
+func newRunState(turn int, req *ChatRequest, pending []*ToolCallInfo) (*RunState, error) {
+	state := &RunState{Turn: turn, Pending: append([]*ToolCallInfo(nil), pending...)}
+	for _, msg := range req.messages {
+		b, err := protojson.Marshal(msg)
+		if err != nil {
+			return nil, &Error{Code: ErrInvalidRequest, Message: "saving run state", Cause: err}
+		}
+		state.Messages = append(state.Messages, b)
+	}
+	return state, nil
+}
+
+// Resolve answers the pending call with the given ID, for example with a
+// human's reply, so it is not executed when the run resumes. It reports
+// whether the call was pending.
+func (s *RunState) Resolve(callID, result string) bool {
+	for i, call := range s.Pending {
+		if call.ID != callID {
+			continue
+		}
+		msg, err := protojson.Marshal(&v1.Message{
+			Role:       v1.MessageRole_ROLE_TOOL,
+			ToolCallId: &callID,
+			Content:    []*v1.Content{{Content: &v1.Content_Text{Text: result}}},
+		})
+		if err != nil {
+			return false
+		}
+		s.Messages = append(s.Messages, msg)
+		s.Pending = append(s.Pending[:i:i], s.Pending[i+1:]...)
+		return true
+	}
+	return false
+}
+
+// restore replaces req's history with the state's.
+func (s *RunState) restore(req *ChatRequest) error {
+	if s == nil {
+		return &Error{Code: ErrInvalidRequest, Message: "resume: no run state"}
+	}
+	msgs := make([]*v1.Message, 0, len(s.Messages))
+	for _, raw := range s.Messages {
+		msg := &v1.Message{}
+		if err := protojson.Unmarshal(raw, msg); err != nil {
+			return &Error{Code: ErrInvalidRequest, Message: "resume: invalid run state", Cause: err}
+		}
+		msgs = append(msgs, msg)
+	}
+	req.messages = msgs
+	return nil
+}
@@ -9,13 +9,19 @@ import (
 
 // SampleRequest builds a text sampling request.
 type SampleRequest struct {
-	prompts     []string
-	model       string
-	maxTokens   *int32
-	seed        *int32
-	stop        []string
-	temperature *float32
-	topP        *float32
+	prompts          []string
+	model            string
+	user             string
+	n                *int32
+	maxTokens        *int32
+	seed             *int32
+	stop             []string
+	temperature      *float32
+	topP             *float32
+	frequencyPenalty *float32
+	presencePenalty  *float32
+	logprobs         bool
+	topLogprobs      *int32
 }
 
 // NewSampleRequest creates a new sampling request.
@@ -35,6 +41,19 @@ func (r *SampleRequest) AddPrompts(prompts ...string) *SampleRequest {
 	return r
 }
 
+// WithN asks for n completions per prompt (at most 128), each returned as
+// a SampleOutput with its own Index.
+func (r *SampleRequest) WithN(n int32) *SampleRequest {
+	r.n = &n
+	return r
+}
+
+// WithUser sets an opaque user identifier for logging.
+func (r *SampleRequest) WithUser(user string) *SampleRequest {
+	r.user = user
+	return r
+}
+
 // WithMaxTokens sets the maximum tokens to generate.
 func (r *SampleRequest) WithMaxTokens(n int32) *SampleRequest {
 	r.maxTokens = &n
@@ -65,11 +84,39 @@ func (r *SampleRequest) WithTopP(p float32) *SampleRequest {
 	return r
 }
 
+// WithFrequencyPenalty sets the frequency penalty (-2 to 2).
+func (r *SampleRequest) WithFrequencyPenalty(p float32) *SampleRequest {
+	r.frequencyPenalty = &p
+	return r
+}
+
+// WithPresencePenalty sets the presence penalty (-2 to 2).
+func (r *SampleRequest) WithPresencePenalty(p float32) *SampleRequest {
+	r.presencePenalty = &p
+	return r
+}
+
+// WithLogprobs requests log probabilities with up to topLogprobs
+// alternatives per token. The option is passed through for parity with the
+// API; the sampling response has no field for them, so they do not appear
+// in SampleResponse.
+func (r *SampleRequest) WithLogprobs(topLogprobs int32) *SampleRequest {
+	r.logprobs = true
+	r.topLogprobs = &topLogprobs
+	return r
+}
+
 func (r *SampleRequest) toProto() *v1.SampleTextRequest {
 	req := &v1.SampleTextRequest{
-		Prompt: r.prompts,
-		Model:  r.model,
-		Stop:   r.stop,
+		Prompt:           r.prompts,
+		Model:            r.model,
+		User:             r.user,
+		N:                r.n,
+		Stop:             r.stop,
+		FrequencyPenalty: r.frequencyPenalty,
+		PresencePenalty:  r.presencePenalty,
+		Logprobs:         r.logprobs,
+		TopLogprobs:      r.topLogprobs,
 	}
 	if r.maxTokens != nil {
 		req.MaxTokens = r.maxTokens
@@ -104,19 +151,31 @@ type SampleResponse struct {
 	Model string
 	// Usage contains token usage information.
 	Usage Usage
+	// Metadata holds the response headers and trailers of SampleText; it is
+	// nil on streamed chunks, see SampleStream.Metadata.
+	Metadata *ResponseMetadata
 }
 
 // SampleText performs a text sampling request.
-func (c *Client) SampleText(ctx context.Context, req *SampleRequest) (*SampleResponse, error) {
-	ctx, cancel := c.withTimeout(ctx)
+func (c *Client) SampleText(ctx context.Context, req *SampleRequest, opts ...CallOption) (*SampleResponse, error) {
+	o := resolveCallOptions(opts)
+	ctx, cancel := c.callContext(ctx, o)
 	defer cancel()
 
-	resp, err := c.sampler.SampleText(ctx, req.toProto())
+	protoReq := req.toProto()
+	if o.model != "" {
+		protoReq.Model = o.model
+	}
+
+	md := &ResponseMetadata{}
+	resp, err := c.sampler.SampleText(ctx, protoReq, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
-	return sampleResponseFromProto(resp), nil
+	result := sampleResponseFromProto(resp)
+	result.Metadata = md
+	return result, nil
 }
 
 func sampleResponseFromProto(resp *v1.SampleTextResponse) *SampleResponse {
@@ -139,7 +198,10 @@ func sampleResponseFromProto(resp *v1.SampleTextResponse) *SampleResponse {
 // SampleStream is an iterator for streaming sample responses.
 type SampleStream struct {
 	stream v1.Sample_SampleTextStreamingClient
+	cancel context.CancelFunc
 	err    error
+	// md receives the stream's headers and trailers.
+	md *ResponseMetadata
 }
 
 // Next returns the next sample chunk, or io.EOF when done.
@@ -149,19 +211,31 @@ func (s *SampleStream) Next() (*SampleResponse, error) {
 	}
 
 	resp, err := s.stream.Recv()
+	if err != nil && s.cancel != nil {
+		s.cancel()
+	}
 	if err == io.EOF {
 		return nil, io.EOF
 	}
 	if err != nil {
-		s.err = FromGRPCError(err)
+		s.err = errorWithMetadata(err, s.md)
 		return nil, s.err
 	}
 
 	return sampleResponseFromProto(resp), nil
 }
 
-// Close closes the stream.
+// Metadata returns the stream's response headers and trailers. They are
+// complete once Next has returned io.EOF or an error.
+func (s *SampleStream) Metadata() *ResponseMetadata {
+	return s.md
+}
+
+// Close closes the stream, canceling it if the server has not finished.
 func (s *SampleStream) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
 	return nil
 }
 
@@ -174,11 +248,21 @@ func (s *SampleStream) Err() error {
 }
 
 // SampleTextStream starts a streaming text sampling request.
-func (c *Client) SampleTextStream(ctx context.Context, req *SampleRequest) (*SampleStream, error) {
-	stream, err := c.sampler.SampleTextStreaming(ctx, req.toProto())
+func (c *Client) SampleTextStream(ctx context.Context, req *SampleRequest, opts ...CallOption) (*SampleStream, error) {
+	o := resolveCallOptions(opts)
+	ctx, cancel := c.streamContext(ctx, o)
+
+	protoReq := req.toProto()
+	if o.model != "" {
+		protoReq.Model = o.model
+	}
+
+	md := &ResponseMetadata{}
+	stream, err := c.sampler.SampleTextStreaming(ctx, protoReq, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		cancel()
+		return nil, errorWithMetadata(err, md)
 	}
 
-	return &SampleStream{stream: stream}, nil
+	return &SampleStream{stream: stream, cancel: cancel, md: md}, nil
 }
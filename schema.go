@@ -0,0 +1,137 @@
+package xai
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// SchemaFor returns the JSON Schema of T, derived as described for schemaOf.
+// It suits FunctionTool.WithParameters and ChatRequest.WithJSONSchema; for
+// function parameters T should be a struct, since tools take an object.
+//
+//	type WeatherArgs struct {
+//		City string `json:"city" description:"city name"`
+//		Unit string `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+//	}
+//	tool := xai.NewFunctionTool("get_weather", "Current weather").
+//		WithParameters(xai.SchemaFor[WeatherArgs]())
+func SchemaFor[T any]() json.RawMessage {
+	return schemaJSON(reflect.TypeFor[T]())
+}
+
+// schemaJSON marshals the schema of t. The schema holds only strings,
+// booleans, maps and slices, so marshaling cannot fail.
+func schemaJSON(t reflect.Type) json.RawMessage {
+	b, _ := json.Marshal(schemaOf(t))
+	return b
+}
+
+// schemaOf derives a JSON Schema for values of t as encoding/json would
+// marshal them. Struct fields follow their json tags; fields without
+// omitempty are required. A description tag documents a field and an enum
+// tag lists its allowed values, comma-separated:
+//
+//	Unit string `json:"unit" description:"temperature unit" enum:"celsius,fahrenheit"`
+func schemaOf(t reflect.Type) map[string]any {
+	return schemaFor(t, map[reflect.Type]bool{})
+}
+
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]any{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			// Recursive types cannot be expanded inline.
+			return map[string]any{"type": "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		props := map[string]any{}
+		required := []string{}
+		addStructFields(t, visiting, props, &required)
+		return map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]any{}
+	}
+}
+
+// addStructFields adds the fields of t, including those promoted from
+// embedded structs, to props.
+func addStructFields(t reflect.Type, visiting map[reflect.Type]bool, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, visiting, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s := schemaFor(f.Type, visiting)
+		if d := f.Tag.Get("description"); d != "" {
+			s["description"] = d
+		}
+		if e := f.Tag.Get("enum"); e != "" {
+			s["enum"] = strings.Split(e, ",")
+		}
+		props[name] = s
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+}
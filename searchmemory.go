@@ -0,0 +1,119 @@
+package xai
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// searchDigestPrefix starts the developer message listing earlier searches.
+const searchDigestPrefix = "Searches already made in this conversation. Reuse their findings instead of repeating them:\n"
+
+// DefaultSearchMemorySize is the number of searches EnableSearchMemory
+// remembers when given 0.
+const DefaultSearchMemorySize = 20
+
+// SearchRecord is a server-side search remembered by a Conversation.
+type SearchRecord struct {
+	// Tool is the server-side tool, e.g. "web_search".
+	Tool string `json:"tool"`
+	// Arguments are the JSON arguments of the call, usually holding the query.
+	Arguments string `json:"arguments"`
+	// Sources are the citations of the response that made the search.
+	Sources []string `json:"sources,omitempty"`
+}
+
+type searchMemory struct {
+	size    int
+	records []SearchRecord
+	digest  *v1.Message
+}
+
+// EnableSearchMemory makes the conversation remember the server-side
+// searches (web, X, collections, ...) of each appended response and keep a
+// short "already searched" digest in the history, so the model reuses
+// earlier findings instead of paying for the same search again. At most
+// size searches are kept, the most recent ones (DefaultSearchMemorySize
+// if 0). Responses only report the calls and the response's citations, so
+// each search is listed with every source of its response.
+func (cv *Conversation) EnableSearchMemory(size int) *Conversation {
+	if size <= 0 {
+		size = DefaultSearchMemorySize
+	}
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	if cv.searches == nil {
+		cv.searches = &searchMemory{}
+	}
+	cv.searches.size = size
+	return cv
+}
+
+// Searches returns the searches remembered with EnableSearchMemory, oldest
+// first.
+func (cv *Conversation) Searches() []SearchRecord {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	if cv.searches == nil {
+		return nil
+	}
+	return append([]SearchRecord(nil), cv.searches.records...)
+}
+
+// rememberSearches records resp's server-side calls and refreshes the
+// digest message. cv.mu must be held.
+func (cv *Conversation) rememberSearches(resp *ChatResponse) {
+	m := cv.searches
+	if m == nil {
+		return
+	}
+	added := false
+	for _, tc := range resp.ToolCalls {
+		if tc == nil || !tc.IsServerSide() || tc.Function == nil || tc.Status == ToolCallStatusFailed {
+			continue
+		}
+		rec := SearchRecord{Tool: tc.Function.Name, Arguments: tc.Function.Arguments, Sources: resp.Citations}
+		// A repeated search moves to the end with the latest sources.
+		for i, old := range m.records {
+			if old.Tool == rec.Tool && old.Arguments == rec.Arguments {
+				m.records = append(m.records[:i], m.records[i+1:]...)
+				break
+			}
+		}
+		m.records = append(m.records, rec)
+		added = true
+	}
+	if !added {
+		return
+	}
+	if over := len(m.records) - m.size; over > 0 {
+		m.records = m.records[over:]
+	}
+
+	var b strings.Builder
+	b.WriteString(searchDigestPrefix)
+	for _, r := range m.records {
+		fmt.Fprintf(&b, "- %s %s", r.Tool, r.Arguments)
+		if len(r.Sources) > 0 {
+			fmt.Fprintf(&b, " -> %s", strings.Join(r.Sources, ", "))
+		}
+		b.WriteByte('\n')
+	}
+	digest := &v1.Message{
+		Role:    v1.MessageRole_ROLE_DEVELOPER,
+		Content: []*v1.Content{{Content: &v1.Content_Text{Text: strings.TrimSpace(b.String())}}},
+	}
+
+	// Replace the previous digest, keeping the latest one just before the
+	// next user turn.
+	messages := make([]*v1.Message, 0, len(cv.req.messages)+1)
+	for _, msg := range cv.req.messages {
+		if msg != m.digest {
+			messages = append(messages, msg)
+		}
+	}
+	delete(cv.tokenCounts, m.digest)
+	cv.req.messages = append(messages, digest)
+	m.digest = digest
+}
@@ -0,0 +1,160 @@
+package xai
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Features a server may advertise. They name the optional chat request
+// fields a server must support for a request setting them to be sent (see
+// Client.ServerInfo).
+const (
+	FeatureReasoningEffort   = "reasoning_effort"
+	FeatureParallelToolCalls = "parallel_tool_calls"
+	FeatureStoredCompletions = "stored_completions" // WithStoreMessages, WithPreviousResponseId
+	FeatureInclude           = "include"            // the Include* options
+	FeatureMaxTurns          = "max_turns"
+	FeatureEncryptedContent  = "encrypted_content"
+)
+
+// Response metadata keys ServerInfo reads, in order of preference. The API
+// does not define them: they are this library's convention, for proxies and
+// gateways in front of xAI to advertise what the deployment behind them
+// accepts. The api.x.ai endpoints send none of them.
+var (
+	apiVersionHeaders  = []string{"x-api-version", "xai-api-version", "api-version"}
+	apiFeaturesHeaders = []string{"x-api-features", "xai-api-features"}
+)
+
+// ServerInfo describes the API deployment the client talks to, as reported
+// in response metadata by the server or a proxy in front of it.
+type ServerInfo struct {
+	// APIVersion is the advertised API version, or "" if none.
+	APIVersion string
+	// Server is the server software, from the "server" header, if set.
+	Server string
+	// Features are the optional features the server advertises. It is nil
+	// if the server advertises none, in which case every feature is
+	// assumed to be supported.
+	Features []string
+	// Metadata is the response metadata the information was read from.
+	Metadata *ResponseMetadata
+	// FetchedAt is when the information was fetched.
+	FetchedAt time.Time
+}
+
+// Supports reports whether the server supports feature. Servers that do not
+// advertise features are assumed to support all of them.
+func (s *ServerInfo) Supports(feature string) bool {
+	if s == nil || s.Features == nil {
+		return true
+	}
+	return slices.Contains(s.Features, feature)
+}
+
+// ServerInfo returns the API version and features of the server, fetched
+// with a cheap authenticated call (GetApiKeyInfo) on first use and cached
+// for the life of the client.
+//
+// Once fetched, chat requests that set optional fields of features the
+// server advertises it lacks (see the Feature constants) fail with
+// ErrInvalidRequest naming the feature, before they are sent. With
+// Config.DropUnsupportedFeatures those fields are instead cleared and the
+// request is sent without them, so the client keeps working against older
+// deployments and proxies that reject unknown fields. Call it at startup to
+// enable this.
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	if info := c.server.Load(); info != nil {
+		return info, nil
+	}
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	md := &ResponseMetadata{}
+	start := c.config.Clock.Now()
+	if _, err := c.auth.GetApiKeyInfo(ctx, &emptypb.Empty{}, md.callOptions()...); err != nil {
+		return nil, WrapError(errorWithMetadata(err, md), "server info")
+	}
+	info := serverInfoFromMetadata(md)
+	info.FetchedAt = start
+	c.server.Store(info)
+	return info, nil
+}
+
+func serverInfoFromMetadata(md *ResponseMetadata) *ServerInfo {
+	info := &ServerInfo{Server: md.Get("server"), Metadata: md}
+	for _, key := range apiVersionHeaders {
+		if v := md.Get(key); v != "" {
+			info.APIVersion = v
+			break
+		}
+	}
+	for _, key := range apiFeaturesHeaders {
+		v := md.Get(key)
+		if v == "" {
+			continue
+		}
+		info.Features = []string{}
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+				info.Features = append(info.Features, f)
+			}
+		}
+		break
+	}
+	return info
+}
+
+// gateFeatures checks the fields of req against the features of the server,
+// if known. Fields of unsupported features fail the request, or are cleared
+// with Config.DropUnsupportedFeatures. A previous_response_id always fails:
+// without it the server would answer without the conversation it holds.
+func (c *Client) gateFeatures(req *v1.GetCompletionsRequest) error {
+	info := c.server.Load()
+	if info == nil || info.Features == nil {
+		return nil
+	}
+	var dropped, failed []string
+	// gate handles one field; a nil clear means it cannot be dropped.
+	gate := func(feature string, set bool, clear func()) {
+		if !set || info.Supports(feature) {
+			return
+		}
+		if c.config.DropUnsupportedFeatures && clear != nil {
+			clear()
+			dropped = append(dropped, feature)
+		} else {
+			failed = append(failed, feature)
+		}
+	}
+	gate(FeatureReasoningEffort, req.ReasoningEffort != nil, func() { req.ReasoningEffort = nil })
+	gate(FeatureParallelToolCalls, req.ParallelToolCalls != nil, func() { req.ParallelToolCalls = nil })
+	if req.PreviousResponseId != nil {
+		gate(FeatureStoredCompletions, true, nil)
+	} else {
+		gate(FeatureStoredCompletions, req.StoreMessages, func() { req.StoreMessages = false })
+	}
+	gate(FeatureInclude, len(req.Include) > 0, func() { req.Include = nil })
+	gate(FeatureMaxTurns, req.MaxTurns != nil, func() { req.MaxTurns = nil })
+	gate(FeatureEncryptedContent, req.UseEncryptedContent, func() { req.UseEncryptedContent = false })
+	if len(failed) > 0 {
+		return &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf(
+			"server (API version %q) does not support %s", info.APIVersion, strings.Join(failed, ", "))}
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+	c.logger.Debug("dropped unsupported request fields",
+		slog.String("api_version", info.APIVersion),
+		slog.Any("features", dropped),
+	)
+	return nil
+}
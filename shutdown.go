@@ -0,0 +1,101 @@
+package xai
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// inflight tracks the client's active RPCs so Shutdown can drain them.
+type inflight struct {
+	mu       sync.Mutex
+	draining bool
+	active   int
+	idle     chan struct{} // closed when draining and active reaches zero
+}
+
+// start registers a new RPC, failing once the client is shutting down.
+func (f *inflight) start() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.draining {
+		return &Error{Code: ErrUnavailable, Message: "client is shutting down"}
+	}
+	f.active++
+	return nil
+}
+
+func (f *inflight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.active--
+	if f.draining && f.active == 0 {
+		close(f.idle)
+	}
+}
+
+// drain stops new RPCs and returns a channel closed once none are active.
+func (f *inflight) drain() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.draining {
+		f.draining = true
+		f.idle = make(chan struct{})
+		if f.active == 0 {
+			close(f.idle)
+		}
+	}
+	return f.idle
+}
+
+// Shutdown gracefully closes the client: new requests fail immediately with
+// ErrUnavailable, while in-flight requests and open streams are allowed to
+// finish. Once they have, or ctx is done, the client is closed as by Close.
+// It returns ctx's error if requests were still running when it gave up.
+//
+// Streams count as finished when they are read to the end, fail, or are
+// closed, so callers should Close abandoned streams.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.logger.Debug("client shutting down")
+	var err error
+	select {
+	case <-c.inflight.drain():
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if closeErr := c.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func inflightUnaryInterceptor(f *inflight) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := f.start(); err != nil {
+			return err
+		}
+		defer f.done()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func inflightStreamInterceptor(f *inflight) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err := f.start(); err != nil {
+			return nil, err
+		}
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			f.done()
+			return nil, err
+		}
+		// The stream context is canceled once the stream finishes for any
+		// reason, including callers closing it early.
+		go func() {
+			<-cs.Context().Done()
+			f.done()
+		}()
+		return cs, nil
+	}
+}
@@ -0,0 +1,85 @@
+package xai
+
+import "strings"
+
+// stopOverrun is how far past the end of a stop sequence the text may run
+// for the sequence to count as the one that ended it: the rest of the last
+// token.
+const stopOverrun = 16
+
+// WithIncludeStopSequence sets whether completions end with the stop
+// sequence (see WithStop) that ended them. The API stops at token
+// boundaries, so the raw text may lack the sequence or run past it to the
+// end of the token that completed it. With include, the text is cut to end
+// exactly with the sequence; without, it is cut just before it, so custom
+// delimiters can be stripped reliably. Untouched if not called.
+//
+// Either way, ChatResponse.StopSequence and ChatOutput.StopSequence report
+// which sequence fired. This is best effort: it is read from the text, so
+// it is empty when the server left the sequence out, and only a sequence
+// within the last few bytes (the token that ended generation) counts, so
+// an earlier occurrence is never mistaken for the stop. This applies to
+// CompleteChat; streams deliver the text as generated.
+func (r *ChatRequest) WithIncludeStopSequence(include bool) *ChatRequest {
+	r.includeStop = &include
+	return r
+}
+
+// applyStop records the stop sequence that ended each output of resp and
+// trims the text per WithIncludeStopSequence.
+func (r *ChatRequest) applyStop(resp *ChatResponse) {
+	if len(r.stop) == 0 {
+		return
+	}
+	// The prefill was not generated, so it cannot have triggered a stop.
+	from := len(r.activePrefill())
+	apply := func(content *string, reason FinishReason) string {
+		if reason != FinishReasonStop {
+			return ""
+		}
+		seq, at := matchStop(*content, from, r.stop)
+		if at < 0 {
+			return ""
+		}
+		if r.includeStop != nil {
+			end := at
+			if *r.includeStop {
+				end += len(seq)
+			}
+			*content = (*content)[:end]
+		}
+		return seq
+	}
+	for i := range resp.Outputs {
+		out := &resp.Outputs[i]
+		out.StopSequence = apply(&out.Content, out.FinishReason)
+	}
+	resp.StopSequence = apply(&resp.Content, resp.FinishReason)
+}
+
+// matchStop returns the stop sequence that ended text and its offset, or
+// -1 if none ends within stopOverrun bytes of the end of the text, at or
+// after from. Generation halts as soon as a sequence is complete, so it is
+// the one whose occurrence ends earliest; of two ending together, the
+// longer one.
+func matchStop(text string, from int, stops []string) (string, int) {
+	best, at, end := "", -1, 0
+	if from > len(text) {
+		return best, at
+	}
+	for _, s := range stops {
+		if s == "" {
+			continue
+		}
+		start := max(from, len(text)-stopOverrun-len(s))
+		i := strings.Index(text[start:], s)
+		if i < 0 {
+			continue
+		}
+		i += start
+		if e := i + len(s); at < 0 || e < end || (e == end && i < at) {
+			best, at, end = s, i, e
+		}
+	}
+	return best, at
+}
@@ -0,0 +1,46 @@
+package xai
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// CompleteChatInto requests structured output shaped like T, sends req and
+// decodes the response content into a T. The JSON Schema is derived from T
+// and replaces any response format already set on req. Struct fields follow
+// their json tags and are required unless tagged omitempty; a description
+// tag documents a field and an enum tag lists its allowed values. Content
+// that does not decode fails with ErrServerError; the response is returned
+// alongside for inspection.
+//
+//	type Weather struct {
+//		City  string  `json:"city"`
+//		TempC float64 `json:"temp_c" description:"temperature in Celsius"`
+//		Sky   string  `json:"sky" enum:"clear,cloudy,rain"`
+//	}
+//	w, resp, err := xai.CompleteChatInto[Weather](ctx, client, req)
+func CompleteChatInto[T any](ctx context.Context, c *Client, req *ChatRequest, opts ...CallOption) (T, *ChatResponse, error) {
+	var out T
+	t := reflect.TypeFor[T]()
+	schema := schemaJSON(t)
+	name := strings.ToLower(t.Name())
+	if name == "" {
+		name = "response"
+	}
+	req.WithJSONSchema(name, schema, true)
+
+	resp, err := c.CompleteChat(ctx, req, opts...)
+	if err != nil {
+		return out, resp, err
+	}
+	if err := json.Unmarshal([]byte(resp.Content), &out); err != nil {
+		return out, resp, &Error{
+			Code:    ErrServerError,
+			Message: "model returned JSON that does not match " + name,
+			Cause:   err,
+		}
+	}
+	return out, resp, nil
+}
@@ -0,0 +1,296 @@
+package xai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// CollectionStore is the document storage a Syncer keeps in step with local
+// files. The xAI gRPC API only exposes document search, so uploads and
+// deletions go through a store supplied by the caller, typically a thin
+// wrapper around xAI's collections management API.
+type CollectionStore interface {
+	// ListDocuments returns the documents currently in the collection.
+	ListDocuments(ctx context.Context, collectionID string) ([]StoredDocument, error)
+	// UploadDocument adds doc to the collection and returns its file ID. The
+	// store must keep doc.Name and doc.Hash so ListDocuments can report them.
+	UploadDocument(ctx context.Context, collectionID string, doc SyncDocument) (fileID string, err error)
+	// DeleteDocument removes a document from the collection.
+	DeleteDocument(ctx context.Context, collectionID, fileID string) error
+}
+
+// StoredDocument is a document as reported by a CollectionStore.
+type StoredDocument struct {
+	FileID string
+	// Name is the SyncDocument.Name it was uploaded under.
+	Name string
+	// Hash is the SyncDocument.Hash it was uploaded with.
+	Hash string
+}
+
+// SyncDocument is a local file to upload.
+type SyncDocument struct {
+	// Name is the slash-separated path relative to the synced directory.
+	Name string
+	// Hash is the hex SHA-256 of the file's bytes, used to detect changes.
+	Hash string
+	// ContentType is the detected media type of the file. It is only set
+	// when the Syncer extracts text; Content is then the extracted text.
+	ContentType string
+	// Content is the document body.
+	Content []byte
+}
+
+// SyncOption configures a Syncer.
+type SyncOption func(*syncOptions)
+
+type syncOptions struct {
+	patterns   []string
+	dryRun     bool
+	clock      Clock
+	extractors *Extractors
+}
+
+// WithSyncPatterns limits syncing to files whose base name matches one of
+// the path.Match patterns, e.g. "*.md". Documents in the collection that do
+// not match are left alone.
+func WithSyncPatterns(patterns ...string) SyncOption {
+	return func(o *syncOptions) {
+		o.patterns = append(o.patterns, patterns...)
+	}
+}
+
+// WithSyncExtractors converts files to plain text before uploading, e.g.
+// with DefaultExtractors. Files without a matching extractor are reported
+// as failed. Changes are still detected on the original bytes.
+func WithSyncExtractors(e *Extractors) SyncOption {
+	return func(o *syncOptions) {
+		o.extractors = e
+	}
+}
+
+// WithSyncDryRun reports what would change without touching the collection.
+func WithSyncDryRun() SyncOption {
+	return func(o *syncOptions) {
+		o.dryRun = true
+	}
+}
+
+// WithSyncClock sets the clock Watch polls with (default SystemClock).
+func WithSyncClock(clock Clock) SyncOption {
+	return func(o *syncOptions) {
+		o.clock = clock
+	}
+}
+
+// Syncer uploads new and changed files to a document collection and removes
+// documents whose files are gone, turning a directory into a RAG source.
+type Syncer struct {
+	store        CollectionStore
+	collectionID string
+	opts         syncOptions
+}
+
+// NewSyncer creates a Syncer for one collection.
+func NewSyncer(store CollectionStore, collectionID string, opts ...SyncOption) *Syncer {
+	o := syncOptions{clock: SystemClock()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Syncer{store: store, collectionID: collectionID, opts: o}
+}
+
+// SyncReport summarizes one sync. Each list holds document names.
+type SyncReport struct {
+	Uploaded  []string
+	Updated   []string
+	Deleted   []string
+	Unchanged []string
+	// Failed holds per-document errors; the other documents are still synced.
+	Failed []SyncFailure
+}
+
+// SyncFailure is a document that could not be synced.
+type SyncFailure struct {
+	Name string
+	Err  error
+}
+
+// Changed reports whether the sync modified the collection.
+func (r *SyncReport) Changed() bool {
+	return len(r.Uploaded)+len(r.Updated)+len(r.Deleted) > 0
+}
+
+// String returns a one-line summary such as
+// "2 uploaded, 1 updated, 0 deleted, 14 unchanged, 0 failed".
+func (r *SyncReport) String() string {
+	return fmt.Sprintf("%d uploaded, %d updated, %d deleted, %d unchanged, %d failed",
+		len(r.Uploaded), len(r.Updated), len(r.Deleted), len(r.Unchanged), len(r.Failed))
+}
+
+// SyncDir syncs the collection with the files of fsys, e.g. os.DirFS(dir).
+// Hidden files and directories (starting with ".") are skipped.
+func (s *Syncer) SyncDir(ctx context.Context, fsys fs.FS) (*SyncReport, error) {
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && d.Name()[0] == '.' {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && s.matches(name) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &Error{Code: ErrInvalidRequest, Message: "reading sync directory", Cause: err}
+	}
+	return s.sync(ctx, names, func(name string) ([]byte, error) { return fs.ReadFile(fsys, name) })
+}
+
+// SyncFiles syncs the collection with the given files, named by their
+// slash-separated paths. Documents not in the list are deleted, so the list
+// must be complete.
+func (s *Syncer) SyncFiles(ctx context.Context, paths []string) (*SyncReport, error) {
+	byName := make(map[string]string, len(paths))
+	names := make([]string, 0, len(paths))
+	for _, p := range paths {
+		name := filepath.ToSlash(p)
+		if _, dup := byName[name]; dup || !s.matches(name) {
+			continue
+		}
+		byName[name] = p
+		names = append(names, name)
+	}
+	return s.sync(ctx, names, func(name string) ([]byte, error) { return os.ReadFile(byName[name]) })
+}
+
+// Watch syncs fsys every interval until ctx is done, passing each result to
+// onSync. A failed sync is reported and retried at the next interval.
+func (s *Syncer) Watch(ctx context.Context, fsys fs.FS, interval time.Duration, onSync func(*SyncReport, error)) error {
+	for {
+		report, err := s.SyncDir(ctx, fsys)
+		if ctx.Err() != nil {
+			return FromGRPCError(ctx.Err())
+		}
+		if onSync != nil {
+			onSync(report, err)
+		}
+		select {
+		case <-ctx.Done():
+			return FromGRPCError(ctx.Err())
+		case <-s.opts.clock.After(interval):
+		}
+	}
+}
+
+func (s *Syncer) matches(name string) bool {
+	if len(s.opts.patterns) == 0 {
+		return true
+	}
+	base := path.Base(name)
+	for _, p := range s.opts.patterns {
+		if ok, _ := path.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Syncer) sync(ctx context.Context, names []string, read func(string) ([]byte, error)) (*SyncReport, error) {
+	stored, err := s.store.ListDocuments(ctx, s.collectionID)
+	if err != nil {
+		return nil, WrapError(err, "listing collection documents")
+	}
+	remote := make(map[string][]StoredDocument)
+	for _, d := range stored {
+		if s.matches(d.Name) {
+			remote[d.Name] = append(remote[d.Name], d)
+		}
+	}
+
+	report := &SyncReport{}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return report, FromGRPCError(err)
+		}
+		existing := remote[name]
+		delete(remote, name)
+
+		content, err := read(name)
+		if err != nil {
+			report.Failed = append(report.Failed, SyncFailure{Name: name, Err: err})
+			continue
+		}
+		sum := sha256.Sum256(content)
+		doc := SyncDocument{Name: name, Hash: hex.EncodeToString(sum[:]), Content: content}
+
+		if len(existing) == 1 && existing[0].Hash == doc.Hash {
+			report.Unchanged = append(report.Unchanged, name)
+			continue
+		}
+		if s.opts.extractors != nil {
+			text, ct, err := s.opts.extractors.Extract(ctx, name, content)
+			if err != nil {
+				report.Failed = append(report.Failed, SyncFailure{Name: name, Err: err})
+				continue
+			}
+			doc.Content, doc.ContentType = []byte(text), ct
+		}
+		if !s.opts.dryRun {
+			// Upload before deleting so the collection never lacks the document.
+			if _, err := s.store.UploadDocument(ctx, s.collectionID, doc); err != nil {
+				report.Failed = append(report.Failed, SyncFailure{Name: name, Err: err})
+				continue
+			}
+			if err := s.deleteAll(ctx, existing); err != nil {
+				report.Failed = append(report.Failed, SyncFailure{Name: name, Err: err})
+				continue
+			}
+		}
+		if len(existing) == 0 {
+			report.Uploaded = append(report.Uploaded, name)
+		} else {
+			report.Updated = append(report.Updated, name)
+		}
+	}
+
+	gone := make([]string, 0, len(remote))
+	for name := range remote {
+		gone = append(gone, name)
+	}
+	sort.Strings(gone)
+	for _, name := range gone {
+		if !s.opts.dryRun {
+			if err := s.deleteAll(ctx, remote[name]); err != nil {
+				report.Failed = append(report.Failed, SyncFailure{Name: name, Err: err})
+				continue
+			}
+		}
+		report.Deleted = append(report.Deleted, name)
+	}
+	return report, nil
+}
+
+func (s *Syncer) deleteAll(ctx context.Context, docs []StoredDocument) error {
+	for _, d := range docs {
+		if err := s.store.DeleteDocument(ctx, s.collectionID, d.FileID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
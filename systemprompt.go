@@ -0,0 +1,191 @@
+package xai
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// PromptSection orders the fragments of a SystemPrompt. Fragments are
+// assembled by section, then by Order, then in the order they were added.
+type PromptSection int
+
+const (
+	// PromptSectionBase holds core instructions that apply everywhere.
+	PromptSectionBase PromptSection = iota
+	// PromptSectionPersona holds tone, voice and role instructions.
+	PromptSectionPersona
+	// PromptSectionTools holds instructions on how and when to use tools.
+	PromptSectionTools
+	// PromptSectionContext holds task or session specific context.
+	PromptSectionContext
+)
+
+// PromptFragment is a named piece of a system prompt.
+type PromptFragment struct {
+	// Name identifies the fragment. Including a fragment with the same name
+	// replaces the earlier one.
+	Name string `json:"name"`
+	// Section determines where the fragment is placed.
+	Section PromptSection `json:"section"`
+	// Order sorts fragments within a section (lower first); fragments with
+	// the same Order are sorted by Name.
+	Order int `json:"order,omitempty"`
+	// Text is the fragment content.
+	Text string `json:"text"`
+}
+
+// SystemPrompt composes a system message from reusable fragments. The
+// assembled text is deterministic regardless of the order named fragments
+// were included in: ties in section and Order are broken by Name.
+//
+//	base := xai.NewSystemPrompt("You are a support assistant for Acme.")
+//	base.Persona("Be concise and friendly.").
+//	    ToolInstructions("Use lookup_order before answering order questions.")
+//	req := xai.NewChatRequest().WithSystemPrompt(base)
+type SystemPrompt struct {
+	fragments []PromptFragment
+}
+
+// NewSystemPrompt creates a prompt with base as its "base" fragment.
+// An empty base adds no fragment.
+func NewSystemPrompt(base string) *SystemPrompt {
+	p := &SystemPrompt{}
+	if base != "" {
+		p.Include(PromptFragment{Name: "base", Section: PromptSectionBase, Text: base})
+	}
+	return p
+}
+
+// Persona sets the "persona" fragment.
+func (p *SystemPrompt) Persona(text string) *SystemPrompt {
+	return p.Include(PromptFragment{Name: "persona", Section: PromptSectionPersona, Text: text})
+}
+
+// ToolInstructions sets the "tools" fragment.
+func (p *SystemPrompt) ToolInstructions(text string) *SystemPrompt {
+	return p.Include(PromptFragment{Name: "tools", Section: PromptSectionTools, Text: text})
+}
+
+// Include adds fragments, replacing any existing fragment with the same name.
+func (p *SystemPrompt) Include(fragments ...PromptFragment) *SystemPrompt {
+	for _, f := range fragments {
+		replaced := false
+		for i := range p.fragments {
+			if f.Name != "" && p.fragments[i].Name == f.Name {
+				p.fragments[i] = f
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			p.fragments = append(p.fragments, f)
+		}
+	}
+	return p
+}
+
+// IncludeFrom adds the named fragments of another prompt, such as a shared
+// library of standard instructions. Unknown names are ignored.
+func (p *SystemPrompt) IncludeFrom(lib *SystemPrompt, names ...string) *SystemPrompt {
+	for _, name := range names {
+		for _, f := range lib.fragments {
+			if f.Name == name {
+				p.Include(f)
+			}
+		}
+	}
+	return p
+}
+
+// Remove drops the named fragment, if present.
+func (p *SystemPrompt) Remove(name string) *SystemPrompt {
+	for i, f := range p.fragments {
+		if f.Name == name {
+			p.fragments = append(p.fragments[:i], p.fragments[i+1:]...)
+			break
+		}
+	}
+	return p
+}
+
+// Fragments returns the non-empty fragments in assembly order.
+func (p *SystemPrompt) Fragments() []PromptFragment {
+	out := make([]PromptFragment, 0, len(p.fragments))
+	for _, f := range p.fragments {
+		if strings.TrimSpace(f.Text) != "" {
+			out = append(out, f)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Section != out[j].Section {
+			return out[i].Section < out[j].Section
+		}
+		if out[i].Order != out[j].Order {
+			return out[i].Order < out[j].Order
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// String assembles the fragments into a single system message, separated by
+// blank lines.
+func (p *SystemPrompt) String() string {
+	fragments := p.Fragments()
+	parts := make([]string, len(fragments))
+	for i, f := range fragments {
+		parts[i] = strings.TrimSpace(f.Text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// FragmentTokens is the token size of one fragment.
+type FragmentTokens struct {
+	// Name is the fragment name.
+	Name string
+	// Tokens is the tokenized size of the fragment text.
+	Tokens int
+}
+
+// TokenCount tokenizes each fragment with model's tokenizer and returns the
+// per-fragment sizes in assembly order along with their total. The total may
+// differ slightly from tokenizing String() because of the separators.
+func (p *SystemPrompt) TokenCount(ctx context.Context, tokenizer Tokenizer, model string) ([]FragmentTokens, int, error) {
+	var counts []FragmentTokens
+	total := 0
+	for _, f := range p.Fragments() {
+		resp, err := tokenizer.Tokenize(ctx, model, f.Text)
+		if err != nil {
+			return nil, 0, WrapError(err, "counting tokens for prompt fragment "+f.Name)
+		}
+		counts = append(counts, FragmentTokens{Name: f.Name, Tokens: resp.TokenCount()})
+		total += resp.TokenCount()
+	}
+	return counts, total, nil
+}
+
+// WithSystemPrompt sets a composed system prompt. It is assembled when the
+// request is built and sent as the first message, ahead of any messages
+// added with SystemMessage.
+func (r *ChatRequest) WithSystemPrompt(p *SystemPrompt) *ChatRequest {
+	r.systemPrompt = p
+	return r
+}
+
+// systemPromptMessage returns the assembled system prompt message, or nil.
+func (r *ChatRequest) systemPromptMessage() *v1.Message {
+	if r.systemPrompt == nil {
+		return nil
+	}
+	text := r.systemPrompt.String()
+	if text == "" {
+		return nil
+	}
+	return &v1.Message{
+		Role:    v1.MessageRole_ROLE_SYSTEM,
+		Content: []*v1.Content{{Content: &v1.Content_Text{Text: text}}},
+	}
+}
@@ -0,0 +1,127 @@
+package xai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// TemplateEscape controls how substituted template values are escaped.
+type TemplateEscape int
+
+const (
+	// TemplateEscapeNone inserts values verbatim (default).
+	TemplateEscapeNone TemplateEscape = iota
+	// TemplateEscapeHTML escapes values for inclusion in HTML.
+	TemplateEscapeHTML
+	// TemplateEscapeJSON escapes values for inclusion inside a JSON string literal.
+	TemplateEscapeJSON
+)
+
+// RenderTemplate substitutes {{name}} placeholders in tmpl with values from vars.
+// Whitespace inside the braces is ignored, so {{ name }} is equivalent.
+// Every placeholder must have a matching variable; missing variables and
+// unterminated placeholders are reported as errors. Values are formatted with
+// fmt.Sprint and escaped according to mode.
+func RenderTemplate(tmpl string, vars map[string]any, mode TemplateEscape) (string, error) {
+	var out strings.Builder
+	var missing []string
+	rest := tmpl
+
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			out.WriteString(rest)
+			break
+		}
+		out.WriteString(rest[:start])
+		rest = rest[start+2:]
+
+		end := strings.Index(rest, "}}")
+		if end < 0 {
+			return "", &Error{
+				Code:    ErrInvalidRequest,
+				Message: "template: unterminated placeholder",
+			}
+		}
+		name := strings.TrimSpace(rest[:end])
+		rest = rest[end+2:]
+
+		if name == "" {
+			return "", &Error{
+				Code:    ErrInvalidRequest,
+				Message: "template: empty placeholder",
+			}
+		}
+		val, ok := vars[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		escaped, err := escapeTemplateValue(fmt.Sprint(val), mode)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(escaped)
+	}
+
+	if len(missing) > 0 {
+		return "", &Error{
+			Code:    ErrInvalidRequest,
+			Message: "template: missing variables: " + strings.Join(missing, ", "),
+		}
+	}
+	return out.String(), nil
+}
+
+func escapeTemplateValue(s string, mode TemplateEscape) (string, error) {
+	switch mode {
+	case TemplateEscapeHTML:
+		return html.EscapeString(s), nil
+	case TemplateEscapeJSON:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(s); err != nil {
+			return "", &Error{Code: ErrInvalidRequest, Message: "template: escaping value", Cause: err}
+		}
+		// Strip the surrounding quotes and trailing newline added by Encode.
+		b := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+		return string(b[1 : len(b)-1]), nil
+	default:
+		return s, nil
+	}
+}
+
+// WithTemplateEscape sets the escaping mode used by subsequent
+// UserMessageTemplate calls on this request.
+func (r *ChatRequest) WithTemplateEscape(mode TemplateEscape) *ChatRequest {
+	r.templateEscape = mode
+	return r
+}
+
+// UserMessageTemplate renders tmpl with vars (see RenderTemplate) and adds the
+// result as a user message. If rendering fails, no message is added and the
+// error is reported by Err and returned when the request is sent.
+func (r *ChatRequest) UserMessageTemplate(tmpl string, vars map[string]any) *ChatRequest {
+	text, err := RenderTemplate(tmpl, vars, r.templateEscape)
+	if err != nil {
+		r.setErr(err)
+		return r
+	}
+	return r.UserMessage(UserContent{Text: text})
+}
+
+// Err returns the first error recorded while building the request, if any.
+func (r *ChatRequest) Err() error {
+	return r.err
+}
+
+// setErr records the first builder error.
+func (r *ChatRequest) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
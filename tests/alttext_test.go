@@ -0,0 +1,82 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+)
+
+type fakeImage struct {
+	v1.UnimplementedImageServer
+	images []*v1.GeneratedImage
+}
+
+func (f *fakeImage) GenerateImage(context.Context, *v1.GenerateImageRequest) (*v1.ImageResponse, error) {
+	return &v1.ImageResponse{Model: "grok-2-image", Images: f.images}, nil
+}
+
+// describeChat answers alt text requests with content, recording the image
+// URL each was asked about.
+func describeChat(content string, urls *[]string) *fakeChat {
+	return &fakeChat{complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		for _, c := range req.GetMessages()[0].GetContent() {
+			if u := c.GetImageUrl().GetImageUrl(); u != "" {
+				*urls = append(*urls, u)
+			}
+		}
+		return answerResponse(content), nil
+	}}
+}
+
+func TestGenerateImageWithAltText(t *testing.T) {
+	var urls []string
+	chat := describeChat(`{"alt_text": " A red bicycle against a wall. ", "caption": "A bike at rest."}`, &urls)
+	images := &fakeImage{images: []*v1.GeneratedImage{
+		{Image: &v1.GeneratedImage_Url{Url: "https://img.example/1.png"}},
+		{Image: &v1.GeneratedImage_Base64{Base64: "iVBORw0KGgo="}},
+	}}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-vision"},
+		func(s *grpc.Server) { v1.RegisterImageServer(s, images) })
+
+	got, err := client.GenerateImageWithAltText(context.Background(), xai.NewImageRequest("a bicycle"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].AltText != "A red bicycle against a wall." || got[1].Caption != "A bike at rest." {
+		t.Fatalf("described = %+v", got)
+	}
+	if got[0].URL != "https://img.example/1.png" || got[1].Base64 == "" {
+		t.Errorf("images not kept: %+v", got)
+	}
+	if len(urls) != 2 || urls[0] != "https://img.example/1.png" || !strings.HasPrefix(urls[1], "data:image/png;base64,") {
+		t.Errorf("described image URLs = %q", urls)
+	}
+}
+
+func TestDescribeImagesErrors(t *testing.T) {
+	var urls []string
+	client := newFakeClient(t, describeChat("not json", &urls), xai.Config{DefaultModel: "grok-vision"})
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		resp *xai.ImageResponse
+		code xai.ErrorCode
+	}{
+		{"nil response", nil, xai.ErrInvalidRequest},
+		{"no image data", &xai.ImageResponse{Images: []xai.GeneratedImage{{}}}, xai.ErrInvalidRequest},
+		{"invalid JSON", &xai.ImageResponse{Images: []xai.GeneratedImage{{URL: "https://img.example/1.png"}}}, xai.ErrServerError},
+	}
+	for _, tt := range tests {
+		_, err := client.DescribeImages(ctx, tt.resp, "")
+		var xerr *xai.Error
+		if !errors.As(err, &xerr) || xerr.Code != tt.code {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.code)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+// bagEmbedder embeds text as a hashed bag of words.
+type bagEmbedder struct{}
+
+func (bagEmbedder) Embed(_ context.Context, req *xai.EmbedRequest, _ ...xai.CallOption) (*xai.EmbedResponse, error) {
+	resp := &xai.EmbedResponse{}
+	for i, in := range req.Inputs() {
+		v := make([]float32, 64)
+		for _, w := range strings.Fields(strings.ToLower(strings.Trim(in.(xai.TextEmbedInput).Text, ".!?"))) {
+			h := fnv.New32a()
+			h.Write([]byte(w))
+			v[h.Sum32()%64]++
+		}
+		resp.Embeddings = append(resp.Embeddings, xai.Embedding{Index: int32(i), Vectors: [][]float32{v}})
+	}
+	return resp, nil
+}
+
+func TestDiffAnswers(t *testing.T) {
+	a := &xai.ChatResponse{Content: "Paris is the capital of France. It has about two million people. The Eiffel Tower is there."}
+	b := &xai.ChatResponse{Content: "Paris is the capital of France. It has about three million people.\nLouvre is a big museum!"}
+
+	diff, err := xai.DiffAnswers(context.Background(), bagEmbedder{}, "embed", a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []string
+	for _, s := range diff.Segments {
+		ops = append(ops, s.Op.String())
+	}
+	if got := strings.Join(ops, ","); got != "same,changed,removed,added" {
+		t.Fatalf("ops = %s; segments %+v", got, diff.Segments)
+	}
+	if len(diff.Divergent()) != 3 || diff.Similarity <= 0 || diff.Similarity >= 1 {
+		t.Errorf("diff = %+v", diff)
+	}
+
+	same, err := xai.DiffTexts(context.Background(), bagEmbedder{}, "embed", a.Content, a.Content)
+	if err != nil || len(same.Divergent()) != 0 || same.Similarity < 0.999 {
+		t.Errorf("identical texts: %+v, %v", same, err)
+	}
+}
+
+// badIndexEmbedder returns an embedding with a negative index.
+type badIndexEmbedder struct{}
+
+func (badIndexEmbedder) Embed(context.Context, *xai.EmbedRequest, ...xai.CallOption) (*xai.EmbedResponse, error) {
+	return &xai.EmbedResponse{Embeddings: []xai.Embedding{{Index: -1, Vectors: [][]float32{{1}}}}}, nil
+}
+
+func TestDiffAnswersBadIndex(t *testing.T) {
+	_, err := xai.DiffTexts(context.Background(), badIndexEmbedder{}, "embed", "One.", "Two.")
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || xaiErr.Code != xai.ErrServerError {
+		t.Errorf("err = %v, want ErrServerError", err)
+	}
+}
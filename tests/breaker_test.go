@@ -0,0 +1,183 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"golang.org/x/text/language"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var down atomic.Bool
+	var calls atomic.Int32
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			calls.Add(1)
+			if down.Load() {
+				return nil, status.Error(codes.Unavailable, "down")
+			}
+			return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{
+				Message: &v1.CompletionMessage{Content: "live: " + req.GetMessages()[0].GetContent()[0].GetText()},
+			}}}, nil
+		},
+	}
+	var transitions []string
+	breaker := xai.NewCircuitBreaker(xai.BreakerConfig{
+		MinRequests: 3,
+		CoolDown:    50 * time.Millisecond,
+		Cache:       xai.NewResponseCache(10),
+		Fallback: func(_ context.Context, _ *xai.ChatRequest, cause error) (*xai.ChatResponse, error) {
+			return &xai.ChatResponse{Content: "stub"}, nil
+		},
+		OnStateChange: func(from, to xai.BreakerState) {
+			transitions = append(transitions, from.String()+">"+to.String())
+		},
+	})
+	client := newFakeClient(t, chat, xai.Config{Breaker: breaker})
+	ctx := context.Background()
+	ask := func(text string) (*xai.ChatResponse, error) {
+		return client.CompleteChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: text}))
+	}
+
+	if _, err := ask("cached"); err != nil {
+		t.Fatal(err)
+	}
+
+	// One success and two failures reach MinRequests at a 2/3 failure rate.
+	down.Store(true)
+	for range 2 {
+		if _, err := ask("x"); err == nil {
+			t.Fatal("expected failure while down")
+		}
+	}
+	if breaker.State() != xai.BreakerOpen {
+		t.Fatalf("state = %v after failures", breaker.State())
+	}
+
+	before := calls.Load()
+	resp, err := ask("cached")
+	if err != nil || resp.Content != "live: cached" || !resp.Degraded {
+		t.Errorf("cached answer = %+v, %v", resp, err)
+	}
+	resp, err = ask("new question")
+	if err != nil || resp.Content != "stub" || !resp.Degraded {
+		t.Errorf("fallback answer = %+v, %v", resp, err)
+	}
+	if calls.Load() != before {
+		t.Error("open breaker let requests through")
+	}
+	if _, err := client.ListModels(ctx); !errors.Is(err, xai.ErrCircuitOpen) {
+		t.Errorf("ListModels while open: %v", err)
+	}
+
+	// After the cool-down a successful probe closes the breaker.
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	resp, err = ask("probe")
+	if err != nil || resp.Degraded {
+		t.Errorf("probe = %+v, %v", resp, err)
+	}
+	if breaker.State() != xai.BreakerClosed {
+		t.Errorf("state = %v after probe", breaker.State())
+	}
+
+	want := []string{"closed>open", "open>half-open", "half-open>closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v", transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transitions = %v, want %v", transitions, want)
+			break
+		}
+	}
+}
+
+func TestCircuitBreakerForce(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	breaker := xai.NewCircuitBreaker(xai.BreakerConfig{CoolDown: time.Millisecond})
+	metrics := xai.NewPrometheusMetrics().WithBreaker(breaker)
+	client := newFakeClient(t, chat, xai.Config{Breaker: breaker, Metrics: metrics})
+	ctx := context.Background()
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+
+	breaker.ForceOpen()
+	time.Sleep(5 * time.Millisecond) // past the cool-down: a forced breaker does not probe
+	if _, err := client.CompleteChat(ctx, req); !errors.Is(err, xai.ErrCircuitOpen) {
+		t.Fatalf("forced open: %v", err)
+	}
+	st := breaker.Stats()
+	if st.State != xai.BreakerOpen || !st.Forced || st.Trips != 1 || st.Rejected != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+
+	var out strings.Builder
+	if _, err := metrics.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"xai_breaker_state 1", "xai_breaker_trips_total 1", "xai_breaker_rejected_total 1"} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+
+	breaker.ForceClose()
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Fatalf("after ForceClose: %v", err)
+	}
+	if st := breaker.Stats(); st.State != xai.BreakerClosed || st.Forced || st.Requests != 1 {
+		t.Errorf("stats after close = %+v", st)
+	}
+}
+
+func TestCircuitBreakerCachesCheckedAnswer(t *testing.T) {
+	var calls int
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			calls++
+			text := "The answer is that the sky is blue because of the way light scatters."
+			if calls > 1 {
+				text = "La respuesta es que el cielo es azul por la forma en que se dispersa la luz."
+			}
+			return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{Message: &v1.CompletionMessage{Content: text}}}}, nil
+		},
+	}
+	breaker := xai.NewCircuitBreaker(xai.BreakerConfig{
+		Cache: xai.NewResponseCache(10),
+		Fallback: func(context.Context, *xai.ChatRequest, error) (*xai.ChatResponse, error) {
+			return nil, nil
+		},
+	})
+	client := newFakeClient(t, chat, xai.Config{Breaker: breaker})
+	ctx := context.Background()
+	ask := func(text string) (*xai.ChatResponse, error) {
+		return client.CompleteChat(ctx, xai.NewChatRequest().
+			WithExpectedLanguage(language.Spanish).
+			UserMessage(xai.UserContent{Text: text}))
+	}
+	if _, err := ask("¿Por qué el cielo es azul?"); err != nil {
+		t.Fatal(err)
+	}
+
+	breaker.ForceOpen()
+	resp, err := ask("¿Por qué el cielo es azul?")
+	if err != nil || !resp.Degraded || !resp.LanguageCorrected {
+		t.Errorf("cached answer = %+v, %v; want the re-prompted one", resp, err)
+	}
+	// The fallback has no answer, so the breaker error stands.
+	if _, err := ask("¿Qué hora es?"); !errors.Is(err, xai.ErrCircuitOpen) {
+		t.Errorf("nil fallback: err = %v, want ErrCircuitOpen", err)
+	}
+}
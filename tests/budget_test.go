@@ -0,0 +1,111 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestMaxBudget(t *testing.T) {
+	var calls int
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			calls++
+			return &v1.GetChatCompletionResponse{
+				Usage: &v1.SamplingUsage{PromptTokens: 1000, CompletionTokens: 1000},
+			}, nil
+		},
+	}
+	models := &fakeModels{models: map[string]*v1.LanguageModel{
+		"grok-4": {
+			Name:                     "grok-4",
+			PromptTextTokenPrice:     20000,  // $2 per million
+			CompletionTextTokenPrice: 100000, // $10 per million
+		},
+	}}
+	// Each request costs $0.012, so the budget is spent by the third.
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-4", MaxBudgetUSD: 0.03},
+		func(s *grpc.Server) { v1.RegisterModelsServer(s, models) })
+	ctx := context.Background()
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+
+	for i := range 3 {
+		if _, err := client.CompleteChat(ctx, req); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	_, err := client.CompleteChat(ctx, req)
+	if !errors.Is(err, xai.ErrBudgetExceeded) {
+		t.Fatalf("over budget: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("server saw %d requests, want 3", calls)
+	}
+
+	client.Costs().Reset()
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Errorf("after Reset: %v", err)
+	}
+}
+
+// failingModels fails ListLanguageModels and counts the calls.
+type failingModels struct {
+	v1.UnimplementedModelsServer
+	calls atomic.Int32
+}
+
+func (f *failingModels) ListLanguageModels(context.Context, *emptypb.Empty) (*v1.ListLanguageModelsResponse, error) {
+	f.calls.Add(1)
+	time.Sleep(20 * time.Millisecond) // let concurrent requests pile up
+	return nil, status.Error(codes.PermissionDenied, "no access to models")
+}
+
+func TestMaxBudgetPricingUnavailable(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return answerResponse("ok"), nil
+		},
+	}
+	models := &failingModels{}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-4", MaxBudgetUSD: 0.01},
+		func(s *grpc.Server) { v1.RegisterModelsServer(s, models) })
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.CompleteChat(context.Background(), req.Clone())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("request failed while pricing is down: %v", err)
+		}
+	}
+	// One shared load, then the failure is kept during the backoff.
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if n := models.calls.Load(); n != 1 {
+		t.Errorf("ListModels called %d times, want 1", n)
+	}
+	if _, err := client.Costs().Spend(context.Background()); err == nil {
+		t.Error("Spend should report the pricing failure")
+	}
+}
@@ -0,0 +1,92 @@
+package xai_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPromptCaching(t *testing.T) {
+	var key string
+	chat := &fakeChat{
+		complete: func(ctx context.Context, _ *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			key = ""
+			if v := md.Get("x-grok-conv-id"); len(v) > 0 {
+				key = v[0]
+			}
+			return &v1.GetChatCompletionResponse{
+				Usage: &v1.SamplingUsage{PromptTokens: 1000, CachedPromptTextTokens: 800, CompletionTokens: 10},
+			}, nil
+		},
+	}
+	models := &fakeModels{models: map[string]*v1.LanguageModel{
+		"grok-4": {Name: "grok-4", PromptTextTokenPrice: 20000, CachedPromptTokenPrice: 5000},
+	}}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-4"},
+		func(s *grpc.Server) { v1.RegisterModelsServer(s, models) })
+	ctx := context.Background()
+
+	send := func(req *xai.ChatRequest) string {
+		t.Helper()
+		if _, err := client.CompleteChat(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	agent := func(system, question string) *xai.ChatRequest {
+		return xai.NewChatRequest().
+			SystemMessage(xai.SystemContent{Text: system}).
+			AddTool(xai.NewFunctionTool("lookup", "Looks things up")).
+			UserMessage(xai.UserContent{Text: question}).
+			WithPromptCaching()
+	}
+
+	if got := send(xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})); got != "" {
+		t.Errorf("no caching: key = %q", got)
+	}
+	if got := send(xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}).WithCacheKey("conv-1")); got != "conv-1" {
+		t.Errorf("WithCacheKey: key = %q", got)
+	}
+	first := send(agent("be brief", "one"))
+	if first == "" || send(agent("be brief", "two")) != first {
+		t.Errorf("requests sharing a prefix got different keys")
+	}
+	if send(agent("be verbose", "one")) == first {
+		t.Errorf("a different system prompt kept the key")
+	}
+
+	spend, err := client.Costs().Spend(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := spend.CacheHitRate(); got != 0.8 {
+		t.Errorf("CacheHitRate = %v", got)
+	}
+	// 5 requests of 800 cached tokens, saving $1.50/M each.
+	if want := 5 * 800 * 1.5e-6; math.Abs(spend.CacheSavingsUSD-want) > 1e-12 {
+		t.Errorf("CacheSavingsUSD = %v, want %v", spend.CacheSavingsUSD, want)
+	}
+}
+
+func TestPromptCachingREST(t *testing.T) {
+	var key string
+	client := newRESTClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("X-Grok-Conv-Id")
+		fmt.Fprint(w, `{"id":"c1","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}).WithCacheKey("conv-2")
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if key != "conv-2" {
+		t.Errorf("key = %q", key)
+	}
+}
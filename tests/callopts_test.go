@@ -0,0 +1,44 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestCallOptions(t *testing.T) {
+	var gotModel string
+	chat := &fakeChat{
+		complete: func(ctx context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			gotModel = req.GetModel()
+			if gotModel == "slow" {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "default"})
+	ctx := context.Background()
+	req := xai.NewChatRequest().WithModel("from-request").UserMessage(xai.UserContent{Text: "hi"})
+
+	if _, err := client.CompleteChat(ctx, req, xai.WithCallModel("grok-3")); err != nil {
+		t.Fatal(err)
+	}
+	if gotModel != "grok-3" {
+		t.Errorf("model = %q, want call override", gotModel)
+	}
+
+	start := time.Now()
+	_, err := client.CompleteChat(ctx, req, xai.WithCallModel("slow"), xai.WithCallTimeout(50*time.Millisecond))
+	if !errors.Is(err, xai.ErrTimeoutSentinel) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("call timeout not applied, took %v", elapsed)
+	}
+}
@@ -0,0 +1,50 @@
+package xai_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func TestCiteAnswer(t *testing.T) {
+	matches := []xai.SearchMatch{
+		{FileID: "file-a", ChunkID: "c1", Content: "Keys are rotated monthly.", Score: 0.9},
+		{FileID: "file-b", ChunkID: "c2", Content: "Unused source.", Score: 0.5},
+		{FileID: "file-c", ChunkID: "c3", Content: "Rotation is automatic.", Score: 0.7},
+	}
+	if got := xai.CitationSources(matches); !strings.HasPrefix(got, "[1] Keys are rotated monthly.\n\n[2] Unused source.") {
+		t.Errorf("CitationSources = %q", got)
+	}
+
+	answer := "Rotation is automatic [3] and monthly [1, 3]. Ignore [9]."
+	cited := xai.CiteAnswer(answer, matches, xai.WithCitationTitles(func(m xai.SearchMatch) string {
+		return strings.ToUpper(m.FileID)
+	}))
+	if want := "Rotation is automatic [1] and monthly [2, 1]. Ignore."; cited.Text != want {
+		t.Errorf("Text = %q, want %q", cited.Text, want)
+	}
+	if len(cited.Sources) != 2 || cited.Sources[0].FileID != "file-c" || cited.Sources[1].Title != "FILE-A" {
+		t.Errorf("Sources = %+v", cited.Sources)
+	}
+
+	md := cited.Markdown()
+	if !strings.Contains(md, "**Sources**\n\n1. FILE-C — \"Rotation is automatic.\"\n2. FILE-A") {
+		t.Errorf("Markdown =\n%s", md)
+	}
+
+	data, err := cited.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back xai.CitedAnswer
+	if err := json.Unmarshal(data, &back); err != nil || back.Sources[0].Number != 1 || !back.Sources[0].Cited {
+		t.Errorf("JSON = %s (%v)", data, err)
+	}
+
+	all := xai.CiteAnswer(answer, matches, xai.WithUncitedSources(), xai.WithCitationSnippetLength(0))
+	if len(all.Sources) != 3 || all.Sources[2].FileID != "file-b" || all.Sources[2].Cited || all.Sources[0].Snippet != "" {
+		t.Errorf("with uncited = %+v", all.Sources)
+	}
+}
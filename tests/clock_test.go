@@ -0,0 +1,86 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// fakeClock jumps forward instead of waiting.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// pendingChat reports every deferred completion as still pending.
+type pendingChat struct {
+	fakeChat
+	mu    sync.Mutex
+	polls int
+}
+
+func (p *pendingChat) GetDeferredCompletion(context.Context, *v1.GetDeferredRequest) (*v1.GetDeferredCompletionResponse, error) {
+	p.mu.Lock()
+	p.polls++
+	p.mu.Unlock()
+	return &v1.GetDeferredCompletionResponse{Status: v1.DeferredStatus_PENDING}, nil
+}
+
+func TestClockDrivesWaitForDeferred(t *testing.T) {
+	chat := &pendingChat{}
+	clock := &fakeClock{now: time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)}
+	client := newFakeClient(t, chat, xai.Config{Clock: clock})
+
+	start := time.Now()
+	_, err := client.WaitForDeferred(context.Background(), "req-1", time.Minute, 10*time.Minute)
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || xaiErr.Code != xai.ErrTimeout {
+		t.Fatalf("WaitForDeferred = %v, want timeout", err)
+	}
+	if chat.polls != 10 {
+		t.Errorf("polled %d times, want 10", chat.polls)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("fake clock still waited %v", elapsed)
+	}
+}
+
+func TestClockDrivesCurrentDate(t *testing.T) {
+	var got string
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			got = req.GetMessages()[0].GetContent()[0].GetText()
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	clock := &fakeClock{now: time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)}
+	client := newFakeClient(t, chat, xai.Config{Clock: clock, CurrentDate: true})
+
+	if _, err := client.CompleteChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "2025-03-14 (Friday)") {
+		t.Errorf("date hint = %q", got)
+	}
+}
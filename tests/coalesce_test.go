@@ -0,0 +1,145 @@
+package xai_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// tickingClock advances by step on every reading.
+type tickingClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+func (c *tickingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(c.step)
+	return c.now
+}
+
+func (c *tickingClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Now().Add(d)
+	return ch
+}
+
+// letterChat streams one byte per chunk, then a tool call and a final chunk.
+var letterChat = &fakeChat{
+	stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+		var chunks []*v1.CompletionOutputChunk
+		for _, s := range strings.Split("abcde", "") {
+			chunks = append(chunks, &v1.CompletionOutputChunk{Delta: &v1.Delta{Content: s}})
+		}
+		chunks = append(chunks,
+			&v1.CompletionOutputChunk{Delta: &v1.Delta{Content: "f"}},
+			&v1.CompletionOutputChunk{Delta: &v1.Delta{ToolCalls: []*v1.ToolCall{{Id: "t1"}}}},
+			&v1.CompletionOutputChunk{Delta: &v1.Delta{Content: "g"}},
+			&v1.CompletionOutputChunk{FinishReason: v1.FinishReason_REASON_STOP},
+		)
+		for _, c := range chunks {
+			if err := srv.Send(&v1.GetChatCompletionChunk{Id: "r1", Outputs: []*v1.CompletionOutputChunk{c}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// streamDeltas returns the Delta of each chunk delivered, marking tool
+// calls with "+" and the finish reason with "|".
+func streamDeltas(t *testing.T, cfg xai.Config) []string {
+	t.Helper()
+	client := newFakeClient(t, letterChat, cfg)
+	stream, err := client.StreamChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var got []string
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := chunk.Delta
+		for range chunk.ToolCalls {
+			d += "+"
+		}
+		if chunk.FinishReason != "" {
+			d += "|"
+		}
+		got = append(got, d)
+	}
+}
+
+func TestDeltaCoalescing(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  xai.Config
+		want string
+	}{
+		{"off", xai.Config{}, "a b c d e f + g |"},
+		{"bytes", xai.Config{MinDeltaBytes: 3}, "a bcd ef+ g|"},
+		// The clock ticks 10ms per chunk: a at 10ms, then bcd by 40ms.
+		{"interval", xai.Config{MinDeltaInterval: 25 * time.Millisecond, Clock: &tickingClock{step: 10 * time.Millisecond}}, "a bcd ef+ g|"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.DefaultModel = "m"
+			if got := strings.Join(streamDeltas(t, tt.cfg), " "); got != tt.want {
+				t.Errorf("chunks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeltaCoalescingKeepsUsage(t *testing.T) {
+	chat := &fakeChat{
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			chunks := []*v1.GetChatCompletionChunk{
+				{Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: "a"}}}},
+				{Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: "b"}}}, Usage: &v1.SamplingUsage{TotalTokens: 7}},
+				{Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: "c"}}}},
+				{Outputs: []*v1.CompletionOutputChunk{{FinishReason: v1.FinishReason_REASON_STOP}}},
+			}
+			for _, c := range chunks {
+				if err := srv.Send(c); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "m", MinDeltaBytes: 100})
+	stream, err := client.StreamChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var last *xai.ChatChunk
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = chunk
+	}
+	if last == nil || last.Delta != "bc" || last.Usage.TotalTokens != 7 {
+		t.Errorf("merged chunk = %+v, want delta \"bc\" with the usage of \"b\"", last)
+	}
+}
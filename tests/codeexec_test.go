@@ -0,0 +1,51 @@
+package xai_test
+
+import (
+	"context"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestCodeExecutionOutput(t *testing.T) {
+	const output = `{"stdout":"42\n"}`
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{
+				{Index: 0, Message: &v1.CompletionMessage{
+					Role:    v1.MessageRole_ROLE_ASSISTANT,
+					Content: "The answer is 42.",
+					ToolCalls: []*v1.ToolCall{{
+						Id:     "call-1",
+						Type:   v1.ToolCallType_TOOL_CALL_TYPE_CODE_EXECUTION_TOOL,
+						Status: v1.ToolCallStatus_TOOL_CALL_STATUS_COMPLETED,
+					}},
+				}},
+				{Index: 1, Message: &v1.CompletionMessage{
+					Role:      v1.MessageRole_ROLE_TOOL,
+					Content:   output,
+					ToolCalls: []*v1.ToolCall{{Id: "call-1"}},
+				}},
+			}}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	req := xai.NewChatRequest().
+		AddTool(xai.NewCodeExecutionTool()).
+		IncludeCodeExecutionOutput().
+		UserMessage(xai.UserContent{Text: "compute"})
+	resp, err := client.CompleteChat(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "The answer is 42." || len(resp.Outputs) != 2 {
+		t.Fatalf("content = %q, outputs = %v", resp.Content, resp.Outputs)
+	}
+	if got := resp.ToolCalls[0].CodeExecutionOutput; got != output {
+		t.Errorf("code execution output = %q, want %q", got, output)
+	}
+	if got := resp.Outputs[1].Content; got != output {
+		t.Errorf("tool output content = %q, want it kept", got)
+	}
+}
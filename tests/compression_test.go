@@ -0,0 +1,60 @@
+package xai_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+)
+
+// encodingRecorder records the compression of every inbound RPC.
+type encodingRecorder struct {
+	mu        sync.Mutex
+	encodings []string
+}
+
+func (r *encodingRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+func (r *encodingRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+func (r *encodingRecorder) HandleConn(context.Context, stats.ConnStats) {}
+func (r *encodingRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if h, ok := s.(*stats.InHeader); ok {
+		r.mu.Lock()
+		r.encodings = append(r.encodings, h.Compression)
+		r.mu.Unlock()
+	}
+}
+
+func TestCompressionThreshold(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	rec := &encodingRecorder{}
+	client := newFakeClient(t, chat, xai.Config{EnableCompression: true, CompressionThreshold: 1024},
+		grpc.StatsHandler(rec))
+	ctx := context.Background()
+
+	small := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+	large := xai.NewChatRequest().UserMessage(xai.UserContent{Text: strings.Repeat("long history ", 200)})
+	for _, req := range []*xai.ChatRequest{small, large} {
+		if _, err := client.CompleteChat(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.encodings) != 2 || rec.encodings[0] != "" || rec.encodings[1] != "gzip" {
+		t.Errorf("encodings = %q, want [\"\" \"gzip\"]", rec.encodings)
+	}
+}
@@ -0,0 +1,37 @@
+package xai_test
+
+import (
+	"math"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func TestConfidenceScore(t *testing.T) {
+	lp := func(tok string, p float64) xai.TokenLogprob {
+		return xai.TokenLogprob{Token: tok, Logprob: float32(math.Log(p))}
+	}
+	resp := &xai.ChatResponse{Logprobs: []xai.TokenLogprob{
+		lp("The", 0.9), lp(" answer", 0.9), lp(" is", 0.9), lp(" 4", 0.1), lp("2", 0.2), lp(".", 0.9),
+	}}
+
+	report, err := resp.ConfidenceScore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Tokens != 6 || report.Score <= 0 || report.Score >= 0.9 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.LowConfidenceSpans) != 1 || report.LowConfidenceSpans[0].Text != " 42" {
+		t.Errorf("spans = %+v", report.LowConfidenceSpans)
+	}
+
+	calibrated, _ := resp.ConfidenceScore(xai.WithCalibration(func(raw float64) float64 { return raw * 10 }))
+	if calibrated.Score != 1 || calibrated.RawScore != report.RawScore {
+		t.Errorf("calibration not applied/clamped: %+v", calibrated)
+	}
+
+	if _, err := (&xai.ChatResponse{}).ConfidenceScore(); err == nil {
+		t.Error("expected error without logprobs")
+	}
+}
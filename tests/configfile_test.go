@@ -0,0 +1,75 @@
+package xai_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("secret-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlPath := filepath.Join(dir, "xai.yaml")
+	yaml := `# shared settings
+endpoint: "localhost:8443"
+default_model: grok-3   # cheaper
+app_name: "say \"hi\""  # the "quoted" name
+rest_endpoint: 'it''s'  # 'single'
+timeout: 45s
+keepalive_time: 60
+keepalive_permit_without_stream: false
+keepalive_timeout: ~
+idle_reconnect: null  # default
+warmup:
+api_key_file: ` + keyFile + "\n"
+	if err := os.WriteFile(yamlPath, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := xai.LoadConfigFile(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Endpoint != "localhost:8443" || cfg.DefaultModel != "grok-3" || cfg.Timeout != 45*time.Second {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if cfg.AppName != `say "hi"` || cfg.RESTEndpoint != "it's" {
+		t.Errorf("quoted values = %q, %q", cfg.AppName, cfg.RESTEndpoint)
+	}
+	if cfg.KeepaliveTime != time.Minute || cfg.KeepalivePermitWithoutStream == nil || *cfg.KeepalivePermitWithoutStream {
+		t.Errorf("keepalive = %v / %v", cfg.KeepaliveTime, cfg.KeepalivePermitWithoutStream)
+	}
+	if cfg.KeepaliveTimeout != 0 || cfg.IdleReconnect != 0 || cfg.Warmup {
+		t.Errorf("null values set: %v / %v / %v", cfg.KeepaliveTimeout, cfg.IdleReconnect, cfg.Warmup)
+	}
+	if cfg.APIKey.Value() != "secret-from-file" {
+		t.Errorf("APIKey not read from file")
+	}
+
+	t.Setenv("MY_XAI_KEY", "secret-from-env")
+	jsonPath := filepath.Join(dir, "xai.json")
+	if err := os.WriteFile(jsonPath, []byte(`{"timeout": 10, "enable_compression": true, "api_key_env": "MY_XAI_KEY"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = xai.LoadConfigFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Timeout != 10*time.Second || !cfg.EnableCompression || cfg.APIKey.Value() != "secret-from-env" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+
+	if err := os.WriteFile(yamlPath, []byte("tiemout: 5s\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := xai.LoadConfigFile(yamlPath); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
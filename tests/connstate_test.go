@@ -0,0 +1,60 @@
+package xai_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// waitForState reads from states until want arrives or the test times out.
+func waitForState(t *testing.T, states <-chan xai.ConnState, want xai.ConnState) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case s := <-states:
+			if s == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %v", want)
+		}
+	}
+}
+
+func TestConnStateCallback(t *testing.T) {
+	states := make(chan xai.ConnState, 16)
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{OnConnStateChange: func(s xai.ConnState) { states <- s }})
+
+	if client.ConnState() != xai.ConnStateIdle {
+		t.Errorf("initial state = %v", client.ConnState())
+	}
+	if _, err := client.CompleteChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, states, xai.ConnStateReady)
+
+	_ = client.Close()
+	waitForState(t, states, xai.ConnStateShutdown)
+}
+
+func TestIdleReconnect(t *testing.T) {
+	states := make(chan xai.ConnState, 16)
+	client := newFakeClient(t, &fakeChat{}, xai.Config{
+		IdleReconnect:     20 * time.Millisecond,
+		OnConnStateChange: func(s xai.ConnState) { states <- s },
+	})
+	// No request is made; the idle connection is brought up proactively.
+	waitForState(t, states, xai.ConnStateReady)
+	if client.ConnState() != xai.ConnStateReady {
+		t.Errorf("state = %v", client.ConnState())
+	}
+}
@@ -0,0 +1,182 @@
+package xai_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestUserMessageParts(t *testing.T) {
+	var got []*v1.Content
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			got = req.GetMessages()[0].GetContent()
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	req := xai.NewChatRequest().UserMessageParts(
+		xai.Text("compare"),
+		xai.ImageURL("https://example.com/a.png"),
+		xai.ImageBase64("aGk=", "image/png").WithDetail(xai.ImageDetailHigh),
+		xai.FileAttachment("file-123"),
+	)
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("parts = %v", got)
+	}
+	if got[0].GetText() != "compare" || got[1].GetImageUrl().GetImageUrl() != "https://example.com/a.png" {
+		t.Errorf("text/url parts = %v, %v", got[0], got[1])
+	}
+	if img := got[2].GetImageUrl(); img.GetImageUrl() != "data:image/png;base64,aGk=" || img.GetDetail() != v1.ImageDetail_DETAIL_HIGH {
+		t.Errorf("base64 part = %v", img)
+	}
+	if got[3].GetFile().GetFileId() != "file-123" {
+		t.Errorf("file part = %v", got[3])
+	}
+}
+
+func TestUserWithImageFile(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	path := filepath.Join(t.TempDir(), "shot.png")
+	if err := os.WriteFile(path, png, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got *v1.Message
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			got = req.GetMessages()[0]
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	if _, err := client.CompleteChat(context.Background(), xai.NewChatRequest().UserWithImageFile("what is this?", path)); err != nil {
+		t.Fatal(err)
+	}
+	want := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+	if got.GetContent()[0].GetText() != "what is this?" || got.GetContent()[1].GetImageUrl().GetImageUrl() != want {
+		t.Errorf("message = %v", got)
+	}
+
+	for name, req := range map[string]*xai.ChatRequest{
+		"missing file": xai.NewChatRequest().UserWithImageFile("x", filepath.Join(t.TempDir(), "nope.png")),
+		"wrong type":   xai.NewChatRequest().UserWithImageBytes("x", []byte("GIF89a"), ""),
+		"too large":    xai.NewChatRequest().UserWithImageBytes("x", make([]byte, xai.MaxImageBytes+1), "image/png"),
+	} {
+		if !errors.Is(req.Err(), xai.ErrInvalidSentinel) {
+			t.Errorf("%s: %v", name, req.Err())
+		}
+	}
+}
+
+func TestTypedHistory(t *testing.T) {
+	var got []*v1.Message
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			got = req.GetMessages()
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	req := xai.NewChatRequest().
+		SystemMessage(xai.SystemContent{Text: "be brief"}).
+		UserMessage(xai.UserContent{
+			Text:     "weather here?",
+			ImageURL: "https://example.com/sky.png",
+			Parts:    []xai.Content{xai.FileAttachment("file-1")},
+		}).
+		AssistantMessage(xai.AssistantContent{
+			ToolCalls:        []xai.HistoryToolCall{{ID: "c1", Name: "weather", Arguments: `{"city":"Paris"}`}},
+			ReasoningContent: "need the forecast",
+			EncryptedContent: "opaque",
+		}).
+		ToolResult(xai.ToolContent{CallID: "c1", Result: "sunny"})
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("messages = %v", got)
+	}
+	if user := got[1].GetContent(); len(user) != 3 || user[2].GetFile().GetFileId() != "file-1" {
+		t.Errorf("user content = %v", user)
+	}
+	asst := got[2]
+	if asst.GetReasoningContent() != "need the forecast" || asst.GetEncryptedContent() != "opaque" || len(asst.GetContent()) != 0 {
+		t.Errorf("assistant = %v", asst)
+	}
+	if tc := asst.GetToolCalls(); len(tc) != 1 || tc[0].GetId() != "c1" || tc[0].GetFunction().GetName() != "weather" {
+		t.Errorf("tool calls = %v", tc)
+	}
+	if got[3].GetToolCallId() != "c1" || got[3].GetContent()[0].GetText() != "sunny" {
+		t.Errorf("tool result = %v", got[3])
+	}
+
+	resp := &xai.ChatResponse{
+		Content: "done",
+		ToolCalls: []*xai.ToolCallInfo{
+			{ID: "c2", Type: xai.ToolCallTypeClient, Function: &xai.FunctionCall{Name: "weather", Arguments: "{}"}},
+			{ID: "s1", Type: xai.ToolCallTypeServer, Function: &xai.FunctionCall{Name: "web_search"}},
+		},
+	}
+	content := resp.AssistantContent()
+	if content.Text != "done" || len(content.ToolCalls) != 1 || content.ToolCalls[0].ID != "c2" {
+		t.Errorf("AssistantContent = %+v", content)
+	}
+}
+
+func TestEncryptedPassthroughRoundTrip(t *testing.T) {
+	var requests []*v1.GetCompletionsRequest
+	chat := &fakeChat{complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		requests = append(requests, req)
+		return &v1.GetChatCompletionResponse{Id: "r1", Outputs: []*v1.CompletionOutput{{Message: &v1.CompletionMessage{
+			Role:             v1.MessageRole_ROLE_ASSISTANT,
+			Content:          "It is sunny.",
+			EncryptedContent: "opaque-state",
+			ToolCalls: []*v1.ToolCall{{
+				Id:   "s1",
+				Type: v1.ToolCallType_TOOL_CALL_TYPE_WEB_SEARCH_TOOL,
+				Tool: &v1.ToolCall_Function{Function: &v1.FunctionCall{Name: "web_search", Arguments: `{"q":"weather"}`}},
+			}},
+		}}}}, nil
+	}}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "m"})
+	ctx := context.Background()
+
+	req := xai.NewChatRequest().WithEncryptedPassthrough().UserMessage(xai.UserContent{Text: "weather?"})
+	resp, err := client.CompleteChat(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := requests[0]; !first.GetUseEncryptedContent() || len(first.GetInclude()) != 3 {
+		t.Errorf("first request: encrypted = %v, include = %v", first.GetUseEncryptedContent(), first.GetInclude())
+	}
+	if resp.EncryptedContent != "opaque-state" {
+		t.Fatalf("EncryptedContent = %q", resp.EncryptedContent)
+	}
+
+	req.AppendResponse(resp).UserMessage(xai.UserContent{Text: "and tomorrow?"})
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	second := requests[1]
+	if second.GetPreviousResponseId() != "" || len(second.GetMessages()) != 3 {
+		t.Fatalf("second request: previous = %q, messages = %v", second.GetPreviousResponseId(), second.GetMessages())
+	}
+	asst := second.GetMessages()[1]
+	if asst.GetEncryptedContent() != "opaque-state" || asst.GetContent()[0].GetText() != "It is sunny." {
+		t.Errorf("assistant message = %v", asst)
+	}
+	// The server ran the search; its state travels in the encrypted content.
+	if len(asst.GetToolCalls()) != 0 {
+		t.Errorf("server-side tool calls sent back: %v", asst.GetToolCalls())
+	}
+}
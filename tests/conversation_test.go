@@ -0,0 +1,252 @@
+package xai_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestConversationContextUsage(t *testing.T) {
+	models := &fakeModels{models: map[string]*v1.LanguageModel{
+		"m": {Name: "m", MaxPromptLength: 100},
+	}}
+	tok := &fakeTokenizer{}
+	client := newFakeClient(t, &fakeChat{}, xai.Config{DefaultModel: "m"}, func(s *grpc.Server) {
+		v1.RegisterModelsServer(s, models)
+		v1.RegisterTokenizeServer(s, tok)
+	})
+
+	conv := client.NewConversation(nil)
+	conv.Edit(func(req *xai.ChatRequest) {
+		req.SystemMessage(xai.SystemContent{Text: "be helpful"}).
+			UserMessage(xai.UserContent{Text: "what is the time"})
+	})
+
+	ctx := context.Background()
+	usage, err := conv.ContextUsage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.MaxTokens != 100 || usage.UsedTokens != 6 || usage.RemainingTokens != 94 {
+		t.Fatalf("usage = %+v", usage)
+	}
+	if len(usage.Turns) != 2 || usage.Turns[1].Role != "user" || usage.Turns[1].Tokens != 4 {
+		t.Fatalf("turns = %+v", usage.Turns)
+	}
+
+	conv.Edit(func(req *xai.ChatRequest) { req.AssistantMessage(xai.AssistantContent{Text: "noon"}) })
+	usage, err = conv.ContextUsage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.UsedTokens != 7 || usage.Fraction() != 0.07 {
+		t.Errorf("used = %d, fraction = %v", usage.UsedTokens, usage.Fraction())
+	}
+	if n := tok.callCount(); n != 3 {
+		t.Errorf("tokenizer called %d times, want 3 (cached per message)", n)
+	}
+}
+
+func TestConversationContextUsageAlias(t *testing.T) {
+	models := &fakeModels{models: map[string]*v1.LanguageModel{
+		"m-latest": {Name: "m-2", MaxPromptLength: 100},
+	}}
+	tok := &fakeTokenizer{}
+	client := newFakeClient(t, &fakeChat{}, xai.Config{DefaultModel: "m-latest"}, func(s *grpc.Server) {
+		v1.RegisterModelsServer(s, models)
+		v1.RegisterTokenizeServer(s, tok)
+	})
+	conv := client.NewConversation(xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+
+	ctx := context.Background()
+	for range 2 {
+		if _, err := conv.ContextUsage(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := tok.callCount(); n != 1 {
+		t.Errorf("tokenizer called %d times, want 1 (cached for the alias)", n)
+	}
+}
+
+func TestConversationModelConcurrent(t *testing.T) {
+	client := newFakeClient(t, &fakeChat{}, xai.Config{DefaultModel: "m"})
+	conv := client.NewConversation(nil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			conv.Configure(xai.NewChatRequest().WithModel("other"))
+		}
+	}()
+	for range 100 {
+		if m := conv.Model(); m != "m" && m != "other" {
+			t.Errorf("Model() = %q", m)
+		}
+	}
+	wg.Wait()
+}
+
+func TestConversationStreamMetadata(t *testing.T) {
+	chat := &fakeChat{
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			return srv.Send(&v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: "ok"}}}})
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "m"})
+	conv := client.NewConversation(xai.NewChatRequest().WithMetadata(map[string]string{"tenant": "acme"}))
+
+	stream, err := conv.Stream(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	// Editing mid-turn neither races with the stream nor changes the
+	// metadata the turn was sent with.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conv.Edit(func(req *xai.ChatRequest) { req.WithMetadata(map[string]string{"tenant": "other"}) })
+	}()
+	for {
+		if _, err := stream.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+	if got := stream.Response().RequestMetadata["tenant"]; got != "acme" {
+		t.Errorf("RequestMetadata tenant = %q, want acme", got)
+	}
+}
+
+func TestConversationSend(t *testing.T) {
+	var mu sync.Mutex
+	var seen []*v1.GetCompletionsRequest
+	reply := func(req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, req)
+		last := req.GetMessages()[len(req.GetMessages())-1].GetContent()[0].GetText()
+		if last == "fail" {
+			return nil, status.Error(codes.Unavailable, "down")
+		}
+		resp := answerResponse("re: " + last)
+		resp.Id = fmt.Sprintf("resp-%d", len(seen))
+		resp.Usage = &v1.SamplingUsage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}
+		return resp, nil
+	}
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return reply(req)
+		},
+		stream: func(req *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			resp, err := reply(req)
+			if err != nil {
+				return err
+			}
+			for _, part := range []string{resp.Outputs[0].Message.Content[:3], resp.Outputs[0].Message.Content[3:]} {
+				if err := srv.Send(&v1.GetChatCompletionChunk{Id: resp.Id, Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: part}}}}); err != nil {
+					return err
+				}
+			}
+			return srv.Send(&v1.GetChatCompletionChunk{Id: resp.Id, Usage: resp.Usage})
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "m"})
+	ctx := context.Background()
+
+	conv := client.NewConversation(xai.NewChatRequest().SystemMessage(xai.SystemContent{Text: "be brief"}))
+	resp, err := conv.Send(ctx, "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "re: hi" || len(conv.Request().Messages()) != 3 {
+		t.Fatalf("content %q, %d messages", resp.Content, len(conv.Request().Messages()))
+	}
+	if _, err := conv.Send(ctx, "fail"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := len(conv.Request().Messages()); n != 3 {
+		t.Errorf("failed turn left %d messages, want 3", n)
+	}
+
+	stream, err := conv.Stream(ctx, "more")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var streamed string
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		streamed += chunk.Delta
+	}
+	if streamed != "re: more" || stream.Response().Content != streamed {
+		t.Errorf("streamed %q, response %+v", streamed, stream.Response())
+	}
+	msgs := conv.Request().Messages()
+	if len(msgs) != 5 || msgs[4].GetContent()[0].GetText() != "re: more" {
+		t.Errorf("history after stream has %d messages", len(msgs))
+	}
+	if u := conv.Usage(); u.TotalTokens != 24 {
+		t.Errorf("usage = %+v, want 24 total tokens over two turns", u)
+	}
+
+	// With chaining, only the new message is sent after the first stored
+	// response.
+	conv.WithResponseChaining(true)
+	if _, err := conv.Send(ctx, "one"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conv.Send(ctx, "two"); err != nil {
+		t.Fatal(err)
+	}
+	first, second := seen[len(seen)-2], seen[len(seen)-1]
+	if !first.GetStoreMessages() || len(first.GetMessages()) != 6 || first.PreviousResponseId != nil {
+		t.Errorf("first chained turn: store=%v, %d messages", first.GetStoreMessages(), len(first.GetMessages()))
+	}
+	if len(second.GetMessages()) != 1 || second.GetPreviousResponseId() != fmt.Sprintf("resp-%d", len(seen)-1) {
+		t.Errorf("second chained turn: %d messages, previous %q", len(second.GetMessages()), second.GetPreviousResponseId())
+	}
+	if n := len(conv.Request().Messages()); n != 9 {
+		t.Errorf("local history has %d messages, want 9", n)
+	}
+
+	// Settings change between turns; the history stays.
+	conv.Configure(xai.NewChatRequest().WithModel("other"))
+	if _, err := conv.Send(ctx, "three"); err != nil {
+		t.Fatal(err)
+	}
+	if got := seen[len(seen)-1].GetModel(); got != "other" || len(conv.Request().Messages()) != 11 {
+		t.Errorf("model %q, %d messages", got, len(conv.Request().Messages()))
+	}
+}
+
+func TestConversationRequestIsCopy(t *testing.T) {
+	client := newFakeClient(t, &fakeChat{}, xai.Config{})
+	conv := client.NewConversation(xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+	conv.Request().UserMessage(xai.UserContent{Text: "lost"})
+	if n := len(conv.Request().Messages()); n != 1 {
+		t.Errorf("history has %d messages after changing a copy, want 1", n)
+	}
+	conv.Edit(func(req *xai.ChatRequest) { req.UserMessage(xai.UserContent{Text: "kept"}) })
+	if n := len(conv.Request().Messages()); n != 2 {
+		t.Errorf("history has %d messages after Edit, want 2", n)
+	}
+}
@@ -0,0 +1,116 @@
+package xai_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+)
+
+func TestCostTracker(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return &v1.GetChatCompletionResponse{
+				Usage: &v1.SamplingUsage{PromptTokens: 1000, CachedPromptTextTokens: 200, CompletionTokens: 500},
+			}, nil
+		},
+	}
+	models := &fakeModels{models: map[string]*v1.LanguageModel{
+		"grok-4": {
+			Name:                     "grok-4",
+			Aliases:                  []string{"grok-latest"},
+			PromptTextTokenPrice:     20000,  // $2 per million
+			CachedPromptTokenPrice:   5000,   // $0.50 per million
+			CompletionTextTokenPrice: 100000, // $10 per million
+		},
+	}}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-4"},
+		func(s *grpc.Server) { v1.RegisterModelsServer(s, models) })
+	ctx := context.Background()
+
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+	for _, model := range []string{"grok-4", "grok-latest", "unknown"} {
+		if _, err := client.CompleteChat(ctx, req, xai.WithCallModel(model)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spend, err := client.Costs().Spend(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 800 text tokens at $2/M + 200 cached at $0.50/M + 500 completion at $10/M.
+	perRequest := 800*2e-6 + 200*0.5e-6 + 500*10e-6
+	if math.Abs(spend.TotalUSD-2*perRequest) > 1e-12 {
+		t.Errorf("TotalUSD = %v, want %v", spend.TotalUSD, 2*perRequest)
+	}
+	if len(spend.Models) != 3 || spend.Models[2].Model != "unknown" || spend.Models[2].Priced {
+		t.Errorf("models = %+v", spend.Models)
+	}
+	if spend.Models[0].Requests != 1 || spend.Models[0].Usage.PromptTokens != 1000 {
+		t.Errorf("breakdown = %+v", spend.Models[0])
+	}
+
+	model, err := client.GetModel(ctx, "grok-4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := model.UsageCost(xai.Usage{PromptTokens: 1000, CachedPromptTokens: 200, CompletionTokens: 500}); math.Abs(got-perRequest) > 1e-12 {
+		t.Errorf("UsageCost = %v, want %v", got, perRequest)
+	}
+
+	client.Costs().Reset()
+	spend, _ = client.Costs().Spend(ctx)
+	if spend.TotalUSD != 0 || len(spend.Models) != 0 {
+		t.Errorf("after reset: %+v", spend)
+	}
+}
+
+func TestCostTrackerSearches(t *testing.T) {
+	usages := []*v1.SamplingUsage{
+		{PromptTokens: 100, ServerSideToolsUsed: []v1.ServerSideTool{
+			v1.ServerSideTool_SERVER_SIDE_TOOL_WEB_SEARCH,
+			v1.ServerSideTool_SERVER_SIDE_TOOL_CODE_EXECUTION,
+			v1.ServerSideTool_SERVER_SIDE_TOOL_X_SEARCH,
+		}, NumSourcesUsed: 4},
+		{PromptTokens: 100, NumSourcesUsed: 3}, // live search: billed per source
+	}
+	var calls int
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			u := usages[calls]
+			calls++
+			return &v1.GetChatCompletionResponse{Usage: u}, nil
+		},
+	}
+	models := &fakeModels{models: map[string]*v1.LanguageModel{
+		"grok-4": {Name: "grok-4", PromptTextTokenPrice: 10000, SearchPrice: 250_000_000}, // $1/M tokens, $25 per 1000 searches
+	}}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-4"},
+		func(s *grpc.Server) { v1.RegisterModelsServer(s, models) })
+	ctx := context.Background()
+
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "news?"})
+	resp, err := client.CompleteChat(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Usage.Searches != 2 || resp.Usage.SourcesUsed != 4 || resp.Usage.BilledSearches() != 2 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+
+	spend, err := client.Costs().Spend(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 tool searches + 3 live search sources at $0.025, plus 200 tokens at $1/M.
+	if math.Abs(spend.SearchUSD-5*0.025) > 1e-12 || math.Abs(spend.TotalUSD-(5*0.025+200e-6)) > 1e-12 {
+		t.Errorf("spend = %+v", spend)
+	}
+}
@@ -0,0 +1,106 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func TestWithAPIKeyContext(t *testing.T) {
+	seen := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen <- r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	pool := xai.NewKeyPool([]*xai.SecureString{xai.NewSecureString("pooled-key")}, xai.KeyPoolRoundRobin)
+	client, err := xai.New(xai.Config{
+		KeyPool:      pool,
+		Transport:    xai.TransportREST,
+		RESTEndpoint: srv.URL,
+		HTTPClient:   srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-seen; got != "Bearer pooled-key" {
+		t.Errorf("default request used %q", got)
+	}
+
+	ctx := xai.WithAPIKeyContext(context.Background(), xai.NewSecureString("tenant-key"))
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-seen; got != "Bearer tenant-key" {
+		t.Errorf("tenant request used %q", got)
+	}
+	if reqs := pool.Stats()[0].Requests; reqs != 1 {
+		t.Errorf("pool served %d requests, want 1", reqs)
+	}
+}
+
+func TestAPIKeyProvider(t *testing.T) {
+	var calls atomic.Int32
+	seen := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen <- r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	// The provider rotates keys, then fails, then returns no key.
+	provider := xai.APIKeyProviderFunc(func(context.Context) (string, error) {
+		switch n := calls.Add(1); n {
+		case 1, 2:
+			return fmt.Sprintf("rotated-%d", n), nil
+		case 3:
+			return "", errors.New("vault sealed")
+		}
+		return "", nil
+	})
+	client, err := xai.New(xai.Config{
+		APIKeyProvider: provider,
+		Transport:      xai.TransportREST,
+		RESTEndpoint:   srv.URL,
+		HTTPClient:     srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+
+	for _, want := range []string{"Bearer rotated-1", "Bearer rotated-2"} {
+		if _, err := client.CompleteChat(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		if got := <-seen; got != want {
+			t.Errorf("authorization = %q, want %q", got, want)
+		}
+	}
+	for _, want := range []string{"vault sealed", "empty key"} {
+		_, err := client.CompleteChat(ctx, req)
+		var xerr *xai.Error
+		if !errors.As(err, &xerr) || xerr.Code != xai.ErrAuth || !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want ErrAuth mentioning %q", err, want)
+		}
+	}
+	if len(seen) != 0 {
+		t.Errorf("%d requests sent without a key", len(seen))
+	}
+}
@@ -0,0 +1,77 @@
+package xai_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTLSListener serves chat over TLS on an in-memory listener, for tests
+// that dial through xai.New rather than newFakeClient. The returned config
+// trusts the server's certificate.
+func newTLSListener(t *testing.T, chat v1.ChatServer) (*bufconn.Listener, *tls.Config) {
+	t.Helper()
+	// Borrow httptest's self-signed certificate (valid for example.com).
+	certSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	serverTLS := &tls.Config{Certificates: certSrv.TLS.Certificates}
+	clientTLS := &tls.Config{
+		RootCAs:    certSrv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+		ServerName: "example.com",
+	}
+	certSrv.Close()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(serverTLS)))
+	v1.RegisterChatServer(srv, chat)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis, clientTLS
+}
+
+func TestDialer(t *testing.T) {
+	lis, clientTLS := newTLSListener(t, &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{
+				Message: &v1.CompletionMessage{Content: "pinned"},
+			}}}, nil
+		},
+	})
+
+	dialed := make(chan string, 1)
+	client, err := xai.New(xai.Config{
+		APIKey:    xai.NewSecureString("test-key"),
+		Endpoint:  "xai.internal:443",
+		TLSConfig: clientTLS,
+		Dialer: func(ctx context.Context, addr string) (net.Conn, error) {
+			select {
+			case dialed <- addr:
+			default:
+			}
+			return lis.DialContext(ctx)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	resp, err := client.CompleteChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "pinned" {
+		t.Errorf("content = %q", resp.Content)
+	}
+	if addr := <-dialed; addr != "xai.internal:443" {
+		t.Errorf("dialer got %q, want the unresolved endpoint", addr)
+	}
+}
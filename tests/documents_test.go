@@ -0,0 +1,58 @@
+package xai_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+)
+
+type fakeDocuments struct {
+	v1.UnimplementedDocumentsServer
+	matches []*v1.SearchMatch
+}
+
+func (f *fakeDocuments) Search(context.Context, *v1.SearchRequest) (*v1.SearchResponse, error) {
+	return &v1.SearchResponse{Matches: f.matches}, nil
+}
+
+func TestSearchHighlights(t *testing.T) {
+	chunk := strings.Repeat("filler text here. ", 10) +
+		"The Rate Limit applies per key; rate limits reset hourly. " +
+		strings.Repeat("more filler words. ", 10)
+	docs := &fakeDocuments{matches: []*v1.SearchMatch{{ChunkContent: chunk, FileId: "f1"}}}
+	client := newFakeClient(t, &fakeChat{}, xai.Config{},
+		func(s *grpc.Server) { v1.RegisterDocumentsServer(s, docs) })
+	ctx := context.Background()
+
+	resp, err := client.SearchDocuments(ctx, xai.NewSearchRequest("rate limit?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := resp.Matches[0]
+	if m.Snippet != chunk || m.SnippetOffset != 0 {
+		t.Errorf("default snippet should be the whole chunk")
+	}
+	var words []string
+	for _, h := range m.Highlights {
+		words = append(words, m.Snippet[h.Start:h.End])
+	}
+	if got := strings.Join(words, ","); got != "Rate,Limit,rate,limit" {
+		t.Errorf("highlights = %s", got)
+	}
+
+	resp, err = client.SearchDocuments(ctx, xai.NewSearchRequest("rate limit?").WithSnippetWindow(80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = resp.Matches[0]
+	if len(m.Snippet) > 80 || chunk[m.SnippetOffset:m.SnippetOffset+len(m.Snippet)] != m.Snippet {
+		t.Errorf("snippet %q at %d", m.Snippet, m.SnippetOffset)
+	}
+	if len(m.Highlights) != 4 || strings.HasPrefix(m.Snippet, " ") || strings.HasSuffix(m.Snippet, " ") {
+		t.Errorf("windowed snippet %q, highlights %v", m.Snippet, m.Highlights)
+	}
+}
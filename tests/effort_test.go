@@ -0,0 +1,86 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestAutoEffortChoose(t *testing.T) {
+	auto := xai.NewAutoEffort(xai.WithEffortRules(xai.EffortRule{
+		Name:   "legal",
+		Match:  func(p string) bool { return strings.Contains(p, "contract") },
+		Effort: xai.ReasoningEffortHigh,
+	}))
+	tests := []struct {
+		prompt string
+		effort xai.ReasoningEffort
+		reason string
+	}{
+		{"What is the capital of France?", xai.ReasoningEffortLow, xai.EffortReasonLength},
+		{strings.Repeat("Tell me more about Paris. ", 20), xai.ReasoningEffortMedium, xai.EffortReasonLength},
+		{strings.Repeat("word ", 1000), xai.ReasoningEffortHigh, xai.EffortReasonLength},
+		{"Why does this fail?\n```go\nx := nil\n```", xai.ReasoningEffortMedium, xai.EffortReasonCode},
+		{"Prove that there are infinitely many primes.", xai.ReasoningEffortHigh, xai.EffortReasonMath},
+		{"Solve $x^2 = 4$", xai.ReasoningEffortHigh, xai.EffortReasonMath},
+		{"Summarize this contract", xai.ReasoningEffortHigh, "legal"},
+	}
+	for _, tt := range tests {
+		effort, reason := auto.Choose(tt.prompt)
+		if effort != tt.effort || reason != tt.reason {
+			t.Errorf("Choose(%.30q) = %v, %q; want %v, %q", tt.prompt, effort, reason, tt.effort, tt.reason)
+		}
+	}
+}
+
+func TestAutoEffortRecordsOutcomes(t *testing.T) {
+	var sent []v1.ReasoningEffort
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			sent = append(sent, req.GetReasoningEffort())
+			if len(sent) == 3 {
+				return nil, errors.New("boom")
+			}
+			return &v1.GetChatCompletionResponse{
+				Usage: &v1.SamplingUsage{CompletionTokens: 10, ReasoningTokens: 100},
+			}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	auto := xai.NewAutoEffort()
+
+	prompts := []string{"hi", "Prove it.", "Prove it again."}
+	for _, p := range prompts {
+		req := xai.NewChatRequest().WithAutoEffort(auto).UserMessage(xai.UserContent{Text: p})
+		_, _ = client.CompleteChat(context.Background(), req)
+	}
+	// An explicit effort wins and is not recorded.
+	req := xai.NewChatRequest().WithAutoEffort(auto).WithReasoningEffort(xai.ReasoningEffortMedium).
+		UserMessage(xai.UserContent{Text: "hi"})
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []v1.ReasoningEffort{v1.ReasoningEffort_EFFORT_LOW, v1.ReasoningEffort_EFFORT_HIGH, v1.ReasoningEffort_EFFORT_HIGH, v1.ReasoningEffort_EFFORT_MEDIUM}
+	for i := range want {
+		if sent[i] != want[i] {
+			t.Errorf("request %d effort = %v, want %v", i, sent[i], want[i])
+		}
+	}
+
+	outcomes := auto.Outcomes()
+	if len(outcomes) != 2 {
+		t.Fatalf("outcomes = %+v", outcomes)
+	}
+	low, high := outcomes[0], outcomes[1]
+	if low.Effort != xai.ReasoningEffortLow || low.Requests != 1 || low.Usage.ReasoningTokens != 100 {
+		t.Errorf("low = %+v", low)
+	}
+	if high.Reason != xai.EffortReasonMath || high.Requests != 2 || high.Errors != 1 || high.AvgReasoningTokens() != 100 {
+		t.Errorf("high = %+v", high)
+	}
+}
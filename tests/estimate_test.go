@@ -0,0 +1,121 @@
+package xai_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+)
+
+// wordTokenizer counts whitespace-separated words as tokens.
+type wordTokenizer struct{}
+
+func (wordTokenizer) Tokenize(_ context.Context, _, text string) (*xai.TokenizeResponse, error) {
+	resp := &xai.TokenizeResponse{}
+	for _, w := range strings.Fields(text) {
+		resp.Tokens = append(resp.Tokens, xai.Token{StringToken: w})
+	}
+	return resp, nil
+}
+
+func TestEstimateCost(t *testing.T) {
+	model := &xai.LanguageModel{
+		Name:              "m",
+		MaxPromptLength:   1000,
+		PromptTextPricing: xai.Pricing{PerMillionTokens: 2},
+		CompletionPricing: xai.Pricing{PerMillionTokens: 10},
+	}
+	req := xai.NewChatRequest().
+		SystemMessage(xai.SystemContent{Text: "be brief"}).
+		UserMessage(xai.UserContent{Text: "one two three"})
+
+	est, err := req.EstimateCost(context.Background(), model, wordTokenizer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.PromptTokens != 5 || est.MaxCompletionTokens != 995 {
+		t.Fatalf("tokens = %d/%d, want 5/995", est.PromptTokens, est.MaxCompletionTokens)
+	}
+	if math.Abs(est.MinUSD-5*2e-6) > 1e-12 {
+		t.Errorf("MinUSD = %v", est.MinUSD)
+	}
+
+	est, err = req.WithMaxTokens(100).EstimateCost(context.Background(), model, wordTokenizer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 5*2e-6 + 100*10e-6; math.Abs(est.MaxUSD-want) > 1e-12 {
+		t.Errorf("MaxUSD = %v, want %v", est.MaxUSD, want)
+	}
+}
+
+func TestEstimateCostWithClient(t *testing.T) {
+	client := newFakeClient(t, &fakeChat{}, xai.Config{
+		DefaultModel: "m",
+		CurrentDate:  true,
+		Clock:        &fakeClock{now: time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)},
+	}, func(s *grpc.Server) { v1.RegisterTokenizeServer(s, &fakeTokenizer{}) })
+	model := &xai.LanguageModel{Name: "m", MaxPromptLength: 1000}
+	req := xai.NewChatRequest().
+		SystemMessage(xai.SystemContent{Text: "be brief"}).
+		UserMessage(xai.UserContent{Text: "one two three"})
+
+	// "Current date: 2026-10-14 (Wednesday)." adds four words.
+	est, err := req.EstimateCost(context.Background(), model, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.PromptTokens != 9 {
+		t.Errorf("PromptTokens = %d, want 9 with the client's current date", est.PromptTokens)
+	}
+
+	type args struct {
+		City string `json:"city"`
+	}
+	tool := xai.NewTypedTool("weather", "get the weather", func(context.Context, args) (string, error) { return "", nil })
+	withTool, err := req.Clone().AddTool(tool).EstimateCost(context.Background(), model, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withTool.PromptTokens <= est.PromptTokens {
+		t.Errorf("PromptTokens with a typed tool = %d, want more than %d", withTool.PromptTokens, est.PromptTokens)
+	}
+}
+
+func TestCountRequestTokens(t *testing.T) {
+	tok := &fakeTokenizer{}
+	client := newFakeClient(t, &fakeChat{}, xai.Config{DefaultModel: "grok-test"},
+		func(s *grpc.Server) { v1.RegisterTokenizeServer(s, tok) })
+
+	req := xai.NewChatRequest().
+		SystemMessage(xai.SystemContent{Text: "be brief"}).
+		UserMessage(xai.UserContent{Text: "what is this", ImageURL: "https://example.com/cat.png"}).
+		AddTool(xai.NewFunctionTool("lookup", "find things").WithParameters(`{"type":"object"}`))
+
+	tokens, err := client.CountRequestTokens(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.Model != "grok-test" || tokens.Messages != 5 || tokens.Tools != 4 {
+		t.Errorf("tokens = %+v, want 5 message and 4 tool tokens for grok-test", tokens)
+	}
+	if tokens.Images != xai.EstimatedImageTokens || tokens.Overhead == 0 {
+		t.Errorf("estimates = %+v", tokens)
+	}
+	if tokens.Total != tokens.Messages+tokens.Tools+tokens.Images+tokens.Overhead {
+		t.Errorf("Total = %d", tokens.Total)
+	}
+
+	model := &xai.LanguageModel{MaxPromptLength: 2000}
+	if got := tokens.Remaining(model); got != 2000-tokens.Total {
+		t.Errorf("Remaining() = %d", got)
+	}
+	if got := tokens.Remaining(&xai.LanguageModel{}); got != 0 {
+		t.Errorf("Remaining() with unknown window = %d", got)
+	}
+}
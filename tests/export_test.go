@@ -0,0 +1,87 @@
+package xai_test
+
+import (
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func exportConversation(t *testing.T) *xai.Conversation {
+	t.Helper()
+	client := newFakeClient(t, &fakeChat{}, xai.Config{})
+	cv := client.NewConversation(xai.NewChatRequest().
+		SystemMessage(xai.SystemContent{Text: "secret instructions"}).
+		UserMessage(xai.UserContent{Text: "What is <b>Go</b>?", ImageURL: "https://example.com/go.png"}))
+	cv.AppendResponse(&xai.ChatResponse{
+		Content:   "A programming language.",
+		Citations: []string{"https://go.dev", "https://en.wikipedia.org/wiki/Go"},
+	})
+	cv.Edit(func(req *xai.ChatRequest) {
+		req.AssistantMessage(xai.AssistantContent{
+			ToolCalls: []xai.HistoryToolCall{{ID: "call_1", Name: "lookup", Arguments: `{"q":"go"}`}},
+		}).ToolResult(xai.ToolContent{CallID: "call_1", Result: "found"})
+	})
+	return cv
+}
+
+func TestExportMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := exportConversation(t).ExportMarkdown(&b, xai.WithExportTitle("Support chat")); err != nil {
+		t.Fatal(err)
+	}
+	md := b.String()
+	for _, want := range []string{
+		"# Support chat\n",
+		"### User\n\nWhat is <b>Go</b>?\n",
+		"![image](<https://example.com/go.png>)",
+		"A programming language. [^1] [^2]\n",
+		"**Tool call:** `lookup`\n\n```json\n{\"q\":\"go\"}\n```",
+		"### Tool result (call_1)\n\n```\nfound\n```",
+		"[^2]: <https://en.wikipedia.org/wiki/Go>\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "secret instructions") {
+		t.Error("system message exported without WithExportInstructions")
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	var b strings.Builder
+	if err := exportConversation(t).ExportHTML(&b, xai.WithExportInstructions()); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"What is &lt;b&gt;Go&lt;/b&gt;?",
+		`<img src="https://example.com/go.png"`,
+		`<a href="#fn2">[2]</a>`,
+		`<li id="fn1"><a href="https://go.dev">`,
+		"secret instructions",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("html missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestExportHTMLDataImage(t *testing.T) {
+	client := newFakeClient(t, &fakeChat{}, xai.Config{})
+	cv := client.NewConversation(xai.NewChatRequest().
+		UserWithImageBytes("What is this?", []byte("\x89PNG\r\n\x1a\n"), "image/png"))
+	var b strings.Builder
+	if err := cv.ExportHTML(&b); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	if !strings.Contains(page, `<img src="data:image/png;base64,`) {
+		t.Errorf("html lost the data URL:\n%s", page)
+	}
+	if strings.Contains(page, "ZgotmplZ") {
+		t.Errorf("html filtered the data URL:\n%s", page)
+	}
+}
@@ -0,0 +1,100 @@
+package xai_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func docxFile(t *testing.T, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(w, `<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>%s</w:body></w:document>`, body)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func pdfFile(t *testing.T, page string) []byte {
+	t.Helper()
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte(page))
+	zw.Close()
+	return []byte(fmt.Sprintf("%%PDF-1.4\n4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n%%%%EOF\n", z.Len(), z.Bytes()))
+}
+
+func TestExtractors(t *testing.T) {
+	ctx := context.Background()
+	e := xai.DefaultExtractors()
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+		ctype   string
+	}{
+		{
+			name:    "page.html",
+			content: []byte(`<html><head><title>T</title><style>p{}</style></head><body><h1>Title</h1><p>Hello   <b>world</b></p><script>x()</script></body></html>`),
+			want:    "Title\n\nHello world",
+			ctype:   xai.ContentTypeHTML,
+		},
+		{
+			name:    "report.docx",
+			content: docxFile(t, `<w:p><w:r><w:t>First</w:t></w:r><w:r><w:t xml:space="preserve"> para</w:t></w:r></w:p><w:p><w:r><w:t>Second</w:t></w:r></w:p>`),
+			want:    "First para\nSecond",
+			ctype:   xai.ContentTypeDOCX,
+		},
+		{
+			name:    "paper.pdf",
+			content: pdfFile(t, `BT /F1 12 Tf 72 712 Td (Hello \(PDF\)) Tj 0 -14 Td [(Wor) -20 (ld)] TJ ET`),
+			want:    "Hello (PDF)\nWorld",
+			ctype:   xai.ContentTypePDF,
+		},
+		{
+			name:    "notes.md",
+			content: []byte("# Notes\n"),
+			want:    "# Notes\n",
+			ctype:   xai.ContentTypeMarkdown,
+		},
+	}
+	for _, tt := range tests {
+		text, ctype, err := e.Extract(ctx, tt.name, tt.content)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if text != tt.want || ctype != tt.ctype {
+			t.Errorf("%s = %q (%s), want %q (%s)", tt.name, text, ctype, tt.want, tt.ctype)
+		}
+	}
+
+	bomb := pdfFile(t, "BT "+strings.Repeat(" ", xai.MaxPDFStreamSize)+" ET")
+	if _, _, err := e.Extract(ctx, "bomb.pdf", bomb); !errors.Is(err, xai.ErrInvalidSentinel) {
+		t.Errorf("oversized PDF stream: %v", err)
+	}
+
+	if _, _, err := e.Extract(ctx, "photo.png", []byte("\x89PNG\r\n\x1a\n")); !errors.Is(err, xai.ErrInvalidSentinel) {
+		t.Errorf("png: %v", err)
+	}
+	e.Register("image/*", xai.TextExtractorFunc(func(context.Context, []byte) (string, error) {
+		return "ocr text", nil
+	}))
+	if text, _, err := e.Extract(ctx, "photo.png", nil); err != nil || text != "ocr text" {
+		t.Errorf("custom extractor = %q, %v", text, err)
+	}
+}
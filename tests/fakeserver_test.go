@@ -0,0 +1,130 @@
+package xai_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeChat is an in-process Chat service used to exercise the client
+// without network access.
+type fakeChat struct {
+	v1.UnimplementedChatServer
+	complete func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error)
+	stream   func(*v1.GetCompletionsRequest, v1.Chat_GetCompletionChunkServer) error
+}
+
+func (f *fakeChat) GetCompletion(ctx context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+	return f.complete(ctx, req)
+}
+
+func (f *fakeChat) GetCompletionChunk(req *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+	return f.stream(req, srv)
+}
+
+// newFakeClient starts chat on an in-memory listener and returns a client
+// connected to it using cfg. An API key is filled in if cfg has none.
+// Each extra is either a grpc.ServerOption or a func(*grpc.Server) that
+// registers additional services.
+func newFakeClient(t testing.TB, chat v1.ChatServer, cfg xai.Config, extra ...any) *xai.Client {
+	t.Helper()
+
+	var opts []grpc.ServerOption
+	var registers []func(*grpc.Server)
+	for _, e := range extra {
+		switch e := e.(type) {
+		case grpc.ServerOption:
+			opts = append(opts, e)
+		case func(*grpc.Server):
+			registers = append(registers, e)
+		default:
+			t.Fatalf("newFakeClient: unsupported extra %T", e)
+		}
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(opts...)
+	v1.RegisterChatServer(srv, chat)
+	for _, register := range registers {
+		register(srv)
+	}
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	if cfg.APIKey == nil {
+		cfg.APIKey = xai.NewSecureString("test-key")
+	}
+	client, err := xai.WithChannelConfig(conn, cfg)
+	if err != nil {
+		t.Fatalf("WithChannelConfig: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// fakeModels serves a fixed set of language models.
+type fakeModels struct {
+	v1.UnimplementedModelsServer
+	models map[string]*v1.LanguageModel
+}
+
+func (f *fakeModels) GetLanguageModel(_ context.Context, req *v1.GetModelRequest) (*v1.LanguageModel, error) {
+	m, ok := f.models[req.GetName()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such model")
+	}
+	return m, nil
+}
+
+func (f *fakeModels) ListLanguageModels(context.Context, *emptypb.Empty) (*v1.ListLanguageModelsResponse, error) {
+	resp := &v1.ListLanguageModelsResponse{}
+	for _, m := range f.models {
+		resp.Models = append(resp.Models, m)
+	}
+	return resp, nil
+}
+
+// fakeTokenizer splits text on whitespace and counts calls.
+type fakeTokenizer struct {
+	v1.UnimplementedTokenizeServer
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeTokenizer) TokenizeText(_ context.Context, req *v1.TokenizeTextRequest) (*v1.TokenizeTextResponse, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	resp := &v1.TokenizeTextResponse{}
+	for _, w := range strings.Fields(req.GetText()) {
+		resp.Tokens = append(resp.Tokens, &v1.Token{StringToken: w})
+	}
+	return resp, nil
+}
+
+func (f *fakeTokenizer) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
@@ -0,0 +1,146 @@
+package xai_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// fuzzSeeds are adversarial responses: missing nested messages, unknown
+// enum values, huge strings and code execution outputs without their call.
+func fuzzSeeds() []*v1.GetChatCompletionResponse {
+	huge := strings.Repeat("x", 1<<12)
+	return []*v1.GetChatCompletionResponse{
+		{},
+		{Outputs: []*v1.CompletionOutput{{}}},
+		{Outputs: []*v1.CompletionOutput{{Index: -1, FinishReason: 99, Message: &v1.CompletionMessage{Role: 42}}}},
+		{Outputs: []*v1.CompletionOutput{{Message: &v1.CompletionMessage{
+			Content:   huge,
+			ToolCalls: []*v1.ToolCall{{Type: 77, Status: 88}, {Type: v1.ToolCallType_TOOL_CALL_TYPE_CODE_EXECUTION_TOOL}},
+		}}}},
+		{Outputs: []*v1.CompletionOutput{
+			{Index: 1, Message: &v1.CompletionMessage{Role: v1.MessageRole_ROLE_TOOL, Content: `{"files": [{"data": 5}], "duration": "x"}`}},
+			{Index: 1, Logprobs: &v1.LogProbs{Content: []*v1.LogProb{{TopLogprobs: []*v1.TopLogProb{{}}}}}},
+		}},
+		{Usage: &v1.SamplingUsage{PromptTokens: -5, ServerSideToolsUsed: []v1.ServerSideTool{-1, 1000}}},
+	}
+}
+
+// FuzzChatResponse feeds arbitrary responses and chunks through the gRPC
+// transport; conversion must never panic. Run with make fuzz.
+func FuzzChatResponse(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		b, err := proto.Marshal(seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+
+	var mu sync.Mutex
+	var resp *v1.GetChatCompletionResponse
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return resp, nil
+		},
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			mu.Lock()
+			r := resp
+			mu.Unlock()
+			// The same bytes decode as a chunk, exercising the stream path.
+			b, _ := proto.Marshal(r)
+			var chunk v1.GetChatCompletionChunk
+			if err := proto.Unmarshal(b, &chunk); err != nil {
+				return nil
+			}
+			return srv.Send(&chunk)
+		},
+	}
+	client := newFakeClient(f, chat, xai.Config{DefaultModel: "m"})
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}).WithStop("x")
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var r v1.GetChatCompletionResponse
+		if err := proto.Unmarshal(data, &r); err != nil {
+			return
+		}
+		mu.Lock()
+		resp = &r
+		mu.Unlock()
+
+		ctx := context.Background()
+		if got, err := client.CompleteChat(ctx, req.Clone()); err == nil {
+			_ = got.AssistantContent()
+			xai.NewChatRequest().AppendResponse(got)
+		}
+		stream, err := client.StreamChat(ctx, req.Clone())
+		if err != nil {
+			return
+		}
+		defer stream.Close()
+		for {
+			if _, err := stream.Next(); err != nil {
+				break
+			}
+		}
+	})
+}
+
+// FuzzRESTResponse does the same for arbitrary REST bodies, both plain JSON
+// and as a server-sent event.
+func FuzzRESTResponse(f *testing.F) {
+	f.Add(`{"id":"c1","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	f.Add(`{"choices":[null,{"message":null,"logprobs":{"content":[null]}}],"usage":null}`)
+	f.Add(`{"choices":[{"index":-3,"message":{"content":[{"type":"text"}],"tool_calls":[null,{"function":null}]},"finish_reason":"banana"}]}`)
+	f.Add(`{"choices":[{"message":{"tool_calls":[{"type":"function","function":{"name":"f","arguments":"{"}}]}}],"created":-1}`)
+	f.Add(`{"choices":[{"delta":{"content":"a","tool_calls":[null]},"finish_reason":null}],"usage":{"prompt_tokens":-1}}`)
+
+	var mu sync.Mutex
+	var body string
+	client := newRESTClient(f, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var in struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		if in.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: %s\n\ndata: [DONE]\n\n", body)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+
+	f.Fuzz(func(t *testing.T, data string) {
+		mu.Lock()
+		body = data
+		mu.Unlock()
+		ctx := context.Background()
+		if got, err := client.CompleteChat(ctx, req.Clone()); err == nil {
+			_ = got.AssistantContent()
+		}
+		stream, err := client.StreamChat(ctx, req.Clone())
+		if err != nil {
+			return
+		}
+		defer stream.Close()
+		for {
+			if _, err := stream.Next(); err != nil {
+				break
+			}
+		}
+	})
+}
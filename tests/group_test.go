@@ -0,0 +1,70 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGroup(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(ctx context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			switch text := req.GetMessages()[0].GetContent()[0].GetText(); text {
+			case "fail":
+				return nil, status.Error(codes.InvalidArgument, "bad")
+			case "hang":
+				<-ctx.Done()
+				return nil, status.FromContextError(ctx.Err()).Err()
+			default:
+				return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{
+					Message: &v1.CompletionMessage{Content: "echo " + text},
+				}}}, nil
+			}
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	ask := func(text string) *xai.ChatRequest {
+		return xai.NewChatRequest().UserMessage(xai.UserContent{Text: text})
+	}
+
+	g := xai.NewGroup(context.Background(), client, xai.WithGroupLimit(2))
+	for _, q := range []string{"a", "b", "c"} {
+		g.Go(ask(q))
+	}
+	results, err := g.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"echo a", "echo b", "echo c"} {
+		if results[i].Index != i || results[i].Response == nil || results[i].Response.Content != want {
+			t.Errorf("result %d = %+v", i, results[i])
+		}
+	}
+
+	// A fatal error cancels the hanging sibling.
+	g = xai.NewGroup(context.Background(), client)
+	g.Go(ask("hang"))
+	g.Go(ask("fail"))
+	results, err = g.Wait()
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || xaiErr.Code != xai.ErrInvalidRequest {
+		t.Fatalf("Wait error = %v", err)
+	}
+	if !errors.As(results[0].Err, &xaiErr) || xaiErr.Code != xai.ErrCanceled {
+		t.Errorf("sibling error = %v", results[0].Err)
+	}
+
+	// Non-fatal errors leave siblings alone.
+	g = xai.NewGroup(context.Background(), client, xai.WithGroupFatal(func(error) bool { return false }))
+	g.Go(ask("fail"))
+	g.Go(ask("ok"))
+	results, err = g.Wait()
+	if err != nil || results[0].Err == nil || results[1].Response.Content != "echo ok" {
+		t.Errorf("partial results = %+v, %v", results, err)
+	}
+}
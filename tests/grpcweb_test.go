@@ -0,0 +1,161 @@
+package xai_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcWebFrame encodes one gRPC-Web frame.
+func grpcWebFrame(flag byte, payload []byte) []byte {
+	frame := make([]byte, 5, 5+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+func TestGRPCWebTransport(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /xai_api.Chat/GetCompletion", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Content-Type"); got != "application/grpc-web+proto" {
+			t.Errorf("content-type = %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer xai-web-key" {
+			w.Header().Set("Grpc-Status", "16")
+			w.Header().Set("Grpc-Message", "bad%20key")
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req v1.GetCompletionsRequest
+		if err := proto.Unmarshal(body[5:], &req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Messages[0].Content[0].GetText() == "fail" {
+			w.Header().Set("Content-Type", "application/grpc-web+proto")
+			w.Write(grpcWebFrame(0x80, []byte("grpc-status: 8\r\ngrpc-message: slow%20down\r\n")))
+			return
+		}
+		resp, _ := proto.Marshal(answerResponse("echo " + req.Model))
+		w.Header().Set("Content-Type", "application/grpc-web+proto")
+		w.Header().Set("X-Request-Id", "req-web")
+		w.Write(grpcWebFrame(0x00, resp))
+		w.Write(grpcWebFrame(0x80, []byte("grpc-status: 0\r\n")))
+	})
+	mux.HandleFunc("POST /xai_api.Chat/GetCompletionChunk", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/grpc-web+proto")
+		var out bytes.Buffer
+		for _, text := range []string{"hel", "lo"} {
+			chunk, _ := proto.Marshal(&v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: text}}}})
+			out.Write(grpcWebFrame(0x00, chunk))
+		}
+		out.Write(grpcWebFrame(0x80, []byte("grpc-status: 0\r\n")))
+		w.Write(out.Bytes())
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	newClient := func(key string) *xai.Client {
+		client, err := xai.New(xai.Config{
+			APIKey:          xai.NewSecureString(key),
+			DefaultModel:    "grok-3",
+			Transport:       xai.TransportGRPCWeb,
+			GRPCWebEndpoint: srv.URL,
+			HTTPClient:      srv.Client(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		return client
+	}
+	client := newClient("xai-web-key")
+	ctx := context.Background()
+
+	resp, err := client.CompleteChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "echo grok-3" {
+		t.Errorf("content = %q", resp.Content)
+	}
+	if resp.Metadata == nil || resp.Metadata.RequestID() != "req-web" {
+		t.Errorf("metadata = %+v", resp.Metadata)
+	}
+
+	stream, err := client.StreamChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "stream"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var content string
+	for {
+		chunk, err := stream.Next()
+		if err != nil {
+			break
+		}
+		content += chunk.Delta
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if content != "hello" {
+		t.Errorf("streamed %q", content)
+	}
+
+	var xerr *xai.Error
+	_, err = client.CompleteChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "fail"}))
+	if !errors.As(err, &xerr) || xerr.Code != xai.ErrRateLimit {
+		t.Errorf("trailer status: err = %v", err)
+	}
+	_, err = newClient("wrong").CompleteChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+	if !errors.As(err, &xerr) || xerr.Code != xai.ErrAuth {
+		t.Errorf("trailers-only status: err = %v", err)
+	}
+}
+
+func TestGRPCWebBadFrames(t *testing.T) {
+	tests := map[string]struct {
+		frame []byte
+		want  string
+	}{
+		"compressed": {grpcWebFrame(0x01, []byte("gzip")), "compressed frames are not supported"},
+		// Only the header: the client must not allocate the claimed 4 GiB.
+		"oversized": {[]byte{0x00, 0xff, 0xff, 0xff, 0xff}, "exceeds the 4194304 byte limit"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/grpc-web+proto")
+				w.Write(tt.frame)
+			}))
+			t.Cleanup(srv.Close)
+			client, err := xai.New(xai.Config{
+				APIKey:          xai.NewSecureString("xai-web-key"),
+				DefaultModel:    "grok-3",
+				Transport:       xai.TransportGRPCWeb,
+				GRPCWebEndpoint: srv.URL,
+				HTTPClient:      srv.Client(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = client.Close() })
+
+			_, err = client.CompleteChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+			var xerr *xai.Error
+			if !errors.As(err, &xerr) || xerr.Code != xai.ErrServerError || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want ErrServerError containing %q", err, tt.want)
+			}
+		})
+	}
+}
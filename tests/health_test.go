@@ -0,0 +1,45 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type fakeAuth struct {
+	v1.UnimplementedAuthServer
+	key *v1.ApiKey
+}
+
+func (f *fakeAuth) GetApiKeyInfo(context.Context, *emptypb.Empty) (*v1.ApiKey, error) {
+	return f.key, nil
+}
+
+func TestPing(t *testing.T) {
+	auth := &fakeAuth{key: &v1.ApiKey{Name: "prod"}}
+	client := newFakeClient(t, &fakeChat{}, xai.Config{}, func(s *grpc.Server) {
+		v1.RegisterAuthServer(s, auth)
+	})
+
+	res, err := client.Ping(context.Background())
+	if err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if res.Latency <= 0 || res.ConnState != "READY" || res.KeyStatus != xai.APIKeyActive {
+		t.Errorf("result = %+v", res)
+	}
+
+	auth.key = &v1.ApiKey{Disabled: true}
+	res, err = client.Ping(context.Background())
+	if !errors.Is(err, xai.ErrAuthSentinel) {
+		t.Fatalf("expected auth error for disabled key, got %v", err)
+	}
+	if res == nil || res.KeyStatus != xai.APIKeyDisabled {
+		t.Errorf("result = %+v", res)
+	}
+}
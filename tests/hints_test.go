@@ -0,0 +1,81 @@
+package xai_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"golang.org/x/text/language"
+)
+
+func TestLocaleAndTimezoneHints(t *testing.T) {
+	loc := time.FixedZone("Africa/Johannesburg", 2*60*60)
+	built := xai.NewChatRequest().
+		SystemMessage(xai.SystemContent{Text: "sys"}).
+		WithLocale(language.MustParse("af-ZA")).
+		WithTimezone(loc).
+		UserMessage(xai.UserContent{Text: "hi"}).
+		Build("m")
+
+	if len(built.Messages) != 3 {
+		t.Fatalf("got %d messages, want hint + 2", len(built.Messages))
+	}
+	// The hint follows the leading system messages.
+	if built.Messages[0].GetRole() != v1.MessageRole_ROLE_SYSTEM {
+		t.Errorf("first message role = %v, want the system message", built.Messages[0].GetRole())
+	}
+	hint := built.Messages[1]
+	if hint.GetRole() != v1.MessageRole_ROLE_DEVELOPER {
+		t.Errorf("hint role = %v", hint.GetRole())
+	}
+	text := hint.GetContent()[0].GetText()
+	for _, want := range []string{"User locale: af-ZA", "Africa/Johannesburg (UTC+02:00)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("hint %q missing %q", text, want)
+		}
+	}
+}
+
+func TestHintsSkippedOnContinuation(t *testing.T) {
+	built := xai.NewChatRequest().
+		WithLocale(language.MustParse("af-ZA")).
+		WithPreviousResponseId("resp-1").
+		UserMessage(xai.UserContent{Text: "more"}).
+		Build("m")
+	if len(built.Messages) != 1 || built.Messages[0].GetRole() != v1.MessageRole_ROLE_USER {
+		t.Errorf("continuation messages = %v, want only the user message", built.Messages)
+	}
+}
+
+func TestCurrentDateHint(t *testing.T) {
+	built := xai.NewChatRequest().
+		WithCurrentDate().
+		UserMessage(xai.UserContent{Text: "what day is it"}).
+		Build("m")
+
+	want := "Current date: " + time.Now().UTC().Format("2006-01-02")
+	if text := built.Messages[0].GetContent()[0].GetText(); !strings.HasPrefix(text, want) {
+		t.Errorf("hint = %q, want prefix %q", text, want)
+	}
+}
+
+func TestResponseLength(t *testing.T) {
+	built := xai.NewChatRequest().
+		WithResponseLength(xai.ResponseLengthShort).
+		UserMessage(xai.UserContent{Text: "explain gravity"}).
+		Build("m")
+
+	if built.GetMaxTokens() != 256 {
+		t.Errorf("max_tokens = %d, want 256", built.GetMaxTokens())
+	}
+	if text := built.Messages[0].GetContent()[0].GetText(); !strings.Contains(text, "three sentences") {
+		t.Errorf("length instruction missing: %q", text)
+	}
+
+	built = xai.NewChatRequest().WithResponseLength(xai.ResponseLengthLong).WithMaxTokens(100).Build("m")
+	if built.GetMaxTokens() != 100 {
+		t.Errorf("explicit max_tokens not kept: %d", built.GetMaxTokens())
+	}
+}
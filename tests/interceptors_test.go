@@ -0,0 +1,96 @@
+package xai_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"slices"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestConfigInterceptors(t *testing.T) {
+	lis, clientTLS := newTLSListener(t, &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return answerResponse("ok"), nil
+		},
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			return srv.Send(&v1.GetChatCompletionChunk{Id: "chunk-1"})
+		},
+	})
+
+	var calls []string
+	// record notes name and whether the built-in interceptors had already
+	// added the client info header when it ran.
+	record := func(ctx context.Context, name string) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if len(md.Get("x-client-info")) > 0 {
+			name += "+info"
+		}
+		calls = append(calls, name)
+	}
+	unary := func(name string) grpc.UnaryClientInterceptor {
+		return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			record(ctx, name)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+	}
+	stream := func(name string) grpc.StreamClientInterceptor {
+		return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			record(ctx, name)
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+	}
+
+	client, err := xai.New(xai.Config{
+		APIKey:             xai.NewSecureString("test-key"),
+		Endpoint:           "xai.internal:443",
+		DefaultModel:       "m",
+		TLSConfig:          clientTLS,
+		Dialer:             func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) },
+		UnaryInterceptors:  []grpc.UnaryClientInterceptor{unary("unary1"), unary("unary2")},
+		StreamInterceptors: []grpc.StreamClientInterceptor{stream("stream1"), stream("stream2")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	client.Use(func(next xai.Handler) xai.Handler {
+		return func(ctx context.Context, call *xai.Call) error {
+			record(ctx, "middleware")
+			return next(ctx, call)
+		}
+	})
+
+	ctx := context.Background()
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	s, err := client.StreamChat(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := s.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	// Middleware runs first and the configured interceptors last, in the
+	// order given, seeing the headers the built-in interceptors added.
+	want := []string{
+		"middleware+info", "unary1+info", "unary2+info",
+		"middleware+info", "stream1+info", "stream2+info",
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
@@ -0,0 +1,117 @@
+package xai_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	"golang.org/x/text/language"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestChatResponseJSON(t *testing.T) {
+	resp := &xai.ChatResponse{
+		ID:           "resp_1",
+		Content:      "hi",
+		FinishReason: xai.FinishReasonToolCalls,
+		Usage:        xai.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+		ToolCalls: []*xai.ToolCallInfo{{
+			ID:       "call_1",
+			Type:     xai.ToolCallTypeClient,
+			Status:   xai.ToolCallStatusCompleted,
+			Function: &xai.FunctionCall{Name: "add", Arguments: `{"a":1}`},
+		}},
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got := string(b)
+	for _, want := range []string{
+		`"id":"resp_1"`,
+		`"finish_reason":"tool_calls"`,
+		`"prompt_tokens":3`,
+		`"completion_tokens":2`,
+		`"tool_calls":[{"id":"call_1","index":0,"type":"client","status":"completed","function":{"name":"add","arguments":"{\"a\":1}"}}]`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Marshal() = %s, missing %s", got, want)
+		}
+	}
+	if strings.Contains(got, "reasoning_content") {
+		t.Errorf("empty reasoning_content should be omitted: %s", got)
+	}
+
+	var back xai.ChatResponse
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if back.ToolCalls[0].Status != xai.ToolCallStatusCompleted || back.Usage.TotalTokens != 5 {
+		t.Errorf("round trip mismatch: %+v", back)
+	}
+}
+
+func TestChatRequestJSON(t *testing.T) {
+	build := func() *xai.ChatRequest {
+		return xai.NewChatRequest().
+			WithModel("grok-4").
+			WithSystemPrompt(xai.NewSystemPrompt("You are terse.").Persona("A pirate.")).
+			UserMessage(xai.UserContent{Text: "What's the weather?"}).
+			AddTool(xai.NewFunctionTool("get_weather", "Get the weather").
+				WithParameters(json.RawMessage(`{"type":"object"}`))).
+			AddTool(xai.NewWebSearchTool().WithAllowedDomains("example.com")).
+			WithToolChoice(xai.ToolChoiceRequired).
+			WithMaxTokens(256).
+			WithTemperature(0.3).
+			WithStop("END").
+			WithReasoningEffort(xai.ReasoningEffortHigh).
+			WithMaxReasoningTokens(1000).
+			WithLocale(language.MustParse("fr-FR")).
+			WithTimezone(time.UTC).
+			IncludeInlineCitations()
+	}
+	orig := build()
+
+	b, err := json.Marshal(orig)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var back xai.ChatRequest
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want, got := orig.Build("default"), back.Build("default")
+	if !proto.Equal(want, got) {
+		t.Errorf("rebuilt request differs:\n got %v\nwant %v", got, want)
+	}
+	if fn, ok := back.Tools()[0].(*xai.FunctionTool); !ok || fn.Name != "get_weather" {
+		t.Errorf("Tools()[0] = %#v, want *FunctionTool get_weather", back.Tools()[0])
+	}
+
+	again, err := json.Marshal(&back)
+	if err != nil {
+		t.Fatalf("Marshal() of restored request error = %v", err)
+	}
+	if string(again) != string(b) {
+		t.Errorf("second round trip differs:\n%s\n%s", again, b)
+	}
+}
+
+func TestChatRequestJSONErrors(t *testing.T) {
+	bad := xai.NewChatRequest().WithJSONSchema("s", json.RawMessage(`{`), false)
+	if _, err := json.Marshal(bad); err == nil {
+		t.Error("Marshal() of request with builder error succeeded")
+	}
+
+	var req xai.ChatRequest
+	if err := json.Unmarshal([]byte(`{"timezone":"Nowhere/Void"}`), &req); err == nil {
+		t.Error("Unmarshal() with unknown timezone succeeded")
+	}
+	if err := json.Unmarshal([]byte(`{"include":["NOPE"]}`), &req); err == nil {
+		t.Error("Unmarshal() with unknown include option succeeded")
+	}
+}
@@ -0,0 +1,66 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKeyPoolBenchesRateLimitedKeys(t *testing.T) {
+	calls := 0
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			calls++
+			if calls == 1 {
+				return nil, status.Error(codes.ResourceExhausted, "too many requests")
+			}
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	pool := xai.NewKeyPool([]*xai.SecureString{
+		xai.NewSecureString("key-aaaa"),
+		xai.NewSecureString("key-bbbb"),
+	}, xai.KeyPoolRoundRobin)
+	client := newFakeClient(t, chat, xai.Config{KeyPool: pool})
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+	ctx := context.Background()
+
+	if _, err := client.CompleteChat(ctx, req); !errors.Is(err, xai.ErrRateLimitSentinel) {
+		t.Fatalf("first call: got %v, want rate limit", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := client.CompleteChat(ctx, req); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+
+	stats := pool.Stats()
+	if stats[0].RateLimitHits != 1 || stats[0].BenchedUntil.IsZero() {
+		t.Errorf("key 0 should be benched: %+v", stats[0])
+	}
+	if stats[0].Requests != 1 || stats[1].Requests != 3 {
+		t.Errorf("requests = %d/%d, want 1/3", stats[0].Requests, stats[1].Requests)
+	}
+	if stats[1].RedactedKey != "****bbbb" {
+		t.Errorf("RedactedKey = %q", stats[1].RedactedKey)
+	}
+
+	// Bench the remaining key: the pool should refuse without calling the server.
+	calls = 0
+	pool2 := xai.NewKeyPool([]*xai.SecureString{xai.NewSecureString("only")}, xai.KeyPoolLeastLoaded)
+	client2 := newFakeClient(t, chat, xai.Config{KeyPool: pool2})
+	_, _ = client2.CompleteChat(ctx, req)
+	_, err := client2.CompleteChat(ctx, req)
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || xaiErr.Code != xai.ErrRateLimit || xaiErr.RetryAfter <= 0 {
+		t.Fatalf("expected pool rate limit with RetryAfter, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("server called %d times, want 1", calls)
+	}
+}
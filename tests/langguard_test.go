@@ -0,0 +1,64 @@
+package xai_test
+
+import (
+	"context"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"golang.org/x/text/language"
+)
+
+func TestDefaultLanguageDetector(t *testing.T) {
+	d := xai.DefaultLanguageDetector()
+	for text, want := range map[string]string{
+		"The weather is nice and it is warm for this time of the year.": "en",
+		"El tiempo es muy bueno y hace calor para esta época del año.":  "es",
+		"Das Wetter ist schön und es ist warm für die Jahreszeit.":      "de",
+		"Die weer is mooi en dit is warm vir die tyd van die jaar.":     "af",
+		"Погода сегодня хорошая и тёплая.":                              "ru",
+		"今日はとても良い天気ですね。":                                                "ja",
+	} {
+		got, conf := d.Detect(text)
+		if got.String() != want {
+			t.Errorf("Detect(%q) = %v (%.2f), want %s", text, got, conf, want)
+		}
+	}
+	if got, _ := d.Detect("ok"); got != language.Und {
+		t.Errorf("short text detected as %v", got)
+	}
+}
+
+func TestExpectedLanguageReprompts(t *testing.T) {
+	var requests []*v1.GetCompletionsRequest
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			requests = append(requests, req)
+			text := "The answer is that the sky is blue because of the way light scatters."
+			if len(requests) > 1 {
+				text = "La respuesta es que el cielo es azul por la forma en que se dispersa la luz."
+			}
+			return &v1.GetChatCompletionResponse{
+				Outputs: []*v1.CompletionOutput{{Message: &v1.CompletionMessage{Content: text}}},
+			}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+
+	req := xai.NewChatRequest().
+		WithExpectedLanguage(language.Spanish).
+		UserMessage(xai.UserContent{Text: "¿Por qué el cielo es azul?"})
+	resp, err := client.CompleteChat(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 || !resp.LanguageCorrected {
+		t.Fatalf("requests = %d, corrected = %v", len(requests), resp.LanguageCorrected)
+	}
+	if n := len(requests[1].GetMessages()); n != 3 {
+		t.Errorf("re-prompt has %d messages, want 3", n)
+	}
+	if len(req.Messages()) != 1 {
+		t.Errorf("original request was modified")
+	}
+}
@@ -0,0 +1,39 @@
+package xai_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestLoggerRedactsKey(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{
+		APIKey: xai.NewSecureString("xai-supersecret-1234"),
+		Logger: logger,
+	})
+	if _, err := client.CompleteChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"client created", "request started", "request finished", "method=Chat/GetCompletion", "api_key=****1234"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "supersecret") {
+		t.Errorf("log leaked API key:\n%s", out)
+	}
+}
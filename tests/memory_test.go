@@ -0,0 +1,98 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestMemoryConsolidator(t *testing.T) {
+	var prompts []string
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			prompt := req.GetMessages()[1].GetContent()[0].GetText()
+			prompts = append(prompts, prompt)
+			return &v1.GetChatCompletionResponse{
+				Outputs: []*v1.CompletionOutput{{Message: &v1.CompletionMessage{Content: fmt.Sprintf("- summary %d", len(prompts))}}},
+			}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-3"})
+	cv := client.NewConversation(xai.NewChatRequest().SystemMessage(xai.SystemContent{Text: "Be helpful."}))
+	addTurns := func(from, to int) {
+		for i := from; i < to; i++ {
+			cv.Edit(func(req *xai.ChatRequest) { req.UserMessage(xai.UserContent{Text: fmt.Sprintf("question %d", i)}) })
+			cv.AppendResponse(&xai.ChatResponse{Content: fmt.Sprintf("answer %d", i)})
+		}
+	}
+	mc := xai.NewMemoryConsolidator(client, xai.WithMemoryKeepRecent(2), xai.WithMemorySchema("List the user's questions."))
+	ctx := context.Background()
+
+	addTurns(0, 2)
+	if done, err := mc.Consolidate(ctx, cv); err != nil || done {
+		t.Fatalf("consolidated too early: %v %v", done, err)
+	}
+
+	addTurns(2, 4)
+	done, err := mc.Consolidate(ctx, cv)
+	if err != nil || !done {
+		t.Fatalf("Consolidate = %v, %v", done, err)
+	}
+	msgs := cv.Request().Messages()
+	if len(msgs) != 4 {
+		t.Fatalf("got %d messages, want system + memory + 2 recent", len(msgs))
+	}
+	if msgs[1].GetRole() != v1.MessageRole_ROLE_DEVELOPER || !strings.Contains(msgs[1].GetContent()[0].GetText(), "- summary 1") {
+		t.Errorf("memory message = %v", msgs[1])
+	}
+	if msgs[2].GetContent()[0].GetText() != "question 3" {
+		t.Errorf("recent turns not kept: %v", msgs[2])
+	}
+	if !strings.Contains(prompts[0], "user: question 0") || strings.Contains(prompts[0], "question 3") {
+		t.Errorf("first prompt = %q", prompts[0])
+	}
+
+	addTurns(4, 6)
+	if done, err := mc.Consolidate(ctx, cv); err != nil || !done {
+		t.Fatalf("second Consolidate = %v, %v", done, err)
+	}
+	if !strings.Contains(prompts[1], "PREVIOUS MEMORY:\n- summary 1") || !strings.Contains(prompts[1], "user: question 3") {
+		t.Errorf("second prompt = %q", prompts[1])
+	}
+	if msgs := cv.Request().Messages(); len(msgs) != 4 || !strings.Contains(msgs[1].GetContent()[0].GetText(), "- summary 2") {
+		t.Errorf("after second consolidation: %d messages", len(msgs))
+	}
+}
+
+func TestMemoryConsolidatorRunClock(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			cancel()
+			return answerResponse("- summary"), nil
+		},
+	}
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-3", Clock: clock})
+	cv := client.NewConversation(nil)
+	for i := range 4 {
+		cv.Edit(func(req *xai.ChatRequest) { req.UserMessage(xai.UserContent{Text: fmt.Sprintf("question %d", i)}) })
+		cv.AppendResponse(&xai.ChatResponse{Content: fmt.Sprintf("answer %d", i)})
+	}
+
+	// An hour on the fake clock passes at once.
+	mc := xai.NewMemoryConsolidator(client, xai.WithMemoryKeepRecent(2))
+	if err := mc.Run(ctx, cv, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled after one consolidation", err)
+	}
+	if got := clock.Now(); got.Before(time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("clock = %v, want at least one tick", got)
+	}
+}
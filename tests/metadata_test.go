@@ -0,0 +1,198 @@
+package xai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestResponseMetadata(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(ctx context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			if req.GetUser() == "limited" {
+				_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", "req-err", "retry-after", "2"))
+				return nil, status.Error(codes.ResourceExhausted, "slow down")
+			}
+			_ = grpc.SetHeader(ctx, metadata.Pairs(
+				"x-request-id", "req-123",
+				"x-ratelimit-remaining-requests", "99",
+				"x-ratelimit-reset-tokens", "1.5",
+			))
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-3"})
+	ctx := context.Background()
+
+	resp, err := client.CompleteChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Metadata.RequestID(); got != "req-123" {
+		t.Errorf("RequestID = %q", got)
+	}
+	rl := resp.Metadata.RateLimit()
+	if rl.RemainingRequests != 99 || rl.ResetTokens != 1500*time.Millisecond {
+		t.Errorf("RateLimit = %+v", rl)
+	}
+
+	_, err = client.CompleteChat(ctx, xai.NewChatRequest().WithUser("limited").UserMessage(xai.UserContent{Text: "hi"}))
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || !xaiErr.IsRateLimit() {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if xaiErr.Metadata.RequestID() != "req-err" || xaiErr.RetryAfter != 2*time.Second {
+		t.Errorf("error metadata: request id %q, retry after %v", xaiErr.Metadata.RequestID(), xaiErr.RetryAfter)
+	}
+}
+
+func TestRequestMetadata(t *testing.T) {
+	got := map[string]string{}
+	chat := &fakeChat{
+		complete: func(ctx context.Context, _ *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			for _, k := range []string{"x-xai-metadata-tenant", "x-xai-metadata-feature"} {
+				if v := md.Get(k); len(v) > 0 {
+					got[k] = v[0]
+				}
+			}
+			return answerResponse("ok"), nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "m"})
+	req := xai.NewChatRequest().
+		UserMessage(xai.UserContent{Text: "hi"}).
+		WithMetadata(map[string]string{"Tenant": "acme"}).
+		WithMetadata(map[string]string{"feature": "search"})
+
+	resp, err := client.CompleteChat(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["x-xai-metadata-tenant"] != "acme" || got["x-xai-metadata-feature"] != "search" {
+		t.Errorf("server saw %v", got)
+	}
+	if resp.RequestMetadata["tenant"] != "acme" || resp.RequestMetadata["feature"] != "search" {
+		t.Errorf("RequestMetadata = %v", resp.RequestMetadata)
+	}
+
+	// The pairs survive a JSON round trip of the request.
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back xai.ChatRequest
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Metadata()["tenant"] != "acme" {
+		t.Errorf("after round trip Metadata() = %v", back.Metadata())
+	}
+
+	for _, bad := range []map[string]string{{"": "x"}, {"a b": "x"}, {"k": "line\nbreak"}} {
+		req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}).WithMetadata(bad)
+		_, err := client.CompleteChat(context.Background(), req)
+		var xaiErr *xai.Error
+		if !errors.As(err, &xaiErr) || xaiErr.Code != xai.ErrInvalidRequest {
+			t.Errorf("WithMetadata(%q): err = %v, want ErrInvalidRequest", bad, err)
+		}
+	}
+}
+
+func TestRequestMetadataREST(t *testing.T) {
+	var tenant string
+	client := newRESTClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = r.Header.Get("X-Xai-Metadata-Tenant")
+		fmt.Fprint(w, `{"id":"c1","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}).WithMetadata(map[string]string{"tenant": "acme"})
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if tenant != "acme" {
+		t.Errorf("tenant header = %q", tenant)
+	}
+}
+
+func TestStreamResponseMetadata(t *testing.T) {
+	chat := &fakeChat{
+		stream: func(req *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			_ = srv.SetHeader(metadata.Pairs("x-request-id", "stream-"+req.GetUser()))
+			srv.SetTrailer(metadata.Pairs("x-ratelimit-remaining-tokens", "42"))
+			if req.GetUser() == "fail" {
+				return status.Error(codes.Unavailable, "overloaded")
+			}
+			return srv.Send(&v1.GetChatCompletionChunk{Id: "c1"})
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "m"})
+	ctx := context.Background()
+
+	next := func(user string) (*xai.ChunkStream, error) {
+		stream, err := client.StreamChat(ctx, xai.NewChatRequest().WithUser(user).UserMessage(xai.UserContent{Text: "hi"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { stream.Close() })
+		for {
+			if _, err := stream.Next(); err != nil {
+				return stream, err
+			}
+		}
+	}
+
+	stream, err := next("ok")
+	if err != io.EOF {
+		t.Fatalf("Next = %v, want io.EOF", err)
+	}
+	if md := stream.Metadata(); md.RequestID() != "stream-ok" || md.RateLimit().RemainingTokens != 42 {
+		t.Errorf("stream metadata = %+v", md)
+	}
+
+	_, err = next("fail")
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || xaiErr.Metadata.RequestID() != "stream-fail" {
+		t.Errorf("stream error = %v, want request ID stream-fail", err)
+	}
+}
+
+func TestServiceResponseMetadata(t *testing.T) {
+	tagged := grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		text := req.(*v1.TokenizeTextRequest).GetText()
+		_ = grpc.SetHeader(ctx, metadata.Pairs("x-request-id", "tok-"+text))
+		if text == "fail" {
+			return nil, status.Error(codes.InvalidArgument, "bad text")
+		}
+		return handler(ctx, req)
+	})
+	client := newFakeClient(t, &fakeChat{}, xai.Config{DefaultModel: "m"}, tagged, func(s *grpc.Server) {
+		v1.RegisterTokenizeServer(s, &fakeTokenizer{})
+	})
+	ctx := context.Background()
+
+	resp, err := client.Tokenize(ctx, "m", "ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Metadata.RequestID(); got != "tok-ok" {
+		t.Errorf("RequestID = %q", got)
+	}
+
+	_, err = client.Tokenize(ctx, "m", "fail")
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || xaiErr.Metadata.RequestID() != "tok-fail" {
+		t.Errorf("error = %v, want request ID tok-fail", err)
+	}
+}
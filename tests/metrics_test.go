@@ -0,0 +1,108 @@
+package xai_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	calls := 0
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			calls++
+			if calls > 1 {
+				return nil, status.Error(codes.ResourceExhausted, "slow down")
+			}
+			return &v1.GetChatCompletionResponse{
+				Outputs: []*v1.CompletionOutput{{Message: &v1.CompletionMessage{Content: "hi"}}},
+				Usage:   &v1.SamplingUsage{PromptTokens: 5, CompletionTokens: 2},
+			}, nil
+		},
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			_ = srv.Send(&v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: "a"}}}})
+			return srv.Send(&v1.GetChatCompletionChunk{Usage: &v1.SamplingUsage{PromptTokens: 3, CompletionTokens: 1}})
+		},
+	}
+	metrics := xai.NewPrometheusMetrics()
+	client := newFakeClient(t, chat, xai.Config{Metrics: metrics, DefaultModel: "m1"})
+	ctx := context.Background()
+
+	if _, err := client.CompleteChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "x"})); err != nil {
+		t.Fatalf("CompleteChat: %v", err)
+	}
+	if _, err := client.CompleteChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "x"})); err == nil {
+		t.Fatal("expected rate limit error")
+	}
+	stream, err := client.StreamChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "x"}))
+	if err != nil {
+		t.Fatalf("StreamChat: %v", err)
+	}
+	for {
+		if _, err := stream.Next(); err != nil {
+			break
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`xai_requests_total{method="Chat/GetCompletion",model="m1",code="ok"} 1`,
+		`xai_requests_total{method="Chat/GetCompletion",model="m1",code="rate_limit_error"} 1`,
+		`xai_requests_total{method="Chat/GetCompletionChunk",model="m1",code="ok"} 1`,
+		`xai_tokens_total{method="Chat/GetCompletion",model="m1",direction="input"} 5`,
+		`xai_tokens_total{method="Chat/GetCompletionChunk",model="m1",direction="output"} 1`,
+		`xai_time_to_first_token_seconds_count{method="Chat/GetCompletionChunk",model="m1"} 1`,
+		`xai_request_duration_seconds_count{method="Chat/GetCompletion",model="m1"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+// observedRequests sends every observed request on a channel.
+type observedRequests chan xai.RequestMetrics
+
+func (o observedRequests) ObserveRequest(m xai.RequestMetrics)                   { o <- m }
+func (o observedRequests) ObserveTimeToFirstToken(string, string, time.Duration) {}
+
+func TestMetricsStreamClosedEarly(t *testing.T) {
+	chat := &fakeChat{
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			if err := srv.Send(&v1.GetChatCompletionChunk{Usage: &v1.SamplingUsage{PromptTokens: 3}}); err != nil {
+				return err
+			}
+			<-srv.Context().Done()
+			return nil
+		},
+	}
+	observed := make(observedRequests, 2)
+	client := newFakeClient(t, chat, xai.Config{Metrics: observed, DefaultModel: "m1"})
+	stream, err := client.StreamChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "x"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Next(); err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+
+	select {
+	case m := <-observed:
+		if m.Err == nil || m.Err.Code != xai.ErrCanceled || m.Usage.PromptTokens != 3 {
+			t.Errorf("observed %+v, want a canceled request with the usage so far", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a stream closed before the end was not observed")
+	}
+}
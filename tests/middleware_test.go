@@ -0,0 +1,138 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestMiddleware(t *testing.T) {
+	var served []string
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			served = append(served, req.GetModel())
+			return &v1.GetChatCompletionResponse{Id: "resp-1"}, nil
+		},
+		stream: func(req *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			served = append(served, req.GetModel())
+			return srv.Send(&v1.GetChatCompletionChunk{Id: "chunk-1"})
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-3"})
+
+	var audit []string
+	errCap := errors.New("cost cap reached")
+	client.Use(
+		func(next xai.Handler) xai.Handler {
+			return func(ctx context.Context, call *xai.Call) error {
+				err := next(ctx, call)
+				entry := call.Method
+				if resp, ok := call.Response.(*v1.GetChatCompletionResponse); ok {
+					entry += ":" + resp.GetId()
+				}
+				if call.Streaming {
+					entry += ":stream"
+				}
+				audit = append(audit, entry)
+				return err
+			}
+		},
+		func(next xai.Handler) xai.Handler {
+			return func(ctx context.Context, call *xai.Call) error {
+				req := call.Request.(*v1.GetCompletionsRequest)
+				if req.GetUser() == "over-budget" {
+					return errCap
+				}
+				req.Model = "rewritten"
+				return next(ctx, call)
+			}
+		},
+	)
+
+	ctx := context.Background()
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.StreamChat(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := stream.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = client.CompleteChat(ctx, xai.NewChatRequest().WithUser("over-budget").UserMessage(xai.UserContent{Text: "hi"}))
+	if !errors.Is(err, errCap) {
+		t.Errorf("expected cost cap error, got %v", err)
+	}
+
+	if len(served) != 2 || served[0] != "rewritten" || served[1] != "rewritten" {
+		t.Errorf("served models = %v", served)
+	}
+	want := []string{"Chat/GetCompletion:resp-1", "Chat/GetCompletionChunk:stream", "Chat/GetCompletion"}
+	if len(audit) != len(want) {
+		t.Fatalf("audit = %v", audit)
+	}
+	for i := range want {
+		if audit[i] != want[i] {
+			t.Errorf("audit[%d] = %q, want %q", i, audit[i], want[i])
+		}
+	}
+}
+
+func TestMiddlewareOutgoingMetadata(t *testing.T) {
+	var got []string
+	tag := func(ctx context.Context) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		got = append(got, strings.Join(md.Get("x-tenant"), ","))
+	}
+	chat := &fakeChat{
+		complete: func(ctx context.Context, _ *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			tag(ctx)
+			return answerResponse("ok"), nil
+		},
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			tag(srv.Context())
+			return srv.Send(&v1.GetChatCompletionChunk{Id: "chunk-1"})
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "m"})
+	client.Use(func(next xai.Handler) xai.Handler {
+		return func(ctx context.Context, call *xai.Call) error {
+			return next(metadata.AppendToOutgoingContext(ctx, "x-tenant", "acme"), call)
+		}
+	})
+
+	ctx := context.Background()
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.StreamChat(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for {
+		if _, err := stream.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"acme", "acme"}; !slices.Equal(got, want) {
+		t.Errorf("x-tenant per call = %q, want %q", got, want)
+	}
+}
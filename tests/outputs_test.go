@@ -0,0 +1,71 @@
+package xai_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestWithN(t *testing.T) {
+	var n int32
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			n = req.GetN()
+			return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{
+				{Index: 1, Message: &v1.CompletionMessage{Content: "second"}, FinishReason: v1.FinishReason_REASON_MAX_LEN},
+				{Index: 0, Message: &v1.CompletionMessage{Content: "first"}, FinishReason: v1.FinishReason_REASON_STOP},
+			}}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	resp, err := client.CompleteChat(context.Background(),
+		xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}).WithN(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("n = %d", n)
+	}
+	if resp.Content != "first" || resp.FinishReason != xai.FinishReasonStop {
+		t.Errorf("top-level = %q, %q", resp.Content, resp.FinishReason)
+	}
+	if len(resp.Outputs) != 2 || resp.Outputs[1].Content != "second" || resp.Outputs[1].FinishReason != xai.FinishReasonLength {
+		t.Errorf("outputs = %+v", resp.Outputs)
+	}
+}
+
+func TestFinishReasons(t *testing.T) {
+	tests := []struct {
+		proto v1.FinishReason
+		rest  string
+		want  xai.FinishReason
+	}{
+		{v1.FinishReason_REASON_STOP, "stop", xai.FinishReasonStop},
+		{v1.FinishReason_REASON_MAX_LEN, "length", xai.FinishReasonLength},
+		{v1.FinishReason_REASON_MAX_CONTEXT, "max_context", xai.FinishReasonContextWindow},
+		{v1.FinishReason_REASON_TOOL_CALLS, "tool_calls", xai.FinishReasonToolCalls},
+	}
+	for _, tt := range tests {
+		chat := &fakeChat{
+			complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+				return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{FinishReason: tt.proto, Message: &v1.CompletionMessage{}}}}, nil
+			},
+		}
+		rest := newRESTClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":%q}]}`, tt.rest)
+		}))
+		for name, client := range map[string]*xai.Client{"grpc": newFakeClient(t, chat, xai.Config{DefaultModel: "m"}), "rest": rest} {
+			resp, err := client.CompleteChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.FinishReason != tt.want {
+				t.Errorf("%s %v: FinishReason = %q, want %q", name, tt.proto, resp.FinishReason, tt.want)
+			}
+		}
+	}
+}
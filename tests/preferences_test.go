@@ -0,0 +1,45 @@
+package xai_test
+
+import (
+	"context"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestPreferencesInjection(t *testing.T) {
+	var got []*v1.Message
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			got = req.GetMessages()
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	store := xai.NewMemoryPreferenceStore()
+	store.Set("alice", xai.Preferences{Tone: "formal", Language: "Afrikaans", FormatRules: []string{"Use metric units"}})
+	client := newFakeClient(t, chat, xai.Config{Preferences: store})
+	ctx := context.Background()
+
+	req := xai.NewChatRequest().WithUser("alice").UserMessage(xai.UserContent{Text: "hi"})
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].GetRole() != v1.MessageRole_ROLE_DEVELOPER {
+		t.Fatalf("messages = %v", got)
+	}
+	want := "User preferences:\n- Tone: formal\n- Language: respond in Afrikaans unless asked otherwise\n- Format: Use metric units"
+	if text := got[0].GetContent()[0].GetText(); text != want {
+		t.Errorf("preferences message = %q", text)
+	}
+
+	for _, user := range []string{"bob", ""} {
+		req := xai.NewChatRequest().WithUser(user).UserMessage(xai.UserContent{Text: "hi"})
+		if _, err := client.CompleteChat(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 {
+			t.Errorf("user %q: unexpected preferences message", user)
+		}
+	}
+}
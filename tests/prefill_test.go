@@ -0,0 +1,83 @@
+package xai_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestAssistantPrefill(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			last := req.GetMessages()[len(req.GetMessages())-1]
+			if last.GetRole() != v1.MessageRole_ROLE_ASSISTANT || last.GetContent()[0].GetText() != "{" {
+				t.Errorf("last message = %v", last)
+			}
+			return answerResponse(`"ok": true}`), nil
+		},
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			for _, part := range []string{`"ok"`, `: true}`} {
+				if err := srv.Send(&v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: part}}}}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "m"})
+	ctx := context.Background()
+
+	req := xai.NewChatRequest().
+		UserMessage(xai.UserContent{Text: "status as JSON"}).
+		AssistantPrefill("{")
+
+	resp, err := client.CompleteChat(ctx, req.Clone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != `{"ok": true}` {
+		t.Errorf("content = %q", resp.Content)
+	}
+
+	stream, err := client.StreamChat(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var streamed string
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		streamed += chunk.Delta
+	}
+	if streamed != `{"ok": true}` {
+		t.Errorf("streamed %q", streamed)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := xai.NewChatRequest()
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := client.CompleteChat(ctx, restored); err != nil || resp.Content != `{"ok": true}` {
+		t.Errorf("restored request: %v, %+v", err, resp)
+	}
+
+	// The answer replaces the prefill in the history.
+	req.AppendResponse(resp)
+	msgs := req.Messages()
+	if len(msgs) != 2 || msgs[1].GetContent()[0].GetText() != `{"ok": true}` {
+		t.Errorf("history = %v", msgs)
+	}
+}
@@ -0,0 +1,95 @@
+package xai_test
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+// jumbfManifest builds a minimal JUMBF superbox labelled "c2pa".
+func jumbfManifest() []byte {
+	label := append([]byte("c2pa"), 0)
+	descPayload := append(make([]byte, 16), 0x03) // type UUID + toggles
+	descPayload = append(descPayload, label...)
+	desc := box("jumd", descPayload)
+	return box("jumb", desc)
+}
+
+func box(typ string, payload []byte) []byte {
+	b := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(b, uint32(8+len(payload)))
+	copy(b[4:], typ)
+	return append(b, payload...)
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	b := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(b, uint32(len(data)))
+	copy(b[4:], typ)
+	b = append(b, data...)
+	return append(b, 0, 0, 0, 0) // CRC is not checked
+}
+
+func TestFindContentCredentials(t *testing.T) {
+	manifest := jumbfManifest()
+
+	t.Run("PNG", func(t *testing.T) {
+		img := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+		img = append(img, pngChunk("IHDR", make([]byte, 13))...)
+		img = append(img, pngChunk("caBX", manifest)...)
+		img = append(img, pngChunk("IEND", nil)...)
+
+		cc, ok := xai.FindContentCredentials(img)
+		if !ok {
+			t.Fatal("expected content credentials in PNG")
+		}
+		if cc.Format != "png" {
+			t.Errorf("Format = %q, want %q", cc.Format, "png")
+		}
+		if err := cc.Validate(); err != nil {
+			t.Errorf("Validate() = %v", err)
+		}
+	})
+
+	t.Run("JPEG", func(t *testing.T) {
+		seg := append([]byte{'J', 'P', 0, 1, 0, 0, 0, 1}, manifest...)
+		img := []byte{0xFF, 0xD8, 0xFF, 0xEB}
+		img = binary.BigEndian.AppendUint16(img, uint16(len(seg)+2))
+		img = append(img, seg...)
+		img = append(img, 0xFF, 0xD9)
+
+		cc, ok := xai.FindContentCredentials(img)
+		if !ok {
+			t.Fatal("expected content credentials in JPEG")
+		}
+		if err := cc.Validate(); err != nil {
+			t.Errorf("Validate() = %v", err)
+		}
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		if _, ok := xai.FindContentCredentials([]byte("not an image")); ok {
+			t.Error("expected no content credentials")
+		}
+	})
+
+	t.Run("GeneratedImage", func(t *testing.T) {
+		img := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+		img = append(img, pngChunk("caBX", manifest)...)
+		gi := xai.GeneratedImage{Base64: base64.StdEncoding.EncodeToString(img)}
+
+		cc, err := gi.ContentCredentials()
+		if err != nil {
+			t.Fatalf("ContentCredentials() error = %v", err)
+		}
+		if cc == nil {
+			t.Fatal("expected content credentials")
+		}
+
+		if _, err := (&xai.GeneratedImage{URL: "https://example.com/a.png"}).ContentCredentials(); err == nil {
+			t.Error("expected error for URL-only image")
+		}
+	})
+}
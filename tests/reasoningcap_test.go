@@ -0,0 +1,107 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// reasoningStream sends reasoning chunks reporting 100 more reasoning tokens
+// each, then an answer. At low effort it reasons for one chunk only.
+func reasoningStream(efforts *[]v1.ReasoningEffort, mu *sync.Mutex) func(*v1.GetCompletionsRequest, v1.Chat_GetCompletionChunkServer) error {
+	return func(req *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+		mu.Lock()
+		*efforts = append(*efforts, req.GetReasoningEffort())
+		mu.Unlock()
+		steps := 5
+		if req.GetReasoningEffort() == v1.ReasoningEffort_EFFORT_LOW {
+			steps = 1
+		}
+		for i := 1; i <= steps; i++ {
+			err := srv.Send(&v1.GetChatCompletionChunk{
+				Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{ReasoningContent: "hmm "}}},
+				Usage:   &v1.SamplingUsage{ReasoningTokens: int32(i * 100)},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return srv.Send(&v1.GetChatCompletionChunk{
+			Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: "answer"}, FinishReason: v1.FinishReason_REASON_STOP}},
+		})
+	}
+}
+
+func collectStream(t *testing.T, stream xai.ChatStream) (string, bool, error) {
+	t.Helper()
+	defer stream.Close()
+	var content string
+	restarted := false
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			return content, restarted, nil
+		}
+		if err != nil {
+			return content, restarted, err
+		}
+		content += chunk.Delta
+		restarted = restarted || chunk.ReasoningRestarted
+	}
+}
+
+func TestMaxReasoningTokensAborts(t *testing.T) {
+	var efforts []v1.ReasoningEffort
+	var mu sync.Mutex
+	client := newFakeClient(t, &fakeChat{stream: reasoningStream(&efforts, &mu)}, xai.Config{})
+
+	req := xai.NewChatRequest().WithMaxReasoningTokens(250).UserMessage(xai.UserContent{Text: "think"})
+	stream, err := client.StreamChat(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _, err := collectStream(t, stream)
+	if !errors.Is(err, xai.ErrReasoningBudgetExceeded) {
+		t.Fatalf("err = %v, want ErrReasoningBudgetExceeded", err)
+	}
+	var xerr *xai.Error
+	if !errors.As(err, &xerr) || xerr.Code != xai.ErrResourceExhausted {
+		t.Errorf("err = %#v", err)
+	}
+	if content != "" {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestMaxReasoningTokensDowngrades(t *testing.T) {
+	var efforts []v1.ReasoningEffort
+	var mu sync.Mutex
+	client := newFakeClient(t, &fakeChat{stream: reasoningStream(&efforts, &mu)}, xai.Config{})
+
+	req := xai.NewChatRequest().
+		WithReasoningEffort(xai.ReasoningEffortMedium).
+		WithMaxReasoningTokens(250).
+		WithReasoningDowngrade().
+		UserMessage(xai.UserContent{Text: "think"})
+	stream, err := client.StreamChat(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, restarted, err := collectStream(t, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "answer" || !restarted {
+		t.Errorf("content = %q, restarted = %v", content, restarted)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(efforts) != 2 || efforts[1] != v1.ReasoningEffort_EFFORT_LOW {
+		t.Errorf("efforts = %v", efforts)
+	}
+}
@@ -0,0 +1,49 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestReasoningSummary(t *testing.T) {
+	var got *v1.GetCompletionsRequest
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			got = req
+			return &v1.GetChatCompletionResponse{
+				Outputs: []*v1.CompletionOutput{{Message: &v1.CompletionMessage{Content: "  To answer, I compared both options.\n"}}},
+			}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+
+	resp := &xai.ChatResponse{Content: "Option B.", ReasoningContent: "Let me weigh A against B..."}
+	summary, err := resp.ReasoningSummary(context.Background(), client, xai.WithSummaryMaxWords(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary != "To answer, I compared both options." {
+		t.Errorf("summary = %q", summary)
+	}
+	if got.GetModel() != xai.DefaultSummaryModel {
+		t.Errorf("model = %q", got.GetModel())
+	}
+	if !strings.Contains(got.GetMessages()[0].GetContent()[0].GetText(), "at most 30 words") {
+		t.Errorf("system prompt = %q", got.GetMessages()[0].GetContent()[0].GetText())
+	}
+	prompt := got.GetMessages()[1].GetContent()[0].GetText()
+	if !strings.Contains(prompt, "weigh A against B") || !strings.Contains(prompt, "Option B.") {
+		t.Errorf("prompt = %q", prompt)
+	}
+
+	_, err = (&xai.ChatResponse{Content: "hi"}).ReasoningSummary(context.Background(), client)
+	var xerr *xai.Error
+	if !errors.As(err, &xerr) || xerr.Code != xai.ErrInvalidRequest {
+		t.Errorf("err = %v, want invalid request", err)
+	}
+}
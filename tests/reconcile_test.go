@@ -0,0 +1,92 @@
+package xai_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestReconciler(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 12, 0, 0, 0, time.UTC) }
+	priced := func(in, out float64) []*xai.LanguageModel {
+		return []*xai.LanguageModel{{
+			Name:              "grok-3",
+			Aliases:           []string{"grok-latest"},
+			PromptTextPricing: xai.Pricing{PerMillionTokens: in},
+			CompletionPricing: xai.Pricing{PerMillionTokens: out},
+		}}
+	}
+
+	r := xai.NewReconciler()
+	r.AddPricing(day(10), priced(2, 4)) // price cut on the 10th
+	r.AddPricing(day(1), priced(3, 6))
+	million := xai.Usage{PromptTokens: 1_000_000, CompletionTokens: 1_000_000}
+	r.Record(
+		xai.UsageEvent{Time: day(2), Model: "grok-3", Usage: million, Label: "acme"},
+		xai.UsageEvent{Time: day(2), Model: "grok-latest", Usage: million, Label: "acme"},
+		xai.UsageEvent{Time: day(11), Model: "grok-3", Usage: million, Label: "acme"},
+		xai.UsageEvent{Time: day(11), Model: "mystery", Usage: million},
+	)
+
+	report := r.Report(xai.WithReportPeriod(24 * time.Hour))
+	if len(report.Rows) != 4 {
+		t.Fatalf("rows = %+v", report.Rows)
+	}
+	// Old price for the 2nd, new price for the 11th.
+	if got := report.Rows[0].USD + report.Rows[1].USD; math.Abs(got-18) > 1e-9 {
+		t.Errorf("day 2 spend = %v, want 18", got)
+	}
+	if report.Rows[2].Model != "grok-3" || math.Abs(report.Rows[2].USD-6) > 1e-9 {
+		t.Errorf("day 11 row = %+v", report.Rows[2])
+	}
+	if report.Unpriced != 1 || math.Abs(report.TotalUSD-24) > 1e-9 {
+		t.Errorf("total = %v, unpriced = %d", report.TotalUSD, report.Unpriced)
+	}
+
+	ranged := r.Report(xai.WithReportRange(day(10), time.Time{}))
+	if len(ranged.Rows) != 2 || ranged.Rows[0].Model != "grok-3" || ranged.Rows[0].Requests != 1 {
+		t.Errorf("ranged rows = %+v", ranged.Rows)
+	}
+
+	var csv strings.Builder
+	if err := report.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "period_start,model,label,requests") {
+		t.Fatalf("csv = %s", csv.String())
+	}
+	if want := "2025-06-11T00:00:00Z,grok-3,acme,1,1000000,0,0,1000000,0,6.000000,0"; lines[3] != want {
+		t.Errorf("csv row = %q, want %q", lines[3], want)
+	}
+}
+
+func TestReconcilerRecordsRequests(t *testing.T) {
+	when := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	chat := &fakeChat{complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		resp := answerResponse("ok")
+		resp.Usage = &v1.SamplingUsage{PromptTokens: 10, CompletionTokens: 5}
+		return resp, nil
+	}}
+	r := xai.NewReconciler(xai.WithMaxEvents(2))
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "grok-3", Metrics: r, Clock: &fakeClock{now: when}})
+	for range 3 {
+		if _, err := client.CompleteChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Events are stamped by the client's clock, and only the last two kept.
+	report := r.Report(xai.WithReportPeriod(time.Hour))
+	if len(report.Rows) != 1 || !report.Rows[0].PeriodStart.Equal(when) || report.Rows[0].Requests != 2 {
+		t.Errorf("rows = %+v, want one row of 2 requests at %v", report.Rows, when)
+	}
+	if r.Dropped() != 1 {
+		t.Errorf("dropped = %d, want 1", r.Dropped())
+	}
+}
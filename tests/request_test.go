@@ -0,0 +1,84 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDo(t *testing.T) {
+	chat := &fakeChat{complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		return answerResponse("hello"), nil
+	}}
+	sampler := &fakeSampler{}
+	client := newFakeClient(t, chat, xai.Config{},
+		func(s *grpc.Server) { v1.RegisterSampleServer(s, sampler) })
+
+	// A wrapper written once sees every request type.
+	var methods []string
+	logged := func(do xai.DoFunc) xai.DoFunc {
+		return func(ctx context.Context, req xai.Request, opts ...xai.CallOption) (any, error) {
+			methods = append(methods, req.Method())
+			return do(ctx, req, opts...)
+		}
+	}
+	do := logged(client.Do)
+	ctx := context.Background()
+
+	chatResp, err := xai.DoRequest[*xai.ChatResponse](ctx, do, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+	if err != nil || chatResp.Content != "hello" {
+		t.Fatalf("chat = %+v, %v", chatResp, err)
+	}
+	sampleResp, err := xai.DoRequest[*xai.SampleResponse](ctx, do, xai.NewSampleRequest("grok-test").AddPrompt("x").WithN(1))
+	if err != nil || len(sampleResp.Outputs) != 1 {
+		t.Fatalf("sample = %+v, %v", sampleResp, err)
+	}
+	if len(methods) != 2 || methods[0] != "Chat/GetCompletion" || methods[1] != "Sample/SampleText" {
+		t.Errorf("methods = %v", methods)
+	}
+
+	_, err = xai.DoRequest[*xai.ImageResponse](ctx, client.Do, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+	var xerr *xai.Error
+	if !errors.As(err, &xerr) || xerr.Code != xai.ErrInvalidRequest {
+		t.Errorf("mismatched response type error = %v", err)
+	}
+
+	// The image service is not registered, so the call fails and Do
+	// returns a nil response.
+	resp, err := client.Do(ctx, xai.NewImageRequest("a cat"))
+	if err == nil || resp != nil {
+		t.Errorf("Do(image) = %v, %v; want nil, error", resp, err)
+	}
+}
+
+func TestChatRequestClone(t *testing.T) {
+	fn := xai.NewFunctionTool("lookup", "Look something up").WithParameters(`{"type":"object"}`)
+	web := xai.NewWebSearchTool().WithAllowedDomains("example.com")
+	base := xai.NewChatRequest().
+		WithSystemPrompt(xai.NewSystemPrompt("Be brief.")).
+		AddTools(fn, web).
+		WithTemperature(0.2).
+		UserMessage(xai.UserContent{Text: "shared"})
+	want := base.Build("m")
+
+	a := base.Clone().UserMessage(xai.UserContent{Text: "from alice"}).WithTemperature(0.9)
+	b := base.Clone().UserMessage(xai.UserContent{Text: "from bob"})
+	a.Messages()[0].Content[0].Content = &v1.Content_Text{Text: "edited"}
+	a.Tools()[0].(*xai.FunctionTool).Name = "renamed"
+	a.Tools()[1].(*xai.WebSearchTool).WithAllowedDomains("other.com")
+
+	if got := base.Build("m"); !proto.Equal(got, want) {
+		t.Errorf("base changed by its clones:\n got %v\nwant %v", got, want)
+	}
+	if n := len(b.Messages()); n != 2 || b.Messages()[1].GetContent()[0].GetText() != "from bob" {
+		t.Errorf("clone b messages = %v", b.Messages())
+	}
+	if got := b.Build("m"); got.GetTemperature() != 0.2 || len(got.GetMessages()) != 3 {
+		t.Errorf("clone b = %v", got)
+	}
+}
@@ -0,0 +1,309 @@
+package xai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func newRESTClient(t testing.TB, handler http.Handler) *xai.Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := xai.New(xai.Config{
+		APIKey:       xai.NewSecureString("xai-rest-key"),
+		DefaultModel: "grok-3",
+		Transport:    xai.TransportREST,
+		RESTEndpoint: srv.URL + "/v1",
+		HTTPClient:   srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestRESTTransport(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer xai-rest-key" {
+			http.Error(w, `{"error":"bad key"}`, http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Model    string `json:"model"`
+			User     string `json:"user"`
+			Stream   bool   `json:"stream"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.User == "limited" {
+			w.Header().Set("Retry-After", "3")
+			w.Header().Set("X-Request-Id", "req-429")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"rate limited"}}`)
+			return
+		}
+		reply := req.Model + ": " + req.Messages[len(req.Messages)-1].Content
+		w.Header().Set("X-Request-Id", "req-1")
+		if !req.Stream {
+			fmt.Fprintf(w, `{"id":"c1","model":%q,"choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`, req.Model, reply)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{reply[:4], reply[4:]} {
+			fmt.Fprintf(w, "data: {\"id\":\"c1\",\"model\":%q,\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", req.Model, part)
+		}
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":3,\"total_tokens\":8}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	mux.HandleFunc("GET /v1/language-models", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models":[{"id":"grok-3","aliases":["grok-latest"],"input_modalities":["text","image"],"output_modalities":["text"]}]}`)
+	})
+	client := newRESTClient(t, mux)
+	ctx := context.Background()
+
+	resp, err := client.CompleteChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hello"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "grok-3: hello" || resp.Usage.TotalTokens != 8 || resp.FinishReason != xai.FinishReasonStop {
+		t.Errorf("response = %q, usage %+v, finish %v", resp.Content, resp.Usage, resp.FinishReason)
+	}
+	if resp.Metadata.RequestID() != "req-1" {
+		t.Errorf("RequestID = %q", resp.Metadata.RequestID())
+	}
+
+	stream, err := client.StreamChat(ctx, xai.NewChatRequest().UserMessage(xai.UserContent{Text: "streamed"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var content string
+	var last *xai.ChatChunk
+	for {
+		chunk, err := stream.Next()
+		if err != nil {
+			break
+		}
+		content += chunk.Delta
+		last = chunk
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if content != "grok-3: streamed" {
+		t.Errorf("streamed content = %q", content)
+	}
+	if last == nil || last.FinishReason != xai.FinishReasonStop || last.Usage.TotalTokens != 8 {
+		t.Errorf("last chunk = %+v", last)
+	}
+
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || models[0].Name != "grok-3" || len(models[0].InputModalities) != 2 {
+		t.Errorf("models = %+v", models)
+	}
+
+	_, err = client.CompleteChat(ctx, xai.NewChatRequest().WithUser("limited").UserMessage(xai.UserContent{Text: "hi"}))
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || !xaiErr.IsRateLimit() {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if !strings.Contains(xaiErr.Message, "rate limited") || xaiErr.RetryAfter != 3*time.Second || xaiErr.Metadata.RequestID() != "req-429" {
+		t.Errorf("error = %q, retry after %v, request id %q", xaiErr.Message, xaiErr.RetryAfter, xaiErr.Metadata.RequestID())
+	}
+
+	_, err = client.CompleteChat(ctx, xai.NewChatRequest().WithPreviousResponseId("r1").UserMessage(xai.UserContent{Text: "hi"}))
+	if !errors.As(err, &xaiErr) || xaiErr.Code != xai.ErrInvalidRequest {
+		t.Errorf("expected invalid request for previous_response_id, got %v", err)
+	}
+}
+
+func TestRESTServices(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"model":%q,"data":[{"index":0,"embedding":[0.5,-1]},{"index":1,"embedding":"AAAAPwAAgL8="}]}`, req.Model)
+	})
+	mux.HandleFunc("POST /v1/images/generations", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Prompt         string `json:"prompt"`
+			N              int    `json:"n"`
+			ResponseFormat string `json:"response_format"`
+			AspectRatio    string `json:"aspect_ratio"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.AspectRatio != "16:9" || req.ResponseFormat != "url" || req.N != 2 {
+			http.Error(w, `{"error":"unexpected request"}`, http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"model":"grok-2-image","data":[{"url":"https://img/1"},{"url":"https://img/2"}]}`)
+	})
+	mux.HandleFunc("POST /v1/completions", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Prompt []string `json:"prompt"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"id":"s1","model":"grok-3","choices":[{"index":0,"text":%q,"finish_reason":"length"}],"usage":{"prompt_tokens":2,"completion_tokens":4,"total_tokens":6}}`, req.Prompt[0]+" there")
+	})
+	mux.HandleFunc("POST /v1/tokenize-text", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"token_ids":[{"token_id":7,"string_token":"he"},{"token_id":9,"string_token":"llo"}]}`)
+	})
+	mux.HandleFunc("GET /v1/embedding-models", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"models":[{"id":"v1","input_modalities":["text"]}]}`)
+	})
+	mux.HandleFunc("GET /v1/embedding-models/v1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"v1","version":"2"}`)
+	})
+	mux.HandleFunc("GET /v1/image-generation-models/grok-2-image", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"grok-2-image","max_prompt_length":1024}`)
+	})
+	client := newRESTClient(t, mux)
+	ctx := context.Background()
+
+	emb, err := client.Embed(ctx, xai.NewEmbedRequest("v1").AddTexts("a", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(emb.Embeddings) != 2 || emb.NumTextEmbeddings != 2 {
+		t.Fatalf("embeddings = %+v", emb)
+	}
+	for _, e := range emb.Embeddings {
+		if v := e.Vectors[0]; len(v) != 2 || v[0] != 0.5 || v[1] != -1 {
+			t.Errorf("embedding %d = %v", e.Index, v)
+		}
+	}
+
+	img, err := client.GenerateImage(ctx, xai.NewImageRequest("a cat").
+		WithCount(2).WithFormat(xai.ImageFormatURL).WithAspectRatio(xai.ImageAspectRatio16x9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(img.Images) != 2 || img.Images[1].URL != "https://img/2" {
+		t.Errorf("images = %+v", img.Images)
+	}
+
+	sample, err := client.SampleText(ctx, xai.NewSampleRequest("grok-3").AddPrompt("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.Outputs[0].Text != "hi there" || sample.Outputs[0].FinishReason != xai.FinishReasonLength || sample.Usage.TotalTokens != 6 {
+		t.Errorf("sample = %+v", sample)
+	}
+
+	toks, err := client.Tokenize(ctx, "grok-3", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toks.TokenCount() != 2 || toks.Tokens[1].StringToken != "llo" {
+		t.Errorf("tokens = %+v", toks.Tokens)
+	}
+
+	models, err := client.ListEmbeddingModels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || models[0].Name != "v1" {
+		t.Errorf("embedding models = %+v", models)
+	}
+
+	embModel, err := client.GetEmbeddingModel(ctx, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if embModel.Name != "v1" || embModel.Version != "2" {
+		t.Errorf("embedding model = %+v", embModel)
+	}
+	imgModel, err := client.GetImageModel(ctx, "grok-2-image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imgModel.Name != "grok-2-image" || imgModel.MaxPromptLength != 1024 {
+		t.Errorf("image model = %+v", imgModel)
+	}
+
+	_, err = client.Embed(ctx, xai.NewEmbedRequest("v1").AddImage("https://img/1"))
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || xaiErr.Code != xai.ErrInvalidRequest {
+		t.Errorf("expected invalid request for image embedding, got %v", err)
+	}
+}
+
+func TestRESTGetAndClose(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/language-models/grok-3", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"grok-3","version":"1.0","max_prompt_length":131072}`)
+	})
+	mux.HandleFunc("GET /v1/api-key", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"redacted_api_key":"xai-...key","api_key_id":"k1","name":"ci"}`)
+	})
+	closed := make(chan struct{}, 1)
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			select {
+			case closed <- struct{}{}:
+			default:
+			}
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	client, err := xai.New(xai.Config{
+		APIKey:       xai.NewSecureString("xai-rest-key"),
+		Transport:    xai.TransportREST,
+		RESTEndpoint: srv.URL + "/v1",
+		HTTPClient:   srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	model, err := client.GetModel(ctx, "grok-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Name != "grok-3" || model.Version != "1.0" || model.MaxPromptLength != 131072 {
+		t.Errorf("model = %+v", model)
+	}
+	info, err := client.GetAPIKeyInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.KeyID != "k1" || info.Name != "ci" || info.RedactedKey != "xai-...key" {
+		t.Errorf("api key = %+v", info)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Error("Close left the idle connection open")
+	}
+}
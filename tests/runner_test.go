@@ -0,0 +1,329 @@
+package xai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// toolCallResponse asks for one client-side call of name with args.
+func toolCallResponse(id, name, args string) *v1.GetChatCompletionResponse {
+	return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{
+		FinishReason: v1.FinishReason_REASON_TOOL_CALLS,
+		Message: &v1.CompletionMessage{ToolCalls: []*v1.ToolCall{{
+			Id:   id,
+			Type: v1.ToolCallType_TOOL_CALL_TYPE_CLIENT_SIDE_TOOL,
+			Tool: &v1.ToolCall_Function{Function: &v1.FunctionCall{Name: name, Arguments: args}},
+		}}},
+	}}}
+}
+
+func answerResponse(text string) *v1.GetChatCompletionResponse {
+	return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{
+		FinishReason: v1.FinishReason_REASON_STOP,
+		Message:      &v1.CompletionMessage{Content: text},
+	}}}
+}
+
+func weatherRunner() *xai.ToolRunner {
+	return xai.NewToolRunner().Register(
+		xai.NewFunctionTool("get_weather", "Current weather").WithParameters(`{"type":"object"}`),
+		func(_ context.Context, args json.RawMessage) (string, error) {
+			var a struct{ City string }
+			if err := json.Unmarshal(args, &a); err != nil {
+				return "", err
+			}
+			if a.City == "" {
+				return "", errors.New("city is required")
+			}
+			return fmt.Sprintf(`{"city":%q,"temp_c":21}`, a.City), nil
+		},
+	)
+}
+
+func TestRunChat(t *testing.T) {
+	var requests []*v1.GetCompletionsRequest
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			requests = append(requests, req)
+			switch len(requests) {
+			case 1:
+				return toolCallResponse("c1", "get_weather", `{}`), nil
+			case 2:
+				return toolCallResponse("c2", "get_weather", `{"city":"Paris"}`), nil
+			}
+			return answerResponse("It is 21°C in Paris."), nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "Weather in Paris?"})
+	resp, err := client.RunChat(context.Background(), req, weatherRunner())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "It is 21°C in Paris." || len(requests) != 3 {
+		t.Fatalf("content = %q after %d requests", resp.Content, len(requests))
+	}
+	if len(requests[0].GetTools()) != 1 {
+		t.Errorf("tools = %v", requests[0].GetTools())
+	}
+
+	// user, assistant, tool error, assistant, tool result
+	msgs := requests[2].GetMessages()
+	if len(msgs) != 5 {
+		t.Fatalf("messages = %d", len(msgs))
+	}
+	if got := msgs[2].GetContent()[0].GetText(); got != "error: city is required" || msgs[2].GetToolCallId() != "c1" {
+		t.Errorf("first result = %q", got)
+	}
+	if got := msgs[4].GetContent()[0].GetText(); got != `{"city":"Paris","temp_c":21}` {
+		t.Errorf("second result = %q", got)
+	}
+}
+
+func TestRunChatKeepsRequestTypedTool(t *testing.T) {
+	var tools []*v1.Tool
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			tools = req.GetTools()
+			return answerResponse("done"), nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	weather := xai.NewTypedTool("get_weather", "Current weather", func(context.Context, struct{}) (string, error) {
+		return "sunny", nil
+	})
+	req := xai.NewChatRequest().AddTool(weather).UserMessage(xai.UserContent{Text: "Weather?"})
+	if _, err := client.RunChat(context.Background(), req, weatherRunner()); err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 1 {
+		t.Errorf("sent %d tools, want the request's get_weather only", len(tools))
+	}
+}
+
+func TestRunChatMaxTurns(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return toolCallResponse("c", "get_weather", `{"city":"Paris"}`), nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "loop"})
+	resp, err := client.RunChat(context.Background(), req, weatherRunner().WithMaxTurns(3))
+	if !errors.Is(err, xai.ErrMaxTurnsExceeded) {
+		t.Fatalf("err = %v", err)
+	}
+	if resp == nil || !resp.HasToolCalls() {
+		t.Errorf("resp = %+v", resp)
+	}
+}
+
+func TestRunChatHooks(t *testing.T) {
+	turn := 0
+	chat := &fakeChat{
+		stream: func(req *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			turn++
+			if turn == 1 {
+				return srv.Send(&v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{
+					FinishReason: v1.FinishReason_REASON_TOOL_CALLS,
+					Delta: &v1.Delta{ToolCalls: []*v1.ToolCall{
+						{Id: "s1", Type: v1.ToolCallType_TOOL_CALL_TYPE_WEB_SEARCH_TOOL},
+						{
+							Id:   "c1",
+							Type: v1.ToolCallType_TOOL_CALL_TYPE_CLIENT_SIDE_TOOL,
+							Tool: &v1.ToolCall_Function{Function: &v1.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+						},
+					}},
+				}}})
+			}
+			for _, d := range []string{"It is ", "21°C."} {
+				if err := srv.Send(&v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: d}}}}); err != nil {
+					return err
+				}
+			}
+			return srv.Send(&v1.GetChatCompletionChunk{
+				Outputs: []*v1.CompletionOutputChunk{{FinishReason: v1.FinishReason_REASON_STOP}},
+				Usage:   &v1.SamplingUsage{CompletionTokens: 4},
+			})
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+
+	var events []string
+	runner := weatherRunner().WithHooks(xai.RunHooks{
+		OnTurnStart: func(n int) { events = append(events, fmt.Sprint("turn ", n)) },
+		OnToolCall:  func(tc *xai.ToolCallInfo) { events = append(events, "call "+tc.ID) },
+		OnToolResult: func(tc *xai.ToolCallInfo, result string, err error) {
+			events = append(events, "result "+tc.ID+" "+result)
+		},
+		OnAssistantDelta: func(d string) { events = append(events, "delta "+d) },
+	})
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "Weather in Paris?"})
+	resp, err := client.RunChat(context.Background(), req, runner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "It is 21°C." || resp.FinishReason != xai.FinishReasonStop || resp.Usage.CompletionTokens != 4 {
+		t.Errorf("resp = %+v", resp)
+	}
+	want := []string{
+		"turn 1", "call s1", "call c1", `result c1 {"city":"Paris","temp_c":21}`,
+		"turn 2", "delta It is ", "delta 21°C.",
+	}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("events = %q\nwant %q", events, want)
+	}
+}
+
+func TestRunChatStreamRepeatedToolCall(t *testing.T) {
+	turn := 0
+	call := func(status v1.ToolCallStatus) *v1.GetChatCompletionChunk {
+		return &v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{ToolCalls: []*v1.ToolCall{{
+			Id:     "c1",
+			Type:   v1.ToolCallType_TOOL_CALL_TYPE_CLIENT_SIDE_TOOL,
+			Status: status,
+			Tool:   &v1.ToolCall_Function{Function: &v1.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+		}}}}}}
+	}
+	var history []*v1.Message
+	chat := &fakeChat{
+		stream: func(req *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			turn++
+			if turn == 1 {
+				// The server mentions the call again with a status update.
+				for _, st := range []v1.ToolCallStatus{v1.ToolCallStatus_TOOL_CALL_STATUS_IN_PROGRESS, v1.ToolCallStatus_TOOL_CALL_STATUS_COMPLETED} {
+					if err := srv.Send(call(st)); err != nil {
+						return err
+					}
+				}
+				return srv.Send(&v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{FinishReason: v1.FinishReason_REASON_TOOL_CALLS}}})
+			}
+			history = req.GetMessages()
+			return srv.Send(&v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{
+				Delta:        &v1.Delta{Content: "It is 21°C."},
+				FinishReason: v1.FinishReason_REASON_STOP,
+			}}})
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+
+	calls := 0
+	runner := weatherRunner().WithHooks(xai.RunHooks{
+		OnToolCall:       func(*xai.ToolCallInfo) { calls++ },
+		OnAssistantDelta: func(string) {},
+	})
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "Weather in Paris?"})
+	if _, err := client.RunChat(context.Background(), req, runner); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("tool ran %d times, want 1", calls)
+	}
+	var results, toolCalls int
+	for _, m := range history {
+		toolCalls += len(m.GetToolCalls())
+		if m.GetRole() == v1.MessageRole_ROLE_TOOL {
+			results++
+		}
+	}
+	if toolCalls != 1 || results != 1 {
+		t.Errorf("history has %d tool calls and %d results, want 1 each", toolCalls, results)
+	}
+}
+
+func TestStartRunAbort(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return toolCallResponse("c1", "slow", `{}`), nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+
+	started := make(chan struct{})
+	handlerErr := make(chan error, 1)
+	runner := xai.NewToolRunner().Register(xai.NewFunctionTool("slow", "Takes a while"),
+		func(ctx context.Context, _ json.RawMessage) (string, error) {
+			close(started)
+			<-ctx.Done()
+			handlerErr <- ctx.Err()
+			return "", ctx.Err()
+		})
+
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "go"})
+	run := client.StartRun(context.Background(), req, runner)
+	<-started
+	run.AbortRun()
+
+	_, err := run.Wait()
+	if !errors.Is(err, xai.ErrRunAborted) {
+		t.Fatalf("err = %v, want ErrRunAborted", err)
+	}
+	var xerr *xai.Error
+	if !errors.As(err, &xerr) || xerr.Code != xai.ErrCanceled {
+		t.Errorf("err = %#v", err)
+	}
+	if err := <-handlerErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("handler ctx err = %v", err)
+	}
+	select {
+	case <-run.Done():
+	default:
+		t.Error("Done not closed after Wait")
+	}
+	run.AbortRun() // no-op once finished
+}
+
+type weatherArgs struct {
+	City string `json:"city" description:"city name"`
+}
+
+type weatherReport struct {
+	City  string  `json:"city"`
+	TempC float64 `json:"temp_c"`
+}
+
+func TestTypedTool(t *testing.T) {
+	var requests []*v1.GetCompletionsRequest
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			requests = append(requests, req)
+			switch len(requests) {
+			case 1:
+				return toolCallResponse("c1", "get_weather", `{"city": 7}`), nil
+			case 2:
+				return toolCallResponse("c2", "get_weather", `{"city":"Oslo"}`), nil
+			}
+			return answerResponse("done"), nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+
+	weather := xai.NewTypedTool("get_weather", "Current weather",
+		func(_ context.Context, in weatherArgs) (weatherReport, error) {
+			return weatherReport{City: in.City, TempC: 3}, nil
+		})
+	if string(weather.Parameters) != string(xai.SchemaFor[weatherArgs]()) {
+		t.Errorf("parameters = %s", weather.Parameters)
+	}
+
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "Weather in Oslo?"})
+	if _, err := client.RunChat(context.Background(), req, xai.NewToolRunner().Add(weather)); err != nil {
+		t.Fatal(err)
+	}
+	msgs := requests[2].GetMessages()
+	if got := msgs[2].GetContent()[0].GetText(); !strings.Contains(got, `argument "city" of get_weather`) {
+		t.Errorf("bad-arguments result = %q", got)
+	}
+	if got := msgs[4].GetContent()[0].GetText(); got != `{"city":"Oslo","temp_c":3}` {
+		t.Errorf("result = %q", got)
+	}
+}
@@ -0,0 +1,112 @@
+package xai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestRunPauseForHumanAndResumeFromState(t *testing.T) {
+	var last *v1.GetCompletionsRequest
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			last = req
+			if len(req.GetMessages()) == 1 {
+				return toolCallResponse("ask-1", "ask_human", `{"question":"Deploy now?"}`), nil
+			}
+			return answerResponse("Deploying."), nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	runner := xai.NewToolRunner().Register(xai.NewFunctionTool("ask_human", "Ask the operator"),
+		func(context.Context, json.RawMessage) (string, error) {
+			return "", fmt.Errorf("waiting for operator: %w", xai.ErrPauseRun)
+		})
+
+	req := xai.NewChatRequest().WithModel("grok-test").UserMessage(xai.UserContent{Text: "Ship it"})
+	run := client.StartRun(context.Background(), req, runner)
+	if _, err := run.Wait(); !errors.Is(err, xai.ErrRunPaused) {
+		t.Fatalf("err = %v, want ErrRunPaused", err)
+	}
+	state := run.State()
+	if state == nil || len(state.Pending) != 1 || state.Pending[0].ID != "ask-1" {
+		t.Fatalf("state = %+v", state)
+	}
+
+	// Save and reload, as across a restart.
+	saved, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded xai.RunState
+	if err := json.Unmarshal(saved, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Resolve("ask-1", "yes") || loaded.Resolve("ask-1", "again") {
+		t.Fatal("Resolve should succeed exactly once")
+	}
+
+	fresh := xai.NewChatRequest().WithModel("grok-test")
+	resumed, err := client.ResumeRun(context.Background(), fresh, &loaded, runner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := resumed.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "Deploying." || last.GetModel() != "grok-test" {
+		t.Errorf("content = %q, model = %q", resp.Content, last.GetModel())
+	}
+	msgs := last.GetMessages()
+	if len(msgs) != 3 || msgs[2].GetToolCallId() != "ask-1" || msgs[2].GetContent()[0].GetText() != "yes" {
+		t.Errorf("messages = %v", msgs)
+	}
+}
+
+func TestRunPauseAndResume(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			if len(req.GetMessages()) == 1 {
+				return toolCallResponse("c1", "get_weather", `{"city":"Paris"}`), nil
+			}
+			return answerResponse("21°C"), nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+
+	var run *xai.Run
+	started := make(chan struct{})
+	calls := 0
+	runner := weatherRunner().WithHooks(xai.RunHooks{
+		OnTurnStart: func(int) { <-started; run.Pause() },
+		OnToolCall:  func(*xai.ToolCallInfo) { calls++ },
+	})
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "Weather?"})
+	run = client.StartRun(context.Background(), req, runner)
+	close(started)
+	if _, err := run.Wait(); !errors.Is(err, xai.ErrRunPaused) {
+		t.Fatalf("err = %v", err)
+	}
+	if calls != 0 || len(run.State().Pending) != 1 {
+		t.Fatalf("calls = %d, state = %+v", calls, run.State())
+	}
+
+	runner.WithHooks(xai.RunHooks{})
+	resumed, err := run.Resume(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := resumed.Wait()
+	if err != nil || resp.Content != "21°C" {
+		t.Fatalf("resp = %v, err = %v", resp, err)
+	}
+	if _, err := resumed.Resume(context.Background()); err == nil {
+		t.Error("resuming a finished run should fail")
+	}
+}
@@ -0,0 +1,52 @@
+package xai_test
+
+import (
+	"context"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+)
+
+type fakeSampler struct {
+	v1.UnimplementedSampleServer
+	got *v1.SampleTextRequest
+}
+
+func (f *fakeSampler) SampleText(_ context.Context, req *v1.SampleTextRequest) (*v1.SampleTextResponse, error) {
+	f.got = req
+	resp := &v1.SampleTextResponse{Model: req.GetModel()}
+	for i := int32(0); i < req.GetN(); i++ {
+		resp.Choices = append(resp.Choices, &v1.SampleChoice{Index: i, Text: "sample"})
+	}
+	return resp, nil
+}
+
+func TestSampleRequestOptions(t *testing.T) {
+	sampler := &fakeSampler{}
+	client := newFakeClient(t, &fakeChat{}, xai.Config{},
+		func(s *grpc.Server) { v1.RegisterSampleServer(s, sampler) })
+
+	req := xai.NewSampleRequest("grok-test").
+		AddPrompt("Once upon a time").
+		WithN(3).
+		WithUser("u-1").
+		WithFrequencyPenalty(0.5).
+		WithPresencePenalty(-0.5).
+		WithLogprobs(2)
+	resp, err := client.SampleText(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Outputs) != 3 || resp.Outputs[2].Index != 2 {
+		t.Errorf("outputs = %+v", resp.Outputs)
+	}
+	got := sampler.got
+	if got.GetN() != 3 || got.GetUser() != "u-1" || got.GetFrequencyPenalty() != 0.5 || got.GetPresencePenalty() != -0.5 {
+		t.Errorf("request = %v", got)
+	}
+	if !got.GetLogprobs() || got.GetTopLogprobs() != 2 {
+		t.Errorf("logprobs = %v, top = %d", got.GetLogprobs(), got.GetTopLogprobs())
+	}
+}
@@ -0,0 +1,66 @@
+package xai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+const weatherSchema = `{"type":"object","properties":{"temp":{"type":"number"}},"required":["temp"]}`
+
+func TestWithJSONSchema(t *testing.T) {
+	var got *v1.ResponseFormat
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			got = req.GetResponseFormat()
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	req := xai.NewChatRequest().
+		UserMessage(xai.UserContent{Text: "weather?"}).
+		WithJSONSchema("weather", json.RawMessage(weatherSchema), true)
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if got.GetFormatType() != v1.FormatType_FORMAT_TYPE_JSON_SCHEMA {
+		t.Fatalf("format = %v", got.GetFormatType())
+	}
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(got.GetSchema()), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema["title"] != "weather" || schema["type"] != "object" {
+		t.Errorf("schema = %s", got.GetSchema())
+	}
+
+	bad := xai.NewChatRequest().WithJSONSchema("broken", json.RawMessage(`{"type":`), true)
+	if !errors.Is(bad.Err(), xai.ErrInvalidSentinel) {
+		t.Errorf("malformed schema: %v", bad.Err())
+	}
+}
+
+func TestWithJSONSchemaREST(t *testing.T) {
+	var body string
+	client := newRESTClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"{\"temp\":21}"}}]}`)
+	}))
+	req := xai.NewChatRequest().
+		UserMessage(xai.UserContent{Text: "weather?"}).
+		WithJSONSchema("weather", json.RawMessage(weatherSchema), true)
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body, `"type":"json_schema","json_schema":{"name":"weather"`) {
+		t.Errorf("request body = %s", body)
+	}
+}
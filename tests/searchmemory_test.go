@@ -0,0 +1,55 @@
+package xai_test
+
+import (
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func webSearch(query string) *xai.ToolCallInfo {
+	return &xai.ToolCallInfo{
+		Type:     xai.ToolCallTypeServer,
+		Status:   xai.ToolCallStatusCompleted,
+		Function: &xai.FunctionCall{Name: "web_search", Arguments: `{"query":"` + query + `"}`},
+	}
+}
+
+func TestSearchMemory(t *testing.T) {
+	client := newFakeClient(t, &fakeChat{}, xai.Config{})
+	cv := client.NewConversation(nil).EnableSearchMemory(2)
+	cv.Edit(func(req *xai.ChatRequest) { req.UserMessage(xai.UserContent{Text: "q1"}) })
+
+	cv.AppendResponse(&xai.ChatResponse{
+		Content:   "a1",
+		ToolCalls: []*xai.ToolCallInfo{webSearch("go generics")},
+		Citations: []string{"https://go.dev/doc"},
+	})
+	cv.Edit(func(req *xai.ChatRequest) { req.UserMessage(xai.UserContent{Text: "q2"}) })
+	cv.AppendResponse(&xai.ChatResponse{
+		Content:   "a2",
+		ToolCalls: []*xai.ToolCallInfo{webSearch("go iterators"), webSearch("go generics")},
+	})
+	cv.AppendResponse(&xai.ChatResponse{Content: "no search"})
+	cv.AppendResponse(&xai.ChatResponse{ToolCalls: []*xai.ToolCallInfo{webSearch("rust traits")}})
+
+	searches := cv.Searches()
+	if len(searches) != 2 || !strings.Contains(searches[0].Arguments, "go generics") || !strings.Contains(searches[1].Arguments, "rust traits") {
+		t.Fatalf("searches = %+v", searches)
+	}
+
+	var digests []string
+	for _, msg := range cv.Request().Messages() {
+		for _, c := range msg.GetContent() {
+			if strings.HasPrefix(c.GetText(), "Searches already made") {
+				digests = append(digests, c.GetText())
+			}
+		}
+	}
+	if len(digests) != 1 {
+		t.Fatalf("history has %d digests", len(digests))
+	}
+	if !strings.Contains(digests[0], `web_search {"query":"rust traits"}`) || strings.Contains(digests[0], "iterators") {
+		t.Errorf("digest = %q", digests[0])
+	}
+}
@@ -0,0 +1,163 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// advertise returns a server option that adds md to every unary response.
+func advertise(md metadata.MD) grpc.ServerOption {
+	return grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		_ = grpc.SetHeader(ctx, md)
+		return handler(ctx, req)
+	})
+}
+
+func TestServerInfo(t *testing.T) {
+	var got *v1.GetCompletionsRequest
+	chat := &fakeChat{complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		got = req
+		return answerResponse("ok"), nil
+	}}
+	auth := &fakeAuth{key: &v1.ApiKey{Name: "prod"}}
+	client := newFakeClient(t, chat, xai.Config{},
+		func(s *grpc.Server) { v1.RegisterAuthServer(s, auth) },
+		advertise(metadata.Pairs("x-api-version", "2025-06-01", "x-api-features", "reasoning_effort, Include")))
+	ctx := context.Background()
+
+	req := func() *xai.ChatRequest {
+		return xai.NewChatRequest().
+			UserMessage(xai.UserContent{Text: "hi"}).
+			WithReasoningEffort(xai.ReasoningEffortLow).
+			WithParallelToolCalls(false).
+			WithMaxTurns(3).
+			IncludeInlineCitations()
+	}
+
+	// Before ServerInfo is fetched nothing is gated.
+	if _, err := client.CompleteChat(ctx, req()); err != nil {
+		t.Fatal(err)
+	}
+	if got.ParallelToolCalls == nil || got.MaxTurns == nil {
+		t.Errorf("fields dropped before server info was known: %v", got)
+	}
+
+	info, err := client.ServerInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.APIVersion != "2025-06-01" {
+		t.Errorf("APIVersion = %q", info.APIVersion)
+	}
+	if !info.Supports(xai.FeatureInclude) || info.Supports(xai.FeatureMaxTurns) {
+		t.Errorf("Features = %v", info.Features)
+	}
+	if again, _ := client.ServerInfo(ctx); again != info {
+		t.Error("ServerInfo not cached")
+	}
+
+	got = nil
+	_, err = client.CompleteChat(ctx, req())
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || xaiErr.Code != xai.ErrInvalidRequest ||
+		!strings.Contains(err.Error(), xai.FeatureParallelToolCalls) || !strings.Contains(err.Error(), xai.FeatureMaxTurns) {
+		t.Errorf("unsupported fields: err = %v, want ErrInvalidRequest naming them", err)
+	}
+	if got != nil {
+		t.Error("request with unsupported fields was sent")
+	}
+	if _, err := client.CompleteChat(ctx, xai.NewChatRequest().
+		UserMessage(xai.UserContent{Text: "hi"}).
+		WithReasoningEffort(xai.ReasoningEffortLow)); err != nil {
+		t.Errorf("supported fields only: %v", err)
+	}
+}
+
+func TestServerInfoDropUnsupportedFeatures(t *testing.T) {
+	var got *v1.GetCompletionsRequest
+	chat := &fakeChat{complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		got = req
+		return answerResponse("ok"), nil
+	}}
+	auth := &fakeAuth{key: &v1.ApiKey{Name: "prod"}}
+	client := newFakeClient(t, chat, xai.Config{DropUnsupportedFeatures: true},
+		func(s *grpc.Server) { v1.RegisterAuthServer(s, auth) },
+		advertise(metadata.Pairs("x-api-features", "reasoning_effort, Include")))
+	ctx := context.Background()
+	if _, err := client.ServerInfo(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := client.CompleteChat(ctx, xai.NewChatRequest().
+		UserMessage(xai.UserContent{Text: "hi"}).
+		WithReasoningEffort(xai.ReasoningEffortLow).
+		WithParallelToolCalls(false).
+		WithMaxTurns(3).
+		IncludeInlineCitations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ParallelToolCalls != nil || got.MaxTurns != nil {
+		t.Errorf("unsupported fields sent: %v", got)
+	}
+	if got.ReasoningEffort == nil || len(got.Include) == 0 {
+		t.Errorf("supported fields dropped: %v", got)
+	}
+}
+
+func TestServerInfoDropKeepsPreviousResponse(t *testing.T) {
+	var got *v1.GetCompletionsRequest
+	chat := &fakeChat{complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		got = req
+		return answerResponse("ok"), nil
+	}}
+	auth := &fakeAuth{key: &v1.ApiKey{Name: "prod"}}
+	client := newFakeClient(t, chat, xai.Config{DropUnsupportedFeatures: true},
+		func(s *grpc.Server) { v1.RegisterAuthServer(s, auth) },
+		advertise(metadata.Pairs("x-api-features", "include")))
+	ctx := context.Background()
+	if _, err := client.ServerInfo(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Storing alone is dropped.
+	if _, err := client.CompleteChat(ctx, xai.NewChatRequest().
+		UserMessage(xai.UserContent{Text: "hi"}).
+		WithStoreMessages(true)); err != nil {
+		t.Fatal(err)
+	}
+	if got.StoreMessages {
+		t.Error("store_messages sent to a server without stored completions")
+	}
+
+	// Continuing a stored response cannot be, as the context would be lost.
+	_, err := client.CompleteChat(ctx, xai.NewChatRequest().
+		UserMessage(xai.UserContent{Text: "and then?"}).
+		WithStoreMessages(true).
+		WithPreviousResponseId("resp-1"))
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || xaiErr.Code != xai.ErrInvalidRequest || !strings.Contains(err.Error(), xai.FeatureStoredCompletions) {
+		t.Errorf("err = %v, want ErrInvalidRequest naming %s", err, xai.FeatureStoredCompletions)
+	}
+}
+
+func TestServerInfoWithoutFeatures(t *testing.T) {
+	auth := &fakeAuth{key: &v1.ApiKey{Name: "prod"}}
+	client := newFakeClient(t, &fakeChat{}, xai.Config{},
+		func(s *grpc.Server) { v1.RegisterAuthServer(s, auth) })
+
+	info, err := client.ServerInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Features != nil || !info.Supports(xai.FeatureMaxTurns) {
+		t.Errorf("server without features: %+v", info)
+	}
+}
@@ -0,0 +1,95 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestShutdownDrainsStreams(t *testing.T) {
+	release := make(chan struct{})
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			<-release
+			return srv.Send(&v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{Delta: &v1.Delta{Content: "done"}}}})
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	ctx := context.Background()
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+
+	stream, err := client.StreamChat(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- client.Shutdown(ctx) }()
+
+	// New requests are refused while the stream drains.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, err := client.CompleteChat(ctx, req)
+		var xaiErr *xai.Error
+		if errors.As(err, &xaiErr) && xaiErr.Code == xai.ErrUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("requests still accepted during shutdown: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned before the stream finished: %v", err)
+	default:
+	}
+
+	close(release)
+	var content string
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content += chunk.Delta
+	}
+	if content != "done" {
+		t.Errorf("content = %q", content)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	chat := &fakeChat{
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			<-srv.Context().Done()
+			return nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	stream, err := client.StreamChat(context.Background(), xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want deadline exceeded", err)
+	}
+}
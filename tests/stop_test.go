@@ -0,0 +1,51 @@
+package xai_test
+
+import (
+	"context"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestIncludeStopSequence(t *testing.T) {
+	// By default the last token ran past the stop sequence "END".
+	var raw string
+	chat := &fakeChat{complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{
+			FinishReason: v1.FinishReason_REASON_STOP,
+			Message:      &v1.CompletionMessage{Content: raw},
+		}}}, nil
+	}}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "m"})
+
+	tests := []struct {
+		name    string
+		req     *xai.ChatRequest
+		raw     string
+		content string
+		stop    string
+	}{
+		{"no stops", xai.NewChatRequest(), "", "a NEXT b END!?", ""},
+		{"report only", xai.NewChatRequest().WithStop("END"), "", "a NEXT b END!?", "END"},
+		{"include", xai.NewChatRequest().WithStop("END").WithIncludeStopSequence(true), "", "a NEXT b END", "END"},
+		{"exclude", xai.NewChatRequest().WithStop("D!", "END").WithIncludeStopSequence(false), "", "a NEXT b ", "END"},
+		{"not found", xai.NewChatRequest().WithStop("###").WithIncludeStopSequence(true), "", "a NEXT b END!?", ""},
+		// An occurrence before the tail did not end generation.
+		{"not at tail", xai.NewChatRequest().WithStop("NEXT").WithIncludeStopSequence(false), "a NEXT was the word, and far more text followed it", "a NEXT was the word, and far more text followed it", ""},
+		{"stripped by server", xai.NewChatRequest().WithStop("END").WithIncludeStopSequence(true), "a NEXT b ", "a NEXT b ", ""},
+	}
+	for _, tt := range tests {
+		raw = tt.raw
+		if raw == "" {
+			raw = "a NEXT b END!?"
+		}
+		resp, err := client.CompleteChat(context.Background(), tt.req.UserMessage(xai.UserContent{Text: "go"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Content != tt.content || resp.StopSequence != tt.stop {
+			t.Errorf("%s: content %q, stop %q; want %q, %q", tt.name, resp.Content, resp.StopSequence, tt.content, tt.stop)
+		}
+	}
+}
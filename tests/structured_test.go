@@ -0,0 +1,85 @@
+package xai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+type forecast struct {
+	City  string   `json:"city" description:"city name"`
+	TempC float64  `json:"temp_c"`
+	Sky   string   `json:"sky" enum:"clear,cloudy"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+func TestCompleteChatInto(t *testing.T) {
+	content := `{"city":"Paris","temp_c":21.5,"sky":"clear"}`
+	var schema map[string]any
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			if err := json.Unmarshal([]byte(req.GetResponseFormat().GetSchema()), &schema); err != nil {
+				return nil, err
+			}
+			return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{
+				Message: &v1.CompletionMessage{Content: content},
+			}}}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	ctx := context.Background()
+	req := func() *xai.ChatRequest {
+		return xai.NewChatRequest().UserMessage(xai.UserContent{Text: "weather in Paris"})
+	}
+
+	got, resp, err := xai.CompleteChatInto[forecast](ctx, client, req())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.City != "Paris" || got.TempC != 21.5 || resp.Content != content {
+		t.Errorf("got %+v, resp %+v", got, resp)
+	}
+
+	props := schema["properties"].(map[string]any)
+	if schema["title"] != "forecast" || props["temp_c"].(map[string]any)["type"] != "number" {
+		t.Errorf("schema = %v", schema)
+	}
+	if props["city"].(map[string]any)["description"] != "city name" || len(props["sky"].(map[string]any)["enum"].([]any)) != 2 {
+		t.Errorf("field tags not applied: %v", props)
+	}
+	if req := schema["required"].([]any); len(req) != 3 {
+		t.Errorf("required = %v", req)
+	}
+
+	content = "not json"
+	if _, resp, err := xai.CompleteChatInto[forecast](ctx, client, req()); !errors.Is(err, xai.ErrServerSentinel) || resp == nil {
+		t.Errorf("bad content: %v, %v", resp, err)
+	}
+}
+
+func TestSchemaFor(t *testing.T) {
+	var schema struct {
+		Type                 string                     `json:"type"`
+		Properties           map[string]json.RawMessage `json:"properties"`
+		Required             []string                   `json:"required"`
+		AdditionalProperties *bool                      `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(xai.SchemaFor[forecast](), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Type != "object" || len(schema.Properties) != 4 || len(schema.Required) != 3 {
+		t.Errorf("schema = %+v", schema)
+	}
+	if schema.AdditionalProperties == nil || *schema.AdditionalProperties {
+		t.Error("additionalProperties should be false")
+	}
+
+	tool := xai.NewFunctionTool("forecast", "Weather forecast").WithParametersFrom(forecast{})
+	if string(tool.Parameters) != string(xai.SchemaFor[forecast]()) {
+		t.Errorf("parameters = %s", tool.Parameters)
+	}
+}
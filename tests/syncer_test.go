@@ -0,0 +1,87 @@
+package xai_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+// memStore is an in-memory CollectionStore.
+type memStore struct {
+	next int
+	docs map[string]xai.StoredDocument // by file ID
+}
+
+func (m *memStore) ListDocuments(context.Context, string) ([]xai.StoredDocument, error) {
+	var out []xai.StoredDocument
+	for _, d := range m.docs {
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (m *memStore) UploadDocument(_ context.Context, _ string, doc xai.SyncDocument) (string, error) {
+	m.next++
+	id := fmt.Sprintf("file-%d", m.next)
+	m.docs[id] = xai.StoredDocument{FileID: id, Name: doc.Name, Hash: doc.Hash}
+	return id, nil
+}
+
+func (m *memStore) DeleteDocument(_ context.Context, _, fileID string) error {
+	delete(m.docs, fileID)
+	return nil
+}
+
+func TestSyncer(t *testing.T) {
+	store := &memStore{docs: map[string]xai.StoredDocument{}}
+	syncer := xai.NewSyncer(store, "col-1", xai.WithSyncPatterns("*.md"))
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"a.md":         {Data: []byte("alpha")},
+		"docs/b.md":    {Data: []byte("beta")},
+		"notes.txt":    {Data: []byte("ignored")},
+		".git/HEAD.md": {Data: []byte("hidden")},
+	}
+
+	report, err := syncer.SyncDir(ctx, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Uploaded, []string{"a.md", "docs/b.md"}) || len(store.docs) != 2 {
+		t.Fatalf("first sync: %v (%v)", report, report.Uploaded)
+	}
+
+	fsys["a.md"] = &fstest.MapFile{Data: []byte("alpha v2")}
+	delete(fsys, "docs/b.md")
+	fsys["c.md"] = &fstest.MapFile{Data: []byte("gamma")}
+	dry, err := xai.NewSyncer(store, "col-1", xai.WithSyncDryRun()).SyncDir(ctx, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dry.Changed() || len(store.docs) != 2 {
+		t.Errorf("dry run: %v, store has %d docs", dry, len(store.docs))
+	}
+
+	report, err = syncer.SyncDir(ctx, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := report.String(); got != "1 uploaded, 1 updated, 1 deleted, 0 unchanged, 0 failed" {
+		t.Errorf("second sync: %s", got)
+	}
+	if len(store.docs) != 2 {
+		t.Errorf("store has %d docs, want 2", len(store.docs))
+	}
+
+	report, err = syncer.SyncDir(ctx, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Changed() || len(report.Unchanged) != 2 {
+		t.Errorf("third sync: %v", report)
+	}
+}
@@ -0,0 +1,56 @@
+package xai_test
+
+import (
+	"context"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func TestSystemPromptComposition(t *testing.T) {
+	lib := xai.NewSystemPrompt("").Include(
+		xai.PromptFragment{Name: "safety", Section: xai.PromptSectionBase, Order: 10, Text: "Never share secrets."},
+		xai.PromptFragment{Name: "style", Section: xai.PromptSectionPersona, Text: "Use plain English."},
+	)
+
+	p := xai.NewSystemPrompt("You are a helper.").
+		ToolInstructions("Call search first.").
+		Persona("Be terse.").
+		IncludeFrom(lib, "style", "safety", "missing")
+
+	want := "You are a helper.\n\nNever share secrets.\n\nBe terse.\n\nUse plain English.\n\nCall search first."
+	if got := p.String(); got != want {
+		t.Fatalf("String() =\n%q\nwant\n%q", got, want)
+	}
+
+	p.Persona("Be chatty.")
+	if got := p.Fragments()[2].Text; got != "Be chatty." {
+		t.Errorf("persona not replaced: %q", got)
+	}
+
+	counts, total, err := p.TokenCount(context.Background(), wordTokenizer{}, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 5 || counts[0].Name != "base" || counts[0].Tokens != 4 || total != 15 {
+		t.Errorf("counts = %+v, total = %d", counts, total)
+	}
+
+	built := xai.NewChatRequest().
+		WithSystemPrompt(p).
+		UserMessage(xai.UserContent{Text: "hi"}).
+		Build("m")
+	if len(built.Messages) != 2 || built.Messages[0].GetContent()[0].GetText() != p.String() {
+		t.Errorf("system prompt not assembled into first message: %v", built.Messages)
+	}
+}
+
+func TestSystemPromptTieOrder(t *testing.T) {
+	a := xai.PromptFragment{Name: "a", Section: xai.PromptSectionTools, Text: "First."}
+	b := xai.PromptFragment{Name: "b", Section: xai.PromptSectionTools, Text: "Second."}
+	ab := xai.NewSystemPrompt("").Include(a, b).String()
+	ba := xai.NewSystemPrompt("").Include(b, a).String()
+	if ab != ba || ab != "First.\n\nSecond." {
+		t.Errorf("Include(a, b) = %q, Include(b, a) = %q", ab, ba)
+	}
+}
@@ -0,0 +1,76 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		vars map[string]any
+		mode xai.TemplateEscape
+		want string
+	}{
+		{"simple", "Hello {{name}}!", map[string]any{"name": "Ada"}, xai.TemplateEscapeNone, "Hello Ada!"},
+		{"whitespace", "Hello {{ name }}", map[string]any{"name": "Ada"}, xai.TemplateEscapeNone, "Hello Ada"},
+		{"non-string", "{{n}} items", map[string]any{"n": 3}, xai.TemplateEscapeNone, "3 items"},
+		{"html", "<p>{{v}}</p>", map[string]any{"v": "<b>&"}, xai.TemplateEscapeHTML, "<p>&lt;b&gt;&amp;</p>"},
+		{"json", `{"q": "{{v}}"}`, map[string]any{"v": "say \"hi\"\n<x>"}, xai.TemplateEscapeJSON, `{"q": "say \"hi\"\n<x>"}`},
+		{"no placeholders", "plain text", nil, xai.TemplateEscapeNone, "plain text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := xai.RenderTemplate(tt.tmpl, tt.vars, tt.mode)
+			if err != nil {
+				t.Fatalf("RenderTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing variable", func(t *testing.T) {
+		_, err := xai.RenderTemplate("{{a}} {{b}}", map[string]any{"a": 1}, xai.TemplateEscapeNone)
+		if !errors.Is(err, xai.ErrInvalidSentinel) {
+			t.Errorf("expected invalid request error, got %v", err)
+		}
+	})
+
+	t.Run("unterminated", func(t *testing.T) {
+		if _, err := xai.RenderTemplate("Hello {{name", nil, xai.TemplateEscapeNone); err == nil {
+			t.Error("expected error for unterminated placeholder")
+		}
+	})
+}
+
+func TestUserMessageTemplate(t *testing.T) {
+	req := xai.NewChatRequest().UserMessageTemplate("Hi {{who}}", map[string]any{"who": "there"})
+	if req.Err() != nil {
+		t.Fatalf("Err() = %v", req.Err())
+	}
+	msgs := req.Messages()
+	if len(msgs) != 1 || msgs[0].GetContent()[0].GetText() != "Hi there" {
+		t.Errorf("unexpected messages: %v", msgs)
+	}
+
+	bad := xai.NewChatRequest().UserMessageTemplate("Hi {{who}}", nil)
+	if bad.Err() == nil {
+		t.Fatal("expected builder error")
+	}
+	if len(bad.Messages()) != 0 {
+		t.Error("failed template should not add a message")
+	}
+
+	// The builder error is returned before any RPC is attempted.
+	var client *xai.Client
+	if _, err := client.CompleteChat(context.Background(), bad); err != bad.Err() {
+		t.Errorf("CompleteChat() error = %v, want %v", err, bad.Err())
+	}
+}
@@ -0,0 +1,253 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// sentTools sends req with tools and returns the tools the server received.
+func sentTools(t *testing.T, tools ...xai.Tool) []*v1.Tool {
+	t.Helper()
+	var got []*v1.Tool
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			got = req.GetTools()
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "search"})
+	for _, tool := range tools {
+		req.AddTool(tool)
+	}
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestPartitionToolCalls(t *testing.T) {
+	weather := xai.NewFunctionTool("get_weather", "Get weather")
+	resp := &xai.ChatResponse{
+		FinishReason: xai.FinishReasonToolCalls,
+		ToolCalls: []*xai.ToolCallInfo{
+			{ID: "1", Type: xai.ToolCallTypeClient, Function: &xai.FunctionCall{Name: "get_weather"}},
+			{ID: "2", Type: xai.ToolCallTypeServer, Function: &xai.FunctionCall{Name: "web_search"}},
+			{ID: "3", Type: xai.ToolCallTypeClient, Function: &xai.FunctionCall{Name: "made_up"}},
+		},
+	}
+	tools := []xai.Tool{weather, xai.NewWebSearchTool()}
+
+	p := resp.PartitionToolCalls(tools)
+	if len(p.Client) != 1 || p.Client[0].ID != "1" {
+		t.Errorf("Client = %v, want call 1", p.Client)
+	}
+	if len(p.Server) != 1 || p.Server[0].ID != "2" {
+		t.Errorf("Server = %v, want call 2", p.Server)
+	}
+	if len(p.Unknown) != 1 || p.Unknown[0].ID != "3" {
+		t.Errorf("Unknown = %v, want call 3", p.Unknown)
+	}
+
+	if got := resp.PendingClientToolCalls(tools); len(got) != 1 {
+		t.Errorf("PendingClientToolCalls() = %v", got)
+	}
+	if !resp.RequiresClientAction(tools) {
+		t.Error("RequiresClientAction() should be true")
+	}
+
+	serverOnly := &xai.ChatResponse{
+		FinishReason: xai.FinishReasonToolCalls,
+		ToolCalls:    []*xai.ToolCallInfo{{Type: xai.ToolCallTypeServer}},
+	}
+	if serverOnly.RequiresClientAction(tools) {
+		t.Error("server-only tool calls should not require client action")
+	}
+	unknownOnly := &xai.ChatResponse{
+		FinishReason: xai.FinishReasonToolCalls,
+		ToolCalls:    resp.ToolCalls[2:],
+	}
+	if unknownOnly.RequiresClientAction(tools) {
+		t.Error("calls to unregistered tools should not require client action")
+	}
+}
+
+func TestWebSearchToolOptions(t *testing.T) {
+	tool := xai.NewWebSearchTool().
+		WithAllowedDomains("go.dev", "golang.org").
+		WithImageUnderstanding(true).
+		WithCountry("ZA").
+		WithUserLocation("Cape Town", "", "Africa/Johannesburg")
+	ws := sentTools(t, tool)[0].GetWebSearch()
+	if len(ws.GetAllowedDomains()) != 2 || !ws.GetEnableImageUnderstanding() {
+		t.Errorf("web search = %v", ws)
+	}
+	loc := ws.GetUserLocation()
+	if loc.GetCountry() != "ZA" || loc.GetCity() != "Cape Town" || loc.Region != nil || loc.GetTimezone() != "Africa/Johannesburg" {
+		t.Errorf("location = %v", loc)
+	}
+}
+
+func TestCloneTools(t *testing.T) {
+	web := xai.NewWebSearchTool().WithUserLocation("Cape Town", "", "")
+	typed := xai.NewTypedTool("lookup", "Look up", func(context.Context, struct{}) (string, error) { return "", nil })
+	base := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "search"}).AddTool(web).AddTool(typed)
+	clone := base.Clone()
+	web.WithUserLocation("Paris", "", "")
+	typed.Description = "changed"
+
+	var got []*v1.Tool
+	chat := &fakeChat{complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		got = req.GetTools()
+		return &v1.GetChatCompletionResponse{}, nil
+	}}
+	client := newFakeClient(t, chat, xai.Config{})
+	if _, err := client.CompleteChat(context.Background(), clone); err != nil {
+		t.Fatal(err)
+	}
+	if city := got[0].GetWebSearch().GetUserLocation().GetCity(); city != "Cape Town" {
+		t.Errorf("cloned web search city = %q, want the one at clone time", city)
+	}
+	if desc := got[1].GetFunction().GetDescription(); desc != "Look up" {
+		t.Errorf("cloned typed tool description = %q, want the one at clone time", desc)
+	}
+}
+
+func TestXSearchToolOptions(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tool := xai.NewXSearchTool().
+		WithAllowedHandles("@xai", "elonmusk").
+		WithDateRange(from, time.Time{}).
+		WithVideoUnderstanding(true)
+	xs := sentTools(t, tool)[0].GetXSearch()
+	if got := xs.GetAllowedXHandles(); len(got) != 2 || got[0] != "xai" {
+		t.Errorf("handles = %v", got)
+	}
+	if !xs.GetFromDate().AsTime().Equal(from) || xs.ToDate != nil {
+		t.Errorf("dates = %v, %v", xs.GetFromDate(), xs.ToDate)
+	}
+	if !xs.GetEnableVideoUnderstanding() || xs.EnableImageUnderstanding != nil {
+		t.Errorf("x search = %v", xs)
+	}
+}
+
+func TestUnmarshalArguments(t *testing.T) {
+	call := func(args string) *xai.ToolCallInfo {
+		return &xai.ToolCallInfo{ID: "c1", Function: &xai.FunctionCall{Name: "get_weather", Arguments: args}}
+	}
+	var in struct {
+		City string `json:"city"`
+	}
+	if err := call(`{"city":"Lima"}`).UnmarshalArguments(&in); err != nil || in.City != "Lima" {
+		t.Fatalf("in = %+v, err = %v", in, err)
+	}
+	if err := call("").UnmarshalArguments(&in); err != nil {
+		t.Errorf("empty arguments: %v", err)
+	}
+
+	tests := []struct{ args, want string }{
+		{`{"city":"Li`, "truncated after 11 bytes"},
+		{`{"city" "Lima"}`, "not valid JSON at offset 9"},
+		{`{"city":42}`, `argument "city" of get_weather: got JSON number, want string`},
+	}
+	for _, tt := range tests {
+		err := call(tt.args).UnmarshalArguments(&in)
+		var xerr *xai.Error
+		if !errors.As(err, &xerr) || xerr.Code != xai.ErrServerError || !strings.Contains(xerr.Message, tt.want) {
+			t.Errorf("UnmarshalArguments(%s) = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+
+	if err := (&xai.ToolCallInfo{ID: "s1"}).UnmarshalArguments(&in); err == nil {
+		t.Error("call without function should fail")
+	}
+}
+
+func TestParallelToolCallOrder(t *testing.T) {
+	call := func(id string) *v1.ToolCall {
+		return &v1.ToolCall{Id: id, Tool: &v1.ToolCall_Function{Function: &v1.FunctionCall{Name: "f" + id}}}
+	}
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{
+				FinishReason: v1.FinishReason_REASON_TOOL_CALLS,
+				Message:      &v1.CompletionMessage{ToolCalls: []*v1.ToolCall{call("a"), call("b"), call("c")}},
+			}}}, nil
+		},
+		stream: func(_ *v1.GetCompletionsRequest, srv v1.Chat_GetCompletionChunkServer) error {
+			done := call("a")
+			done.Status = v1.ToolCallStatus_TOOL_CALL_STATUS_COMPLETED
+			for _, calls := range [][]*v1.ToolCall{{call("a"), call("b")}, {call("c")}, {done}} {
+				if err := srv.Send(&v1.GetChatCompletionChunk{Outputs: []*v1.CompletionOutputChunk{{
+					Delta: &v1.Delta{ToolCalls: calls},
+				}}}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "m"})
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "go"}).WithParallelToolCalls(true)
+	ctx := context.Background()
+
+	check := func(name string, calls []*xai.ToolCallInfo) {
+		t.Helper()
+		var got []string
+		for _, tc := range calls {
+			got = append(got, fmt.Sprintf("%d:%s", tc.Index, tc.ID))
+		}
+		if want := "0:a 1:b 2:c"; strings.Join(got, " ") != want {
+			t.Errorf("%s tool calls = %v, want %s", name, got, want)
+		}
+	}
+
+	resp, err := client.CompleteChat(ctx, req.Clone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("CompleteChat", resp.ToolCalls)
+
+	stream, err := client.StreamChat(ctx, req.Clone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var streamed []*xai.ToolCallInfo
+	for {
+		chunk, err := stream.Next()
+		if err != nil {
+			break
+		}
+		streamed = append(streamed, chunk.ToolCalls...)
+	}
+	if len(streamed) != 4 {
+		t.Fatalf("streamed %d tool calls, want 4", len(streamed))
+	}
+	// The status update for a keeps a's Index.
+	if update := streamed[3]; update.ID != "a" || update.Index != 0 {
+		t.Errorf("status update = %+v, want a at index 0", update)
+	}
+	check("StreamChat", streamed[:3])
+
+	// REST carries an explicit index, which wins over arrival order.
+	rest := newRESTClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","tool_calls":[`+
+			`{"index":2,"id":"c","type":"function","function":{"name":"fc","arguments":"{}"}},`+
+			`{"index":0,"id":"a","type":"function","function":{"name":"fa","arguments":"{}"}},`+
+			`{"index":1,"id":"b","type":"function","function":{"name":"fb","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`)
+	}))
+	resp, err = rest.CompleteChat(ctx, req.Clone())
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("REST", resp.ToolCalls)
+}
@@ -0,0 +1,72 @@
+package xai_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func TestTranscriptReplay(t *testing.T) {
+	var buf bytes.Buffer
+	tw := xai.NewTranscriptWriter(&buf)
+	chunks := []*xai.ChatChunk{
+		{ID: "r1", Delta: "Hel"},
+		{ID: "r1", Delta: "lo", FinishReason: xai.FinishReasonStop, Usage: xai.Usage{TotalTokens: 7}},
+	}
+	for _, c := range chunks {
+		if err := tw.WriteChunk(c); err != nil {
+			t.Fatalf("WriteChunk() error = %v", err)
+		}
+	}
+	if err := tw.WriteEnd(); err != nil {
+		t.Fatalf("WriteEnd() error = %v", err)
+	}
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Errorf("expected 3 JSONL lines, got %d", lines)
+	}
+
+	var stream xai.ChatStream = xai.NewReplayStream(&buf).WithSpeed(0)
+	var content string
+	var last *xai.ChatChunk
+	for {
+		chunk, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		content += chunk.Delta
+		last = chunk
+	}
+	if content != "Hello" {
+		t.Errorf("replayed content = %q, want %q", content, "Hello")
+	}
+	if last.FinishReason != xai.FinishReasonStop || last.Usage.TotalTokens != 7 {
+		t.Errorf("final chunk = %+v", last)
+	}
+	if stream.Err() != nil {
+		t.Errorf("Err() = %v", stream.Err())
+	}
+}
+
+func TestReplayRecordedError(t *testing.T) {
+	var buf bytes.Buffer
+	tw := xai.NewTranscriptWriter(&buf)
+	_ = tw.WriteChunk(&xai.ChatChunk{Delta: "partial"})
+	_ = tw.WriteError(&xai.Error{Code: xai.ErrRateLimit, Message: "slow down"})
+
+	stream := xai.NewReplayStream(&buf).WithSpeed(0)
+	if _, err := stream.Next(); err != nil {
+		t.Fatalf("first Next() error = %v", err)
+	}
+	_, err := stream.Next()
+	if !errors.Is(err, xai.ErrRateLimitSentinel) {
+		t.Errorf("expected rate limit error, got %v", err)
+	}
+}
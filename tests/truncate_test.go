@@ -0,0 +1,147 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+)
+
+func TestAutoTruncate(t *testing.T) {
+	models := &fakeModels{models: map[string]*v1.LanguageModel{
+		"drop": {Name: "drop", MaxPromptLength: 700},
+		"keep": {Name: "keep", MaxPromptLength: 1500},
+		"sum":  {Name: "sum", MaxPromptLength: 1200},
+		"big":  {Name: "big", MaxPromptLength: 2000},
+		"tiny": {Name: "tiny", MaxPromptLength: 100},
+	}}
+	var mu sync.Mutex
+	var sent, summarized *v1.GetCompletionsRequest
+	chat := &fakeChat{complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.Contains(req.GetMessages()[0].GetContent()[0].GetText(), "condense") {
+			summarized = req
+			return answerResponse("they talked"), nil
+		}
+		sent = req
+		return answerResponse("ok"), nil
+	}}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "keep"}, func(s *grpc.Server) {
+		v1.RegisterModelsServer(s, models)
+		v1.RegisterTokenizeServer(s, &fakeTokenizer{})
+	})
+
+	// 6 + 604 + 6 + 604 + 604 = 1824 tokens with overhead.
+	long := strings.Repeat("word ", 600)
+	history := func(model string) *xai.ChatRequest {
+		return xai.NewChatRequest().
+			WithModel(model).
+			SystemMessage(xai.SystemContent{Text: "be brief"}).
+			UserMessage(xai.UserContent{Text: long}).
+			AssistantMessage(xai.AssistantContent{ToolCalls: []xai.HistoryToolCall{{ID: "c1", Name: "lookup", Arguments: "{}"}}}).
+			ToolResult(xai.ToolContent{CallID: "c1", Result: long}).
+			UserMessage(xai.UserContent{Text: long})
+	}
+	roles := func(req *v1.GetCompletionsRequest) string {
+		var out []string
+		for _, msg := range req.GetMessages() {
+			out = append(out, strings.TrimPrefix(strings.ToLower(msg.GetRole().String()), "role_"))
+		}
+		return strings.Join(out, ",")
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		model    string
+		strategy xai.TruncateStrategy
+		want     string
+	}{
+		{"keep", 0, "system,user,assistant,tool,user"},
+		{"big", xai.TruncateDropOldest, "system,user,assistant,tool,user"}, // fits
+		{"keep", xai.TruncateKeepSystem, "system,assistant,tool,user"},
+		{"drop", xai.TruncateDropOldest, "user"}, // the tool result goes with its call
+		{"sum", xai.TruncateSummarizeOldest, "system,developer,user"},
+	}
+	for _, tt := range tests {
+		req := history(tt.model)
+		if tt.strategy != 0 {
+			req.WithAutoTruncate(tt.strategy)
+		}
+		if _, err := client.CompleteChat(ctx, req); err != nil {
+			t.Fatalf("%s/%d: %v", tt.model, tt.strategy, err)
+		}
+		if got := roles(sent); got != tt.want {
+			t.Errorf("%s/%d: sent %s, want %s", tt.model, tt.strategy, got, tt.want)
+		}
+		if len(req.Messages()) != 5 {
+			t.Errorf("%s/%d: request modified", tt.model, tt.strategy)
+		}
+	}
+	if summarized == nil || !strings.Contains(summarized.GetMessages()[1].GetContent()[0].GetText(), "tool: "+strings.TrimSpace(long)) {
+		t.Error("summary request did not carry the dropped turns")
+	}
+	if got := sent.GetMessages()[1].GetContent()[0].GetText(); !strings.HasSuffix(got, "they talked") {
+		t.Errorf("summary message = %q", got)
+	}
+
+	_, err := client.CompleteChat(ctx, history("tiny").WithAutoTruncate(xai.TruncateKeepSystem))
+	var xerr *xai.Error
+	if !errors.As(err, &xerr) || xerr.Code != xai.ErrInvalidRequest {
+		t.Errorf("oversized last message: err = %v", err)
+	}
+}
+
+func TestMaxTokensAuto(t *testing.T) {
+	models := &fakeModels{models: map[string]*v1.LanguageModel{
+		"window":  {Name: "window", MaxPromptLength: 1000},
+		"tiny":    {Name: "tiny", MaxPromptLength: 50},
+		"unknown": {Name: "unknown"},
+	}}
+	var sent *v1.GetCompletionsRequest
+	chat := &fakeChat{complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+		sent = req
+		return answerResponse("ok"), nil
+	}}
+	client := newFakeClient(t, chat, xai.Config{DefaultModel: "window"}, func(s *grpc.Server) {
+		v1.RegisterModelsServer(s, models)
+		v1.RegisterTokenizeServer(s, &fakeTokenizer{})
+	})
+	// 100 words and 4 tokens of message overhead.
+	prompt := strings.Repeat("word ", 100)
+	ctx := context.Background()
+
+	tests := []struct {
+		model string
+		limit int32
+		want  int32 // 0 for unset
+	}{
+		{"window", 0, 896},
+		{"window", 500, 500}, // an explicit limit caps it
+		{"window", 2000, 896},
+		{"unknown", 0, 0},
+	}
+	for _, tt := range tests {
+		req := xai.NewChatRequest().WithModel(tt.model).UserMessage(xai.UserContent{Text: prompt}).WithMaxTokensAuto()
+		if tt.limit > 0 {
+			req.WithMaxTokens(tt.limit)
+		}
+		if _, err := client.CompleteChat(ctx, req); err != nil {
+			t.Fatalf("%s/%d: %v", tt.model, tt.limit, err)
+		}
+		if got := sent.GetMaxTokens(); got != tt.want {
+			t.Errorf("%s/%d: max tokens = %d, want %d", tt.model, tt.limit, got, tt.want)
+		}
+	}
+
+	_, err := client.CompleteChat(ctx, xai.NewChatRequest().WithModel("tiny").UserMessage(xai.UserContent{Text: prompt}).WithMaxTokensAuto())
+	var xaiErr *xai.Error
+	if !errors.As(err, &xaiErr) || xaiErr.Code != xai.ErrInvalidRequest {
+		t.Errorf("prompt over the window: err = %v, want ErrInvalidRequest", err)
+	}
+}
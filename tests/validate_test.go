@@ -0,0 +1,54 @@
+package xai_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func TestValidate(t *testing.T) {
+	ok := xai.NewChatRequest().
+		UserMessage(xai.UserContent{Text: "hi"}).
+		WithTemperature(0.7).
+		AddTool(xai.NewFunctionTool("get_weather", "Weather").WithParameters(`{"type":"object"}`))
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("Validate() of valid request = %v", err)
+	}
+
+	bad := xai.NewChatRequest().
+		WithTemperature(3).
+		WithMaxTokens(0).
+		AddTools(
+			xai.NewFunctionTool("get weather", "bad name"),
+			xai.NewFunctionTool("lookup", "bad schema").WithParameters(`[1, 2`),
+			xai.NewFunctionTool("lookup", "duplicate"),
+			xai.NewTypedTool("lookup", "typed duplicate", func(context.Context, struct{}) (string, error) { return "", nil }),
+		)
+	err := bad.Validate()
+	var xerr *xai.Error
+	if !errors.As(err, &xerr) || xerr.Code != xai.ErrInvalidRequest {
+		t.Fatalf("Validate() = %v, want ErrInvalidRequest", err)
+	}
+	var problems xai.ValidationErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("Validate() = %v, want ValidationErrors", err)
+	}
+	var fields []string
+	for _, p := range problems {
+		fields = append(fields, p.Field)
+	}
+	want := "messages max_tokens temperature tools[0].name tools[1].parameters tools[2].name tools[3].name"
+	if got := strings.Join(fields, " "); got != want {
+		t.Errorf("fields = %q, want %q", got, want)
+	}
+	var one *xai.ValidationError
+	if !errors.As(err, &one) || one.Field != "messages" {
+		t.Errorf("errors.As(*ValidationError) = %v", one)
+	}
+	if !strings.Contains(err.Error(), "temperature: must be between 0 and 2, got 3") {
+		t.Errorf("Error() = %q", err)
+	}
+}
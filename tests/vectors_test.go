@@ -0,0 +1,63 @@
+package xai_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+)
+
+func TestVectorExport(t *testing.T) {
+	resp := &xai.EmbedResponse{Embeddings: []xai.Embedding{
+		{Index: 1, Vectors: [][]float32{{0.5, -1}}},
+		{Index: 0, Vectors: [][]float32{{0.25, 2}, {3, 4}}},
+	}}
+	records, err := xai.NewVectorRecords(resp, []string{"img", "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0].ID != "7" || records[1].ID != "img-0" || records[2].ID != "img-1" {
+		t.Fatalf("records = %+v", records)
+	}
+	records[0].Payload = map[string]any{"title": "tab\there"}
+
+	var pg strings.Builder
+	if err := xai.WritePgvectorCOPY(&pg, records); err != nil {
+		t.Fatal(err)
+	}
+	// JSON escapes the tab as \t, and COPY escapes that backslash again.
+	wantPG := "7\t[0.5,-1]\t{\"title\":\"tab\\\\there\"}\n" +
+		"img-0\t[0.25,2]\t\\N\n" +
+		"img-1\t[3,4]\t\\N\n"
+	if pg.String() != wantPG {
+		t.Errorf("pgvector COPY =\n%q\nwant\n%q", pg.String(), wantPG)
+	}
+
+	var qd strings.Builder
+	if err := xai.WriteQdrantJSON(&qd, records[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"points":[{"id":7,"vector":[0.5,-1],"payload":{"title":"tab\there"}}]}` + "\n"; qd.String() != want {
+		t.Errorf("qdrant = %s", qd.String())
+	}
+
+	var mv strings.Builder
+	if err := xai.WriteMilvusJSON(&mv, records); err != nil {
+		t.Fatal(err)
+	}
+	var milvus struct {
+		Rows []map[string]any `json:"rows"`
+	}
+	if err := json.Unmarshal([]byte(mv.String()), &milvus); err != nil {
+		t.Fatal(err)
+	}
+	if len(milvus.Rows) != 3 || milvus.Rows[0]["id"] != "7" || milvus.Rows[0]["title"] != "tab\there" {
+		t.Errorf("milvus rows = %v", milvus.Rows)
+	}
+
+	if _, err := xai.NewVectorRecords(resp, []string{"only-one"}); !errors.Is(err, xai.ErrInvalidSentinel) {
+		t.Errorf("missing ID: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package xai_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+func TestVerifyAgainstSources(t *testing.T) {
+	var prompt string
+	chat := &fakeChat{
+		complete: func(_ context.Context, req *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			prompt = req.GetMessages()[1].GetContent()[0].GetText()
+			return &v1.GetChatCompletionResponse{
+				Outputs: []*v1.CompletionOutput{{Message: &v1.CompletionMessage{Content: `{"claims": [
+					{"claim": "Paris is the capital of France", "verdict": "supported", "sources": [0]},
+					{"claim": "Paris has 20 million residents", "verdict": "contradicted", "sources": [0]},
+					{"claim": "It rains often", "verdict": "maybe"}
+				]}`}}},
+			}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{})
+
+	resp := &xai.ChatResponse{Content: "Paris is the capital of France with 20 million residents. It rains often."}
+	report, err := client.VerifyAgainstSources(context.Background(), resp, []xai.Source{
+		{ID: "wiki/Paris", Content: "Paris, capital of France, population 2.1 million."},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(prompt, "[0] wiki/Paris") || !strings.Contains(prompt, resp.Content) {
+		t.Errorf("prompt missing answer or sources:\n%s", prompt)
+	}
+	if report.Supported != 1 || report.Contradicted != 1 || report.Unsupported != 1 || report.Grounded() {
+		t.Errorf("report = %+v", report)
+	}
+	if flagged := report.Flagged(); len(flagged) != 2 || flagged[1].Verdict != xai.ClaimUnsupported {
+		t.Errorf("flagged = %+v", flagged)
+	}
+}
@@ -0,0 +1,62 @@
+package xai_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestClientInfoHeader(t *testing.T) {
+	if xai.Version() == "" {
+		t.Fatal("Version() is empty")
+	}
+	want := "xai-go/" + xai.Version() + " " + runtime.Version()
+
+	var got string
+	chat := &fakeChat{
+		complete: func(ctx context.Context, _ *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			got = strings.Join(md.Get("x-client-info"), ",")
+			return &v1.GetChatCompletionResponse{}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{AppName: "billing/1.4"})
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "hi"})
+	if _, err := client.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, want) || !strings.HasSuffix(got, " billing/1.4") {
+		t.Errorf("gRPC client info = %q", got)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+	rest, err := xai.New(xai.Config{
+		APIKey:       xai.NewSecureString("k"),
+		AppName:      "billing/1.4",
+		Transport:    xai.TransportREST,
+		RESTEndpoint: srv.URL,
+		HTTPClient:   srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rest.Close()
+	if _, err := rest.CompleteChat(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, want) || !strings.HasSuffix(got, " billing/1.4") {
+		t.Errorf("REST user agent = %q", got)
+	}
+}
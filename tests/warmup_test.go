@@ -0,0 +1,36 @@
+package xai_test
+
+import (
+	"context"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/grpc"
+)
+
+// warmupModels reports each GetLanguageModel call on a channel.
+type warmupModels struct {
+	fakeModels
+	calls chan string
+}
+
+func (f *warmupModels) GetLanguageModel(ctx context.Context, req *v1.GetModelRequest) (*v1.LanguageModel, error) {
+	f.calls <- req.GetName()
+	return f.fakeModels.GetLanguageModel(ctx, req)
+}
+
+func TestWarmup(t *testing.T) {
+	models := &warmupModels{calls: make(chan string, 4)}
+	register := func(s *grpc.Server) { v1.RegisterModelsServer(s, models) }
+	client := newFakeClient(t, &fakeChat{}, xai.Config{DefaultModel: "grok-3", Warmup: true}, register)
+
+	if got := <-models.calls; got != "grok-3" {
+		t.Errorf("warm-up fetched %q", got)
+	}
+
+	// An unknown model still proves the connection works.
+	if err := client.Warmup(context.Background()); err != nil {
+		t.Errorf("Warmup: %v", err)
+	}
+}
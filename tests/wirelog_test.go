@@ -0,0 +1,67 @@
+package xai_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	xai "github.com/roelfdiedericks/xai-go"
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// syncBuffer is a strings.Builder safe for concurrent writes.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+func TestWireLogging(t *testing.T) {
+	chat := &fakeChat{
+		complete: func(context.Context, *v1.GetCompletionsRequest) (*v1.GetChatCompletionResponse, error) {
+			return &v1.GetChatCompletionResponse{Outputs: []*v1.CompletionOutput{{
+				Message: &v1.CompletionMessage{Content: "pong"},
+			}}}, nil
+		},
+	}
+	client := newFakeClient(t, chat, xai.Config{APIKey: xai.NewSecureString("xai-secret-1234")})
+	ctx := context.Background()
+	req := xai.NewChatRequest().UserMessage(xai.UserContent{Text: "ping"}).WithTemperature(0.5)
+
+	var out syncBuffer
+	client.EnableWireLogging(&out)
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	log := out.String()
+	// protojson randomizes its whitespace, so match with spaces removed.
+	compact := strings.Join(strings.Fields(log), "")
+	for _, want := range []string{"==>Chat/GetCompletion", "api-key:****1234", `"temperature":0.5`, `"text":"ping"`, "<--Chat/GetCompletionresponse", `"content":"pong"`} {
+		if !strings.Contains(compact, want) {
+			t.Errorf("wire log missing %q:\n%s", want, log)
+		}
+	}
+	if strings.Contains(log, "secret") {
+		t.Errorf("wire log leaks the key:\n%s", log)
+	}
+
+	client.EnableWireLogging(nil)
+	if _, err := client.CompleteChat(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != log {
+		t.Error("logging continued after EnableWireLogging(nil)")
+	}
+}
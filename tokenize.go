@@ -18,6 +18,9 @@ type Token struct {
 type TokenizeResponse struct {
 	// Tokens are the tokenized results.
 	Tokens []Token
+	// Metadata holds the response headers and trailers, such as the request
+	// ID and rate-limit state.
+	Metadata *ResponseMetadata
 }
 
 // TokenCount returns the total number of tokens.
@@ -30,15 +33,16 @@ func (c *Client) Tokenize(ctx context.Context, model, text string) (*TokenizeRes
 	ctx, cancel := c.withTimeout(ctx)
 	defer cancel()
 
+	md := &ResponseMetadata{}
 	resp, err := c.tokenizer.TokenizeText(ctx, &v1.TokenizeTextRequest{
 		Model: model,
 		Text:  text,
-	})
+	}, md.callOptions()...)
 	if err != nil {
-		return nil, FromGRPCError(err)
+		return nil, errorWithMetadata(err, md)
 	}
 
-	result := &TokenizeResponse{}
+	result := &TokenizeResponse{Metadata: md}
 	for _, t := range resp.GetTokens() {
 		result.Tokens = append(result.Tokens, Token{
 			TokenID:     t.GetTokenId(),
@@ -2,8 +2,17 @@ package xai
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Tool represents a tool that can be used by the model.
@@ -13,6 +22,45 @@ type Tool interface {
 	toProto() *v1.Tool
 }
 
+// cloneTool returns a copy of tool that the original's builder methods do
+// not affect. Tools of other types are returned as is.
+func cloneTool(tool Tool) Tool {
+	if t, ok := tool.(interface{ clone() Tool }); ok {
+		return t.clone()
+	}
+	switch t := tool.(type) {
+	case *FunctionTool:
+		c := *t
+		c.Parameters = slices.Clone(t.Parameters)
+		return &c
+	case *WebSearchTool:
+		c := *t
+		c.allowedDomains = slices.Clone(t.allowedDomains)
+		c.excludedDomains = slices.Clone(t.excludedDomains)
+		if t.location != nil {
+			c.location = proto.Clone(t.location).(*v1.WebSearchUserLocation)
+		}
+		return &c
+	case *XSearchTool:
+		c := *t
+		c.allowedHandles = slices.Clone(t.allowedHandles)
+		c.excludedHandles = slices.Clone(t.excludedHandles)
+		return &c
+	case *CollectionsSearchTool:
+		c := *t
+		c.CollectionIDs = slices.Clone(t.CollectionIDs)
+		return &c
+	case *AttachmentSearchTool:
+		c := *t
+		c.Limit = clonePtr(t.Limit)
+		return &c
+	case *MCPTool:
+		c := *t
+		return &c
+	}
+	return tool
+}
+
 // ToolChoice controls how the model uses tools.
 type ToolChoice int
 
@@ -60,6 +108,18 @@ type FunctionTool struct {
 	Strict bool
 }
 
+// functionTool returns the function definition of tool, also for tools
+// carrying their own handler such as TypedTool, or nil for other tools.
+func functionTool(tool Tool) *FunctionTool {
+	switch t := tool.(type) {
+	case *FunctionTool:
+		return t
+	case RunnableTool:
+		return t.Function()
+	}
+	return nil
+}
+
 // NewFunctionTool creates a new function tool with the given name and description.
 func NewFunctionTool(name, description string) *FunctionTool {
 	return &FunctionTool{
@@ -87,6 +147,18 @@ func (f *FunctionTool) WithParameters(params any) *FunctionTool {
 	return f
 }
 
+// WithParametersFrom sets the parameters schema to that of v's type, as
+// SchemaFor does; v is typically the zero value of an arguments struct:
+//
+//	tool.WithParametersFrom(WeatherArgs{})
+func (f *FunctionTool) WithParametersFrom(v any) *FunctionTool {
+	if v == nil {
+		return f
+	}
+	f.Parameters = schemaJSON(reflect.TypeOf(v))
+	return f
+}
+
 func (f *FunctionTool) toProto() *v1.Tool {
 	fn := &v1.Function{
 		Name:        f.Name,
@@ -104,33 +176,154 @@ func (f *FunctionTool) toProto() *v1.Tool {
 }
 
 // WebSearchTool enables web search capabilities.
-type WebSearchTool struct{}
+type WebSearchTool struct {
+	allowedDomains  []string
+	excludedDomains []string
+	images          *bool
+	location        *v1.WebSearchUserLocation
+}
 
 // NewWebSearchTool creates a new web search tool.
 func NewWebSearchTool() *WebSearchTool {
 	return &WebSearchTool{}
 }
 
+// WithAllowedDomains restricts results to these domains (e.g. "example.com",
+// without scheme or subdomain). At most 5 are allowed, and it cannot be
+// combined with WithExcludedDomains.
+func (w *WebSearchTool) WithAllowedDomains(domains ...string) *WebSearchTool {
+	w.allowedDomains = domains
+	return w
+}
+
+// WithExcludedDomains drops results from these domains. At most 5 are
+// allowed, and it cannot be combined with WithAllowedDomains.
+func (w *WebSearchTool) WithExcludedDomains(domains ...string) *WebSearchTool {
+	w.excludedDomains = domains
+	return w
+}
+
+// WithImageUnderstanding lets the search fetch and interpret images.
+func (w *WebSearchTool) WithImageUnderstanding(enabled bool) *WebSearchTool {
+	w.images = &enabled
+	return w
+}
+
+// WithCountry prefers results relevant to a country, given as an ISO 3166-1
+// alpha-2 code such as "US".
+func (w *WebSearchTool) WithCountry(country string) *WebSearchTool {
+	w.userLocation().Country = &country
+	return w
+}
+
+// WithUserLocation prefers results relevant to a location. Empty values are
+// left unset; timezone is an IANA name such as "Europe/Paris".
+func (w *WebSearchTool) WithUserLocation(city, region, timezone string) *WebSearchTool {
+	loc := w.userLocation()
+	if city != "" {
+		loc.City = &city
+	}
+	if region != "" {
+		loc.Region = &region
+	}
+	if timezone != "" {
+		loc.Timezone = &timezone
+	}
+	return w
+}
+
+func (w *WebSearchTool) userLocation() *v1.WebSearchUserLocation {
+	if w.location == nil {
+		w.location = &v1.WebSearchUserLocation{}
+	}
+	return w.location
+}
+
 func (w *WebSearchTool) toProto() *v1.Tool {
 	return &v1.Tool{
 		Tool: &v1.Tool_WebSearch{
-			WebSearch: &v1.WebSearch{},
+			WebSearch: &v1.WebSearch{
+				AllowedDomains:           w.allowedDomains,
+				ExcludedDomains:          w.excludedDomains,
+				EnableImageUnderstanding: w.images,
+				UserLocation:             w.location,
+			},
 		},
 	}
 }
 
 // XSearchTool enables X (Twitter) search capabilities.
-type XSearchTool struct{}
+type XSearchTool struct {
+	allowedHandles  []string
+	excludedHandles []string
+	from, to        *timestamppb.Timestamp
+	images          *bool
+	videos          *bool
+}
 
 // NewXSearchTool creates a new X search tool.
 func NewXSearchTool() *XSearchTool {
 	return &XSearchTool{}
 }
 
+// WithAllowedHandles restricts results to posts by these accounts. A leading
+// "@" is stripped. It cannot be combined with WithExcludedHandles.
+func (x *XSearchTool) WithAllowedHandles(handles ...string) *XSearchTool {
+	x.allowedHandles = trimHandles(handles)
+	return x
+}
+
+// WithExcludedHandles drops posts by these accounts. A leading "@" is
+// stripped. It cannot be combined with WithAllowedHandles.
+func (x *XSearchTool) WithExcludedHandles(handles ...string) *XSearchTool {
+	x.excludedHandles = trimHandles(handles)
+	return x
+}
+
+// WithDateRange limits results to posts between from and to. A zero time
+// leaves that end of the range open.
+func (x *XSearchTool) WithDateRange(from, to time.Time) *XSearchTool {
+	x.from, x.to = nil, nil
+	if !from.IsZero() {
+		x.from = timestamppb.New(from)
+	}
+	if !to.IsZero() {
+		x.to = timestamppb.New(to)
+	}
+	return x
+}
+
+// WithImageUnderstanding lets the search fetch and interpret images in posts.
+func (x *XSearchTool) WithImageUnderstanding(enabled bool) *XSearchTool {
+	x.images = &enabled
+	return x
+}
+
+// WithVideoUnderstanding lets the search fetch and interpret videos in posts.
+func (x *XSearchTool) WithVideoUnderstanding(enabled bool) *XSearchTool {
+	x.videos = &enabled
+	return x
+}
+
+func trimHandles(handles []string) []string {
+	out := make([]string, len(handles))
+	for i, h := range handles {
+		out[i] = strings.TrimPrefix(strings.TrimSpace(h), "@")
+	}
+	return out
+}
+
 func (x *XSearchTool) toProto() *v1.Tool {
 	return &v1.Tool{
 		Tool: &v1.Tool_XSearch{
-			XSearch: &v1.XSearch{},
+			XSearch: &v1.XSearch{
+				FromDate:                 x.from,
+				ToDate:                   x.to,
+				AllowedXHandles:          x.allowedHandles,
+				ExcludedXHandles:         x.excludedHandles,
+				EnableImageUnderstanding: x.images,
+				EnableVideoUnderstanding: x.videos,
+			},
 		},
 	}
 }
@@ -240,6 +433,32 @@ const (
 	ToolCallTypeServer
 )
 
+// String returns "client" or "server".
+func (t ToolCallType) String() string {
+	if t == ToolCallTypeServer {
+		return "server"
+	}
+	return "client"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t ToolCallType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *ToolCallType) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "client":
+		*t = ToolCallTypeClient
+	case "server":
+		*t = ToolCallTypeServer
+	default:
+		return fmt.Errorf("unknown tool call type %q", b)
+	}
+	return nil
+}
+
 // ToolCallStatus indicates the status of a tool call.
 type ToolCallStatus int
 
@@ -252,26 +471,116 @@ const (
 	ToolCallStatusFailed
 )
 
+// String returns "pending", "completed" or "failed".
+func (s ToolCallStatus) String() string {
+	switch s {
+	case ToolCallStatusCompleted:
+		return "completed"
+	case ToolCallStatusFailed:
+		return "failed"
+	default:
+		return "pending"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s ToolCallStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *ToolCallStatus) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "pending":
+		*s = ToolCallStatusPending
+	case "completed":
+		*s = ToolCallStatusCompleted
+	case "failed":
+		*s = ToolCallStatusFailed
+	default:
+		return fmt.Errorf("unknown tool call status %q", b)
+	}
+	return nil
+}
+
 // ToolCallInfo represents a tool call made by the model.
 type ToolCallInfo struct {
 	// ID is the unique identifier for this tool call.
-	ID string
+	ID string `json:"id"`
+	// Index is the call's position among the tool calls of its output,
+	// from 0. Streams number calls by ID across chunks, so a call keeps its
+	// Index in every chunk that mentions it, such as status updates, and
+	// in the assembled response.
+	Index int `json:"index"`
 	// Type indicates if this is a client-side or server-side tool call.
-	Type ToolCallType
+	Type ToolCallType `json:"type"`
 	// Status is the current status of the tool call.
-	Status ToolCallStatus
+	Status ToolCallStatus `json:"status"`
 	// ErrorMessage contains an error message if the call failed.
-	ErrorMessage string
+	ErrorMessage string `json:"error_message,omitempty"`
 	// Function contains the function call details.
-	Function *FunctionCall
+	Function *FunctionCall `json:"function,omitempty"`
+	// CodeExecutionOutput is the output of a server-side code execution
+	// call exactly as the server returned it, when the request enabled
+	// IncludeCodeExecutionOutput. Its format is not documented by the API.
+	CodeExecutionOutput string `json:"code_execution_output,omitempty"`
+
+	kind v1.ToolCallType
 }
 
 // FunctionCall represents a function call made by the model.
 type FunctionCall struct {
 	// Name is the function name.
-	Name string
+	Name string `json:"name"`
 	// Arguments is the JSON-encoded arguments.
-	Arguments string
+	Arguments string `json:"arguments"`
+}
+
+// UnmarshalArguments decodes the call's JSON arguments into v. Empty
+// arguments decode as {}. Malformed arguments fail with ErrServerError and
+// a message naming the tool and what went wrong: the offset of a syntax
+// error, the field of a type mismatch, or that the JSON ends early, as it
+// does when a stream was cut off mid-call.
+func (tc *ToolCallInfo) UnmarshalArguments(v any) error {
+	if tc.Function == nil {
+		return &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf("tool call %s has no function arguments", tc.ID)}
+	}
+	return unmarshalArguments(tc.Function.Name, []byte(tc.Function.Arguments), v)
+}
+
+func unmarshalArguments(name string, args []byte, v any) error {
+	if len(strings.TrimSpace(string(args))) == 0 {
+		args = []byte("{}")
+	}
+	err := json.Unmarshal(args, v)
+	if err == nil {
+		return nil
+	}
+
+	var (
+		msg       string
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF) || (errors.As(err, &syntaxErr) && syntaxErr.Offset >= int64(len(args))):
+		msg = fmt.Sprintf("arguments for %s are truncated after %d bytes", name, len(args))
+	case errors.As(err, &syntaxErr):
+		msg = fmt.Sprintf("arguments for %s are not valid JSON at offset %d near %q",
+			name, syntaxErr.Offset, argumentsNear(args, syntaxErr.Offset))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		msg = fmt.Sprintf("argument %q of %s: got JSON %s, want %s", typeErr.Field, name, typeErr.Value, typeErr.Type)
+	default:
+		msg = "decoding arguments for " + name
+	}
+	return &Error{Code: ErrServerError, Message: msg, Cause: err}
+}
+
+// argumentsNear returns the text around offset, for error messages.
+func argumentsNear(args []byte, offset int64) string {
+	start := max(int(offset)-10, 0)
+	end := min(int(offset)+10, len(args))
+	return string(args[start:end])
 }
 
 // IsClientSide returns true if this is a client-side tool call that you must execute.
@@ -291,7 +600,8 @@ func toolCallFromProto(tc *v1.ToolCall) *ToolCallInfo {
 	}
 
 	info := &ToolCallInfo{
-		ID: tc.GetId(),
+		ID:   tc.GetId(),
+		kind: tc.GetType(),
 	}
 
 	// Type - determine if server-side or client-side based on type
@@ -344,11 +654,52 @@ func IsClientSideTool(call *ToolCallInfo, registeredTools []Tool) bool {
 	}
 
 	for _, tool := range registeredTools {
-		if fn, ok := tool.(*FunctionTool); ok {
-			if fn.Name == call.Function.Name {
-				return true
-			}
+		if fn := functionTool(tool); fn != nil && fn.Name == call.Function.Name {
+			return true
 		}
 	}
 	return false
 }
+
+// ToolCallPartition groups the tool calls of a response by who must act on them.
+type ToolCallPartition struct {
+	// Client are calls to registered function tools that the caller must execute.
+	Client []*ToolCallInfo
+	// Server are calls xAI already executed (web search, code execution, etc).
+	Server []*ToolCallInfo
+	// Unknown are client-side calls that match no registered tool, typically
+	// a hallucinated function name. They should be answered with an error result.
+	Unknown []*ToolCallInfo
+}
+
+// PartitionToolCalls splits the response's tool calls into client-executed,
+// server-executed and unknown calls based on the tools registered on the request.
+func (r *ChatResponse) PartitionToolCalls(registeredTools []Tool) ToolCallPartition {
+	var p ToolCallPartition
+	for _, tc := range r.ToolCalls {
+		switch {
+		case tc == nil:
+			continue
+		case tc.IsServerSide():
+			p.Server = append(p.Server, tc)
+		case IsClientSideTool(tc, registeredTools):
+			p.Client = append(p.Client, tc)
+		default:
+			p.Unknown = append(p.Unknown, tc)
+		}
+	}
+	return p
+}
+
+// PendingClientToolCalls returns the tool calls the caller must execute before
+// continuing the conversation. Server-side calls are excluded.
+func (r *ChatResponse) PendingClientToolCalls(registeredTools []Tool) []*ToolCallInfo {
+	return r.PartitionToolCalls(registeredTools).Client
+}
+
+// RequiresClientAction reports whether the response has calls to registered
+// function tools that the caller must execute, i.e. PendingClientToolCalls
+// is not empty.
+func (r *ChatResponse) RequiresClientAction(registeredTools []Tool) bool {
+	return len(r.PartitionToolCalls(registeredTools).Client) > 0
+}
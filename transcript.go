@@ -0,0 +1,108 @@
+package xai
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// TranscriptEventType identifies the kind of a recorded stream event.
+type TranscriptEventType string
+
+const (
+	// TranscriptEventChunk records a streamed chunk (deltas, tool calls, usage).
+	TranscriptEventChunk TranscriptEventType = "chunk"
+	// TranscriptEventError records a stream failure.
+	TranscriptEventError TranscriptEventType = "error"
+	// TranscriptEventEnd records normal end of stream.
+	TranscriptEventEnd TranscriptEventType = "end"
+)
+
+// TranscriptEvent is a single line of a JSONL stream transcript.
+type TranscriptEvent struct {
+	// Type is the kind of event.
+	Type TranscriptEventType `json:"type"`
+	// Time is the wall-clock time the event was recorded.
+	Time time.Time `json:"time"`
+	// ElapsedMs is the time since the transcript writer was created.
+	ElapsedMs int64 `json:"elapsed_ms"`
+	// Chunk is set for chunk events.
+	Chunk *ChatChunk `json:"chunk,omitempty"`
+	// Error is set for error events.
+	Error string `json:"error,omitempty"`
+	// ErrorCode is the error category for error events.
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// TranscriptWriter serializes stream events as JSON Lines to an io.Writer as
+// they occur. It is safe for concurrent use.
+//
+// Attach it to a stream with ChunkStream.Record, or call the Write methods
+// directly from custom stream handling code.
+type TranscriptWriter struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	start time.Time
+	err   error
+}
+
+// NewTranscriptWriter creates a transcript writer that writes to w.
+// Elapsed times are measured from the moment of creation.
+func NewTranscriptWriter(w io.Writer) *TranscriptWriter {
+	return &TranscriptWriter{
+		enc:   json.NewEncoder(w),
+		start: time.Now(),
+	}
+}
+
+// WriteChunk records a streamed chunk.
+func (t *TranscriptWriter) WriteChunk(chunk *ChatChunk) error {
+	return t.write(TranscriptEvent{Type: TranscriptEventChunk, Chunk: chunk})
+}
+
+// WriteError records a stream error.
+func (t *TranscriptWriter) WriteError(err error) error {
+	ev := TranscriptEvent{Type: TranscriptEventError, Error: err.Error()}
+	var xaiErr *Error
+	if errors.As(err, &xaiErr) {
+		ev.ErrorCode = xaiErr.Code.String()
+	}
+	return t.write(ev)
+}
+
+// WriteEnd records the normal end of a stream.
+func (t *TranscriptWriter) WriteEnd() error {
+	return t.write(TranscriptEvent{Type: TranscriptEventEnd})
+}
+
+// Err returns the first error encountered while writing, if any.
+func (t *TranscriptWriter) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *TranscriptWriter) write(ev TranscriptEvent) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	ev.Time = now
+	ev.ElapsedMs = now.Sub(t.start).Milliseconds()
+	if err := t.enc.Encode(ev); err != nil {
+		if t.err == nil {
+			t.err = err
+		}
+		return err
+	}
+	return nil
+}
+
+// Record attaches a transcript writer so that every chunk, error and the end of
+// stream returned by Next are written to tw. Returns the stream for chaining.
+// Transcript write failures do not interrupt the stream; check tw.Err.
+func (s *ChunkStream) Record(tw *TranscriptWriter) *ChunkStream {
+	s.transcript = tw
+	return s
+}
@@ -0,0 +1,6 @@
+//go:build !(js && wasm)
+
+package xai
+
+// nativeGRPC reports whether the platform can dial gRPC over HTTP/2.
+const nativeGRPC = true
@@ -0,0 +1,7 @@
+//go:build js && wasm
+
+package xai
+
+// nativeGRPC reports whether the platform can dial gRPC over HTTP/2. Browsers
+// cannot, so TransportGRPC falls back to TransportGRPCWeb.
+const nativeGRPC = false
@@ -0,0 +1,183 @@
+package xai
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	v1 "github.com/roelfdiedericks/xai-go/proto/xai/api/v1"
+)
+
+// TruncateStrategy selects how WithAutoTruncate shortens a history that does
+// not fit the model's context window.
+type TruncateStrategy int
+
+const (
+	// TruncateDropOldest drops the oldest messages, whatever their role.
+	TruncateDropOldest TruncateStrategy = iota + 1
+	// TruncateKeepSystem drops the oldest messages other than system
+	// messages.
+	TruncateKeepSystem
+	// TruncateSummarizeOldest drops like TruncateKeepSystem and puts a
+	// summary of the dropped messages, written by the request's model, in
+	// their place.
+	TruncateSummarizeOldest
+)
+
+// truncateSummaryTokens caps the summary written by TruncateSummarizeOldest.
+// Room for it is left when choosing which messages to drop.
+const truncateSummaryTokens = 512
+
+const truncateSummaryPrompt = `You condense the earlier part of a conversation so it can continue without it. Summarize the CONVERSATION TURNS below: keep facts, decisions, open questions and anything the user asked to remember; drop pleasantries. Do not invent facts. Reply with the summary only.`
+
+// truncateSummaryPrefix starts the developer message holding the summary.
+const truncateSummaryPrefix = "Summary of the earlier conversation:\n"
+
+// WithAutoTruncate trims the history client-side when the prompt does not
+// fit the model's context window (MaxPromptLength), instead of leaving the
+// server to silently drop the oldest context. Before each call the prompt is
+// tokenized, leaving room for max tokens if set; if it is too long, the
+// oldest messages are dropped per strategy until it fits.
+//
+// The last message is never dropped, nor, if the history ends in tool
+// results, the assistant message that called them. Tool results are dropped
+// together with their call. If the prompt does not fit even so, the call
+// fails with ErrInvalidRequest.
+//
+// Only the request sent is trimmed; the ChatRequest keeps its messages. A
+// prompt that fits costs one Tokenize call (the model lookup is cached per
+// client); one that does not costs a Tokenize call per message, plus a chat
+// completion with TruncateSummarizeOldest.
+func (r *ChatRequest) WithAutoTruncate(strategy TruncateStrategy) *ChatRequest {
+	r.autoTruncate = strategy
+	return r
+}
+
+// contextWindow returns the maximum prompt length of model, or 0 if unknown.
+func (c *Client) contextWindow(ctx context.Context, model string) (int, error) {
+	if n, ok := c.contextWindows.Load(model); ok {
+		return n.(int), nil
+	}
+	info, err := c.GetModel(ctx, model)
+	if err != nil {
+		return 0, err
+	}
+	n := int(info.MaxPromptLength)
+	c.contextWindows.Store(model, n)
+	return n, nil
+}
+
+// autoTruncate drops messages from req until it fits the context window of
+// its model.
+func (c *Client) autoTruncate(ctx context.Context, req *v1.GetCompletionsRequest, strategy TruncateStrategy) error {
+	model := req.GetModel()
+	window, err := c.contextWindow(ctx, model)
+	if err != nil {
+		return WrapError(err, "auto truncate: looking up model context length")
+	}
+	if window <= 0 {
+		return nil
+	}
+	budget := window - int(req.GetMaxTokens())
+
+	tokens, err := c.requestTokens(ctx, req)
+	if err != nil {
+		return WrapError(err, "auto truncate")
+	}
+	if tokens.Total <= budget {
+		return nil
+	}
+
+	msgs := req.GetMessages()
+	counts := make([]int, len(msgs))
+	used := tokens.Tools
+	for i, msg := range msgs {
+		n, err := c.countTokens(ctx, model, messageText(msg))
+		if err != nil {
+			return WrapError(err, "auto truncate: counting message tokens")
+		}
+		counts[i] = n + imageTokens(msg) + messageOverheadTokens
+		used += counts[i]
+	}
+	if strategy == TruncateSummarizeOldest {
+		used += truncateSummaryTokens + messageOverheadTokens
+	}
+
+	// The final turn is kept: the last message, and the call its tool
+	// results answer.
+	protect := len(msgs) - 1
+	for protect > 0 && msgs[protect].GetRole() == v1.MessageRole_ROLE_TOOL {
+		protect--
+	}
+	drop := make([]bool, len(msgs))
+	first, dropped := -1, 0
+	for i := 0; i < protect && used > budget; i++ {
+		if strategy != TruncateDropOldest && msgs[i].GetRole() == v1.MessageRole_ROLE_SYSTEM {
+			continue
+		}
+		for {
+			drop[i] = true
+			used -= counts[i]
+			dropped++
+			if first < 0 {
+				first = i
+			}
+			// Drop the results of a dropped call too.
+			if i+1 >= protect || msgs[i+1].GetRole() != v1.MessageRole_ROLE_TOOL {
+				break
+			}
+			i++
+		}
+	}
+	if used > budget {
+		return &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf(
+			"auto truncate: prompt needs %d tokens after truncation, but only %d of %s's context window are available",
+			used, budget, model)}
+	}
+
+	var summary *v1.Message
+	if strategy == TruncateSummarizeOldest {
+		var turns strings.Builder
+		for i, msg := range msgs {
+			if drop[i] {
+				turns.WriteString(roleName(msg.GetRole()))
+				turns.WriteString(": ")
+				turns.WriteString(strings.TrimSpace(messageText(msg)))
+				turns.WriteString("\n")
+			}
+		}
+		resp, err := c.CompleteChat(ctx, NewChatRequest().
+			WithModel(model).
+			SystemMessage(SystemContent{Text: truncateSummaryPrompt}).
+			UserMessage(UserContent{Text: "CONVERSATION TURNS:\n" + turns.String()}).
+			WithMaxTokens(truncateSummaryTokens).
+			WithTemperature(0))
+		if err != nil {
+			return WrapError(err, "auto truncate: summarizing dropped messages")
+		}
+		summary = &v1.Message{
+			Role:    v1.MessageRole_ROLE_DEVELOPER,
+			Content: []*v1.Content{{Content: &v1.Content_Text{Text: truncateSummaryPrefix + strings.TrimSpace(resp.Content)}}},
+		}
+	}
+
+	kept := make([]*v1.Message, 0, len(msgs)-dropped+1)
+	for i, msg := range msgs {
+		if i == first && summary != nil {
+			kept = append(kept, summary)
+		}
+		if !drop[i] {
+			kept = append(kept, msg)
+		}
+	}
+	req.Messages = kept
+
+	c.logger.DebugContext(ctx, "truncated history to fit the context window",
+		slog.String("model", model),
+		slog.Int("dropped", dropped),
+		slog.Int("tokens", used),
+		slog.Int("context_window", window),
+	)
+	return nil
+}
@@ -0,0 +1,80 @@
+package xai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// RunnableTool is a function tool that carries its own handler, such as one
+// made with NewTypedTool. Add it to a ToolRunner with ToolRunner.Add.
+type RunnableTool interface {
+	// Function returns the tool definition sent to the model.
+	Function() *FunctionTool
+	// Handle executes a call with the given JSON arguments.
+	Handle(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// TypedTool is a function tool whose arguments and result are Go types.
+// It is a Tool, so it can also be added to a request directly.
+type TypedTool[Args, Result any] struct {
+	*FunctionTool
+	fn func(context.Context, Args) (Result, error)
+}
+
+// NewTypedTool creates a function tool whose parameters schema is derived
+// from Args (see SchemaFor) and whose calls are decoded into Args, passed to
+// fn, and answered with fn's Result: a string result is sent as is, anything
+// else as JSON.
+//
+//	weather := xai.NewTypedTool("get_weather", "Current weather",
+//		func(ctx context.Context, in WeatherArgs) (Weather, error) {
+//			return lookup(ctx, in.City)
+//		})
+//	runner := xai.NewToolRunner().Add(weather)
+func NewTypedTool[Args, Result any](name, description string, fn func(context.Context, Args) (Result, error)) *TypedTool[Args, Result] {
+	tool := NewFunctionTool(name, description)
+	tool.Parameters = schemaJSON(reflect.TypeFor[Args]())
+	return &TypedTool[Args, Result]{FunctionTool: tool, fn: fn}
+}
+
+// Function returns the tool definition.
+func (t *TypedTool[Args, Result]) Function() *FunctionTool {
+	return t.FunctionTool
+}
+
+// clone copies the tool definition; the function is shared.
+func (t *TypedTool[Args, Result]) clone() Tool {
+	return &TypedTool[Args, Result]{FunctionTool: cloneTool(t.FunctionTool).(*FunctionTool), fn: t.fn}
+}
+
+// Handle decodes args, calls the tool's function and encodes its result.
+// Arguments that do not decode are reported as an error, which a
+// ToolRunner passes back to the model.
+func (t *TypedTool[Args, Result]) Handle(ctx context.Context, args json.RawMessage) (string, error) {
+	var in Args
+	if err := unmarshalArguments(t.Name, args, &in); err != nil {
+		return "", err
+	}
+	out, err := t.fn(ctx, in)
+	if err != nil {
+		return "", err
+	}
+	if s, ok := any(out).(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return "", fmt.Errorf("encoding %s result: %w", t.Name, err)
+	}
+	return string(b), nil
+}
+
+// Add registers tools that carry their own handlers.
+func (r *ToolRunner) Add(tools ...RunnableTool) *ToolRunner {
+	for _, t := range tools {
+		r.Register(t.Function(), t.Handle)
+	}
+	return r
+}
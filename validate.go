@@ -0,0 +1,124 @@
+package xai
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ValidationError is one problem found by ChatRequest.Validate.
+type ValidationError struct {
+	// Field is the request setting at fault, e.g. "temperature" or
+	// "tools[1].parameters".
+	Field string
+	// Problem describes what is wrong with it.
+	Problem string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Problem
+}
+
+// ValidationErrors are the problems found by ChatRequest.Validate.
+type ValidationErrors []*ValidationError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the individual problems for errors.As.
+func (es ValidationErrors) Unwrap() []error {
+	out := make([]error, len(es))
+	for i, e := range es {
+		out[i] = e
+	}
+	return out
+}
+
+// functionNamePattern is the form the API accepts for function tool names.
+var functionNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Validate checks the request for mistakes the server would reject, so they
+// surface locally with a clear message instead of as an opaque
+// InvalidArgument. It returns the builder error, if any, or an *Error with
+// code ErrInvalidRequest whose Cause is the ValidationErrors found; nil
+// means no problems were found, not that the server will accept the
+// request.
+//
+// It checks that there is at least one message, that sampling parameters
+// are in range, that max tokens are positive, and that function tools have
+// valid, unique names and JSON object parameter schemas.
+func (r *ChatRequest) Validate() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	var problems ValidationErrors
+	add := func(field, format string, args ...any) {
+		problems = append(problems, &ValidationError{Field: field, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	if len(r.messages) == 0 {
+		add("messages", "at least one message is required")
+	}
+	if r.maxTokens != nil && *r.maxTokens <= 0 {
+		add("max_tokens", "must be positive, got %d", *r.maxTokens)
+	}
+	if r.maxReasoningTokens != nil && *r.maxReasoningTokens <= 0 {
+		add("max_reasoning_tokens", "must be positive, got %d", *r.maxReasoningTokens)
+	}
+	if r.n != nil && *r.n < 1 {
+		add("n", "must be at least 1, got %d", *r.n)
+	}
+	if r.temperature != nil && (*r.temperature < 0 || *r.temperature > 2) {
+		add("temperature", "must be between 0 and 2, got %g", *r.temperature)
+	}
+	if r.topP != nil && (*r.topP <= 0 || *r.topP > 1) {
+		add("top_p", "must be greater than 0 and at most 1, got %g", *r.topP)
+	}
+	if r.frequencyPenalty != nil && (*r.frequencyPenalty < -2 || *r.frequencyPenalty > 2) {
+		add("frequency_penalty", "must be between -2 and 2, got %g", *r.frequencyPenalty)
+	}
+	if r.presencePenalty != nil && (*r.presencePenalty < -2 || *r.presencePenalty > 2) {
+		add("presence_penalty", "must be between -2 and 2, got %g", *r.presencePenalty)
+	}
+	if r.topLogprobs != nil && *r.topLogprobs < 0 {
+		add("top_logprobs", "must not be negative, got %d", *r.topLogprobs)
+	}
+	if r.toolChoice != nil && *r.toolChoice == ToolChoiceRequired && len(r.tools) == 0 {
+		add("tool_choice", "required, but the request has no tools")
+	}
+
+	names := make(map[string]int)
+	for i, tool := range r.tools {
+		fn := functionTool(tool)
+		if fn == nil {
+			continue
+		}
+		field := fmt.Sprintf("tools[%d]", i)
+		switch {
+		case !functionNamePattern.MatchString(fn.Name):
+			add(field+".name", "%q must be 1-64 letters, digits, underscores or dashes", fn.Name)
+		case names[fn.Name] > 0:
+			add(field+".name", "%q is also used by tools[%d]", fn.Name, names[fn.Name]-1)
+		default:
+			names[fn.Name] = i + 1
+		}
+		if len(fn.Parameters) > 0 {
+			var schema map[string]json.RawMessage
+			if err := json.Unmarshal(fn.Parameters, &schema); err != nil {
+				add(field+".parameters", "not a JSON object schema: %v", err)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &Error{Code: ErrInvalidRequest, Message: "invalid chat request", Cause: problems}
+}
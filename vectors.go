@@ -0,0 +1,125 @@
+package xai
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// VectorRecord is one embedding prepared for a vector store.
+type VectorRecord struct {
+	// ID identifies the record in the store.
+	ID string
+	// Vector is the embedding.
+	Vector []float32
+	// Payload is optional metadata stored alongside the vector.
+	Payload map[string]any
+}
+
+// NewVectorRecords pairs the embeddings of resp with ids, where ids[i] is the
+// ID of the i-th request input. Inputs that produced several vectors (some
+// images do) yield one record per vector, with IDs suffixed "-0", "-1", ...
+func NewVectorRecords(resp *EmbedResponse, ids []string) ([]VectorRecord, error) {
+	var records []VectorRecord
+	for _, emb := range resp.Embeddings {
+		if int(emb.Index) >= len(ids) || emb.Index < 0 {
+			return nil, &Error{Code: ErrInvalidRequest, Message: fmt.Sprintf("no ID for embedding %d (%d IDs given)", emb.Index, len(ids))}
+		}
+		id := ids[emb.Index]
+		for n, vec := range emb.Vectors {
+			rid := id
+			if len(emb.Vectors) > 1 {
+				rid = id + "-" + strconv.Itoa(n)
+			}
+			records = append(records, VectorRecord{ID: rid, Vector: vec})
+		}
+	}
+	return records, nil
+}
+
+// WritePgvectorCOPY writes records in PostgreSQL COPY text format with the
+// columns id, embedding and metadata (jsonb, NULL if there is no payload),
+// ready for
+//
+//	COPY items (id, embedding, metadata) FROM STDIN
+func WritePgvectorCOPY(w io.Writer, records []VectorRecord) error {
+	bw := bufio.NewWriter(w)
+	var line []byte
+	for _, r := range records {
+		line = append(line[:0], copyEscape(r.ID)...)
+		line = append(line, '\t')
+		line = appendVector(line, r.Vector)
+		line = append(line, '\t')
+		if r.Payload == nil {
+			line = append(line, `\N`...)
+		} else {
+			payload, err := json.Marshal(r.Payload)
+			if err != nil {
+				return fmt.Errorf("record %s: %w", r.ID, err)
+			}
+			line = append(line, copyEscape(string(payload))...)
+		}
+		line = append(line, '\n')
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func copyEscape(s string) string {
+	return copyEscaper.Replace(s)
+}
+
+// appendVector appends v in pgvector's text form, e.g. [0.1,0.2].
+func appendVector(b []byte, v []float32) []byte {
+	b = append(b, '[')
+	for i, f := range v {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendFloat(b, float64(f), 'g', -1, 32)
+	}
+	return append(b, ']')
+}
+
+// WriteQdrantJSON writes records as the body of a Qdrant upsert request
+// (PUT /collections/{name}/points). Qdrant only accepts unsigned integers
+// and UUIDs as point IDs; IDs that parse as integers are written as numbers.
+func WriteQdrantJSON(w io.Writer, records []VectorRecord) error {
+	type point struct {
+		ID      any            `json:"id"`
+		Vector  []float32      `json:"vector"`
+		Payload map[string]any `json:"payload,omitempty"`
+	}
+	points := make([]point, len(records))
+	for i, r := range records {
+		points[i] = point{ID: r.ID, Vector: r.Vector, Payload: r.Payload}
+		if n, err := strconv.ParseUint(r.ID, 10, 64); err == nil {
+			points[i].ID = n
+		}
+	}
+	return json.NewEncoder(w).Encode(map[string]any{"points": points})
+}
+
+// WriteMilvusJSON writes records in Milvus' row-based JSON bulk import
+// format. Each row has the fields id and vector; payload keys become fields
+// of their own and must match the collection schema or be dynamic fields.
+func WriteMilvusJSON(w io.Writer, records []VectorRecord) error {
+	rows := make([]map[string]any, len(records))
+	for i, r := range records {
+		row := make(map[string]any, len(r.Payload)+2)
+		for k, v := range r.Payload {
+			row[k] = v
+		}
+		row["id"] = r.ID
+		row["vector"] = r.Vector
+		rows[i] = row
+	}
+	return json.NewEncoder(w).Encode(map[string]any{"rows": rows})
+}
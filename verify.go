@@ -0,0 +1,157 @@
+package xai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// verifyPrompt instructs the model to check each claim of an answer against
+// the numbered sources that follow.
+const verifyPrompt = `You are a fact-checking assistant. Split the ANSWER into its individual factual claims and check each one against the numbered SOURCES only, not your own knowledge.
+Respond with a JSON object: {"claims": [{"claim": string, "verdict": "supported" | "unsupported" | "contradicted", "sources": [source numbers], "explanation": string}]}.
+Use "unsupported" when no source addresses the claim, and "contradicted" when a source says otherwise.`
+
+// ClaimVerdict is the outcome of checking one claim against the sources.
+type ClaimVerdict string
+
+const (
+	// ClaimSupported means at least one source backs the claim.
+	ClaimSupported ClaimVerdict = "supported"
+	// ClaimUnsupported means no source addresses the claim.
+	ClaimUnsupported ClaimVerdict = "unsupported"
+	// ClaimContradicted means a source states otherwise.
+	ClaimContradicted ClaimVerdict = "contradicted"
+)
+
+// Source is a piece of evidence an answer should be grounded in.
+type Source struct {
+	// ID identifies the source, e.g. a URL or document file ID.
+	ID string `json:"id"`
+	// Content is the source text. If empty, only the ID is shown to the
+	// verifier, which then cannot check much beyond relevance.
+	Content string `json:"content,omitempty"`
+}
+
+// SourcesFromSearch converts document search matches into sources.
+func SourcesFromSearch(resp *SearchResponse) []Source {
+	sources := make([]Source, 0, len(resp.Matches))
+	for _, m := range resp.Matches {
+		sources = append(sources, Source{ID: m.FileID + "#" + m.ChunkID, Content: m.Content})
+	}
+	return sources
+}
+
+// VerifiedClaim is the verdict for one claim in the answer.
+type VerifiedClaim struct {
+	// Claim is the claim as restated by the verifier.
+	Claim string `json:"claim"`
+	// Verdict is whether the sources support the claim.
+	Verdict ClaimVerdict `json:"verdict"`
+	// Sources are the zero-based indexes of the sources cited for the verdict.
+	Sources []int `json:"sources,omitempty"`
+	// Explanation is the verifier's reasoning.
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// VerificationReport is the result of VerifyAgainstSources.
+type VerificationReport struct {
+	// Claims are the per-claim verdicts, in answer order.
+	Claims []VerifiedClaim `json:"claims"`
+	// Supported, Unsupported and Contradicted count the verdicts.
+	Supported    int `json:"supported"`
+	Unsupported  int `json:"unsupported"`
+	Contradicted int `json:"contradicted"`
+	// Usage is the token usage of the verification pass.
+	Usage Usage `json:"usage"`
+}
+
+// Grounded returns true if every claim is supported.
+func (r *VerificationReport) Grounded() bool {
+	return r.Unsupported == 0 && r.Contradicted == 0
+}
+
+// Flagged returns the claims that are unsupported or contradicted.
+func (r *VerificationReport) Flagged() []VerifiedClaim {
+	var out []VerifiedClaim
+	for _, c := range r.Claims {
+		if c.Verdict != ClaimSupported {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// VerifyAgainstSources runs a second model pass that checks each claim in
+// resp against sources and reports unsupported or contradicted claims. If
+// sources is empty, resp.Citations are used as ID-only sources. If model is
+// empty, the client's default model is used.
+//
+// This is a heuristic hallucination check: the verifier is itself a model and
+// can be wrong.
+func (c *Client) VerifyAgainstSources(ctx context.Context, resp *ChatResponse, sources []Source, model string) (*VerificationReport, error) {
+	if model == "" {
+		model = c.config.DefaultModel
+	}
+	if len(sources) == 0 {
+		for _, url := range resp.Citations {
+			sources = append(sources, Source{ID: url})
+		}
+	}
+	if len(sources) == 0 {
+		return nil, &Error{Code: ErrInvalidRequest, Message: "verify: no sources or citations to check against"}
+	}
+
+	var b strings.Builder
+	b.WriteString("ANSWER:\n")
+	b.WriteString(resp.Content)
+	b.WriteString("\n\nSOURCES:\n")
+	for i, s := range sources {
+		fmt.Fprintf(&b, "[%d] %s\n", i, s.ID)
+		if s.Content != "" {
+			b.WriteString(s.Content)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	req := NewChatRequest().
+		WithModel(model).
+		SystemMessage(SystemContent{Text: verifyPrompt}).
+		UserMessage(UserContent{Text: b.String()}).
+		WithResponseFormat(ResponseFormatJSON).
+		WithTemperature(0)
+
+	out, err := c.CompleteChat(ctx, req)
+	if err != nil {
+		return nil, WrapError(err, "verify")
+	}
+
+	var parsed struct {
+		Claims []VerifiedClaim `json:"claims"`
+	}
+	if err := json.Unmarshal([]byte(out.Content), &parsed); err != nil {
+		return nil, &Error{
+			Code:    ErrServerError,
+			Message: "verify: model returned invalid JSON",
+			Cause:   err,
+		}
+	}
+
+	report := &VerificationReport{Usage: out.Usage}
+	for _, claim := range parsed.Claims {
+		switch claim.Verdict {
+		case ClaimSupported:
+			report.Supported++
+		case ClaimContradicted:
+			report.Contradicted++
+		default:
+			// Anything unrecognized is treated conservatively.
+			claim.Verdict = ClaimUnsupported
+			report.Unsupported++
+		}
+		report.Claims = append(report.Claims, claim)
+	}
+	return report, nil
+}
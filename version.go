@@ -0,0 +1,69 @@
+package xai
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const modulePath = "github.com/roelfdiedericks/xai-go"
+
+// clientInfoHeader identifies the library, Go version, platform and calling
+// application on every request.
+const clientInfoHeader = "x-client-info"
+
+var moduleVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	if info.Main.Path == modulePath {
+		if v := info.Main.Version; v != "" && v != "(devel)" {
+			return v
+		}
+		return "devel"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "devel"
+})
+
+// Version returns the library's module version (e.g. "v0.6.0"), as recorded
+// in the binary's build info, or "devel" for local builds.
+func Version() string {
+	return moduleVersion()
+}
+
+// clientInfo builds the identification string, e.g.
+// "xai-go/v0.6.0 go1.23.4 linux/amd64 myapp/1.2".
+func clientInfo(appName string) string {
+	info := "xai-go/" + Version() + " " + runtime.Version() + " " + runtime.GOOS + "/" + runtime.GOARCH
+	if appName != "" {
+		info += " " + appName
+	}
+	return info
+}
+
+// clientInfoUnaryInterceptor attaches the client info header to unary RPCs.
+func clientInfoUnaryInterceptor(info string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(metadata.AppendToOutgoingContext(ctx, clientInfoHeader, info), method, req, reply, cc, opts...)
+	}
+}
+
+// clientInfoStreamInterceptor attaches the client info header to streams.
+func clientInfoStreamInterceptor(info string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(metadata.AppendToOutgoingContext(ctx, clientInfoHeader, info), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,41 @@
+package xai
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Warmup makes a cheap round trip (fetching the default model's details) so
+// the connection handshake and any server-side cold start are paid before the
+// first real request. A NotFound error still counts as warm: the connection
+// was established. Config.Warmup runs this in the background on creation.
+func (c *Client) Warmup(ctx context.Context) error {
+	_, err := c.GetModel(ctx, c.config.DefaultModel)
+	if errors.Is(err, ErrNotFoundSentinel) {
+		return nil
+	}
+	return err
+}
+
+// startWarmup runs Warmup in the background if the config asks for it. It is
+// cancelled by Close.
+func (c *Client) startWarmup() {
+	if !c.config.Warmup {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopWarmup = cancel
+	go func() {
+		defer cancel()
+		start := time.Now()
+		if err := c.Warmup(ctx); err != nil {
+			if ctx.Err() == nil {
+				c.logger.Warn("warm-up failed", errorAttrs(err)...)
+			}
+			return
+		}
+		c.logger.Debug("warm-up done", slog.Duration("duration", time.Since(start)))
+	}()
+}
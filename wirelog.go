@@ -0,0 +1,161 @@
+package xai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// wireLog writes the raw messages of every RPC while enabled.
+type wireLog struct {
+	apiKey *SecureString
+	w      atomic.Pointer[wireWriter]
+}
+
+type wireWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// EnableWireLogging writes every request and response message to w as
+// indented protobuf JSON, exactly as marshaled for the API, together with the
+// outgoing metadata. It is meant for troubleshooting what the builders
+// actually send. API keys and authorization metadata are redacted. Pass nil
+// to turn it off again.
+func (c *Client) EnableWireLogging(w io.Writer) {
+	if w == nil {
+		c.wire.w.Store(nil)
+		return
+	}
+	c.wire.w.Store(&wireWriter{w: w})
+}
+
+var wireJSON = protojson.MarshalOptions{Multiline: true, Indent: "  "}
+
+// logf writes one entry: a header line followed by an optional message.
+func (l *wireLog) logf(msg any, format string, args ...any) {
+	ww := l.w.Load()
+	if ww == nil {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, format, args...)
+	b.WriteByte('\n')
+	if m, ok := msg.(proto.Message); ok {
+		data, err := wireJSON.Marshal(m)
+		if err != nil {
+			fmt.Fprintf(&b, "(marshal: %v)\n", err)
+		} else {
+			b.Write(data)
+			b.WriteByte('\n')
+		}
+	}
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	_, _ = io.WriteString(ww.w, b.String())
+}
+
+// logRequest writes the header of an outgoing call.
+func (l *wireLog) logRequest(ctx context.Context, method string) {
+	if l.w.Load() == nil {
+		return
+	}
+	key := l.apiKey
+	if k, ok := ctx.Value(apiKeyContextKey{}).(*SecureString); ok {
+		key = k
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "api-key: %s", redactKey(key))
+	md, _ := metadata.FromOutgoingContext(ctx)
+	names := make([]string, 0, len(md))
+	for name := range md {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range md[name] {
+			if name == "authorization" || strings.Contains(name, "key") {
+				v = "[REDACTED]"
+			}
+			fmt.Fprintf(&b, "\n%s: %s", name, v)
+		}
+	}
+	l.logf(nil, "==> %s\n%s", shortMethod(method), b.String())
+}
+
+func (l *wireLog) logResult(method string, start time.Time, err error) {
+	if err != nil {
+		l.logf(nil, "<-- %s error (%s): %v", shortMethod(method), time.Since(start).Round(time.Millisecond), err)
+	}
+}
+
+// wireUnaryInterceptor logs the request and response of unary RPCs.
+func wireUnaryInterceptor(l *wireLog) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if l.w.Load() == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		l.logRequest(ctx, method)
+		l.logf(req, "--> %s request", shortMethod(method))
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil {
+			l.logResult(method, start, err)
+			return err
+		}
+		l.logf(reply, "<-- %s response (%s)", shortMethod(method), time.Since(start).Round(time.Millisecond))
+		return nil
+	}
+}
+
+// wireStreamInterceptor logs every message sent and received on streams.
+func wireStreamInterceptor(l *wireLog) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if l.w.Load() == nil {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		l.logRequest(ctx, method)
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			l.logResult(method, start, err)
+			return nil, err
+		}
+		return &wireStream{ClientStream: cs, log: l, method: method, start: start}, nil
+	}
+}
+
+type wireStream struct {
+	grpc.ClientStream
+	log    *wireLog
+	method string
+	start  time.Time
+}
+
+func (s *wireStream) SendMsg(m any) error {
+	s.log.logf(m, "--> %s request", shortMethod(s.method))
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *wireStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.log.logf(nil, "<-- %s end of stream (%s)", shortMethod(s.method), time.Since(s.start).Round(time.Millisecond))
+	case err != nil:
+		s.log.logResult(s.method, s.start, err)
+	default:
+		s.log.logf(m, "<-- %s message", shortMethod(s.method))
+	}
+	return err
+}